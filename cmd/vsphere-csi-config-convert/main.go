@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// vsphere-csi-config-convert converts a vSphere CSI driver config in the INI
+// format, e.g. the csi-vsphere.conf of the vsphere-config-secret, to the
+// structured YAML format.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+)
+
+var (
+	input  = flag.String("input", "", "Path of the INI config to convert. Read from stdin if not set.")
+	output = flag.String("output", "", "Path of the converted YAML config. Written to stdout if not set.")
+)
+
+func main() {
+	flag.Parse()
+	if err := convert(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to convert config: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func convert() error {
+	var (
+		data []byte
+		err  error
+	)
+	if *input == "" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*input)
+	}
+	if err != nil {
+		return err
+	}
+	converted, err := config.ConvertConfigToStructured(data)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(converted)
+		return err
+	}
+	// The config holds the vCenter credentials.
+	return os.WriteFile(*output, converted, 0600)
+}
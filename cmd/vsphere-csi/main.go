@@ -27,6 +27,7 @@ import (
 	"syscall"
 
 	csiconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/tracing"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
@@ -74,6 +75,11 @@ func main() {
 		log.Errorf("failed retrieving the cluster flavor. Error: %v", err)
 	}
 	serviceMode := os.Getenv(csitypes.EnvVarMode)
+	shutdownTracing, err := tracing.InitTracing(ctx, "vsphere-csi-"+serviceMode, service.Version)
+	if err != nil {
+		log.Errorf("failed to initialize OpenTelemetry tracing. Error: %v", err)
+		os.Exit(1)
+	}
 	commonco.SetInitParams(ctx, clusterFlavor, &service.COInitParams, *supervisorFSSName, *supervisorFSSNamespace,
 		*internalFSSName, *internalFSSNamespace, serviceMode, "")
 
@@ -100,6 +106,10 @@ func main() {
 			if sig == syscall.SIGTERM {
 				log.Info("SIGTERM signal received")
 				utils.LogoutAllvCenterSessions(ctx)
+				// Flush the pending spans before exiting.
+				if err := shutdownTracing(ctx); err != nil {
+					log.Errorf("failed to shutdown OpenTelemetry tracing. Error: %v", err)
+				}
 				os.Exit(0)
 			}
 		}
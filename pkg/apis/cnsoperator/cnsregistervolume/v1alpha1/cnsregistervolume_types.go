@@ -65,6 +65,23 @@ type CnsRegisterVolumeSpec struct {
 	// SparseVer2BackingInfo, RawDiskMappingVer1BackingInfo, SeSparseBackingInfo,
 	// LocalPMemBackingInfo, or empty string.
 	BackingType string `json:"backingType,omitempty"`
+
+	// DiskFolderURLPath is URL path to a datastore folder whose virtual disks
+	// are all to be imported. A CnsRegisterVolume instance is created for each
+	// disk of the folder, importing it as the PVC named PvcName suffixed with
+	// the name of the disk.
+	// DiskFolderURLPath cannot be specified together with VolumeID or
+	// DiskURLPath.
+	// This field must be in the following format:
+	// Format:
+	// https://<vc_ip>/folder/<folder_path>?dcPath=<datacenterName>&dsName=<datastoreName>
+	DiskFolderURLPath string `json:"diskFolderURLPath,omitempty"`
+
+	// StorageClassName is the name of the StorageClass of the PV and PVC of
+	// the imported volume on vanilla Kubernetes clusters. The PV and PVC have
+	// no StorageClass if it is not specified. On Supervisor clusters, the
+	// StorageClass is derived from the storage policy of the volume.
+	StorageClassName string `json:"storageClassName,omitempty"`
 }
 
 // CnsRegisterVolumeStatus defines the observed state of CnsRegisterVolume
@@ -0,0 +1,90 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CnsSnapshotExportSpec defines the desired state of CnsSnapshotExport.
+// vSphere cannot copy a disk from one vCenter to another, so a snapshot can
+// only be exported to a datastore or a VMDK on the vCenter of the snapshot.
+// Exporting a snapshot to another vCenter is not supported.
+// +k8s:openapi-gen=true
+type CnsSnapshotExportSpec struct {
+	// SnapshotID is the snapshot handle of the CNS snapshot to be exported,
+	// in the <volumeID>+<snapshotID> format of the VolumeSnapshotContent.
+	SnapshotID string `json:"snapshotID"`
+
+	// DatastoreURL is the URL of the datastore the snapshot is exported to as
+	// a standalone FCD. The datastore must be on the vCenter of the snapshot.
+	// DatastoreURL and DiskURLPath cannot be specified together.
+	DatastoreURL string `json:"datastoreURL,omitempty"`
+
+	// DiskURLPath is the URL path of the VMDK the snapshot is exported to.
+	// The VMDK must be on the vCenter of the snapshot.
+	// DatastoreURL and DiskURLPath cannot be specified together.
+	// This field must be in the same format as the DiskURLPath of
+	// CnsRegisterVolume:
+	// https://<vc_ip>/folder/<vmdk_path>?dcPath=<datacenterName>&dsName=<datastoreName>
+	DiskURLPath string `json:"diskURLPath,omitempty"`
+}
+
+// CnsSnapshotExportStatus defines the observed state of CnsSnapshotExport
+// +k8s:openapi-gen=true
+type CnsSnapshotExportStatus struct {
+	// Indicates the snapshot is successfully exported.
+	// This field must only be set by the entity completing the export
+	// operation, i.e. the CNS Operator.
+	Exported bool `json:"exported"`
+
+	// VolumeID is the ID of the FCD the snapshot is exported to. When
+	// exporting to a DiskURLPath, it is the ID of the intermediate FCD the
+	// VMDK is copied from, and is cleared once the export completes.
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// DiskCopied indicates the intermediate FCD is copied to the DiskURLPath,
+	// and only remains to be deleted.
+	DiskCopied bool `json:"diskCopied,omitempty"`
+
+	// The last error encountered during export operation, if any.
+	// This field must only be set by the entity completing the export
+	// operation, i.e. the CNS Operator.
+	Error string `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CnsSnapshotExport is the Schema for the cnssnapshotexports API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+type CnsSnapshotExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CnsSnapshotExportSpec   `json:"spec,omitempty"`
+	Status CnsSnapshotExportStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CnsSnapshotExportList contains a list of CnsSnapshotExport
+type CnsSnapshotExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CnsSnapshotExport `json:"items"`
+}
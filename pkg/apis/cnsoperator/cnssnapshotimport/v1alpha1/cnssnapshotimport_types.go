@@ -0,0 +1,79 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CnsSnapshotImportSpec defines the desired state of CnsSnapshotImport
+// +k8s:openapi-gen=true
+type CnsSnapshotImportSpec struct {
+	// Name of the PVC the exported snapshot is imported as.
+	PvcName string `json:"pvcName"`
+
+	// VolumeID is the ID of an FCD a snapshot was exported to.
+	// VolumeID and DiskURLPath cannot be specified together.
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// DiskURLPath is the URL path of a VMDK a snapshot was exported to. The
+	// VMDK is registered as an FCD before it is imported.
+	// VolumeID and DiskURLPath cannot be specified together.
+	// This field must be in the same format as the DiskURLPath of
+	// CnsRegisterVolume:
+	// https://<vc_ip>/folder/<vmdk_path>?dcPath=<datacenterName>&dsName=<datastoreName>
+	DiskURLPath string `json:"diskURLPath,omitempty"`
+}
+
+// CnsSnapshotImportStatus defines the observed state of CnsSnapshotImport
+// +k8s:openapi-gen=true
+type CnsSnapshotImportStatus struct {
+	// Indicates the exported snapshot is successfully imported.
+	// This field must only be set by the entity completing the import
+	// operation, i.e. the CNS Operator.
+	Imported bool `json:"imported"`
+
+	// VolumeID is the ID of the FCD being imported.
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// The last error encountered during import operation, if any.
+	// This field must only be set by the entity completing the import
+	// operation, i.e. the CNS Operator.
+	Error string `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CnsSnapshotImport is the Schema for the cnssnapshotimports API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+type CnsSnapshotImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CnsSnapshotImportSpec   `json:"spec,omitempty"`
+	Status CnsSnapshotImportStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CnsSnapshotImportList contains a list of CnsSnapshotImport
+type CnsSnapshotImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CnsSnapshotImport `json:"items"`
+}
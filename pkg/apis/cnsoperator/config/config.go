@@ -32,6 +32,16 @@ var EmbedCnsUnregisterVolumeCRFile embed.FS
 
 const EmbedCnsUnregisterVolumeCRFileName = "cnsunregistervolume_crd.yaml"
 
+//go:embed cnssnapshotexport_crd.yaml
+var EmbedCnsSnapshotExportCRFile embed.FS
+
+const EmbedCnsSnapshotExportCRFileName = "cnssnapshotexport_crd.yaml"
+
+//go:embed cnssnapshotimport_crd.yaml
+var EmbedCnsSnapshotImportCRFile embed.FS
+
+const EmbedCnsSnapshotImportCRFileName = "cnssnapshotimport_crd.yaml"
+
 //go:embed cns.vmware.com_storagepolicyquotas.yaml
 var EmbedStoragePolicyQuotaCRFile embed.FS
 
@@ -29,6 +29,8 @@ import (
 	cnsnodevmattachmentv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsnodevmattachment/v1alpha1"
 	cnsnodevmbatchattachmentv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsnodevmbatchattachment/v1alpha1"
 	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
+	cnssnapshotexportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnssnapshotexport/v1alpha1"
+	cnssnapshotimportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnssnapshotimport/v1alpha1"
 	cnsunregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsunregistervolume/v1alpha1"
 	cnsvolumemetadatav1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumemetadata/v1alpha1"
 	storagepolicyv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/storagepolicy/v1alpha1"
@@ -61,6 +63,10 @@ var (
 	CnsRegisterVolumePlural = "cnsregistervolumes"
 	// CnsUnregisterVolumePlural is plural of CnsUnregisterVolume
 	CnsUnregisterVolumePlural = "cnsunregistervolumes"
+	// CnsSnapshotExportPlural is plural of CnsSnapshotExport
+	CnsSnapshotExportPlural = "cnssnapshotexports"
+	// CnsSnapshotImportPlural is plural of CnsSnapshotImport
+	CnsSnapshotImportPlural = "cnssnapshotimports"
 	// CnsFileAccessConfigPlural is plural of CnsFileAccessConfig
 	CnsFileAccessConfigPlural = "cnsfileaccessconfigs"
 	// CnsStoragePolicyUsageSingular is singular of StoragePolicyUsage
@@ -119,6 +125,18 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&cnsunregistervolumev1alpha1.CnsUnregisterVolumeList{},
 	)
 
+	scheme.AddKnownTypes(
+		SchemeGroupVersion,
+		&cnssnapshotexportv1alpha1.CnsSnapshotExport{},
+		&cnssnapshotexportv1alpha1.CnsSnapshotExportList{},
+	)
+
+	scheme.AddKnownTypes(
+		SchemeGroupVersion,
+		&cnssnapshotimportv1alpha1.CnsSnapshotImport{},
+		&cnssnapshotimportv1alpha1.CnsSnapshotImportList{},
+	)
+
 	scheme.AddKnownTypes(
 		SchemeGroupVersion,
 		&cnsvolumemetadatav1alpha1.CnsVolumeMetadata{},
@@ -19,6 +19,8 @@ package node
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/client-go/tools/cache"
@@ -28,10 +30,33 @@ import (
 	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 )
 
+// nodeListerPollInterval is the interval at which the nodes of a NodeLister
+// are listed again.
+const nodeListerPollInterval = time.Minute
+
+var (
+	// stopNodeListerPoller stops the poller started by the last call of
+	// InitializeWithNodeLister.
+	stopNodeListerPoller context.CancelFunc
+	// nodeListerPollerLock protects stopNodeListerPoller.
+	nodeListerPollerLock = &sync.Mutex{}
+)
+
+// NodeLister lists the nodes of container orchestrators other than
+// Kubernetes, which have no CSINode instances.
+type NodeLister interface {
+	// ListCSINodes returns a map of the node IDs reported by the node plugins,
+	// i.e. the node VM UUIDs, to the node names.
+	ListCSINodes(ctx context.Context) (map[string]string, error)
+}
+
 // Nodes comprises cns node manager and kubernetes informer.
 type Nodes struct {
 	cnsNodeManager Manager
 	informMgr      *k8s.InformerManager
+	// listedNodes maps the names of the nodes registered from a NodeLister to
+	// their UUIDs.
+	listedNodes map[string]string
 }
 
 // Initialize helps initialize node manager and node informer manager.
@@ -54,6 +79,77 @@ func (nodes *Nodes) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// InitializeWithNodeLister helps initialize node manager for container
+// orchestrators other than Kubernetes. The nodes of the lister are registered,
+// and listed again periodically to register new nodes and unregister removed
+// ones.
+func (nodes *Nodes) InitializeWithNodeLister(ctx context.Context, lister NodeLister) error {
+	nodes.cnsNodeManager = GetManager(ctx)
+	nodes.listedNodes = make(map[string]string)
+	if err := nodes.syncListedNodes(ctx, lister); err != nil {
+		return err
+	}
+	nodeListerPollerLock.Lock()
+	defer nodeListerPollerLock.Unlock()
+	if stopNodeListerPoller != nil {
+		stopNodeListerPoller()
+	}
+	pollerCtx, cancel := context.WithCancel(context.Background())
+	stopNodeListerPoller = cancel
+	go func() {
+		ticker := time.NewTicker(nodeListerPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pollerCtx.Done():
+				return
+			case <-ticker.C:
+				syncCtx, log := logger.GetNewContextWithLogger()
+				if err := nodes.syncListedNodes(syncCtx, lister); err != nil {
+					log.Errorf("failed to sync the nodes of the container orchestrator. Error: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// syncListedNodes registers the nodes of the lister which are new or whose
+// UUID has changed, and unregisters the nodes which are not listed anymore.
+// Nodes failing to register are registered again on the next sync.
+func (nodes *Nodes) syncListedNodes(ctx context.Context, lister NodeLister) error {
+	log := logger.GetLogger(ctx)
+	nodeIDToName, err := lister.ListCSINodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes. Error: %v", err)
+	}
+	currentNodes := make(map[string]string)
+	for nodeUUID, nodeName := range nodeIDToName {
+		currentNodes[nodeName] = nodeUUID
+	}
+	for nodeName, nodeUUID := range currentNodes {
+		if nodes.listedNodes[nodeName] == nodeUUID {
+			continue
+		}
+		if err := nodes.cnsNodeManager.RegisterNode(ctx, nodeUUID, nodeName); err != nil {
+			log.Errorf("failed to register node %q with UUID %q. err=%v", nodeName, nodeUUID, err)
+			continue
+		}
+		nodes.listedNodes[nodeName] = nodeUUID
+	}
+	for nodeName := range nodes.listedNodes {
+		if _, exists := currentNodes[nodeName]; exists {
+			continue
+		}
+		if err := nodes.cnsNodeManager.UnregisterNode(ctx, nodeName); err != nil {
+			log.Warnf("failed to unregister node:%q. err=%v", nodeName, err)
+			continue
+		}
+		delete(nodes.listedNodes, nodeName)
+	}
+	return nil
+}
+
 func (nodes *Nodes) csiNodeAdd(obj interface{}) {
 	ctx, log := logger.GetNewContextWithLogger()
 	csiNode, ok := obj.(*storagev1.CSINode)
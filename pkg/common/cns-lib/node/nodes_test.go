@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeManager records the nodes registered with it.
+type fakeManager struct {
+	Manager
+	registered   map[string]string
+	failRegister map[string]bool
+}
+
+func (m *fakeManager) RegisterNode(ctx context.Context, nodeUUID string, nodeName string) error {
+	if m.failRegister[nodeName] {
+		return errors.New("node VM not found")
+	}
+	m.registered[nodeName] = nodeUUID
+	return nil
+}
+
+func (m *fakeManager) UnregisterNode(ctx context.Context, nodeName string) error {
+	delete(m.registered, nodeName)
+	return nil
+}
+
+// fakeNodeLister returns the nodes it holds, or the error it holds.
+type fakeNodeLister struct {
+	nodeIDToName map[string]string
+	err          error
+}
+
+func (l *fakeNodeLister) ListCSINodes(ctx context.Context) (map[string]string, error) {
+	return l.nodeIDToName, l.err
+}
+
+func TestSyncListedNodes(t *testing.T) {
+	ctx := context.Background()
+	manager := &fakeManager{registered: make(map[string]string), failRegister: map[string]bool{"node-3": true}}
+	nodes := &Nodes{cnsNodeManager: manager, listedNodes: make(map[string]string)}
+	lister := &fakeNodeLister{nodeIDToName: map[string]string{
+		"uuid-1": "node-1",
+		"uuid-2": "node-2",
+		"uuid-3": "node-3",
+	}}
+
+	assert.NoError(t, nodes.syncListedNodes(ctx, lister))
+	assert.Equal(t, map[string]string{"node-1": "uuid-1", "node-2": "uuid-2"}, manager.registered)
+
+	// Nodes failing to register are registered on the next sync, nodes with a
+	// new UUID are registered again, and removed nodes are unregistered.
+	delete(manager.failRegister, "node-3")
+	lister.nodeIDToName = map[string]string{
+		"uuid-1-new": "node-1",
+		"uuid-3":     "node-3",
+	}
+	assert.NoError(t, nodes.syncListedNodes(ctx, lister))
+	assert.Equal(t, map[string]string{"node-1": "uuid-1-new", "node-3": "uuid-3"}, manager.registered)
+	assert.Equal(t, manager.registered, nodes.listedNodes)
+
+	// The registered nodes are kept if the nodes cannot be listed.
+	lister.err = errors.New("connection refused")
+	assert.Error(t, nodes.syncListedNodes(ctx, lister))
+	assert.Len(t, manager.registered, 2)
+}
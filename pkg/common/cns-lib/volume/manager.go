@@ -73,6 +73,10 @@ const (
 
 	// MbInBytes is the number of bytes in one mebibyte.
 	MbInBytes = int64(1024 * 1024)
+
+	// groupSnapshotIDDelimiter joins the member volume and snapshot IDs of a
+	// group snapshot in its CnsVolumeOperationRequest instance.
+	groupSnapshotIDDelimiter = ","
 )
 
 // Manager provides functionality to manage volumes.
@@ -136,6 +140,10 @@ type Manager interface {
 	// DeleteSnapshot helps delete a snapshot for a block volume
 	DeleteSnapshot(ctx context.Context, volumeID string, snapshotID string,
 		extraParams interface{}) (*CnsSnapshotInfo, error)
+	// CreateGroupSnapshot creates a snapshot of all the given block volumes in
+	// a single CNS task.
+	CreateGroupSnapshot(ctx context.Context, groupSnapshotName string, volumeIDs []string) (
+		[]*CnsSnapshotInfo, error)
 	// QuerySnapshots retrieves the list of snapshots based on the query filter.
 	QuerySnapshots(ctx context.Context, snapshotQueryFilter cnstypes.CnsSnapshotQueryFilter) (
 		*cnstypes.CnsSnapshotQueryResult, error)
@@ -160,6 +168,10 @@ type Manager interface {
 	UnregisterVolume(ctx context.Context, volumeID string, unregisterDisk bool) (string, error)
 	// SyncVolume returns the aggregated capacity for volumes
 	SyncVolume(ctx context.Context, syncVolumeSpecs []cnstypes.CnsSyncVolumeSpec) (string, error)
+	// ReconfigVolumePolicy applies the given storage policy to a volume in place.
+	// When ReconfigVolumePolicy failed, the first return value (faultType) and second return value(error)
+	// need to be set, and should not be nil.
+	ReconfigVolumePolicy(ctx context.Context, volumeID string, storagePolicyID string) (string, error)
 }
 
 // CnsVolumeInfo hold information related to volume created by CNS.
@@ -3041,6 +3053,259 @@ func (m *defaultManager) CreateSnapshot(
 	return cnsSnapshotInfo, err
 }
 
+// CreateGroupSnapshot creates a snapshot of every given volume in a single
+// CNS CreateSnapshots task. The member snapshots get the
+// group snapshot name as description and are returned in the order of
+// volumeIDs. If any member fails, the created member snapshots are deleted.
+func (m *defaultManager) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string,
+	volumeIDs []string) ([]*CnsSnapshotInfo, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	internalCreateGroupSnapshot := func() ([]*CnsSnapshotInfo, error) {
+		log := logger.GetLogger(ctx)
+		if len(volumeIDs) == 0 {
+			return nil, logger.LogNewErrorf(log, "no volumes given for group snapshot %q", groupSnapshotName)
+		}
+		err := validateManager(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		// Set up the VC connection
+		err = m.virtualCenter.ConnectCns(ctx)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "ConnectCns failed with err: %+v", err)
+		}
+		return m.createGroupSnapshotWithImprovedIdempotencyCheck(ctx, groupSnapshotName, volumeIDs)
+	}
+
+	start := time.Now()
+	cnsSnapshotInfos, err := internalCreateGroupSnapshot()
+	if err != nil {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCreateGroupSnapshotOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCreateGroupSnapshotOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return cnsSnapshotInfos, err
+}
+
+// createGroupSnapshotWithImprovedIdempotencyCheck creates the snapshots of a
+// group snapshot. The CnsVolumeOperationRequest instance of the group is named
+// after the group snapshot and holds the member volume IDs and, once created,
+// the member snapshot IDs joined by groupSnapshotIDDelimiter.
+func (m *defaultManager) createGroupSnapshotWithImprovedIdempotencyCheck(ctx context.Context,
+	groupSnapshotName string, volumeIDs []string) ([]*CnsSnapshotInfo, error) {
+	log := logger.GetLogger(ctx)
+	var (
+		// Reference to the CreateSnapshots task on CNS.
+		createSnapshotsTask *object.Task
+		// Name of the CnsVolumeOperationRequest instance.
+		instanceName = groupSnapshotName
+		// Member volume IDs as persisted in the CnsVolumeOperationRequest instance.
+		groupVolumeIDs = strings.Join(volumeIDs, groupSnapshotIDDelimiter)
+		// Local instance of CreateGroupSnapshot details that needs to be persisted.
+		volumeOperationDetails *cnsvolumeoperationrequest.VolumeOperationRequestDetails
+		err                    error
+	)
+	if m.idempotencyHandlingEnabled {
+		if m.operationStore == nil {
+			return nil, logger.LogNewError(log, "operation store cannot be nil")
+		}
+
+		volumeOperationDetails, err = m.operationStore.GetRequestDetails(ctx, instanceName)
+		switch {
+		case err == nil:
+			if volumeOperationDetails.VolumeID != groupVolumeIDs {
+				return nil, logger.LogNewErrorf(log, "group snapshot %q already exists for volumes %q",
+					groupSnapshotName, volumeOperationDetails.VolumeID)
+			}
+			// Validate if previous operation was successful.
+			if volumeOperationDetails.OperationDetails.TaskStatus == taskInvocationStatusSuccess &&
+				volumeOperationDetails.SnapshotID != "" {
+				log.Infof("Group snapshot %q with snapshots %q on volumes %q is already created on CNS "+
+					"with opId: %q.", instanceName, volumeOperationDetails.SnapshotID, groupVolumeIDs,
+					volumeOperationDetails.OperationDetails.OpID)
+				snapshotIDs := strings.Split(volumeOperationDetails.SnapshotID, groupSnapshotIDDelimiter)
+				if len(snapshotIDs) != len(volumeIDs) {
+					return nil, logger.LogNewErrorf(log, "group snapshot %q has %d snapshots for %d volumes",
+						groupSnapshotName, len(snapshotIDs), len(volumeIDs))
+				}
+				cnsSnapshotInfos := make([]*CnsSnapshotInfo, 0, len(volumeIDs))
+				for i, volumeID := range volumeIDs {
+					cnsSnapshotInfos = append(cnsSnapshotInfos, &CnsSnapshotInfo{
+						SnapshotID:          snapshotIDs[i],
+						SourceVolumeID:      volumeID,
+						SnapshotDescription: groupSnapshotName,
+					})
+				}
+				return cnsSnapshotInfos, nil
+			}
+			// Validate if previous operation is pending.
+			if IsTaskPending(volumeOperationDetails) {
+				log.Infof("Group snapshot %s has CreateSnapshots task %s pending on CNS.",
+					instanceName, volumeOperationDetails.OperationDetails.TaskID)
+				taskMoRef := vim25types.ManagedObjectReference{
+					Type:  "Task",
+					Value: volumeOperationDetails.OperationDetails.TaskID,
+				}
+				createSnapshotsTask = object.NewTask(m.virtualCenter.Client.Client, taskMoRef)
+			}
+		case apierrors.IsNotFound(err):
+			// Instance doesn't exist. This is likely the first attempt to create the group snapshot.
+			volumeOperationDetails = createRequestDetails(
+				instanceName, groupVolumeIDs, "", 0, nil, metav1.Now(), "", "", "",
+				taskInvocationStatusInProgress, "")
+		default:
+			return nil, err
+		}
+	} else {
+		// get group snapshot task details from an in-memory map
+		createSnapshotsTask = getPendingCreateSnapshotTaskFromMap(ctx, instanceName)
+	}
+
+	defer func() {
+		// Persist the operation details before returning if the improved idempotency is enabled. Only success or error
+		// needs to be stored as InProgress details are stored when the task is created on CNS.
+		if m.idempotencyHandlingEnabled &&
+			volumeOperationDetails != nil && volumeOperationDetails.OperationDetails != nil &&
+			volumeOperationDetails.OperationDetails.TaskStatus != taskInvocationStatusInProgress {
+			if err := m.operationStore.StoreRequestDetails(ctx, volumeOperationDetails); err != nil {
+				log.Warnf("failed to store CreateGroupSnapshot details with error: %v", err)
+			}
+		}
+	}()
+	// failGroupSnapshot records the failure of the group snapshot and returns
+	// the error.
+	failGroupSnapshot := func(taskID, opID, errMsg string) error {
+		if m.idempotencyHandlingEnabled {
+			volumeOperationDetails = createRequestDetails(instanceName, groupVolumeIDs, "", 0, nil,
+				volumeOperationDetails.OperationDetails.TaskInvocationTimestamp, taskID, "", opID,
+				taskInvocationStatusError, errMsg)
+		} else {
+			snapshotTaskMapLock.Lock()
+			defer snapshotTaskMapLock.Unlock()
+			delete(snapshotTaskMap, instanceName)
+		}
+		return logger.LogNewError(log, errMsg)
+	}
+
+	if createSnapshotsTask == nil {
+		createSnapshotsTask, err = invokeCNSCreateSnapshots(ctx, m.virtualCenter, volumeIDs, groupSnapshotName)
+		if err != nil {
+			return nil, failGroupSnapshot("", "", fmt.Sprintf("failed to create group snapshot with error: %v", err))
+		}
+		if m.idempotencyHandlingEnabled {
+			// Persist the volume operation details.
+			volumeOperationDetails = createRequestDetails(instanceName, groupVolumeIDs, "", 0, nil,
+				volumeOperationDetails.OperationDetails.TaskInvocationTimestamp,
+				createSnapshotsTask.Reference().Value, "", "", taskInvocationStatusInProgress, "")
+			if err := m.operationStore.StoreRequestDetails(ctx, volumeOperationDetails); err != nil {
+				// Don't return if CreateGroupSnapshot details can't be stored.
+				log.Warnf("failed to store CreateGroupSnapshot details with error: %v", err)
+			}
+		} else {
+			// store task details into snapshotTaskMap
+			var taskDetails createSnapshotTaskDetails
+			taskDetails.task = createSnapshotsTask
+			taskDetails.expirationTime = time.Now().Add(time.Hour * time.Duration(
+				defaultOpsExpirationTimeInHours))
+			func() {
+				snapshotTaskMapLock.Lock()
+				defer snapshotTaskMapLock.Unlock()
+				snapshotTaskMap[instanceName] = &taskDetails
+			}()
+		}
+	}
+	taskID := createSnapshotsTask.Reference().Value
+
+	cnsSnapshotInfos := make(map[string]*CnsSnapshotInfo, len(volumeIDs))
+	var opID string
+	createSnapshotsTaskInfo, err := m.waitOnTask(ctx, createSnapshotsTask.Reference())
+	if err != nil {
+		if !cnsvsphere.IsManagedObjectNotFound(err, createSnapshotsTask.Reference()) {
+			return nil, logger.LogNewErrorf(log, "Failed to get taskInfo for CreateSnapshots task "+
+				"from vCenter %q with err: %v", m.virtualCenter.Config.Host, err)
+		}
+		log.Infof("CreateSnapshots task %s not found in vCenter. Querying CNS "+
+			"to determine if the group snapshot %s was successfully created.", taskID, instanceName)
+		for _, volumeID := range volumeIDs {
+			queriedCnsSnapshot, ok := queryCreatedSnapshotByName(ctx, m, volumeID, groupSnapshotName)
+			if !ok {
+				continue
+			}
+			cnsSnapshotInfos[volumeID] = &CnsSnapshotInfo{
+				SnapshotID:                          queriedCnsSnapshot.SnapshotId.Id,
+				SourceVolumeID:                      volumeID,
+				SnapshotDescription:                 groupSnapshotName,
+				SnapshotLatestOperationCompleteTime: queriedCnsSnapshot.CreateTime,
+			}
+		}
+	} else {
+		opID = createSnapshotsTaskInfo.ActivationId
+		log.Infof("CreateSnapshots: VolumeIDs: %v, opId: %q", volumeIDs, opID)
+		taskResults, err := cns.GetTaskResultArray(ctx, createSnapshotsTaskInfo)
+		if err != nil || len(taskResults) == 0 {
+			return nil, logger.LogNewErrorf(log, "unable to find the task results for CreateSnapshots task "+
+				"from vCenter %q. taskID: %q, opId: %q, error: %v", m.virtualCenter.Config.Host, taskID, opID, err)
+		}
+		for _, taskResult := range taskResults {
+			operationResult := taskResult.GetCnsVolumeOperationResult()
+			if operationResult.Fault != nil {
+				log.Errorf("failed to create snapshot of group snapshot %q on volume %q with fault: %q, opID: %q",
+					instanceName, operationResult.VolumeId.Id, spew.Sdump(operationResult.Fault), opID)
+				continue
+			}
+			snapshotCreateResult, ok := taskResult.(*cnstypes.CnsSnapshotCreateResult)
+			if !ok {
+				continue
+			}
+			cnsSnapshotInfos[snapshotCreateResult.Snapshot.VolumeId.Id] = &CnsSnapshotInfo{
+				SnapshotID:                          snapshotCreateResult.Snapshot.SnapshotId.Id,
+				SourceVolumeID:                      snapshotCreateResult.Snapshot.VolumeId.Id,
+				SnapshotDescription:                 snapshotCreateResult.Snapshot.Description,
+				SnapshotLatestOperationCompleteTime: *createSnapshotsTaskInfo.CompleteTime,
+			}
+		}
+	}
+
+	var failedVolumeIDs []string
+	for _, volumeID := range volumeIDs {
+		if _, ok := cnsSnapshotInfos[volumeID]; !ok {
+			failedVolumeIDs = append(failedVolumeIDs, volumeID)
+		}
+	}
+	if len(failedVolumeIDs) > 0 {
+		// The group snapshot is not usable without all of its members, so the
+		// created member snapshots are deleted rather than left behind.
+		for volumeID, cnsSnapshotInfo := range cnsSnapshotInfos {
+			if _, err := m.DeleteSnapshot(ctx, volumeID, cnsSnapshotInfo.SnapshotID, nil); err != nil {
+				log.Warnf("failed to delete snapshot %q on volume %q of failed group snapshot %q. Error: %v",
+					cnsSnapshotInfo.SnapshotID, volumeID, instanceName, err)
+			}
+		}
+		return nil, failGroupSnapshot(taskID, opID, fmt.Sprintf("failed to create group snapshot %q: "+
+			"snapshots of volumes %v were not created, opID: %q", instanceName, failedVolumeIDs, opID))
+	}
+
+	orderedSnapshotInfos := make([]*CnsSnapshotInfo, 0, len(volumeIDs))
+	snapshotIDs := make([]string, 0, len(volumeIDs))
+	for _, volumeID := range volumeIDs {
+		orderedSnapshotInfos = append(orderedSnapshotInfos, cnsSnapshotInfos[volumeID])
+		snapshotIDs = append(snapshotIDs, cnsSnapshotInfos[volumeID].SnapshotID)
+	}
+	if m.idempotencyHandlingEnabled {
+		// create the volumeOperationDetails object for persistence
+		volumeOperationDetails = createRequestDetails(instanceName, groupVolumeIDs,
+			strings.Join(snapshotIDs, groupSnapshotIDDelimiter), 0, nil,
+			volumeOperationDetails.OperationDetails.TaskInvocationTimestamp, taskID, "", opID,
+			taskInvocationStatusSuccess, "")
+	}
+	log.Infof("CreateGroupSnapshot: Group snapshot %q created successfully. VolumeIDs: %v, SnapshotIDs: %v, "+
+		"opId: %q", instanceName, volumeIDs, snapshotIDs, opID)
+	return orderedSnapshotInfos, nil
+}
+
 // Helper function for create snapshot with different behaviors in the idempotency handling
 // depends on whether the improved idempotency FSS is enabled.
 func (m *defaultManager) deleteSnapshotWithImprovedIdempotencyCheck(
@@ -3728,6 +3993,94 @@ func (m *defaultManager) SyncVolume(ctx context.Context, syncVolumeSpecs []cnsty
 	return faultType, err
 }
 
+// ReconfigVolumePolicy applies the given storage policy to a volume in place.
+func (m *defaultManager) ReconfigVolumePolicy(ctx context.Context, volumeID string,
+	storagePolicyID string) (string, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	internalReconfigVolumePolicy := func() (string, error) {
+		log := logger.GetLogger(ctx)
+		err := validateManager(ctx, m)
+		var faultType string
+		if err != nil {
+			faultType = ExtractFaultTypeFromErr(ctx, err)
+			log.Errorf("failed to validate manager with error: %v", err)
+			return faultType, err
+		}
+		// Set up the VC connection.
+		err = m.virtualCenter.ConnectCns(ctx)
+		if err != nil {
+			faultType = ExtractFaultTypeFromErr(ctx, err)
+			log.Errorf("ConnectCns failed with err: %+v", err)
+			return faultType, err
+		}
+		reconfigSpecs := []cnstypes.CnsVolumePolicyReconfigSpec{
+			{
+				VolumeId: cnstypes.CnsVolumeId{Id: volumeID},
+				Profile: []vim25types.BaseVirtualMachineProfileSpec{
+					&vim25types.VirtualMachineDefinedProfileSpec{
+						ProfileId: storagePolicyID,
+					},
+				},
+			},
+		}
+		task, err := m.virtualCenter.CnsClient.ReconfigVolumePolicy(ctx, reconfigSpecs)
+		if err != nil {
+			faultType = ExtractFaultTypeFromErr(ctx, err)
+			log.Errorf("CNS ReconfigVolumePolicy failed from vCenter %q with err: %v",
+				m.virtualCenter.Config.Host, err)
+			return faultType, err
+		}
+		// Get the taskInfo.
+		var taskInfo *vim25types.TaskInfo
+		taskInfo, err = m.waitOnTask(ctx, task.Reference())
+		if err != nil || taskInfo == nil {
+			log.Errorf("failed to get ReconfigVolumePolicy taskInfo from vCenter %q with err: %v",
+				m.virtualCenter.Config.Host, err)
+			if err != nil {
+				faultType = ExtractFaultTypeFromErr(ctx, err)
+			} else {
+				faultType = csifault.CSITaskInfoEmptyFault
+			}
+			return faultType, err
+		}
+		log.Infof("ReconfigVolumePolicy: volumeID: %q, storagePolicyID: %q, opId: %q", volumeID,
+			storagePolicyID, taskInfo.ActivationId)
+		// Get the task results for the given task.
+		taskResult, err := cns.GetTaskResult(ctx, taskInfo)
+		if err != nil {
+			log.Errorf("unable to find ReconfigVolumePolicy result from vCenter %q: taskID %q, opId %q",
+				m.virtualCenter.Config.Host, taskInfo.Task.Value, taskInfo.ActivationId)
+			return ExtractFaultTypeFromErr(ctx, err), err
+		}
+		if taskResult == nil {
+			return csifault.CSITaskResultEmptyFault, logger.LogNewErrorf(log,
+				"taskResult is empty for ReconfigVolumePolicy task: %q, opId: %q",
+				taskInfo.Task.Value, taskInfo.ActivationId)
+		}
+		volumeOperationRes := taskResult.GetCnsVolumeOperationResult()
+		if volumeOperationRes.Fault != nil {
+			faultType = ExtractFaultTypeFromVolumeResponseResult(ctx, volumeOperationRes)
+			return faultType, logger.LogNewErrorf(log,
+				"failed to reconfig storage policy of volume %q to %q. fault: %q, opID: %q",
+				volumeID, storagePolicyID, spew.Sdump(volumeOperationRes.Fault), taskInfo.ActivationId)
+		}
+		log.Infof("ReconfigVolumePolicy: storage policy of volume %q updated successfully to %q. opId: %q",
+			volumeID, storagePolicyID, taskInfo.ActivationId)
+		return "", nil
+	}
+	start := time.Now()
+	faultType, err := internalReconfigVolumePolicy()
+	if err != nil {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsReconfigVolumePolicyOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsReconfigVolumePolicyOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return faultType, err
+}
+
 func (m *defaultManager) IsListViewReady() bool {
 	if m.listViewIf == nil {
 		return false
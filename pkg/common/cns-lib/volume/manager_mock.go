@@ -199,3 +199,17 @@ func (m MockManager) SyncVolume(ctx context.Context, syncVolumeSpecs []cnstypes.
 	//TODO implement me
 	panic("implement me")
 }
+
+func (m MockManager) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string,
+	volumeIDs []string) ([]*CnsSnapshotInfo, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m MockManager) ReconfigVolumePolicy(ctx context.Context, volumeID string, storagePolicyID string) (string, error) {
+	if m.failRequest {
+		return "", m.err
+	}
+
+	return "", nil
+}
@@ -451,6 +451,32 @@ func invokeCNSCreateSnapshot(ctx context.Context, virtualCenter *cnsvsphere.Virt
 	return task, err
 }
 
+// invokeCNSCreateSnapshots invokes CreateSnapshots operation on CNS to snapshot
+// all the given volumes in a single task. Every snapshot gets snapshotName as
+// description.
+func invokeCNSCreateSnapshots(ctx context.Context, virtualCenter *cnsvsphere.VirtualCenter,
+	volumeIDs []string, snapshotName string) (*object.Task, error) {
+	log := logger.GetLogger(ctx)
+	cnsSnapshotCreateSpecList := make([]cnstypes.CnsSnapshotCreateSpec, 0, len(volumeIDs))
+	for _, volumeID := range volumeIDs {
+		cnsSnapshotCreateSpecList = append(cnsSnapshotCreateSpecList, cnstypes.CnsSnapshotCreateSpec{
+			VolumeId: cnstypes.CnsVolumeId{
+				Id: volumeID,
+			},
+			Description: snapshotName,
+		})
+	}
+
+	log.Infof("Calling CnsClient.CreateSnapshots: VolumeIDs [%v] Description [%q]"+
+		" cnsSnapshotCreateSpecList [%#v]", volumeIDs, snapshotName, cnsSnapshotCreateSpecList)
+	task, err := virtualCenter.CnsClient.CreateSnapshots(ctx, cnsSnapshotCreateSpecList)
+	if err != nil {
+		log.Errorf("CNS CreateSnapshots failed from vCenter %q with err: %v", virtualCenter.Config.Host, err)
+		return nil, err
+	}
+	return task, nil
+}
+
 // invokeCNSDeleteSnapshot invokes DeleteSnapshot operation for that volume on CNS.
 func invokeCNSDeleteSnapshot(ctx context.Context, virtualCenter *cnsvsphere.VirtualCenter,
 	volumeID string, snapshotID string) (*object.Task, error) {
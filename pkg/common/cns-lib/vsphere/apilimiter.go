@@ -0,0 +1,470 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+)
+
+// apiOperationClass is the class of a call to a vCenter API, used to cap the
+// number of concurrent calls.
+type apiOperationClass string
+
+const (
+	// apiOperationClassCreate is the class of the calls creating, deleting or
+	// expanding volumes and snapshots.
+	apiOperationClassCreate apiOperationClass = "create"
+	// apiOperationClassAttach is the class of the calls attaching or detaching
+	// volumes.
+	apiOperationClassAttach apiOperationClass = "attach"
+	// apiOperationClassQuery is the class of the calls querying volumes,
+	// snapshots, policies and properties.
+	apiOperationClassQuery apiOperationClass = "query"
+	// apiOperationClassOther is the class of the other calls, which are only
+	// rate limited.
+	apiOperationClassOther apiOperationClass = "other"
+	// apiOperationClassLongPoll is the class of the property collector long
+	// polls, which are neither limited nor accounted by the circuit breaker.
+	apiOperationClassLongPoll apiOperationClass = "long-poll"
+
+	// Reasons of the calls rejected by the client side limits.
+	rejectedReasonCircuitOpen  = "circuit-open"
+	rejectedReasonWaitCanceled = "wait-canceled"
+)
+
+var (
+	// apiOperationClasses maps the names of the vCenter API requests to
+	// their class, for the requests not classified by prefix.
+	apiOperationClasses = map[string]apiOperationClass{
+		"CnsCreateVolume":              apiOperationClassCreate,
+		"CnsDeleteVolume":              apiOperationClassCreate,
+		"CnsExtendVolume":              apiOperationClassCreate,
+		"CnsRelocateVolume":            apiOperationClassCreate,
+		"CnsCreateSnapshots":           apiOperationClassCreate,
+		"CnsDeleteSnapshot":            apiOperationClassCreate,
+		"CnsUnregisterVolume":          apiOperationClassCreate,
+		"CnsAttachVolume":              apiOperationClassAttach,
+		"CnsDetachVolume":              apiOperationClassAttach,
+		"ReconfigVM_Task":              apiOperationClassAttach,
+		"RetrieveProperties":           apiOperationClassQuery,
+		"RetrievePropertiesEx":         apiOperationClassQuery,
+		"ContinueRetrievePropertiesEx": apiOperationClassQuery,
+		"PbmRetrieveContent":           apiOperationClassQuery,
+		"WaitForUpdates":               apiOperationClassLongPoll,
+		"WaitForUpdatesEx":             apiOperationClassLongPoll,
+		"CancelWaitForUpdates":         apiOperationClassLongPoll,
+	}
+	// apiQueryRequestPrefixes are the prefixes of the names of the query
+	// requests.
+	apiQueryRequestPrefixes = []string{"CnsQuery", "PbmQuery", "VsanQuery"}
+
+	// apiLimiters maps the vCenter hosts to the limiter of their calls.
+	apiLimiters = make(map[string]*apiLimiter)
+	// apiLimitersLock protects apiLimiters.
+	apiLimitersLock = &sync.RWMutex{}
+)
+
+// APILimitsConfig configures the client side limits of the calls to a
+// vCenter. Zero values disable the corresponding limit.
+type APILimitsConfig struct {
+	// QPS is the rate of calls allowed per second.
+	QPS float64
+	// Burst is the maximum burst of calls above QPS.
+	Burst int
+	// MaxConcurrentOps is the maximum number of concurrent calls per
+	// operation class.
+	MaxConcurrentOps map[apiOperationClass]int
+	// RequestTimeout is the timeout of the calls, except the long polls.
+	RequestTimeout time.Duration
+	// CircuitBreakerFailureThreshold is the number of consecutive failed calls
+	// after which the circuit breaker opens.
+	CircuitBreakerFailureThreshold int
+	// CircuitBreakerOpenDuration is the duration for which the calls fail fast
+	// once the circuit breaker has opened.
+	CircuitBreakerOpenDuration time.Duration
+}
+
+// newAPILimitsConfig returns the API limits set in the given [VirtualCenter]
+// config section.
+func newAPILimitsConfig(vcConfig *config.VirtualCenterConfig) APILimitsConfig {
+	return APILimitsConfig{
+		QPS:   vcConfig.APIQPS,
+		Burst: vcConfig.APIBurst,
+		MaxConcurrentOps: map[apiOperationClass]int{
+			apiOperationClassCreate: vcConfig.MaxConcurrentCreateOps,
+			apiOperationClassAttach: vcConfig.MaxConcurrentAttachOps,
+			apiOperationClassQuery:  vcConfig.MaxConcurrentQueryOps,
+		},
+		RequestTimeout:                 time.Duration(vcConfig.APIRequestTimeoutInSec) * time.Second,
+		CircuitBreakerFailureThreshold: vcConfig.CircuitBreakerFailureThreshold,
+		CircuitBreakerOpenDuration:     time.Duration(vcConfig.CircuitBreakerOpenDurationInSec) * time.Second,
+	}
+}
+
+// isEnabled returns true if any limit is set.
+func (c APILimitsConfig) isEnabled() bool {
+	if c.QPS > 0 || c.RequestTimeout > 0 || c.CircuitBreakerFailureThreshold > 0 {
+		return true
+	}
+	for _, maxOps := range c.MaxConcurrentOps {
+		if maxOps > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// equal returns true if both configs set the same limits.
+func (c APILimitsConfig) equal(other APILimitsConfig) bool {
+	if c.QPS != other.QPS || c.Burst != other.Burst || c.RequestTimeout != other.RequestTimeout ||
+		c.CircuitBreakerFailureThreshold != other.CircuitBreakerFailureThreshold ||
+		c.CircuitBreakerOpenDuration != other.CircuitBreakerOpenDuration {
+		return false
+	}
+	for _, class := range []apiOperationClass{apiOperationClassCreate, apiOperationClassAttach,
+		apiOperationClassQuery} {
+		if c.MaxConcurrentOps[class] != other.MaxConcurrentOps[class] {
+			return false
+		}
+	}
+	return true
+}
+
+// getAPIOperationClass returns the class of the vCenter API request with the
+// given name.
+func getAPIOperationClass(requestName string) apiOperationClass {
+	if class, ok := apiOperationClasses[requestName]; ok {
+		return class
+	}
+	for _, prefix := range apiQueryRequestPrefixes {
+		if strings.HasPrefix(requestName, prefix) {
+			return apiOperationClassQuery
+		}
+	}
+	return apiOperationClassOther
+}
+
+// apiLimiter limits the calls to a vCenter.
+type apiLimiter struct {
+	host        string
+	config      APILimitsConfig
+	rateLimiter *rate.Limiter
+	semaphores  map[apiOperationClass]chan struct{}
+	breaker     *circuitBreaker
+}
+
+// newAPILimiter returns a limiter of the calls to the given vCenter host. The
+// state of the previous limiter of the host, if any, is carried over for the
+// limits that are still set: the tokens of the rate limiter, the calls in
+// flight and the circuit breaker. This keeps the budget of the vCenter shared
+// by all its clients when its limits are reconfigured.
+func newAPILimiter(host string, limitsConfig APILimitsConfig, previous *apiLimiter) *apiLimiter {
+	limiter := &apiLimiter{
+		host:       host,
+		config:     limitsConfig,
+		semaphores: make(map[apiOperationClass]chan struct{}),
+	}
+	if previous == nil {
+		previous = &apiLimiter{}
+	}
+	if limitsConfig.QPS > 0 {
+		burst := limitsConfig.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(limitsConfig.QPS))
+		}
+		if previous.rateLimiter != nil {
+			limiter.rateLimiter = previous.rateLimiter
+			limiter.rateLimiter.SetLimit(rate.Limit(limitsConfig.QPS))
+			limiter.rateLimiter.SetBurst(burst)
+		} else {
+			limiter.rateLimiter = rate.NewLimiter(rate.Limit(limitsConfig.QPS), burst)
+		}
+	}
+	for class, maxOps := range limitsConfig.MaxConcurrentOps {
+		if maxOps <= 0 {
+			continue
+		}
+		if previous.config.MaxConcurrentOps[class] == maxOps && previous.semaphores[class] != nil {
+			limiter.semaphores[class] = previous.semaphores[class]
+		} else {
+			limiter.semaphores[class] = make(chan struct{}, maxOps)
+		}
+	}
+	if limitsConfig.CircuitBreakerFailureThreshold > 0 {
+		if previous.breaker != nil &&
+			previous.config.CircuitBreakerFailureThreshold == limitsConfig.CircuitBreakerFailureThreshold &&
+			previous.config.CircuitBreakerOpenDuration == limitsConfig.CircuitBreakerOpenDuration {
+			limiter.breaker = previous.breaker
+		} else {
+			limiter.breaker = newCircuitBreaker(host, limitsConfig.CircuitBreakerFailureThreshold,
+				limitsConfig.CircuitBreakerOpenDuration)
+		}
+	}
+	return limiter
+}
+
+// configureAPILimiter sets the limits of the calls to the given vCenter host.
+// There is a single limiter per vCenter host, shared by all the clients of
+// the vCenter, including the ones created when reconnecting to it. The state
+// of the limiter is kept if the limits have not changed.
+func configureAPILimiter(host string, limitsConfig APILimitsConfig) {
+	apiLimitersLock.Lock()
+	defer apiLimitersLock.Unlock()
+	previous, ok := apiLimiters[host]
+	if ok && previous.config.equal(limitsConfig) {
+		return
+	}
+	if !limitsConfig.isEnabled() {
+		delete(apiLimiters, host)
+		prometheus.VCenterAPICircuitBreakerStateGaugeVec.DeleteLabelValues(host)
+		return
+	}
+	apiLimiters[host] = newAPILimiter(host, limitsConfig, previous)
+	if apiLimiters[host].breaker == nil {
+		prometheus.VCenterAPICircuitBreakerStateGaugeVec.DeleteLabelValues(host)
+	}
+}
+
+// getAPILimiter returns the limiter of the calls to the given vCenter host, or
+// nil if no limits are configured.
+func getAPILimiter(host string) *apiLimiter {
+	apiLimitersLock.RLock()
+	defer apiLimitersLock.RUnlock()
+	return apiLimiters[host]
+}
+
+// newRoundTripper returns the round tripper chain of the calls of the given
+// client to the given vCenter host: the limiter of the vCenter and then the
+// request metrics.
+func newRoundTripper(host string, clientName string, rt soap.RoundTripper) soap.RoundTripper {
+	return &LimitRoundTripper{host: host, roundTripper: &MetricRoundTripper{clientName, rt}}
+}
+
+// LimitRoundTripper applies the rate limit, concurrency caps, timeout and
+// circuit breaker of a vCenter to its calls. The limiter of the vCenter is
+// looked up for every call, so that all the clients of the vCenter share the
+// same limiter, even after it is reconfigured.
+type LimitRoundTripper struct {
+	host         string
+	roundTripper soap.RoundTripper
+}
+
+// RoundTrip waits for the limits of the vCenter before making the call. It
+// fails fast with codes.Unavailable while the circuit breaker is open.
+func (lrt *LimitRoundTripper) RoundTrip(ctx context.Context, req, resp soap.HasFault) error {
+	class := getAPIOperationClass(getRequestName(req))
+	if class == apiOperationClassLongPoll {
+		return lrt.roundTripper.RoundTrip(ctx, req, resp)
+	}
+	limiter := getAPILimiter(lrt.host)
+	if limiter == nil {
+		return lrt.roundTripper.RoundTrip(ctx, req, resp)
+	}
+	if limiter.breaker != nil {
+		if err := limiter.breaker.allow(); err != nil {
+			prometheus.VCenterAPIRejectedRequestsCounterVec.WithLabelValues(limiter.host,
+				rejectedReasonCircuitOpen).Inc()
+			return err
+		}
+	}
+	release, err := limiter.wait(ctx, class)
+	if err != nil {
+		if limiter.breaker != nil {
+			limiter.breaker.record(circuitBreakerResultIgnored)
+		}
+		prometheus.VCenterAPIRejectedRequestsCounterVec.WithLabelValues(limiter.host,
+			rejectedReasonWaitCanceled).Inc()
+		return err
+	}
+	defer release()
+
+	callCtx := ctx
+	if limiter.config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, limiter.config.RequestTimeout)
+		defer cancel()
+	}
+	err = lrt.roundTripper.RoundTrip(callCtx, req, resp)
+	if limiter.breaker != nil {
+		limiter.breaker.record(getCircuitBreakerResult(ctx, err))
+	}
+	return err
+}
+
+// wait waits for the rate limiter and for a free slot of the given operation
+// class. The returned function releases the slot.
+func (l *apiLimiter) wait(ctx context.Context, class apiOperationClass) (func(), error) {
+	start := time.Now()
+	if l.rateLimiter != nil {
+		if err := l.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	semaphore := l.semaphores[class]
+	if semaphore != nil {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	prometheus.VCenterAPILimiterWaitHistVec.WithLabelValues(l.host, string(class)).
+		Observe(time.Since(start).Seconds())
+	inflight := prometheus.VCenterAPIInflightRequestsGaugeVec.WithLabelValues(l.host, string(class))
+	inflight.Inc()
+	return func() {
+		inflight.Dec()
+		if semaphore != nil {
+			<-semaphore
+		}
+	}, nil
+}
+
+// circuitBreakerResult is the outcome of a call, as seen by the circuit
+// breaker.
+type circuitBreakerResult int
+
+const (
+	// circuitBreakerResultSuccess is the result of the calls vCenter responded
+	// to, including with a fault.
+	circuitBreakerResultSuccess circuitBreakerResult = iota
+	// circuitBreakerResultFailure is the result of the calls vCenter did not
+	// respond to, e.g. because of a network error or a timeout.
+	circuitBreakerResultFailure
+	// circuitBreakerResultIgnored is the result of the calls canceled by the
+	// caller.
+	circuitBreakerResultIgnored
+)
+
+// getCircuitBreakerResult returns the result of a call made with the given
+// context and returning the given error.
+func getCircuitBreakerResult(ctx context.Context, err error) circuitBreakerResult {
+	if err == nil || soap.IsSoapFault(err) || soap.IsVimFault(err) {
+		return circuitBreakerResultSuccess
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return circuitBreakerResultIgnored
+	}
+	return circuitBreakerResultFailure
+}
+
+// circuitBreakerState is the state of a circuit breaker.
+type circuitBreakerState int
+
+const (
+	// circuitBreakerClosed lets all the calls through.
+	circuitBreakerClosed circuitBreakerState = iota
+	// circuitBreakerOpen rejects all the calls.
+	circuitBreakerOpen
+	// circuitBreakerHalfOpen lets a single trial call through.
+	circuitBreakerHalfOpen
+)
+
+// circuitBreaker fails the calls to a vCenter fast after a number of
+// consecutive failures. Once the open duration has elapsed, a single trial
+// call is let through, which closes the circuit breaker if it succeeds and
+// opens it again otherwise.
+type circuitBreaker struct {
+	host             string
+	failureThreshold int
+	openDuration     time.Duration
+	// now returns the current time. It is replaced in unit tests.
+	now func() time.Time
+
+	mutex               sync.Mutex
+	state               circuitBreakerState
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInFlight       bool
+}
+
+// newCircuitBreaker returns a closed circuit breaker for the given vCenter.
+func newCircuitBreaker(host string, failureThreshold int, openDuration time.Duration) *circuitBreaker {
+	breaker := &circuitBreaker{
+		host:             host,
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+	}
+	breaker.setState(circuitBreakerClosed)
+	return breaker
+}
+
+// allow returns a codes.Unavailable error if the call must fail fast.
+func (cb *circuitBreaker) allow() error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state == circuitBreakerOpen && cb.now().Sub(cb.openedAt) >= cb.openDuration {
+		cb.setState(circuitBreakerHalfOpen)
+	}
+	switch cb.state {
+	case circuitBreakerOpen:
+		return status.Errorf(codes.Unavailable, "vCenter %q is unavailable: circuit breaker is open after "+
+			"%d consecutive failed calls", cb.host, cb.consecutiveFailures)
+	case circuitBreakerHalfOpen:
+		if cb.trialInFlight {
+			return status.Errorf(codes.Unavailable, "vCenter %q is unavailable: circuit breaker is "+
+				"waiting for the result of a trial call", cb.host)
+		}
+		cb.trialInFlight = true
+	}
+	return nil
+}
+
+// record updates the state of the circuit breaker with the result of a call
+// it allowed.
+func (cb *circuitBreaker) record(result circuitBreakerResult) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state == circuitBreakerHalfOpen {
+		cb.trialInFlight = false
+	}
+	switch result {
+	case circuitBreakerResultSuccess:
+		cb.consecutiveFailures = 0
+		if cb.state != circuitBreakerClosed {
+			cb.setState(circuitBreakerClosed)
+		}
+	case circuitBreakerResultFailure:
+		cb.consecutiveFailures++
+		if cb.state == circuitBreakerHalfOpen ||
+			(cb.state == circuitBreakerClosed && cb.consecutiveFailures >= cb.failureThreshold) {
+			cb.openedAt = cb.now()
+			cb.setState(circuitBreakerOpen)
+		}
+	}
+}
+
+// setState sets the state of the circuit breaker and its metric. The mutex
+// must be held by the caller, if the breaker is shared.
+func (cb *circuitBreaker) setState(state circuitBreakerState) {
+	cb.state = state
+	prometheus.VCenterAPICircuitBreakerStateGaugeVec.WithLabelValues(cb.host).Set(float64(state))
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cnsmethods "github.com/vmware/govmomi/cns/methods"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRoundTripper returns the errors of its calls from a channel, or err if
+// the channel is nil.
+type fakeRoundTripper struct {
+	err     error
+	results chan error
+	calls   atomic.Int32
+}
+
+func (f *fakeRoundTripper) RoundTrip(ctx context.Context, req, resp soap.HasFault) error {
+	f.calls.Add(1)
+	if f.results == nil {
+		return f.err
+	}
+	select {
+	case err := <-f.results:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestGetAPIOperationClass(t *testing.T) {
+	assert.Equal(t, apiOperationClassCreate, getAPIOperationClass("CnsCreateVolume"))
+	assert.Equal(t, apiOperationClassAttach, getAPIOperationClass("CnsAttachVolume"))
+	assert.Equal(t, apiOperationClassQuery, getAPIOperationClass("CnsQueryAllVolume"))
+	assert.Equal(t, apiOperationClassQuery, getAPIOperationClass("PbmQueryProfile"))
+	assert.Equal(t, apiOperationClassQuery, getAPIOperationClass("RetrievePropertiesEx"))
+	assert.Equal(t, apiOperationClassLongPoll, getAPIOperationClass("WaitForUpdatesEx"))
+	assert.Equal(t, apiOperationClassOther, getAPIOperationClass("CnsUpdateVolume"))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker("test-vc", 2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	// A successful call resets the consecutive failures.
+	assert.NoError(t, breaker.allow())
+	breaker.record(circuitBreakerResultFailure)
+	assert.NoError(t, breaker.allow())
+	breaker.record(circuitBreakerResultSuccess)
+	assert.NoError(t, breaker.allow())
+	breaker.record(circuitBreakerResultFailure)
+	assert.NoError(t, breaker.allow())
+	breaker.record(circuitBreakerResultFailure)
+	err := breaker.allow()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// A single trial call is allowed once the open duration has elapsed.
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.allow())
+	assert.Equal(t, codes.Unavailable, status.Code(breaker.allow()))
+	breaker.record(circuitBreakerResultFailure)
+	assert.Equal(t, circuitBreakerOpen, breaker.state)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.allow())
+	breaker.record(circuitBreakerResultSuccess)
+	assert.Equal(t, circuitBreakerClosed, breaker.state)
+	assert.NoError(t, breaker.allow())
+}
+
+func TestGetCircuitBreakerResult(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, circuitBreakerResultSuccess, getCircuitBreakerResult(ctx, nil))
+	assert.Equal(t, circuitBreakerResultSuccess, getCircuitBreakerResult(ctx,
+		soap.WrapVimFault(&types.NotFound{})))
+	assert.Equal(t, circuitBreakerResultFailure, getCircuitBreakerResult(ctx, errors.New("connection refused")))
+	assert.Equal(t, circuitBreakerResultFailure, getCircuitBreakerResult(ctx, context.DeadlineExceeded))
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, circuitBreakerResultIgnored, getCircuitBreakerResult(canceledCtx, context.Canceled))
+}
+
+func TestLimitRoundTripperCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	rt := &fakeRoundTripper{err: errors.New("connection reset by peer")}
+	host := "test-circuit-breaker-vc"
+	configureAPILimiter(host, APILimitsConfig{
+		CircuitBreakerFailureThreshold: 2,
+		CircuitBreakerOpenDuration:     time.Minute,
+	})
+	defer configureAPILimiter(host, APILimitsConfig{})
+	lrt := &LimitRoundTripper{host: host, roundTripper: rt}
+	req := &cnsmethods.CnsCreateVolumeBody{Req: &cnstypes.CnsCreateVolume{}}
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, rt.err, lrt.RoundTrip(ctx, req, req))
+	}
+	err := lrt.RoundTrip(ctx, req, req)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(2), rt.calls.Load())
+
+	// Long polls are not failed fast.
+	longPoll := &methods.WaitForUpdatesExBody{Req: &types.WaitForUpdatesEx{}}
+	assert.Equal(t, rt.err, lrt.RoundTrip(ctx, longPoll, longPoll))
+	assert.Equal(t, int32(3), rt.calls.Load())
+}
+
+func TestLimitRoundTripperConcurrencyCap(t *testing.T) {
+	ctx := context.Background()
+	rt := &fakeRoundTripper{results: make(chan error)}
+	host := "test-concurrency-cap-vc"
+	configureAPILimiter(host, APILimitsConfig{
+		MaxConcurrentOps: map[apiOperationClass]int{apiOperationClassAttach: 1},
+	})
+	defer configureAPILimiter(host, APILimitsConfig{})
+	limiter := getAPILimiter(host)
+	lrt := &LimitRoundTripper{host: host, roundTripper: rt}
+	attach := &cnsmethods.CnsAttachVolumeBody{Req: &cnstypes.CnsAttachVolume{}}
+
+	done := make(chan error)
+	go func() {
+		done <- lrt.RoundTrip(ctx, attach, attach)
+	}()
+	// Wait for the first call to take the only attach slot.
+	assert.Eventually(t, func() bool { return len(limiter.semaphores[apiOperationClassAttach]) == 1 },
+		time.Second, time.Millisecond)
+
+	// A second attach call waits for the slot until its context expires.
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, lrt.RoundTrip(waitCtx, attach, attach), context.DeadlineExceeded)
+
+	// Calls of other classes are not capped.
+	query := &cnsmethods.CnsQueryVolumeBody{Req: &cnstypes.CnsQueryVolume{}}
+	queryDone := make(chan error)
+	go func() {
+		queryDone <- lrt.RoundTrip(ctx, query, query)
+	}()
+	rt.results <- nil
+	rt.results <- nil
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-queryDone)
+	assert.Empty(t, limiter.semaphores[apiOperationClassAttach])
+}
+
+func TestConfigureAPILimiter(t *testing.T) {
+	host := "test-configure-vc"
+	defer configureAPILimiter(host, APILimitsConfig{})
+	ctx := context.Background()
+	rt := &fakeRoundTripper{}
+	req := &cnsmethods.CnsCreateVolumeBody{Req: &cnstypes.CnsCreateVolume{}}
+
+	// The calls are not limited when no limits are configured.
+	lrt := newRoundTripper(host, "cns", rt)
+	assert.Nil(t, getAPILimiter(host))
+	assert.NoError(t, lrt.RoundTrip(ctx, req, req))
+
+	limitsConfig := APILimitsConfig{
+		QPS:                            1,
+		MaxConcurrentOps:               map[apiOperationClass]int{apiOperationClassCreate: 2},
+		CircuitBreakerFailureThreshold: 3,
+	}
+	configureAPILimiter(host, limitsConfig)
+	limiter := getAPILimiter(host)
+	assert.NotNil(t, limiter)
+	assert.Equal(t, 1, limiter.rateLimiter.Burst())
+
+	// The limiter is kept if the limits have not changed.
+	configureAPILimiter(host, limitsConfig)
+	assert.Same(t, limiter, getAPILimiter(host))
+
+	// The clients created before and after a reconnect share the same
+	// budget: the token used by the first client is not refilled for the
+	// second one.
+	assert.NoError(t, lrt.RoundTrip(ctx, req, req))
+	reconnectedLrt := newRoundTripper(host, "cns", rt)
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, reconnectedLrt.RoundTrip(waitCtx, req, req))
+
+	// The state of the limiter is carried over when the limits change.
+	limitsConfig.QPS = 2
+	configureAPILimiter(host, limitsConfig)
+	updated := getAPILimiter(host)
+	assert.NotSame(t, limiter, updated)
+	assert.Same(t, limiter.rateLimiter, updated.rateLimiter)
+	assert.Equal(t, 2, updated.rateLimiter.Burst())
+	assert.Equal(t, limiter.semaphores[apiOperationClassCreate], updated.semaphores[apiOperationClassCreate])
+	assert.Same(t, limiter.breaker, updated.breaker)
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+const (
+	// EnvCredentialExecVCenterHost is the environment variable set to the
+	// vCenter host for the command of an ExecCredentialProvider.
+	EnvCredentialExecVCenterHost = "VSPHERE_CSI_VCENTER_HOST"
+	// credentialExecTimeout is the timeout of the command of an
+	// ExecCredentialProvider.
+	credentialExecTimeout = 30 * time.Second
+)
+
+var (
+	// credentialProviders are the file and exec credential providers created
+	// from the vSphere config, keyed by their settings, so that the config can
+	// be read again without starting new watches.
+	credentialProviders = make(map[string]CredentialProvider)
+	// credentialProvidersLock protects credentialProviders.
+	credentialProvidersLock = &sync.Mutex{}
+)
+
+// Credentials are the credentials used to log in to a virtual center, either
+// a user and password, or a certificate and private key in PEM format.
+type Credentials struct {
+	// Username is the user, or the certificate in PEM format.
+	Username string `json:"username"`
+	// Password is the password in clear text, or the private key in PEM format.
+	Password string `json:"password"`
+}
+
+// CredentialProvider provides the credentials used to log in to a virtual
+// center. It is called for every login, so the credentials can be rotated
+// without recreating the VirtualCenter.
+type CredentialProvider interface {
+	// GetCredentials returns the current credentials.
+	GetCredentials(ctx context.Context) (*Credentials, error)
+}
+
+// configCredentialProvider provides the user and password of the
+// VirtualCenterConfig. It is used when no other provider is configured.
+type configCredentialProvider struct {
+	config *VirtualCenterConfig
+}
+
+// GetCredentials returns the user and password of the VirtualCenterConfig.
+func (p *configCredentialProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	return &Credentials{Username: p.config.Username, Password: p.config.Password}, nil
+}
+
+// FileCredentialProvider provides the credentials read from a JSON file with
+// "username" and "password" fields, e.g. mounted from a Kubernetes secret. The
+// file is watched and read again when it changes.
+type FileCredentialProvider struct {
+	path        string
+	mu          sync.RWMutex
+	credentials *Credentials
+	err         error
+}
+
+// NewFileCredentialProvider reads the credentials of the file at the given
+// path and starts watching its directory for changes.
+func NewFileCredentialProvider(ctx context.Context, path string) (*FileCredentialProvider, error) {
+	log := logger.GetLogger(ctx)
+	p := &FileCredentialProvider{path: path}
+	p.reload(ctx)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to create fsnotify watcher. err=%v", err)
+	}
+	// Kubernetes updates the files of mounted secrets by replacing a symlink in
+	// their directory, so the directory is watched instead of the file.
+	dirPath := filepath.Dir(path)
+	if err := watcher.Add(dirPath); err != nil {
+		_ = watcher.Close()
+		return nil, logger.LogNewErrorf(log, "failed to watch on path: %q. err=%v", dirPath, err)
+	}
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				log.Debugf("fsnotify event for credential file %q: %q", path, event.String())
+				p.reload(ctx)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Errorf("fsnotify error while watching credential file %q: %+v", path, err)
+			}
+		}
+	}()
+	return p, nil
+}
+
+// reload reads the credentials of the file. The last credentials read are
+// kept if the file cannot be read, e.g. while it is being replaced.
+func (p *FileCredentialProvider) reload(ctx context.Context) {
+	log := logger.GetLogger(ctx)
+	credentials, err := readCredentialFile(p.path)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		log.Errorf("failed to read credential file %q. err: %v", p.path, err)
+		if p.credentials == nil {
+			p.err = err
+		}
+		return
+	}
+	if p.credentials != nil && *p.credentials != *credentials {
+		log.Infof("Credentials of credential file %q have changed", p.path)
+	}
+	p.credentials = credentials
+	p.err = nil
+}
+
+// GetCredentials returns the last credentials read from the file.
+func (p *FileCredentialProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	credentials := *p.credentials
+	return &credentials, nil
+}
+
+// readCredentialFile reads the JSON credentials of the file at the given path.
+func readCredentialFile(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	credentials := &Credentials{}
+	if err := json.Unmarshal(data, credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse credential file %q. err: %v", path, err)
+	}
+	if credentials.Username == "" || credentials.Password == "" {
+		return nil, fmt.Errorf("credential file %q must have a username and a password", path)
+	}
+	return credentials, nil
+}
+
+// execCredentials is the output of the command of an ExecCredentialProvider.
+type execCredentials struct {
+	Credentials
+	// ExpirationTimestamp is the time in RFC 3339 format until which the
+	// credentials can be reused. The command is run for every login if not set.
+	ExpirationTimestamp *time.Time `json:"expirationTimestamp,omitempty"`
+}
+
+// ExecCredentialProvider provides the credentials printed in JSON by a
+// command, with "username", "password" and an optional "expirationTimestamp".
+// The vCenter host is passed to the command in the VSPHERE_CSI_VCENTER_HOST
+// environment variable.
+type ExecCredentialProvider struct {
+	host        string
+	command     string
+	args        []string
+	mu          sync.Mutex
+	credentials *Credentials
+	expiration  time.Time
+	now         func() time.Time
+}
+
+// NewExecCredentialProvider returns an ExecCredentialProvider running the
+// given command for the vCenter host.
+func NewExecCredentialProvider(host string, command string, args []string) *ExecCredentialProvider {
+	return &ExecCredentialProvider{
+		host:    host,
+		command: command,
+		args:    args,
+		now:     time.Now,
+	}
+}
+
+// GetCredentials returns the credentials printed by the command, or the last
+// ones if they have not expired yet.
+func (p *ExecCredentialProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	log := logger.GetLogger(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.credentials != nil && p.now().Before(p.expiration) {
+		credentials := *p.credentials
+		return &credentials, nil
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, credentialExecTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(execCtx, p.command, p.args...)
+	cmd.Env = append(os.Environ(), EnvCredentialExecVCenterHost+"="+p.host)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to run credential command %q for vCenter %q. err: %v, stderr: %s",
+			p.command, p.host, err, strings.TrimSpace(stderr.String()))
+	}
+	result := &execCredentials{}
+	if err := json.Unmarshal(output, result); err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to parse the output of credential command %q. err: %v",
+			p.command, err)
+	}
+	if result.Username == "" || result.Password == "" {
+		return nil, logger.LogNewErrorf(log, "credential command %q must print a username and a password",
+			p.command)
+	}
+	p.credentials = &result.Credentials
+	p.expiration = time.Time{}
+	if result.ExpirationTimestamp != nil {
+		p.expiration = *result.ExpirationTimestamp
+	}
+	credentials := *p.credentials
+	return &credentials, nil
+}
+
+// getCredentialProvider returns the credential provider configured for the
+// vCenter host, or nil if the user and password of the config are used. The
+// providers are reused for the same settings.
+func getCredentialProvider(ctx context.Context, host string,
+	vcConfig *config.VirtualCenterConfig) (CredentialProvider, error) {
+	var key string
+	switch vcConfig.CredentialProvider {
+	case "", config.CredentialProviderConfig:
+		return nil, nil
+	case config.CredentialProviderFile:
+		key = strings.Join([]string{config.CredentialProviderFile, vcConfig.CredentialFile}, "\x00")
+	case config.CredentialProviderExec:
+		key = strings.Join(append([]string{config.CredentialProviderExec, host, vcConfig.CredentialExecCommand},
+			vcConfig.CredentialExecArgs...), "\x00")
+	default:
+		return nil, fmt.Errorf("invalid credential provider %q for vCenter %q",
+			vcConfig.CredentialProvider, host)
+	}
+
+	credentialProvidersLock.Lock()
+	defer credentialProvidersLock.Unlock()
+	if provider, ok := credentialProviders[key]; ok {
+		return provider, nil
+	}
+	var provider CredentialProvider
+	if vcConfig.CredentialProvider == config.CredentialProviderFile {
+		fileProvider, err := NewFileCredentialProvider(ctx, vcConfig.CredentialFile)
+		if err != nil {
+			return nil, err
+		}
+		provider = fileProvider
+	} else {
+		provider = NewExecCredentialProvider(host, vcConfig.CredentialExecCommand, vcConfig.CredentialExecArgs)
+	}
+	credentialProviders[key] = provider
+	return provider, nil
+}
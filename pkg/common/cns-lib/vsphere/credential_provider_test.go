@@ -0,0 +1,185 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/simulator"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+)
+
+// countingCredentialProvider returns the given credentials and counts its
+// calls.
+type countingCredentialProvider struct {
+	credentials Credentials
+	calls       int
+}
+
+func (p *countingCredentialProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	p.calls++
+	credentials := p.credentials
+	return &credentials, nil
+}
+
+func TestFileCredentialProvider(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	err := os.WriteFile(path, []byte(`{"username": "user1@vsphere.local", "password": "pass1"}`), 0600)
+	assert.NoError(t, err)
+
+	provider, err := NewFileCredentialProvider(ctx, path)
+	assert.NoError(t, err)
+	credentials, err := provider.GetCredentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &Credentials{Username: "user1@vsphere.local", Password: "pass1"}, credentials)
+
+	// Kubernetes replaces the files of the secrets instead of writing them.
+	newPath := filepath.Join(filepath.Dir(path), "credentials.json.new")
+	err = os.WriteFile(newPath, []byte(`{"username": "user1@vsphere.local", "password": "pass2"}`), 0600)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Rename(newPath, path))
+	assert.Eventually(t, func() bool {
+		credentials, err := provider.GetCredentials(ctx)
+		return err == nil && credentials.Password == "pass2"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The last credentials are kept while the file is invalid.
+	err = os.WriteFile(path, []byte(`{"username": "user1@vsphere.local"}`), 0600)
+	assert.NoError(t, err)
+	provider.reload(ctx)
+	credentials, err = provider.GetCredentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "pass2", credentials.Password)
+
+	_, err = NewFileCredentialProvider(ctx, filepath.Join(t.TempDir(), "missing", "credentials.json"))
+	assert.Error(t, err)
+}
+
+func TestExecCredentialProvider(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	countFile := filepath.Join(t.TempDir(), "count")
+	script := `echo run >> "$1"; ` +
+		`printf '{"username": "%s", "password": "pass", "expirationTimestamp": "%s"}' "$VSPHERE_CSI_VCENTER_HOST" "$2"`
+	provider := NewExecCredentialProvider("vc1.example.com", "/bin/sh",
+		[]string{"-c", script, "sh", countFile, now.Add(time.Hour).Format(time.RFC3339)})
+	provider.now = func() time.Time { return now }
+	runs := func() int {
+		data, err := os.ReadFile(countFile)
+		assert.NoError(t, err)
+		return len(data) / len("run\n")
+	}
+
+	credentials, err := provider.GetCredentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &Credentials{Username: "vc1.example.com", Password: "pass"}, credentials)
+
+	// The credentials are reused until they expire.
+	_, err = provider.GetCredentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, runs())
+	now = now.Add(2 * time.Hour)
+	_, err = provider.GetCredentials(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, runs())
+
+	provider = NewExecCredentialProvider("vc1.example.com", "/bin/sh", []string{"-c", `echo '{"username": "u"}'`})
+	_, err = provider.GetCredentials(ctx)
+	assert.Error(t, err)
+}
+
+func TestGetCredentialProvider(t *testing.T) {
+	ctx := context.Background()
+	provider, err := getCredentialProvider(ctx, "vc1", &config.VirtualCenterConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+
+	vcConfig := &config.VirtualCenterConfig{
+		CredentialProvider:    config.CredentialProviderExec,
+		CredentialExecCommand: "/usr/local/bin/vc-credentials",
+	}
+	provider, err = getCredentialProvider(ctx, "vc1", vcConfig)
+	assert.NoError(t, err)
+	assert.IsType(t, &ExecCredentialProvider{}, provider)
+	// The provider is reused when the config is read again.
+	sameProvider, err := getCredentialProvider(ctx, "vc1", vcConfig)
+	assert.NoError(t, err)
+	assert.Same(t, provider, sameProvider)
+
+	_, err = getCredentialProvider(ctx, "vc1", &config.VirtualCenterConfig{CredentialProvider: "vault"})
+	assert.Error(t, err)
+}
+
+func TestConnectLogsInAgainInPlace(t *testing.T) {
+	ctx := context.Background()
+	model := simulator.VPX()
+	defer model.Remove()
+	err := model.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.Service.TLS = new(tls.Config)
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	// The session user agent is read from the vSphere config.
+	cfgPath := filepath.Join(t.TempDir(), "vsphere.conf")
+	conf := fmt.Sprintf("[Global]\ncluster-id = \"test-cluster\"\n[VirtualCenter \"%s\"]\n"+
+		"user = \"administrator@vsphere.local\"\npassword = \"pass\"\nport = \"%s\"\n",
+		s.URL.Hostname(), s.URL.Port())
+	assert.NoError(t, os.WriteFile(cfgPath, []byte(conf), 0600))
+	t.Setenv("VSPHERE_CSI_CONFIG", cfgPath)
+
+	port, err := strconv.Atoi(s.URL.Port())
+	assert.NoError(t, err)
+	password, _ := s.URL.User.Password()
+	provider := &countingCredentialProvider{
+		credentials: Credentials{Username: s.URL.User.Username(), Password: password},
+	}
+	vc := &VirtualCenter{
+		Config: &VirtualCenterConfig{
+			Host:               s.URL.Hostname(),
+			Port:               port,
+			Insecure:           true,
+			CredentialProvider: provider,
+		},
+		ClientMutex: &sync.Mutex{},
+	}
+	assert.NoError(t, vc.Connect(ctx))
+	client := vc.Client
+	assert.Equal(t, 1, provider.calls)
+
+	// The session expires, e.g. after the password has been rotated.
+	assert.NoError(t, vc.Client.SessionManager.Logout(ctx))
+	assert.Error(t, vc.CheckSession(ctx))
+
+	assert.NoError(t, vc.Connect(ctx))
+	assert.NoError(t, vc.CheckSession(ctx))
+	assert.Same(t, client, vc.Client)
+	assert.Equal(t, 2, provider.calls)
+}
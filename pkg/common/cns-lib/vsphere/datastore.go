@@ -58,3 +58,28 @@ func (ds *Datastore) GetDatastoreURLAndType(ctx context.Context) (string, string
 	}
 	return dsMo.Summary.Url, dsMo.Summary.Type, nil
 }
+
+// GetCluster returns the cluster of the hosts the datastore is mounted on,
+// e.g. the vSAN cluster of a vSAN datastore.
+func (ds *Datastore) GetCluster(ctx context.Context) (types.ManagedObjectReference, error) {
+	log := logger.GetLogger(ctx)
+	var dsMo mo.Datastore
+	pc := property.DefaultCollector(ds.Client())
+	err := pc.RetrieveOne(ctx, ds.Datastore.Reference(), []string{"host"}, &dsMo)
+	if err != nil {
+		log.Errorf("Failed to retrieve datastore host property: %v", err)
+		return types.ManagedObjectReference{}, err
+	}
+	for _, hostMount := range dsMo.Host {
+		var hostMo mo.HostSystem
+		err = pc.RetrieveOne(ctx, hostMount.Key, []string{"parent"}, &hostMo)
+		if err != nil {
+			log.Errorf("Failed to retrieve parent of host %v: %v", hostMount.Key, err)
+			return types.ManagedObjectReference{}, err
+		}
+		if hostMo.Parent != nil && hostMo.Parent.Type == "ClusterComputeResource" {
+			return *hostMo.Parent, nil
+		}
+	}
+	return types.ManagedObjectReference{}, fmt.Errorf("no cluster found for datastore %v", ds.Reference())
+}
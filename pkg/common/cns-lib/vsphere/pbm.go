@@ -66,6 +66,7 @@ func (vc *VirtualCenter) ConnectPbm(ctx context.Context) error {
 			log.Errorf("failed to create pbm client with err: %v", err)
 			return err
 		}
+		vc.PbmClient.RoundTripper = newRoundTripper(vc.Config.Host, "pbm", vc.PbmClient.RoundTripper)
 	}
 	return nil
 }
@@ -201,6 +201,13 @@ func GetVirtualCenterConfig(ctx context.Context, cfg *config.Config) (*VirtualCe
 		ListVolumeThreshold:         cfg.Global.ListVolumeThreshold,
 		MigrationDataStoreURL:       cfg.VirtualCenter[host].MigrationDataStoreURL,
 		FileVolumeActivated:         cfg.VirtualCenter[host].FileVolumeActivated,
+		APILimits:                   newAPILimitsConfig(cfg.VirtualCenter[host]),
+	}
+
+	vcConfig.CredentialProvider, err = getCredentialProvider(ctx, host, cfg.VirtualCenter[host])
+	if err != nil {
+		log.Errorf("failed to get the credential provider of vCenter %q. err: %v", host, err)
+		return nil, err
 	}
 
 	log.Debugf("Setting the queryLimit = %v, ListVolumeThreshold = %v", vcConfig.QueryLimit, vcConfig.ListVolumeThreshold)
@@ -247,6 +254,7 @@ func GetVirtualCenterConfigs(ctx context.Context, cfg *config.Config) ([]*Virtua
 			QueryLimit:                  cfg.Global.QueryLimit,
 			ListVolumeThreshold:         cfg.Global.ListVolumeThreshold,
 			FileVolumeActivated:         cfg.VirtualCenter[vCenterIP].FileVolumeActivated,
+			APILimits:                   newAPILimitsConfig(cfg.VirtualCenter[vCenterIP]),
 		}
 		if vcConfig.CAFile == "" {
 			vcConfig.CAFile = cfg.Global.CAFile
@@ -254,6 +262,11 @@ func GetVirtualCenterConfigs(ctx context.Context, cfg *config.Config) ([]*Virtua
 		if vcConfig.Thumbprint == "" {
 			vcConfig.Thumbprint = cfg.Global.Thumbprint
 		}
+		vcConfig.CredentialProvider, err = getCredentialProvider(ctx, vCenterIP, cfg.VirtualCenter[vCenterIP])
+		if err != nil {
+			log.Errorf("failed to get the credential provider of vCenter %q. err: %v", vCenterIP, err)
+			return nil, err
+		}
 		log.Debugf("Setting the queryLimit = %v, ListVolumeThreshold = %v", vcConfig.QueryLimit, vcConfig.ListVolumeThreshold)
 		if strings.TrimSpace(cfg.VirtualCenter[vCenterIP].Datacenters) != "" {
 			vcConfig.DatacenterPaths = strings.Split(cfg.VirtualCenter[vCenterIP].Datacenters, ",")
@@ -342,12 +355,16 @@ func GetTagManager(ctx context.Context, vc *VirtualCenter) (*tags.Manager, error
 	}
 
 	restClient := rest.NewClient(vc.Client.Client)
-	signer, err := signer(ctx, vc.Client.Client, vc.Config.Username, vc.Config.Password)
+	credentials, err := vc.GetCredentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the credentials. Error: %v", err)
+	}
+	signer, err := signer(ctx, vc.Client.Client, credentials.Username, credentials.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the Signer. Error: %v", err)
 	}
 	if signer == nil {
-		user := url.UserPassword(vc.Config.Username, vc.Config.Password)
+		user := url.UserPassword(credentials.Username, credentials.Password)
 		err = restClient.Login(ctx, user)
 	} else {
 		err = restClient.LoginByToken(restClient.WithSigner(ctx, signer))
@@ -43,11 +43,15 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/govmomi/vsan"
 	"github.com/vmware/govmomi/vslm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/tracing"
 )
 
 const (
@@ -148,6 +152,12 @@ type VirtualCenterConfig struct {
 	ReloadVCConfigForNewClient bool
 	// FileVolumeActivated indicates whether file service has been enabled on any vSAN cluster or not
 	FileVolumeActivated bool
+	// APILimits configures the client side rate limits, concurrency caps and
+	// circuit breaker of the calls to the virtual center.
+	APILimits APILimitsConfig
+	// CredentialProvider provides the credentials used to log in to the
+	// virtual center. Username and Password are used if not set.
+	CredentialProvider CredentialProvider
 }
 
 // NewClient creates a new govmomi Client instance.
@@ -163,6 +173,10 @@ func (vc *VirtualCenter) NewClient(ctx context.Context, useragent string) (*govm
 		return nil, err
 	}
 
+	// Set the limits of the calls to the vCenter before creating the clients
+	// making them.
+	configureAPILimiter(vc.Config.Host, vc.Config.APILimits)
+
 	soapClient := soap.NewClient(url, vc.Config.Insecure)
 	if len(vc.Config.CAFile) > 0 && !vc.Config.Insecure {
 		if err := soapClient.SetRootCAs(vc.Config.CAFile); err != nil {
@@ -220,23 +234,38 @@ func (vc *VirtualCenter) NewClient(ctx context.Context, useragent string) (*govm
 		vc.Config.RoundTripperCount = DefaultRoundTripperCount
 	}
 	rt := vim25.Retry(client.RoundTripper, vim25.TemporaryNetworkError(vc.Config.RoundTripperCount))
-	client.RoundTripper = &MetricRoundTripper{"soap", rt}
+	client.RoundTripper = newRoundTripper(vc.Config.Host, "soap", rt)
 	return client, nil
 }
 
+// GetCredentials returns the current credentials of the virtual center from
+// its CredentialProvider, or its Username and Password if none is configured.
+func (vc *VirtualCenter) GetCredentials(ctx context.Context) (*Credentials, error) {
+	var provider CredentialProvider = &configCredentialProvider{config: vc.Config}
+	if vc.Config.CredentialProvider != nil {
+		provider = vc.Config.CredentialProvider
+	}
+	return provider.GetCredentials(ctx)
+}
+
 // login calls SessionManager.LoginByToken if certificate and private key are
-// configured. Otherwise, calls SessionManager.Login with user and password.
+// provided. Otherwise, calls SessionManager.Login with user and password. The
+// credentials are fetched from the CredentialProvider for every login.
 func (vc *VirtualCenter) login(ctx context.Context, client *govmomi.Client) error {
 	log := logger.GetLogger(ctx)
-	var err error
+	credentials, err := vc.GetCredentials(ctx)
+	if err != nil {
+		log.Errorf("failed to get the credentials of vCenter %q with err: %v", vc.Config.Host, err)
+		return err
+	}
 
-	b, _ := pem.Decode([]byte(vc.Config.Username))
+	b, _ := pem.Decode([]byte(credentials.Username))
 	if b == nil {
 		return client.SessionManager.Login(ctx,
-			neturl.UserPassword(vc.Config.Username, vc.Config.Password))
+			neturl.UserPassword(credentials.Username, credentials.Password))
 	}
 
-	cert, err := tls.X509KeyPair([]byte(vc.Config.Username), []byte(vc.Config.Password))
+	cert, err := tls.X509KeyPair([]byte(credentials.Username), []byte(credentials.Password))
 	if err != nil {
 		log.Errorf("failed to load X509 key pair with err: %v", err)
 		return err
@@ -330,6 +359,22 @@ func (vc *VirtualCenter) connect(ctx context.Context) error {
 		return nil
 	}
 
+	if vc.Config.ReloadVCConfigForNewClient {
+		err = ReadVCConfigs(ctx, vc)
+		if err != nil {
+			return err
+		}
+	}
+	// If session has expired, log in again on the existing client with fresh
+	// credentials, so that the managers and listviews using it keep working.
+	log.Infof("Logging in again to vCenter %q as the current session isn't valid or not authenticated",
+		vc.Config.Host)
+	if err = vc.login(ctx, vc.Client); err == nil {
+		log.Infof("Successfully logged in again to vCenter %q", vc.Config.Host)
+		return vc.recreateServiceClients(ctx)
+	}
+	log.Errorf("failed to log in again to vCenter %q. Creating a new client. err: %v", vc.Config.Host, err)
+
 	log.Infof("logging out current session and clearing idle sessions")
 
 	if vc.Client != nil && vc.Client.Client != nil {
@@ -339,14 +384,8 @@ func (vc *VirtualCenter) connect(ctx context.Context) error {
 		}
 	}
 
-	// If session has expired, create a new instance.
+	// If the session cannot be renewed, create a new instance.
 	log.Infof("Creating a new client session as the existing one isn't valid or not authenticated")
-	if vc.Config.ReloadVCConfigForNewClient {
-		err = ReadVCConfigs(ctx, vc)
-		if err != nil {
-			return err
-		}
-	}
 	if vc.Client, err = vc.NewClient(ctx, useragent); err != nil {
 		log.Errorf("failed to create govmomi client with err: %v", err)
 		if !vc.Config.Insecure {
@@ -354,13 +393,21 @@ func (vc *VirtualCenter) connect(ctx context.Context) error {
 		}
 		return err
 	}
+	return vc.recreateServiceClients(ctx)
+}
+
+// recreateServiceClients recreates the PBM, CNS, Vslm and VSAN clients that
+// have been created, so that they use the current session of the VC Client.
+func (vc *VirtualCenter) recreateServiceClients(ctx context.Context) error {
+	log := logger.GetLogger(ctx)
+	var err error
 	// Recreate PbmClient if created using timed out VC Client.
 	if vc.PbmClient != nil {
 		if vc.PbmClient, err = pbm.NewClient(ctx, vc.Client.Client); err != nil {
 			log.Errorf("failed to create pbm client with err: %v", err)
 			return err
 		}
-		vc.PbmClient.RoundTripper = &MetricRoundTripper{"pbm", vc.PbmClient.RoundTripper}
+		vc.PbmClient.RoundTripper = newRoundTripper(vc.Config.Host, "pbm", vc.PbmClient.RoundTripper)
 	}
 	// Recreate CNSClient if created using timed out VC Client.
 	if vc.CnsClient != nil {
@@ -384,7 +431,7 @@ func (vc *VirtualCenter) connect(ctx context.Context) error {
 			log.Errorf("failed to create vsan client with err: %v", err)
 			return err
 		}
-		vc.VsanClient.RoundTripper = &MetricRoundTripper{"vsan", vc.VsanClient.RoundTripper}
+		vc.VsanClient.RoundTripper = newRoundTripper(vc.Config.Host, "vsan", vc.VsanClient.RoundTripper)
 	}
 	return nil
 }
@@ -478,6 +525,24 @@ func (vc *VirtualCenter) GetDatacenters(ctx context.Context) ([]*Datacenter, err
 	return vc.ListDatacenters(ctx)
 }
 
+// CheckSession returns an error if the virtual center client is not
+// connected or its session is not authenticated. Unlike Connect, it does not
+// try to reconnect.
+func (vc *VirtualCenter) CheckSession(ctx context.Context) error {
+	client := vc.Client
+	if client == nil {
+		return errors.New("vCenter client is not connected")
+	}
+	userSession, err := session.NewManager(client.Client).UserSession(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the vCenter user session. Err: %v", err)
+	}
+	if userSession == nil {
+		return errors.New("vCenter session is not authenticated")
+	}
+	return nil
+}
+
 // Disconnect disconnects the virtual center host connection if connected.
 func (vc *VirtualCenter) Disconnect(ctx context.Context) error {
 	log := logger.GetLogger(ctx)
@@ -780,12 +845,26 @@ func (vc *VirtualCenter) GetAllVirtualMachines(ctx context.Context,
 	return virtualMachines, nil
 }
 
-func (mrt *MetricRoundTripper) RoundTrip(ctx context.Context, req, resp soap.HasFault) error {
+// getRequestName returns the name of the vCenter API request, e.g.
+// "CnsCreateVolume".
+func getRequestName(req soap.HasFault) string {
 	vreq := reflect.ValueOf(req).Elem().FieldByName("Req").Elem()
-	requestName := vreq.Type().Name()
+	return vreq.Type().Name()
+}
+
+func (mrt *MetricRoundTripper) RoundTrip(ctx context.Context, req, resp soap.HasFault) error {
+	requestName := getRequestName(req)
+	// Record the request as a child span of the span in ctx, e.g. the span
+	// of the CSI RPC making the request.
+	ctx, span := tracing.StartSpan(ctx, mrt.clientName+"/"+requestName, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("vsphere.client", mrt.clientName),
+			attribute.String("vsphere.request", requestName)))
+	defer span.End()
 	requestTime := time.Now()
 	err := mrt.roundTripper.RoundTrip(ctx, req, resp)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		timeTaken := time.Since(requestTime).Seconds()
 		prometheus.RequestOpsMetric.WithLabelValues(requestName, mrt.clientName, statusFailUnknown).Observe(timeTaken)
 		return err
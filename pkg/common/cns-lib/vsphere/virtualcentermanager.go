@@ -61,6 +61,9 @@ type VirtualCenterManager interface {
 	// IsCnsTransactionSupported checks if cns transaction is supported
 	// or not on the vCenter Host.
 	IsCnsTransactionSupported(ctx context.Context, host string) (bool, error)
+	// IsCnsVolumePolicyReconfigSupported checks if changing the storage policy
+	// of a volume is supported or not on the vCenter Host.
+	IsCnsVolumePolicyReconfigSupported(ctx context.Context, host string) (bool, error)
 }
 
 var (
@@ -214,6 +217,26 @@ func (m *defaultVirtualCenterManager) IsCnsSnapshotSupported(ctx context.Context
 	return false, nil
 }
 
+// IsCnsVolumePolicyReconfigSupported checks if the CnsReconfigVolumePolicy and
+// CnsRelocateVolume APIs are supported or not.
+func (m *defaultVirtualCenterManager) IsCnsVolumePolicyReconfigSupported(ctx context.Context,
+	host string) (bool, error) {
+	log := logger.GetLogger(ctx)
+
+	// Get VC instance.
+	vcenter, err := m.GetVirtualCenter(ctx, host)
+	if err != nil {
+		log.Errorf("Failed to get vCenter. Err: %v", err)
+		return false, err
+	}
+	vCenterVersion := vcenter.Client.Version
+	if vCenterVersion != cns.ReleaseVSAN67u3 && vCenterVersion != cns.ReleaseVSAN70 {
+		return true, nil
+	}
+	log.Infof("Volume storage policy reconfiguration is not supported on vCenter version %q", vCenterVersion)
+	return false, nil
+}
+
 // IsCnsTransactionSupported checks if cns transaction is supported or not.
 func (m *defaultVirtualCenterManager) IsCnsTransactionSupported(ctx context.Context, host string) (bool, error) {
 	log := logger.GetLogger(ctx)
@@ -39,6 +39,25 @@ var (
 	ErrInvalidVC               = errors.New("invalid VC Object")
 )
 
+const (
+	// MaxDisksPerPVSCSIController is the number of disks that can be attached
+	// to a VMware paravirtual SCSI controller of a VM of hardware version 14
+	// (vSphere 6.7) or later. Unit number 7 of its 64 units is reserved for
+	// the controller itself.
+	MaxDisksPerPVSCSIController = 63
+	// MaxDisksPerSCSIController is the number of disks that can be attached to
+	// the LSI Logic and BusLogic SCSI controllers, and to the VMware
+	// paravirtual SCSI controllers of VMs before hardware version 14. Unit
+	// number 7 of their 16 units is reserved for the controller itself.
+	MaxDisksPerSCSIController = 15
+	// MaxDisksPerNVMEController is the number of disks that can be attached to
+	// an NVMe controller of a VM of hardware version 20 (vSphere 8.0) or later.
+	MaxDisksPerNVMEController = 64
+	// MaxDisksPerLegacyNVMEController is the number of disks that can be
+	// attached to an NVMe controller of a VM before hardware version 20.
+	MaxDisksPerLegacyNVMEController = 15
+)
+
 // VirtualMachine holds details of a virtual machine instance.
 type VirtualMachine struct {
 	// VirtualCenterHost represents the virtual machine's vCenter host.
@@ -185,6 +204,104 @@ func GetVirtualMachineByUUID(ctx context.Context, uuid string, instanceUUID bool
 	return nil, ErrVMNotFound
 }
 
+// GetVolumeSlots returns the number of disks that can be attached to the SCSI
+// and NVMe controllers of the virtual machine, and the number of disks that
+// are attached to them.
+func (vm *VirtualMachine) GetVolumeSlots(ctx context.Context) (int, int, error) {
+	log := logger.GetLogger(ctx)
+	var vmMo mo.VirtualMachine
+	err := vm.Properties(ctx, vm.Reference(), []string{"config.version", "config.hardware.device"}, &vmMo)
+	if err != nil {
+		return 0, 0, logger.LogNewErrorf(log, "failed to get devices of VM %v. Error: %v", vm, err)
+	}
+	if vmMo.Config == nil {
+		return 0, 0, logger.LogNewErrorf(log, "failed to get devices of VM %v. Config is not set", vm)
+	}
+	// The hardware version is only used to raise the limits of the
+	// controllers, so the lowest limits are used if it can't be parsed.
+	hardwareVersion, _ := types.ParseHardwareVersion(vmMo.Config.Version)
+	total, used := getVolumeSlots(hardwareVersion, vmMo.Config.Hardware.Device)
+	return total, used, nil
+}
+
+// getVolumeSlots returns the number of disks that can be attached to the SCSI
+// and NVMe controllers in the given devices of a VM of the given hardware
+// version, and the number of disks that are attached to them.
+func getVolumeSlots(hardwareVersion types.HardwareVersion, devices object.VirtualDeviceList) (int, int) {
+	var total, used int
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	for _, device := range devices {
+		switch device.(type) {
+		case *types.ParaVirtualSCSIController:
+			if hardwareVersion >= types.VMX14 {
+				total += MaxDisksPerPVSCSIController
+			} else {
+				total += MaxDisksPerSCSIController
+			}
+		case *types.VirtualLsiLogicController, *types.VirtualLsiLogicSASController,
+			*types.VirtualBusLogicController:
+			total += MaxDisksPerSCSIController
+		case *types.VirtualNVMEController:
+			if hardwareVersion >= types.VMX20 {
+				total += MaxDisksPerNVMEController
+			} else {
+				total += MaxDisksPerLegacyNVMEController
+			}
+		default:
+			continue
+		}
+		for _, disk := range disks {
+			if disk.GetVirtualDevice().ControllerKey == device.GetVirtualDevice().Key {
+				used++
+			}
+		}
+	}
+	return total, used
+}
+
+// SetDiskStorageIOAllocation sets the storage I/O limit and shares of the
+// disk of the given volume, which needs to be attached to the virtual machine.
+func (vm *VirtualMachine) SetDiskStorageIOAllocation(ctx context.Context, volumeID string,
+	allocation *types.StorageIOAllocationInfo) error {
+	log := logger.GetLogger(ctx)
+	devices, err := vm.Device(ctx)
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to get devices of VM %v. Error: %v", vm, err)
+	}
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+		if disk.VDiskId == nil || disk.VDiskId.Id != volumeID {
+			continue
+		}
+		if disk.StorageIOAllocation == nil {
+			disk.StorageIOAllocation = &types.StorageIOAllocationInfo{}
+		}
+		if allocation.Limit != nil {
+			disk.StorageIOAllocation.Limit = allocation.Limit
+		}
+		if allocation.Shares != nil {
+			disk.StorageIOAllocation.Shares = allocation.Shares
+		}
+		task, err := vm.Reconfigure(ctx, types.VirtualMachineConfigSpec{
+			DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+				&types.VirtualDeviceConfigSpec{
+					Operation: types.VirtualDeviceConfigSpecOperationEdit,
+					Device:    disk,
+				},
+			},
+		})
+		if err == nil {
+			err = task.Wait(ctx)
+		}
+		if err != nil {
+			return logger.LogNewErrorf(log, "failed to set storage I/O allocation of volume %q on VM %v. Error: %v",
+				volumeID, vm, err)
+		}
+		return nil
+	}
+	return logger.LogNewErrorf(log, "volume %q is not attached to VM %v", volumeID, vm)
+}
+
 // GetHostSystem returns HostSystem object of the virtual machine.
 func (vm *VirtualMachine) GetHostSystem(ctx context.Context) (*object.HostSystem, error) {
 	log := logger.GetLogger(ctx)
@@ -24,11 +24,14 @@ import (
 	"testing"
 
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/simulator"
 	"github.com/vmware/govmomi/vapi/rest"
 	_ "github.com/vmware/govmomi/vapi/simulator"
 	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 var (
@@ -180,3 +183,77 @@ func TestIsInZoneRegion(t *testing.T) {
 		t.Fatalf("VM should belong to specified zone and region")
 	}
 }
+
+func TestGetVolumeSlots(t *testing.T) {
+	disk := func(key, controllerKey int32) types.BaseVirtualDevice {
+		return &types.VirtualDisk{VirtualDevice: types.VirtualDevice{Key: key, ControllerKey: controllerKey}}
+	}
+	devices := object.VirtualDeviceList{
+		&types.VirtualIDEController{VirtualController: types.VirtualController{
+			VirtualDevice: types.VirtualDevice{Key: 200}}},
+		&types.VirtualLsiLogicController{VirtualSCSIController: types.VirtualSCSIController{
+			VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{Key: 1000}}}},
+		&types.ParaVirtualSCSIController{VirtualSCSIController: types.VirtualSCSIController{
+			VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{Key: 1001}}}},
+		&types.VirtualNVMEController{VirtualController: types.VirtualController{
+			VirtualDevice: types.VirtualDevice{Key: 31000}}},
+		disk(2000, 1000),
+		disk(2001, 1001),
+		disk(2002, 1001),
+		disk(3000, 200),
+	}
+	total, used := getVolumeSlots(types.VMX20, devices)
+	expectedTotal := MaxDisksPerSCSIController + MaxDisksPerPVSCSIController + MaxDisksPerNVMEController
+	if total != expectedTotal || used != 3 {
+		t.Errorf("Expected %d volume slots with 3 used, got %d with %d used", expectedTotal, total, used)
+	}
+
+	// The paravirtual SCSI and NVMe controllers of older VMs have fewer slots.
+	total, used = getVolumeSlots(types.VMX13, devices)
+	expectedTotal = 2*MaxDisksPerSCSIController + MaxDisksPerLegacyNVMEController
+	if total != expectedTotal || used != 3 {
+		t.Errorf("Expected %d volume slots with 3 used, got %d with %d used", expectedTotal, total, used)
+	}
+}
+
+func TestSetDiskStorageIOAllocation(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		finder := find.NewFinder(c)
+		obj, err := finder.VirtualMachine(ctx, "DC0_H0_VM0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm := &VirtualMachine{VirtualMachine: obj}
+		devices, err := obj.Device(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		disk := devices.SelectByType((*types.VirtualDisk)(nil))[0].(*types.VirtualDisk)
+		disk.VDiskId = &types.ID{Id: "volume-1"}
+		if err = obj.EditDevice(ctx, disk); err != nil {
+			t.Fatal(err)
+		}
+
+		limit := int64(1000)
+		err = vm.SetDiskStorageIOAllocation(ctx, "volume-1", &types.StorageIOAllocationInfo{
+			Limit:  &limit,
+			Shares: &types.SharesInfo{Level: types.SharesLevelHigh},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		devices, err = obj.Device(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		allocation := devices.SelectByType((*types.VirtualDisk)(nil))[0].(*types.VirtualDisk).StorageIOAllocation
+		if allocation == nil || allocation.Limit == nil || *allocation.Limit != limit ||
+			allocation.Shares == nil || allocation.Shares.Level != types.SharesLevelHigh {
+			t.Errorf("Expected storage I/O limit %d with high shares, got %+v", limit, allocation)
+		}
+
+		if err = vm.SetDiskStorageIOAllocation(ctx, "volume-2", &types.StorageIOAllocationInfo{}); err == nil {
+			t.Error("Expected an error for a volume that is not attached to the VM")
+		}
+	})
+}
@@ -18,11 +18,24 @@ package vsphere
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/govmomi/vsan"
+	"github.com/vmware/govmomi/vsan/methods"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 )
 
+// vsanFileServiceSystemInstance is the vSAN file service system of vCenter,
+// which manages the vSAN file shares.
+var vsanFileServiceSystemInstance = types.ManagedObjectReference{
+	Type:  "VsanFileServiceSystem",
+	Value: "vsan-cluster-file-service-system",
+}
+
 // ConnectVsan creates a VSAN client for the virtual center.
 func (vc *VirtualCenter) ConnectVsan(ctx context.Context) error {
 	log := logger.GetLogger(ctx)
@@ -36,6 +49,7 @@ func (vc *VirtualCenter) ConnectVsan(ctx context.Context) error {
 			log.Errorf("failed to create vsan client with err: %v", err)
 			return err
 		}
+		vc.VsanClient.RoundTripper = newRoundTripper(vc.Config.Host, "vsan", vc.VsanClient.RoundTripper)
 	}
 	return nil
 }
@@ -50,3 +64,143 @@ func (vc *VirtualCenter) DisconnectVsan(ctx context.Context) error {
 	}
 	return nil
 }
+
+// getFileShare returns the vSAN file share of the given CNS file volume in
+// the cluster.
+func (vc *VirtualCenter) getFileShare(ctx context.Context, cluster types.ManagedObjectReference,
+	volumeID string) (*vsantypes.VsanFileShare, error) {
+	if err := vc.ConnectVsan(ctx); err != nil {
+		return nil, err
+	}
+	// The CNS ID of a file volume is the vSAN file share UUID prefixed with
+	// "file:".
+	shareUUID := strings.TrimPrefix(volumeID, "file:")
+	res, err := methods.VsanClusterQueryFileShares(ctx, vc.VsanClient, &vsantypes.VsanClusterQueryFileShares{
+		This:      vsanFileServiceSystemInstance,
+		QuerySpec: vsantypes.VsanFileShareQuerySpec{Uuids: []string{shareUUID}},
+		Cluster:   &cluster,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Returnval != nil {
+		for _, share := range res.Returnval.FileShares {
+			if share.Uuid == shareUUID && share.Config != nil {
+				return &share, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("file share %q not found", shareUUID)
+}
+
+// reconfigureFileShare reconfigures the vSAN file share of the given CNS file
+// volume in the cluster, and returns the reconfigured share.
+// VsanReconfigureFileShare replaces the whole config of the share, so the
+// current config of the share is sent back with the changes made by update.
+func (vc *VirtualCenter) reconfigureFileShare(ctx context.Context, cluster types.ManagedObjectReference,
+	volumeID string, update func(*vsantypes.VsanFileShareConfig)) (*vsantypes.VsanFileShare, error) {
+	share, err := vc.getFileShare(ctx, cluster, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	shareConfig := *share.Config
+	update(&shareConfig)
+	res, err := methods.VsanReconfigureFileShare(ctx, vc.VsanClient, &vsantypes.VsanReconfigureFileShare{
+		This:      vsanFileServiceSystemInstance,
+		ShareUuid: share.Uuid,
+		Config:    shareConfig,
+		Cluster:   &cluster,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = object.NewTask(vc.Client.Client, res.Returnval).Wait(ctx); err != nil {
+		return nil, err
+	}
+	return vc.getFileShare(ctx, cluster, volumeID)
+}
+
+// SmbFileShareConfig is the SMB configuration of a vSAN file share.
+type SmbFileShareConfig struct {
+	// DomainName is the vSAN file service domain serving the share. The
+	// domain must be joined to Active Directory, which authenticates the SMB
+	// clients.
+	DomainName string
+	// Encryption is the SMB encryption of the share. It is left to the file
+	// service default when empty.
+	Encryption string
+	// Permissions are the net permissions of the clients of the share.
+	Permissions []vsantypes.VsanFileShareNetPermission
+}
+
+// applySmbFileShareConfig changes the given share config to serve the share
+// over SMB with the given SMB configuration. The NFS security only applies to
+// NFS shares, so it is cleared.
+func applySmbFileShareConfig(shareConfig *vsantypes.VsanFileShareConfig, smbConfig SmbFileShareConfig) {
+	shareConfig.DomainName = smbConfig.DomainName
+	shareConfig.Protocols = []string{string(vsantypes.VsanFileProtocolSMB)}
+	shareConfig.NfsSecType = ""
+	shareConfig.SmbOptions = nil
+	if smbConfig.Encryption != "" {
+		shareConfig.SmbOptions = &vsantypes.VsanFileShareSmbOptions{Encryption: smbConfig.Encryption}
+	}
+	shareConfig.Permission = smbConfig.Permissions
+}
+
+// getAccessPoint returns the access point of the file share with the given
+// key, e.g. "SMB" or "NFSv4.1".
+func getAccessPoint(share *vsantypes.VsanFileShare, key string) string {
+	if share.Runtime == nil {
+		return ""
+	}
+	for _, kv := range share.Runtime.AccessPoints {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return ""
+}
+
+// ConfigureSmbFileShare reconfigures the vSAN file share of the given CNS file
+// volume in the cluster to be served over SMB. CNS only creates NFS file
+// shares, so SMB file shares are created through CNS and then converted by
+// replacing the protocols of the share. The file service serves a converted
+// share from the SMB server of its domain, which only has an SMB access point
+// once the domain is joined to Active Directory, so the access point of the
+// reconfigured share is checked.
+func (vc *VirtualCenter) ConfigureSmbFileShare(ctx context.Context, cluster types.ManagedObjectReference,
+	volumeID string, smbConfig SmbFileShareConfig) error {
+	log := logger.GetLogger(ctx)
+	share, err := vc.reconfigureFileShare(ctx, cluster, volumeID, func(shareConfig *vsantypes.VsanFileShareConfig) {
+		applySmbFileShareConfig(shareConfig, smbConfig)
+	})
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to reconfigure file share of volume %q to SMB. Error: %v",
+			volumeID, err)
+	}
+	if getAccessPoint(share, string(vsantypes.VsanFileProtocolSMB)) == "" {
+		return logger.LogNewErrorf(log, "file share of volume %q has no SMB access point after it was "+
+			"reconfigured to SMB. Check that the file service domain %q is joined to Active Directory",
+			volumeID, smbConfig.DomainName)
+	}
+	log.Infof("Reconfigured file share of volume %q to SMB in domain %q", volumeID, smbConfig.DomainName)
+	return nil
+}
+
+// ConfigureNfsFileShareSecurity reconfigures the NFS security of the vSAN
+// file share of the given CNS file volume in the cluster, e.g. to KRB5P. CNS
+// creates file shares with the SYS security, so shares with Kerberos security
+// are created through CNS and then reconfigured.
+func (vc *VirtualCenter) ConfigureNfsFileShareSecurity(ctx context.Context, cluster types.ManagedObjectReference,
+	volumeID string, secType vsantypes.VsanFileShareNfsSecType) error {
+	log := logger.GetLogger(ctx)
+	_, err := vc.reconfigureFileShare(ctx, cluster, volumeID, func(shareConfig *vsantypes.VsanFileShareConfig) {
+		shareConfig.NfsSecType = string(secType)
+	})
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to reconfigure NFS security of file share of volume %q to %s. "+
+			"Error: %v", volumeID, secType, err)
+	}
+	log.Infof("Reconfigured NFS security of file share of volume %q to %s", volumeID, secType)
+	return nil
+}
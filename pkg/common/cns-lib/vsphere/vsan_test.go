@@ -0,0 +1,73 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/types"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
+)
+
+func TestApplySmbFileShareConfig(t *testing.T) {
+	allowRoot := false
+	permissions := []vsantypes.VsanFileShareNetPermission{
+		{Ips: "10.20.30.0/24", Permissions: "READ_WRITE", AllowRoot: &allowRoot},
+	}
+	// The config of a file share created by CNS.
+	shareConfig := vsantypes.VsanFileShareConfig{
+		Name:       "pvc-1",
+		Quota:      "1024MB",
+		SoftQuota:  "1024MB",
+		Labels:     []types.KeyValue{{Key: "cns.clusterID", Value: "cluster-1"}},
+		Permission: []vsantypes.VsanFileShareNetPermission{{Ips: "*", Permissions: "READ_WRITE"}},
+		Protocols:  []string{string(vsantypes.VsanFileProtocolNFSv4)},
+		NfsSecType: string(vsantypes.VsanFileShareNfsSecTypeSYS),
+	}
+
+	applySmbFileShareConfig(&shareConfig, SmbFileShareConfig{
+		DomainName:  "fs-domain",
+		Encryption:  "mandatory",
+		Permissions: permissions,
+	})
+
+	assert.Equal(t, vsantypes.VsanFileShareConfig{
+		Name:       "pvc-1",
+		DomainName: "fs-domain",
+		Quota:      "1024MB",
+		SoftQuota:  "1024MB",
+		Labels:     []types.KeyValue{{Key: "cns.clusterID", Value: "cluster-1"}},
+		Permission: permissions,
+		Protocols:  []string{string(vsantypes.VsanFileProtocolSMB)},
+		SmbOptions: &vsantypes.VsanFileShareSmbOptions{Encryption: "mandatory"},
+	}, shareConfig)
+
+	applySmbFileShareConfig(&shareConfig, SmbFileShareConfig{DomainName: "fs-domain"})
+	assert.Nil(t, shareConfig.SmbOptions, "Expected the SMB encryption to be left to the file service default")
+}
+
+func TestGetAccessPoint(t *testing.T) {
+	share := &vsantypes.VsanFileShare{
+		Runtime: &vsantypes.VsanFileShareRuntimeInfo{
+			AccessPoints: []types.KeyValue{{Key: "SMB", Value: `\\fs-1.example.com\pvc-1`}},
+		},
+	}
+	assert.Equal(t, `\\fs-1.example.com\pvc-1`, getAccessPoint(share, "SMB"))
+	assert.Empty(t, getAccessPoint(share, "NFSv4.1"))
+	assert.Empty(t, getAccessPoint(&vsantypes.VsanFileShare{}, "SMB"))
+}
@@ -18,12 +18,19 @@ package vsphere
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/govmomi/vslm"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 )
 
+// vslmDiskTaskTimeout is the timeout of the Vslm tasks which copy or move the
+// data of a disk.
+const vslmDiskTaskTimeout = time.Hour
+
 // NewVslmClient creates a new Vslm client
 func NewVslmClient(ctx context.Context, c *vim25.Client) (*vslm.Client, error) {
 	log := logger.GetLogger(ctx)
@@ -61,3 +68,158 @@ func (vc *VirtualCenter) DisconnectVslm(ctx context.Context) {
 		vc.VslmClient = nil
 	}
 }
+
+// RetrieveSnapshotDetails returns the details of the given snapshot of an FCD,
+// including its changed block tracking ID.
+func (vc *VirtualCenter) RetrieveSnapshotDetails(ctx context.Context, volumeID string,
+	snapshotID string) (*types.VStorageObjectSnapshotDetails, error) {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVslm(ctx); err != nil {
+		return nil, err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(vc.VslmClient)
+	details, err := globalObjectManager.RetrieveSnapshotDetails(ctx, types.ID{Id: volumeID},
+		types.ID{Id: snapshotID})
+	if err != nil {
+		log.Errorf("failed to retrieve details of snapshot %q of volume %q. err: %v", snapshotID, volumeID, err)
+		return nil, err
+	}
+	return details, nil
+}
+
+// RetrieveVStorageObject returns the FCD with the given ID.
+func (vc *VirtualCenter) RetrieveVStorageObject(ctx context.Context, volumeID string) (
+	*types.VStorageObject, error) {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVslm(ctx); err != nil {
+		return nil, err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(vc.VslmClient)
+	vso, err := globalObjectManager.Retrieve(ctx, types.ID{Id: volumeID})
+	if err != nil {
+		log.Errorf("failed to retrieve volume %q. err: %v", volumeID, err)
+		return nil, err
+	}
+	return vso, nil
+}
+
+// QueryChangedDiskAreas returns the areas of the given snapshot of an FCD
+// which changed since the snapshot with the given changed block tracking ID,
+// starting at startOffset. A changeID of "*" returns all the allocated areas
+// of the snapshot.
+func (vc *VirtualCenter) QueryChangedDiskAreas(ctx context.Context, volumeID string, snapshotID string,
+	startOffset int64, changeID string) (*types.DiskChangeInfo, error) {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVslm(ctx); err != nil {
+		return nil, err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(vc.VslmClient)
+	changeInfo, err := globalObjectManager.QueryChangedDiskAreas(ctx, types.ID{Id: volumeID},
+		types.ID{Id: snapshotID}, startOffset, changeID)
+	if err != nil {
+		log.Errorf("failed to query changed disk areas of snapshot %q of volume %q at offset %d. err: %v",
+			snapshotID, volumeID, startOffset, err)
+		return nil, err
+	}
+	return changeInfo, nil
+}
+
+// CreateDisk creates an FCD with the given spec.
+func (vc *VirtualCenter) CreateDisk(ctx context.Context, spec types.VslmCreateSpec) (*types.VStorageObject, error) {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVslm(ctx); err != nil {
+		return nil, err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(vc.VslmClient)
+	task, err := globalObjectManager.CreateDisk(ctx, spec)
+	if err != nil {
+		log.Errorf("failed to create disk %q. err: %v", spec.Name, err)
+		return nil, err
+	}
+	res, err := task.Wait(ctx, vslmDiskTaskTimeout)
+	if err != nil {
+		log.Errorf("failed to create disk %q. err: %v", spec.Name, err)
+		return nil, err
+	}
+	vso, ok := res.(types.VStorageObject)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result %T of creating disk %q", res, spec.Name)
+	}
+	log.Infof("Created disk %q with name %q", vso.Config.Id.Id, spec.Name)
+	return &vso, nil
+}
+
+// CreateDiskFromSnapshot creates an FCD with the given name from a snapshot of
+// an FCD. The new FCD is created on the datastore of the source FCD.
+func (vc *VirtualCenter) CreateDiskFromSnapshot(ctx context.Context, volumeID string, snapshotID string,
+	name string) (*types.VStorageObject, error) {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVslm(ctx); err != nil {
+		return nil, err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(vc.VslmClient)
+	task, err := globalObjectManager.CreateDiskFromSnapshot(ctx, types.ID{Id: volumeID},
+		types.ID{Id: snapshotID}, name, nil, nil, "")
+	if err != nil {
+		log.Errorf("failed to create disk from snapshot %q of volume %q. err: %v", snapshotID, volumeID, err)
+		return nil, err
+	}
+	res, err := task.Wait(ctx, vslmDiskTaskTimeout)
+	if err != nil {
+		log.Errorf("failed to create disk from snapshot %q of volume %q. err: %v", snapshotID, volumeID, err)
+		return nil, err
+	}
+	vso, ok := res.(types.VStorageObject)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result %T of creating disk from snapshot %q of volume %q",
+			res, snapshotID, volumeID)
+	}
+	log.Infof("Created disk %q from snapshot %q of volume %q", vso.Config.Id.Id, snapshotID, volumeID)
+	return &vso, nil
+}
+
+// RelocateDisk moves an FCD to the given datastore.
+func (vc *VirtualCenter) RelocateDisk(ctx context.Context, volumeID string,
+	datastore types.ManagedObjectReference) error {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVslm(ctx); err != nil {
+		return err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(vc.VslmClient)
+	spec := types.VslmRelocateSpec{
+		VslmMigrateSpec: types.VslmMigrateSpec{
+			BackingSpec: &types.VslmCreateSpecDiskFileBackingSpec{
+				VslmCreateSpecBackingSpec: types.VslmCreateSpecBackingSpec{
+					Datastore: datastore,
+				},
+			},
+		},
+	}
+	task, err := globalObjectManager.Relocate(ctx, types.ID{Id: volumeID}, spec)
+	if err == nil {
+		_, err = task.Wait(ctx, vslmDiskTaskTimeout)
+	}
+	if err != nil {
+		log.Errorf("failed to relocate volume %q to datastore %v. err: %v", volumeID, datastore, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteDisk deletes an FCD along with its backing disk.
+func (vc *VirtualCenter) DeleteDisk(ctx context.Context, volumeID string) error {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVslm(ctx); err != nil {
+		return err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(vc.VslmClient)
+	task, err := globalObjectManager.Delete(ctx, types.ID{Id: volumeID})
+	if err == nil {
+		_, err = task.Wait(ctx, vslmDiskTaskTimeout)
+	}
+	if err != nil {
+		log.Errorf("failed to delete volume %q. err: %v", volumeID, err)
+		return err
+	}
+	return nil
+}
@@ -29,7 +29,6 @@ import (
 
 	cnstypes "github.com/vmware/govmomi/cns/types"
 	vsanfstypes "github.com/vmware/govmomi/vsan/vsanfs/types"
-	"gopkg.in/gcfg.v1"
 	corev1 "k8s.io/api/core/v1"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
@@ -82,6 +81,18 @@ const (
 	// interval after which stale CnsVSphereVolumeMigration CRs will be cleaned up.
 	// Current default value is set to 15 minutes.
 	DefaultCnsVolumeOperationRequestCleanupIntervalInMin = 15
+	// DefaultCircuitBreakerOpenDurationInSec is the default duration for which
+	// the calls to vCenter APIs fail fast once the circuit breaker has opened.
+	DefaultCircuitBreakerOpenDurationInSec = 30
+	// CredentialProviderConfig is the credential provider using the user and
+	// password of the vSphere config.
+	CredentialProviderConfig = "config"
+	// CredentialProviderFile is the credential provider reading the credentials
+	// from a watched JSON file.
+	CredentialProviderFile = "file"
+	// CredentialProviderExec is the credential provider running a command
+	// printing the credentials in JSON.
+	CredentialProviderExec = "exec"
 	// DefaultGlobalMaxSnapshotsPerBlockVolume is the default maximum number of block volume snapshots per volume.
 	DefaultGlobalMaxSnapshotsPerBlockVolume = 3
 	// MaxNumberOfTopologyCategories is the max number of topology domains/categories allowed.
@@ -158,6 +169,16 @@ var (
 	// servers
 	ErrMaxVCenterSupportedForMultiVCenterSetup = errors.New("max 5 vCenters are supported for multi " +
 		"vCenter deployment")
+
+	// ErrInvalidVCenterAPILimits is returned when the vCenter API rate limits,
+	// concurrency caps or circuit breaker settings are negative.
+	ErrInvalidVCenterAPILimits = errors.New("vCenter API limits and circuit breaker settings " +
+		"must not be negative")
+
+	// ErrInvalidCredentialProvider is returned when the credential provider of
+	// a vCenter is unknown or its file or command is missing.
+	ErrInvalidCredentialProvider = errors.New("credential-provider must be \"config\", " +
+		"\"file\" with credential-file or \"exec\" with credential-exec-command")
 )
 
 // GeneratedVanillaClusterID is used to save unique cluster ID generated
@@ -340,6 +361,33 @@ func isValidvCenterUsernameWithDomain(username string) bool {
 	return match
 }
 
+// negativeAPILimit returns the name of the first negative vCenter API limit
+// or circuit breaker setting of vcConfig, or an empty string if none is
+// negative.
+func negativeAPILimit(vcConfig *VirtualCenterConfig) string {
+	for _, limit := range []struct {
+		field    string
+		negative bool
+	}{
+		{"api-qps", vcConfig.APIQPS < 0},
+		{"api-burst", vcConfig.APIBurst < 0},
+		{"max-concurrent-create-ops", vcConfig.MaxConcurrentCreateOps < 0},
+		{"max-concurrent-attach-ops", vcConfig.MaxConcurrentAttachOps < 0},
+		{"max-concurrent-query-ops", vcConfig.MaxConcurrentQueryOps < 0},
+		{"api-request-timeout-seconds", vcConfig.APIRequestTimeoutInSec < 0},
+		{"circuit-breaker-failure-threshold", vcConfig.CircuitBreakerFailureThreshold < 0},
+		{"circuit-breaker-open-duration-seconds", vcConfig.CircuitBreakerOpenDurationInSec < 0},
+	} {
+		if limit.negative {
+			return limit.field
+		}
+	}
+	return ""
+}
+
+// validateConfig validates cfg and sets the defaults of the unset values.
+// The returned errors are ConfigErrors qualified with the path of the invalid
+// field in the structured config format.
 func validateConfig(ctx context.Context, cfg *Config) error {
 	log := logger.GetLogger(ctx)
 	// Fix default global values.
@@ -349,25 +397,25 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 	// Must have at least one vCenter defined.
 	if len(cfg.VirtualCenter) == 0 {
 		log.Error(ErrMissingVCenter)
-		return ErrMissingVCenter
+		return configError("virtualCenter", ErrMissingVCenter)
 	}
 	if len(cfg.VirtualCenter) > 5 {
 		log.Error(ErrMaxVCenterSupportedForMultiVCenterSetup)
-		return ErrMaxVCenterSupportedForMultiVCenterSetup
+		return configError("virtualCenter", ErrMaxVCenterSupportedForMultiVCenterSetup)
 	}
 	// Cluster ID should not exceed 64 characters.
 	if len(cfg.Global.ClusterID) > 64 {
 		log.Error(ErrClusterIDCharLimit)
-		return ErrClusterIDCharLimit
+		return configError("global.cluster-id", ErrClusterIDCharLimit)
 	}
 	// SupervisorID should not exceed 64 characters.
 	if len(cfg.Global.SupervisorID) > 64 {
 		log.Error(ErrSupervisorIDCharLimit)
-		return ErrSupervisorIDCharLimit
+		return configError("global.supervisor-id", ErrSupervisorIDCharLimit)
 	}
 	if len(cfg.VirtualCenter) > 1 && strings.TrimSpace(cfg.Labels.TopologyCategories) == "" {
 		log.Error(ErrMissingTopologyCategoriesForMultiVCenterSetup)
-		return ErrMissingTopologyCategoriesForMultiVCenterSetup
+		return configError("labels.topology-categories", ErrMissingTopologyCategoriesForMultiVCenterSetup)
 	}
 	var setCfgGlobalvCenter bool
 	if len(cfg.VirtualCenter) == 1 {
@@ -375,33 +423,50 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 	}
 	for vcServer, vcConfig := range cfg.VirtualCenter {
 		log.Debugf("Initializing vc server %s", vcServer)
+		vcPath := mapEntryPath("virtualCenter", vcServer)
 		if vcServer == "" {
 			log.Error(ErrInvalidVCenterIP)
-			return ErrInvalidVCenterIP
+			return configError(vcPath, ErrInvalidVCenterIP)
 		}
 
-		if vcConfig.User == "" {
-			vcConfig.User = cfg.Global.User
+		switch vcConfig.CredentialProvider {
+		case "", CredentialProviderConfig:
 			if vcConfig.User == "" {
-				log.Errorf("vcConfig.User is empty for vc %s!", vcServer)
-				return ErrUsernameMissing
+				vcConfig.User = cfg.Global.User
+				if vcConfig.User == "" {
+					log.Errorf("vcConfig.User is empty for vc %s!", vcServer)
+					return configError(vcPath+".user", ErrUsernameMissing)
+				}
 			}
-		}
 
-		// vCenter server username provided in vSphere config secret should contain domain name,
-		// CSI driver will crash if username doesn't contain domain name.
-		if !isValidvCenterUsernameWithDomain(vcConfig.User) {
-			log.Errorf("username %v specified in vSphere config secret is invalid, "+
-				"make sure that username is a fully qualified domain name.", vcConfig.User)
-			return ErrInvalidUsername
-		}
+			// vCenter server username provided in vSphere config secret should contain domain name,
+			// CSI driver will crash if username doesn't contain domain name.
+			if !isValidvCenterUsernameWithDomain(vcConfig.User) {
+				log.Errorf("username %v specified in vSphere config secret is invalid, "+
+					"make sure that username is a fully qualified domain name.", vcConfig.User)
+				return configError(vcPath+".user", ErrInvalidUsername)
+			}
 
-		if vcConfig.Password == "" {
-			vcConfig.Password = cfg.Global.Password
 			if vcConfig.Password == "" {
-				log.Errorf("vcConfig.Password is empty for vc %s!", vcServer)
-				return ErrPasswordMissing
+				vcConfig.Password = cfg.Global.Password
+				if vcConfig.Password == "" {
+					log.Errorf("vcConfig.Password is empty for vc %s!", vcServer)
+					return configError(vcPath+".password", ErrPasswordMissing)
+				}
+			}
+		case CredentialProviderFile:
+			if vcConfig.CredentialFile == "" {
+				log.Errorf("credential-file is empty for vc %s!", vcServer)
+				return configError(vcPath+".credential-file", ErrInvalidCredentialProvider)
 			}
+		case CredentialProviderExec:
+			if vcConfig.CredentialExecCommand == "" {
+				log.Errorf("credential-exec-command is empty for vc %s!", vcServer)
+				return configError(vcPath+".credential-exec-command", ErrInvalidCredentialProvider)
+			}
+		default:
+			log.Errorf("invalid credential-provider %q for vc %s", vcConfig.CredentialProvider, vcServer)
+			return configError(vcPath+".credential-provider", ErrInvalidCredentialProvider)
 		}
 		if vcConfig.VCenterPort == "" {
 			vcConfig.VCenterPort = cfg.Global.VCenterPort
@@ -418,6 +483,13 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 		if setCfgGlobalvCenter && cfg.Global.VCenterIP == "" {
 			cfg.Global.VCenterIP = vcServer
 		}
+		if field := negativeAPILimit(vcConfig); field != "" {
+			log.Errorf("invalid API limits for vc %s", vcServer)
+			return configError(vcPath+"."+field, ErrInvalidVCenterAPILimits)
+		}
+		if vcConfig.CircuitBreakerFailureThreshold > 0 && vcConfig.CircuitBreakerOpenDurationInSec == 0 {
+			vcConfig.CircuitBreakerOpenDurationInSec = DefaultCircuitBreakerOpenDurationInSec
+		}
 		// Print out the config.
 		log.Debugf("vc server %s config: %+v", vcServer, vcConfig)
 	}
@@ -440,7 +512,7 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 				netPerm.Permissions != vsanfstypes.VsanFileShareAccessTypeREAD_ONLY &&
 				netPerm.Permissions != vsanfstypes.VsanFileShareAccessTypeREAD_WRITE {
 				log.Errorf("Invalid value %s for Permissions under NetPermission Config %s", netPerm.Permissions, key)
-				return ErrInvalidNetPermission
+				return configError(mapEntryPath("netPermissions", key)+".permissions", ErrInvalidNetPermission)
 			}
 			if netPerm.Ips == "" {
 				netPerm.Ips = "*"
@@ -473,15 +545,15 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 	// parameter. Specifying all the 3 parameters is not allowed.
 	if strings.TrimSpace(cfg.Labels.TopologyCategories) != "" &&
 		(strings.TrimSpace(cfg.Labels.Zone) != "" || strings.TrimSpace(cfg.Labels.Region) != "") {
-		return logger.LogNewErrorf(log,
-			"zone and region parameters should be skipped when topologyCategories is specified.")
+		return configError("labels", logger.LogNewErrorf(log,
+			"zone and region parameters should be skipped when topologyCategories is specified."))
 	}
 
 	// Validate length of topologyCategories in Labels section
 	if strings.TrimSpace(cfg.Labels.TopologyCategories) != "" {
 		if len(strings.Split(cfg.Labels.TopologyCategories, ",")) > MaxNumberOfTopologyCategories {
-			return logger.LogNewErrorf(log, "maximum limit of topology categories exceeded. Only %d allowed.",
-				MaxNumberOfTopologyCategories)
+			return configError("labels.topology-categories", logger.LogNewErrorf(log,
+				"maximum limit of topology categories exceeded. Only %d allowed.", MaxNumberOfTopologyCategories))
 		}
 	}
 
@@ -491,8 +563,8 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 	for key, categoryInfo := range cfg.TopologyCategory {
 		topoDomain := strings.Split(categoryInfo.Label, "/")[0]
 		if topoDomain != betaDomain && topoDomain != gaDomain && topoDomain != TopologyLabelsDomain {
-			return logger.LogNewErrorf(log, "unrecognised topology label %q used for topology category %q",
-				categoryInfo.Label, key)
+			return configError(mapEntryPath("topologyCategory", key)+".label", logger.LogNewErrorf(log,
+				"unrecognised topology label %q used for topology category %q", categoryInfo.Label, key))
 		}
 	}
 
@@ -509,14 +581,21 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 }
 
 // ReadConfig parses vSphere cloud config file and stores it into VSphereConfig.
-// Environment variables are also checked.
+// The config file is either in the gcfg INI format or in the structured
+// YAML/JSON format, which is detected automatically. Environment variables are
+// also checked.
 func ReadConfig(ctx context.Context, config io.Reader) (*Config, error) {
 	log := logger.GetLogger(ctx)
 	if config == nil {
 		return nil, fmt.Errorf("no vSphere CSI driver config file given")
 	}
+	data, err := io.ReadAll(config)
+	if err != nil {
+		log.Errorf("error while reading config file: %+v", err)
+		return nil, err
+	}
 	cfg := &Config{}
-	if err := gcfg.FatalOnly(gcfg.ReadInto(cfg, config)); err != nil {
+	if err := readConfigInto(data, cfg); err != nil {
 		log.Errorf("error while reading config file: %+v", err)
 		return nil, err
 	}
@@ -597,14 +676,18 @@ func FromEnvToGC(ctx context.Context, cfg *Config) error {
 	return nil
 }
 
-// ReadGCConfig parses gc config file and stores it into GCConfig.
-// Environment variables are also checked.
+// ReadGCConfig parses gc config file in either the INI or the structured
+// format and stores it into GCConfig. Environment variables are also checked.
 func ReadGCConfig(ctx context.Context, config io.Reader) (*Config, error) {
 	if config == nil {
 		return nil, fmt.Errorf("guest cluster config file is not present")
 	}
+	data, err := io.ReadAll(config)
+	if err != nil {
+		return nil, err
+	}
 	cfg := &Config{}
-	if err := gcfg.FatalOnly(gcfg.ReadInto(cfg, config)); err != nil {
+	if err := readConfigInto(data, cfg); err != nil {
 		return nil, err
 	}
 	// Env Vars should override config file entries if present.
@@ -651,11 +734,11 @@ func validateGCConfig(ctx context.Context, cfg *Config) error {
 	log := logger.GetLogger(ctx)
 	if cfg.GC.Endpoint == "" {
 		log.Error(ErrMissingEndpoint)
-		return ErrMissingEndpoint
+		return configError("gc.endpoint", ErrMissingEndpoint)
 	}
 	if cfg.GC.TanzuKubernetesClusterUID == "" {
 		log.Error(ErrMissingTanzuKubernetesClusterUID)
-		return ErrMissingTanzuKubernetesClusterUID
+		return configError("gc.tanzukubernetescluster-uid", ErrMissingTanzuKubernetesClusterUID)
 	}
 	// ClusterAPIVersion and ClusterKind parameters have been introduced for the uTKGS effort.
 	// To maintain backward compatibility with GCs created with TKC objects,
@@ -18,6 +18,7 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -161,6 +162,73 @@ func TestValidateConfigWithInvalidClusterId(t *testing.T) {
 	}
 }
 
+func TestValidateConfigWithAPILimits(t *testing.T) {
+	vcConfig := map[string]*VirtualCenterConfig{
+		"1.1.1.1": {
+			User:                           "Administrator@vsphere.local",
+			Password:                       "Password",
+			VCenterPort:                    "443",
+			Datacenters:                    "dc1",
+			APIQPS:                         20,
+			MaxConcurrentCreateOps:         10,
+			CircuitBreakerFailureThreshold: 5,
+		},
+	}
+	cfg := &Config{
+		VirtualCenter: vcConfig,
+	}
+	err := validateConfig(ctx, cfg)
+	if err != nil {
+		t.Errorf("Unexpected error while validating API limits. Config given - %+v", *cfg)
+	}
+	if vcConfig["1.1.1.1"].CircuitBreakerOpenDurationInSec != DefaultCircuitBreakerOpenDurationInSec {
+		t.Errorf("Expected default circuit breaker open duration, got %d",
+			vcConfig["1.1.1.1"].CircuitBreakerOpenDurationInSec)
+	}
+
+	vcConfig["1.1.1.1"].MaxConcurrentAttachOps = -1
+	err = validateConfig(ctx, cfg)
+	if !errors.Is(err, ErrInvalidVCenterAPILimits) {
+		t.Errorf("Expected error due to negative API limits. Got %v", err)
+	}
+}
+
+func TestValidateConfigWithCredentialProvider(t *testing.T) {
+	vcConfig := map[string]*VirtualCenterConfig{
+		"1.1.1.1": {
+			VCenterPort:        "443",
+			Datacenters:        "dc1",
+			CredentialProvider: CredentialProviderFile,
+			CredentialFile:     "/etc/vmware/credentials/vc.json",
+		},
+	}
+	cfg := &Config{
+		VirtualCenter: vcConfig,
+	}
+	err := validateConfig(ctx, cfg)
+	if err != nil {
+		t.Errorf("Unexpected error while validating file credential provider. Config given - %+v", *cfg)
+	}
+
+	vcConfig["1.1.1.1"].CredentialProvider = CredentialProviderExec
+	err = validateConfig(ctx, cfg)
+	if !errors.Is(err, ErrInvalidCredentialProvider) {
+		t.Errorf("Expected error due to missing credential command. Got %v", err)
+	}
+
+	vcConfig["1.1.1.1"].CredentialProvider = "vault"
+	err = validateConfig(ctx, cfg)
+	if !errors.Is(err, ErrInvalidCredentialProvider) {
+		t.Errorf("Expected error due to unknown credential provider. Got %v", err)
+	}
+
+	vcConfig["1.1.1.1"].CredentialProvider = CredentialProviderConfig
+	err = validateConfig(ctx, cfg)
+	if !errors.Is(err, ErrUsernameMissing) {
+		t.Errorf("Expected error due to missing username. Got %v", err)
+	}
+}
+
 func TestValidateConfigWithInvalidUsername(t *testing.T) {
 	vcConfigInvalidUsername := map[string]*VirtualCenterConfig{
 		"1.1.1.1": {
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/gcfg.v1"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ConfigAPIVersion is the API version of the structured YAML/JSON config
+	// format.
+	ConfigAPIVersion = "csi.vsphere.vmware.com/v1alpha1"
+	// ConfigKind is the kind of the structured YAML/JSON config format.
+	ConfigKind = "CSIConfig"
+)
+
+// StructuredConfig is the versioned YAML/JSON representation of Config. The
+// sections of the INI format are top-level keys, and the named sections such
+// as [VirtualCenter "host"] are maps keyed by the section name, e.g.
+//
+//	apiVersion: csi.vsphere.vmware.com/v1alpha1
+//	kind: CSIConfig
+//	global:
+//	  cluster-id: cluster-1
+//	virtualCenter:
+//	  10.0.0.1:
+//	    user: administrator@vsphere.local
+//	    password: secret
+type StructuredConfig struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Config     `yaml:",inline"`
+}
+
+// ConfigError is a config error qualified with the path of the invalid
+// field, e.g. virtualCenter["10.0.0.1"].user.
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// configError returns err qualified with the given field path.
+func configError(path string, err error) error {
+	return &ConfigError{Path: path, Err: err}
+}
+
+// mapEntryPath returns the path of the entry with the given key of a named
+// section, e.g. virtualCenter["10.0.0.1"].
+func mapEntryPath(section, key string) string {
+	return fmt.Sprintf("%s[%q]", section, key)
+}
+
+// isStructuredConfig returns true if data is in the structured YAML/JSON
+// format rather than the gcfg INI format. INI files start with a section
+// header once the blank and comment lines are skipped.
+func isStructuredConfig(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		return !strings.HasPrefix(line, "[")
+	}
+	return false
+}
+
+// readConfigInto parses data in either the INI or the structured format into
+// cfg.
+func readConfigInto(data []byte, cfg *Config) error {
+	if !isStructuredConfig(data) {
+		return gcfg.FatalOnly(gcfg.ReadStringInto(cfg, string(data)))
+	}
+	structured := &StructuredConfig{}
+	if err := yaml.UnmarshalStrict(data, structured); err != nil {
+		return fmt.Errorf("failed to parse structured config: %v", err)
+	}
+	if structured.APIVersion != ConfigAPIVersion {
+		return configError("apiVersion",
+			fmt.Errorf("unsupported value %q, expected %q", structured.APIVersion, ConfigAPIVersion))
+	}
+	if structured.Kind != ConfigKind {
+		return configError("kind", fmt.Errorf("unsupported value %q, expected %q", structured.Kind, ConfigKind))
+	}
+	*cfg = structured.Config
+	return nil
+}
+
+// ConvertConfigToStructured converts a config in the INI format to the
+// structured YAML format. The environment variables are not applied and the
+// config is not validated, so that the result only holds the values of the
+// INI config.
+func ConvertConfigToStructured(data []byte) ([]byte, error) {
+	cfg := &Config{}
+	if err := gcfg.FatalOnly(gcfg.ReadStringInto(cfg, string(data))); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&StructuredConfig{
+		APIVersion: ConfigAPIVersion,
+		Kind:       ConfigKind,
+		Config:     *cfg,
+	})
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const iniTestConfig = `
+# vSphere CSI driver config
+[Global]
+cluster-id = "cluster-1"
+
+[VirtualCenter "10.0.0.1"]
+user = "administrator@vsphere.local"
+password = "secret"
+datacenters = "dc-1, dc-2"
+api-qps = 20
+credential-exec-arg = "--a"
+credential-exec-arg = "--b"
+
+[VirtualCenter "10.0.0.2"]
+user = "vsphere.local\\administrator"
+password = "secret2"
+insecure-flag = true
+
+[NetPermissions "A"]
+ips = "10.20.30.0/24"
+permissions = "READ_ONLY"
+
+[Labels]
+topology-categories = "k8s-zone"
+
+[TopologyCategory "k8s-zone"]
+label = "topology.kubernetes.io/zone"
+`
+
+const yamlTestConfig = `
+# vSphere CSI driver config
+apiVersion: csi.vsphere.vmware.com/v1alpha1
+kind: CSIConfig
+global:
+  cluster-id: cluster-1
+virtualCenter:
+  10.0.0.1:
+    user: administrator@vsphere.local
+    password: secret
+    datacenters: dc-1, dc-2
+    api-qps: 20
+    credential-exec-arg: ["--a", "--b"]
+  10.0.0.2:
+    user: vsphere.local\administrator
+    password: secret2
+    insecure-flag: true
+netPermissions:
+  A:
+    ips: 10.20.30.0/24
+    permissions: READ_ONLY
+labels:
+  topology-categories: k8s-zone
+topologyCategory:
+  k8s-zone:
+    label: topology.kubernetes.io/zone
+`
+
+const jsonTestConfig = `{
+  "apiVersion": "csi.vsphere.vmware.com/v1alpha1",
+  "kind": "CSIConfig",
+  "global": {"cluster-id": "cluster-1"},
+  "virtualCenter": {
+    "10.0.0.1": {
+      "user": "administrator@vsphere.local",
+      "password": "secret",
+      "datacenters": "dc-1, dc-2",
+      "api-qps": 20,
+      "credential-exec-arg": ["--a", "--b"]
+    },
+    "10.0.0.2": {"user": "vsphere.local\\administrator", "password": "secret2", "insecure-flag": true}
+  },
+  "netPermissions": {"A": {"ips": "10.20.30.0/24", "permissions": "READ_ONLY"}},
+  "labels": {"topology-categories": "k8s-zone"},
+  "topologyCategory": {"k8s-zone": {"label": "topology.kubernetes.io/zone"}}
+}`
+
+func TestIsStructuredConfig(t *testing.T) {
+	for data, expected := range map[string]bool{
+		iniTestConfig:                         false,
+		"; comment\n[Global]\n":               false,
+		yamlTestConfig:                        true,
+		jsonTestConfig:                        true,
+		"\n\n# only comments\n; and blanks\n": false,
+	} {
+		if isStructuredConfig([]byte(data)) != expected {
+			t.Errorf("Expected isStructuredConfig to be %v for config:\n%s", expected, data)
+		}
+	}
+}
+
+func TestReadConfigFormats(t *testing.T) {
+	t.Setenv("CLUSTER_FLAVOR", "VANILLA")
+	iniCfg, err := ReadConfig(ctx, strings.NewReader(iniTestConfig))
+	if err != nil {
+		t.Fatalf("Failed to read INI config: %v", err)
+	}
+	vc := iniCfg.VirtualCenter["10.0.0.1"]
+	if vc == nil || vc.APIQPS != 20 || !reflect.DeepEqual(vc.CredentialExecArgs, []string{"--a", "--b"}) {
+		t.Fatalf("Unexpected vCenter config read from INI config: %+v", vc)
+	}
+
+	for name, data := range map[string]string{"YAML": yamlTestConfig, "JSON": jsonTestConfig} {
+		cfg, err := ReadConfig(ctx, strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("Failed to read %s config: %v", name, err)
+		}
+		if !reflect.DeepEqual(cfg, iniCfg) {
+			t.Errorf("%s config %+v does not match INI config %+v", name, cfg, iniCfg)
+		}
+	}
+}
+
+func TestReadStructuredConfigErrors(t *testing.T) {
+	t.Setenv("CLUSTER_FLAVOR", "VANILLA")
+	for name, test := range map[string]struct {
+		data string
+		path string
+		err  error
+	}{
+		"unsupported apiVersion": {
+			data: strings.Replace(yamlTestConfig, "v1alpha1", "v2", 1),
+			path: "apiVersion",
+		},
+		"unsupported kind": {
+			data: strings.Replace(yamlTestConfig, "kind: CSIConfig", "kind: Config", 1),
+			path: "kind",
+		},
+		"missing password": {
+			data: strings.Replace(yamlTestConfig, "    password: secret2\n", "", 1),
+			path: `virtualCenter["10.0.0.2"].password`,
+			err:  ErrPasswordMissing,
+		},
+		"negative API limit": {
+			data: strings.Replace(yamlTestConfig, "api-qps: 20", "api-burst: -1", 1),
+			path: `virtualCenter["10.0.0.1"].api-burst`,
+			err:  ErrInvalidVCenterAPILimits,
+		},
+		"invalid net permission": {
+			data: strings.Replace(yamlTestConfig, "READ_ONLY", "READ", 1),
+			path: `netPermissions["A"].permissions`,
+			err:  ErrInvalidNetPermission,
+		},
+		"invalid topology label": {
+			data: strings.Replace(yamlTestConfig, "topology.kubernetes.io/zone", "example.com/zone", 1),
+			path: `topologyCategory["k8s-zone"].label`,
+		},
+	} {
+		_, err := ReadConfig(ctx, strings.NewReader(test.data))
+		var configErr *ConfigError
+		if !errors.As(err, &configErr) || configErr.Path != test.path {
+			t.Errorf("%s: expected error for %s, got: %v", name, test.path, err)
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v, got: %v", name, test.err, err)
+		}
+	}
+
+	// Unknown fields are rejected.
+	_, err := ReadConfig(ctx, strings.NewReader(strings.Replace(yamlTestConfig, "api-qps", "api-qsp", 1)))
+	if err == nil || !strings.Contains(err.Error(), "api-qsp") {
+		t.Errorf("Expected error for unknown field api-qsp, got: %v", err)
+	}
+}
+
+func TestConvertConfigToStructured(t *testing.T) {
+	t.Setenv("CLUSTER_FLAVOR", "VANILLA")
+	data, err := ConvertConfigToStructured([]byte(iniTestConfig))
+	if err != nil {
+		t.Fatalf("Failed to convert INI config: %v", err)
+	}
+	if !isStructuredConfig(data) {
+		t.Fatalf("Converted config is not in the structured format:\n%s", data)
+	}
+	// The converted config is read as the INI config it was converted from.
+	path := filepath.Join(t.TempDir(), "csi-vsphere.conf")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write converted config: %v", err)
+	}
+	cfg, err := GetCnsconfig(ctx, path)
+	if err != nil {
+		t.Fatalf("Failed to read converted config: %v\n%s", err, data)
+	}
+	iniCfg, err := ReadConfig(ctx, strings.NewReader(iniTestConfig))
+	if err != nil {
+		t.Fatalf("Failed to read INI config: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, iniCfg) {
+		t.Errorf("Converted config %+v does not match INI config %+v", cfg, iniCfg)
+	}
+
+	if _, err := ConvertConfigToStructured([]byte("[Global\ncluster-id = cluster-1\n")); err == nil {
+		t.Errorf("Expected error converting invalid INI config")
+	}
+}
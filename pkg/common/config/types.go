@@ -24,83 +24,87 @@ import (
 type Config struct {
 	Global struct {
 		//vCenter IP address or FQDN
-		VCenterIP string
+		VCenterIP string `yaml:"vcenterip,omitempty"`
 		// Kubernetes Cluster ID
-		ClusterID string `gcfg:"cluster-id"`
+		ClusterID string `gcfg:"cluster-id" yaml:"cluster-id,omitempty"`
 		// SupervisorID is the UUID representing Supervisor Cluster. ClusterID is being deprecated
 		// and SupervisorID is the replacement ID we need to use for VolumeMetadata and datastore lookup.
-		SupervisorID string `gcfg:"supervisor-id"`
+		SupervisorID string `gcfg:"supervisor-id" yaml:"supervisor-id,omitempty"`
 		// vCenter username.
-		User string `gcfg:"user"`
+		User string `gcfg:"user" yaml:"user,omitempty"`
 		// vCenter password in clear text.
-		Password string `gcfg:"password"`
+		Password string `gcfg:"password" yaml:"password,omitempty"`
 		// vCenter port.
-		VCenterPort string `gcfg:"port"`
+		VCenterPort string `gcfg:"port" yaml:"port,omitempty"`
 		// Specifies whether to verify the server's certificate chain. Set to true to
 		// skip verification.
-		InsecureFlag bool `gcfg:"insecure-flag"`
+		InsecureFlag bool `gcfg:"insecure-flag" yaml:"insecure-flag,omitempty"`
 		// Specifies the path to a CA certificate in PEM format. This has no effect if
 		// InsecureFlag is enabled. Optional; if not configured, the system's CA
 		// certificates will be used.
-		CAFile string `gcfg:"ca-file"`
+		CAFile string `gcfg:"ca-file" yaml:"ca-file,omitempty"`
 		// Thumbprint specifies the certificate thumbprint to use
 		// This has no effect if InsecureFlag is enabled.
-		Thumbprint string `gcfg:"thumbprint"`
+		Thumbprint string `gcfg:"thumbprint" yaml:"thumbprint,omitempty"`
 		// Datacenter in which Node VMs are located.
-		Datacenters string `gcfg:"datacenters"`
+		Datacenters string `gcfg:"datacenters" yaml:"datacenters,omitempty"`
 		// CnsRegisterVolumesCleanupIntervalInMin specifies the interval after which
 		// successful CnsRegisterVolumes will be cleaned up.
-		CnsRegisterVolumesCleanupIntervalInMin int `gcfg:"cnsregistervolumes-cleanup-intervalinmin"`
+		CnsRegisterVolumesCleanupIntervalInMin int `gcfg:"cnsregistervolumes-cleanup-intervalinmin" yaml:"cnsregistervolumes-cleanup-intervalinmin,omitempty"` //nolint:lll
 		// VolumeMigrationCRCleanupIntervalInMin specifies the interval after which
 		// stale CnsVSphereVolumeMigration CRs will be cleaned up.
-		VolumeMigrationCRCleanupIntervalInMin int `gcfg:"volumemigration-cr-cleanup-intervalinmin"`
+		VolumeMigrationCRCleanupIntervalInMin int `gcfg:"volumemigration-cr-cleanup-intervalinmin" yaml:"volumemigration-cr-cleanup-intervalinmin,omitempty"` //nolint:lll
 		// Cluster Distribution Name
-		ClusterDistribution string `gcfg:"cluster-distribution"`
+		ClusterDistribution string `gcfg:"cluster-distribution" yaml:"cluster-distribution,omitempty"`
 
 		//CSIAuthCheckIntervalInMin specifies the interval that the auth check for datastores will be trigger
-		CSIAuthCheckIntervalInMin int `gcfg:"csi-auth-check-intervalinmin"`
+		CSIAuthCheckIntervalInMin int `gcfg:"csi-auth-check-intervalinmin" yaml:"csi-auth-check-intervalinmin,omitempty"`
 		// CnsVolumeOperationRequestCleanupIntervalInMin specifies the interval after which
 		// stale CnsVolumeOperationRequest instances will be cleaned up.
-		CnsVolumeOperationRequestCleanupIntervalInMin int `gcfg:"cnsvolumeoperationrequest-cleanup-intervalinmin"`
+		CnsVolumeOperationRequestCleanupIntervalInMin int `gcfg:"cnsvolumeoperationrequest-cleanup-intervalinmin" yaml:"cnsvolumeoperationrequest-cleanup-intervalinmin,omitempty"` //nolint:lll
 		// CSIFetchPreferredDatastoresIntervalInMin specifies the interval
 		// after which the preferred datastores cache is refreshed in the driver.
-		CSIFetchPreferredDatastoresIntervalInMin int `gcfg:"csi-fetch-preferred-datastores-intervalinmin"`
+		CSIFetchPreferredDatastoresIntervalInMin int `gcfg:"csi-fetch-preferred-datastores-intervalinmin" yaml:"csi-fetch-preferred-datastores-intervalinmin,omitempty"` //nolint:lll
 
 		// QueryLimit specifies the number of volumes that can be fetched by CNS QueryAll API at a time
-		QueryLimit int `gcfg:"query-limit"`
+		QueryLimit int `gcfg:"query-limit" yaml:"query-limit,omitempty"`
 		// ListVolumeThreshold specifies the maximum number of differences in volume that can exist between CNS
 		// and kubernetes
-		ListVolumeThreshold int `gcfg:"list-volume-threshold"`
-	}
+		ListVolumeThreshold int `gcfg:"list-volume-threshold" yaml:"list-volume-threshold,omitempty"`
+		// VolumeMetricsNamespaces is a comma separated list of namespaces for which
+		// per-volume metrics are exported by the syncer. Per-volume metrics are not
+		// exported if it is not set.
+		VolumeMetricsNamespaces string `gcfg:"volume-metrics-namespaces" yaml:"volume-metrics-namespaces,omitempty"`
+	} `yaml:"global,omitempty"`
 
 	// Multiple sets of Net Permissions applied to all file shares
 	// The string can uniquely represent each Net Permissions config
-	NetPermissions map[string]*NetPermissionConfig
+	NetPermissions map[string]*NetPermissionConfig `yaml:"netPermissions,omitempty"`
 
 	// Virtual Center configurations
-	VirtualCenter map[string]*VirtualCenterConfig
+	VirtualCenter map[string]*VirtualCenterConfig `yaml:"virtualCenter,omitempty"`
 
 	// Snapshot configurations.
-	Snapshot SnapshotConfig
+	Snapshot SnapshotConfig `yaml:"snapshot,omitempty"`
 
 	// Guest Cluster configurations, only used by GC
-	GC GCConfig
+	GC GCConfig `yaml:"gc,omitempty"`
 
 	// Labels will list the topology domains the CSI driver is expected
 	// to pick up from the inventory. This info will later be used while provisioning volumes.
 	Labels struct {
 		// Zone and Region correspond to the vSphere categories
 		// created to tag specific topology domains in the inventory.
-		Zone   string `gcfg:"zone"`   // Deprecated
-		Region string `gcfg:"region"` // Deprecated
+		Zone   string `gcfg:"zone" yaml:"zone,omitempty"`     // Deprecated
+		Region string `gcfg:"region" yaml:"region,omitempty"` // Deprecated
 		// TopologyCategories is a comma separated string of topology domains
 		// which will correspond to the `Categories` the vSphere admin will
 		// create in the inventory using the UI.
 		// Maximum number of categories allowed is 5.
-		TopologyCategories string `gcfg:"topology-categories"`
-	}
+		TopologyCategories string `gcfg:"topology-categories" yaml:"topology-categories,omitempty"`
+	} `yaml:"labels,omitempty"`
 
-	TopologyCategory map[string]*TopologyCategoryInfo
+	TopologyCategory map[string]*TopologyCategoryInfo `yaml:"topologyCategory,omitempty"`
 }
 
 // ConfigurationInfo is a struct that used to capture config param details
@@ -116,79 +120,128 @@ type FeatureStatesConfigInfo struct {
 
 // TopologyCategoryInfo contains metadata for the Zone and Region parameters under Labels section.
 type TopologyCategoryInfo struct {
-	Label string `gcfg:"label"`
+	Label string `gcfg:"label" yaml:"label,omitempty"`
 }
 
 // NetPermissionConfig consists of information used to restrict the
 // network permissions set on file share volumes
 type NetPermissionConfig struct {
 	// Client IP address, IP range or IP subnet. Example: "10.20.30.0/24"; defaults to "*" if not specified
-	Ips string `gcfg:"ips"`
+	Ips string `gcfg:"ips" yaml:"ips,omitempty"`
 	// Is it READ_ONLY, READ_WRITE or NO_ACCESS. Defaults to "READ_WRITE" if not specified
-	Permissions vsanfstypes.VsanFileShareAccessType `gcfg:"permissions"`
+	Permissions vsanfstypes.VsanFileShareAccessType `gcfg:"permissions" yaml:"permissions,omitempty"`
 	// Disallow root access for this IP range. Defaults to "false" if not specified
-	RootSquash bool `gcfg:"rootsquash"`
+	RootSquash bool `gcfg:"rootsquash" yaml:"rootsquash,omitempty"`
 }
 
 // VirtualCenterConfig contains information used to access a remote vCenter
 // endpoint.
 type VirtualCenterConfig struct {
 	// vCenter username.
-	User string `gcfg:"user" sensitive:"true"`
+	User string `gcfg:"user" yaml:"user,omitempty" sensitive:"true"`
 	// vCenter password in clear text.
-	Password string `gcfg:"password" sensitive:"true"`
+	Password string `gcfg:"password" yaml:"password,omitempty" sensitive:"true"`
 	// vCenter port.
-	VCenterPort string `gcfg:"port"`
+	VCenterPort string `gcfg:"port" yaml:"port,omitempty"`
 	// True if vCenter uses self-signed cert.
-	InsecureFlag bool `gcfg:"insecure-flag"`
+	InsecureFlag bool `gcfg:"insecure-flag" yaml:"insecure-flag,omitempty"`
 	// Specifies the path to a CA certificate in PEM format. This has no effect if
 	// InsecureFlag is enabled. Optional; if not configured, the system's CA
 	// certificates will be used.
-	CAFile string `gcfg:"ca-file"`
+	CAFile string `gcfg:"ca-file" yaml:"ca-file,omitempty"`
 	// Thumbprint specifies the certificate thumbprint to use
 	// This has no effect if InsecureFlag is enabled.
-	Thumbprint string `gcfg:"thumbprint"`
+	Thumbprint string `gcfg:"thumbprint" yaml:"thumbprint,omitempty"`
 	// Datacenter in which VMs are located.
-	Datacenters string `gcfg:"datacenters"`
+	Datacenters string `gcfg:"datacenters" yaml:"datacenters,omitempty"`
 	// TargetvSANFileShareClusters represents file service enabled vSAN clusters on which file volumes can be created.
-	TargetvSANFileShareClusters string `gcfg:"targetvSANFileShareClusters"`
+	TargetvSANFileShareClusters string `gcfg:"targetvSANFileShareClusters" yaml:"targetvSANFileShareClusters,omitempty"`
 	// MigrationDataStore specifies datastore which is set as default datastore in legacy cloud-config
 	// and hence should be used as default datastore.
-	MigrationDataStoreURL string `gcfg:"migration-datastore-url"`
+	MigrationDataStoreURL string `gcfg:"migration-datastore-url" yaml:"migration-datastore-url,omitempty"`
 	// FileVolumeActivated indicates whether file service has been enabled on any vSAN cluster or not
-	FileVolumeActivated bool
+	FileVolumeActivated bool `yaml:"-"`
+	// APIQPS is the rate of the calls to vCenter APIs allowed per second.
+	// The calls are not rate limited if not set.
+	APIQPS float64 `gcfg:"api-qps" yaml:"api-qps,omitempty"`
+	// APIBurst is the maximum burst of calls to vCenter APIs above APIQPS.
+	// Defaults to the ceiling of APIQPS.
+	APIBurst int `gcfg:"api-burst" yaml:"api-burst,omitempty"`
+	// MaxConcurrentCreateOps is the maximum number of concurrent calls to the
+	// vCenter APIs creating, deleting or expanding volumes and snapshots.
+	// Unlimited if not set.
+	MaxConcurrentCreateOps int `gcfg:"max-concurrent-create-ops" yaml:"max-concurrent-create-ops,omitempty"`
+	// MaxConcurrentAttachOps is the maximum number of concurrent calls to the
+	// vCenter APIs attaching or detaching volumes. Unlimited if not set.
+	MaxConcurrentAttachOps int `gcfg:"max-concurrent-attach-ops" yaml:"max-concurrent-attach-ops,omitempty"`
+	// MaxConcurrentQueryOps is the maximum number of concurrent calls to the
+	// vCenter APIs querying volumes, snapshots, policies and properties.
+	// Unlimited if not set.
+	MaxConcurrentQueryOps int `gcfg:"max-concurrent-query-ops" yaml:"max-concurrent-query-ops,omitempty"`
+	// APIRequestTimeoutInSec is the timeout of the calls to vCenter APIs,
+	// except the property collector long polls. No timeout if not set.
+	APIRequestTimeoutInSec int `gcfg:"api-request-timeout-seconds" yaml:"api-request-timeout-seconds,omitempty"`
+	// CircuitBreakerFailureThreshold is the number of consecutive calls to
+	// vCenter APIs failing without a response from vCenter, e.g. because of
+	// network errors or timeouts, after which the calls fail fast for
+	// CircuitBreakerOpenDurationInSec. The circuit breaker is disabled if not
+	// set.
+	CircuitBreakerFailureThreshold int `gcfg:"circuit-breaker-failure-threshold" yaml:"circuit-breaker-failure-threshold,omitempty"` //nolint:lll
+	// CircuitBreakerOpenDurationInSec is the duration for which the calls to
+	// vCenter APIs fail fast once the circuit breaker has opened, before a
+	// trial call is allowed.
+	CircuitBreakerOpenDurationInSec int `gcfg:"circuit-breaker-open-duration-seconds" yaml:"circuit-breaker-open-duration-seconds,omitempty"` //nolint:lll
+	// CredentialProvider is the provider of the credentials used to log in to
+	// the vCenter: "config" for User and Password, which is the default,
+	// "file" for CredentialFile or "exec" for CredentialExecCommand.
+	CredentialProvider string `gcfg:"credential-provider" yaml:"credential-provider,omitempty"`
+	// CredentialFile is the path of a JSON file with the "username" and
+	// "password" used to log in to the vCenter, e.g. mounted from a secret.
+	// The file is watched and its changes are used by the next login.
+	CredentialFile string `gcfg:"credential-file" yaml:"credential-file,omitempty"`
+	// CredentialExecCommand is the command printing the "username",
+	// "password" and optional "expirationTimestamp" used to log in to the
+	// vCenter in JSON. It is run with the vCenter host in the
+	// VSPHERE_CSI_VCENTER_HOST environment variable.
+	CredentialExecCommand string `gcfg:"credential-exec-command" yaml:"credential-exec-command,omitempty"`
+	// CredentialExecArgs are the arguments of CredentialExecCommand.
+	CredentialExecArgs []string `gcfg:"credential-exec-arg" yaml:"credential-exec-arg,omitempty"`
 }
 
 // GCConfig contains information used by guest cluster to access a supervisor
 // cluster endpoint
 type GCConfig struct {
 	// Supervisor Cluster server IP
-	Endpoint string `gcfg:"endpoint"`
+	Endpoint string `gcfg:"endpoint" yaml:"endpoint,omitempty"`
 	// Supervisor Cluster server port
-	Port string `gcfg:"port"`
+	Port string `gcfg:"port" yaml:"port,omitempty"`
 	// Guest Cluster UID
-	TanzuKubernetesClusterUID string `gcfg:"tanzukubernetescluster-uid"`
+	TanzuKubernetesClusterUID string `gcfg:"tanzukubernetescluster-uid" yaml:"tanzukubernetescluster-uid,omitempty"`
 	// Guest Cluster Name
-	TanzuKubernetesClusterName string `gcfg:"tanzukubernetescluster-name"`
+	TanzuKubernetesClusterName string `gcfg:"tanzukubernetescluster-name" yaml:"tanzukubernetescluster-name,omitempty"`
 	// Cluster Distribution Name
-	ClusterDistribution string `gcfg:"cluster-distribution"`
+	ClusterDistribution string `gcfg:"cluster-distribution" yaml:"cluster-distribution,omitempty"`
 	// ClusterAPIVersion refers to the API version of the object guest cluster is created from.
-	ClusterAPIVersion string `gcfg:"cluster-api-version"`
+	ClusterAPIVersion string `gcfg:"cluster-api-version" yaml:"cluster-api-version,omitempty"`
 	// ClusterKind refers to the kind of object guest cluster is created from.
-	ClusterKind string `gcfg:"cluster-kind"`
+	ClusterKind string `gcfg:"cluster-kind" yaml:"cluster-kind,omitempty"`
 }
 
 // SnapshotConfig contains snapshot configuration.
 type SnapshotConfig struct {
 	// GlobalMaxSnapshotsPerBlockVolume specifies the maximum number of block volume snapshots per volume.
-	GlobalMaxSnapshotsPerBlockVolume int `gcfg:"global-max-snapshots-per-block-volume"`
+	GlobalMaxSnapshotsPerBlockVolume int `gcfg:"global-max-snapshots-per-block-volume" yaml:"global-max-snapshots-per-block-volume,omitempty"` //nolint:lll
 	// GranularMaxSnapshotsPerBlockVolumeInVSAN specifies the maximum number of block volume snapshots
 	// per volume in VSAN datastores.
-	GranularMaxSnapshotsPerBlockVolumeInVSAN int `gcfg:"granular-max-snapshots-per-block-volume-vsan"`
+	GranularMaxSnapshotsPerBlockVolumeInVSAN int `gcfg:"granular-max-snapshots-per-block-volume-vsan" yaml:"granular-max-snapshots-per-block-volume-vsan,omitempty"` //nolint:lll
 	// GranularMaxSnapshotsPerBlockVolumeInVVOL specifies the maximum number of block volume snapshots
 	// per volume in VVOL datastores.
-	GranularMaxSnapshotsPerBlockVolumeInVVOL int `gcfg:"granular-max-snapshots-per-block-volume-vvol"`
+	GranularMaxSnapshotsPerBlockVolumeInVVOL int `gcfg:"granular-max-snapshots-per-block-volume-vvol" yaml:"granular-max-snapshots-per-block-volume-vvol,omitempty"` //nolint:lll
 }
 
 // EnvClusterFlavor is the k8s cluster type on which CSI Driver is being deployed
 const EnvClusterFlavor = "CLUSTER_FLAVOR"
+
+// EnvContainerOrchestrator is the container orchestrator on which CSI Driver
+// is being deployed, either "kubernetes" (default) or "nomad".
+const EnvContainerOrchestrator = "CONTAINER_ORCHESTRATOR"
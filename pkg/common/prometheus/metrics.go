@@ -49,6 +49,22 @@ const (
 	PrometheusListSnapshotsOpType = "list-snapshot"
 	// PrometheusListVolumeOpType represents the ListVolumes operation.
 	PrometheusListVolumeOpType = "list-volume"
+	// PrometheusGetCapacityOpType represents the GetCapacity operation.
+	PrometheusGetCapacityOpType = "get-capacity"
+	// PrometheusModifyVolumeOpType represents the ModifyVolume operation.
+	PrometheusModifyVolumeOpType = "modify-volume"
+	// PrometheusGetVolumeOpType represents the GetVolume operation.
+	PrometheusGetVolumeOpType = "get-volume"
+	// PrometheusGetMetadataAllocatedOpType represents the GetMetadataAllocated operation.
+	PrometheusGetMetadataAllocatedOpType = "get-metadata-allocated"
+	// PrometheusGetMetadataDeltaOpType represents the GetMetadataDelta operation.
+	PrometheusGetMetadataDeltaOpType = "get-metadata-delta"
+	// PrometheusCreateGroupSnapshotOpType represents the CreateVolumeGroupSnapshot operation.
+	PrometheusCreateGroupSnapshotOpType = "create-group-snapshot"
+	// PrometheusGetGroupSnapshotOpType represents the GetVolumeGroupSnapshot operation.
+	PrometheusGetGroupSnapshotOpType = "get-group-snapshot"
+	// PrometheusDeleteGroupSnapshotOpType represents the DeleteVolumeGroupSnapshot operation.
+	PrometheusDeleteGroupSnapshotOpType = "delete-group-snapshot"
 
 	// CNS operation types
 
@@ -78,12 +94,16 @@ const (
 	PrometheusCnsRelocateVolumeOpType = "relocate-volume"
 	// PrometheusCnsConfigureVolumeACLOpType represents the ConfigureVolumeAcl operation.
 	PrometheusCnsConfigureVolumeACLOpType = "configure-volume-acl"
+	// PrometheusCnsReconfigVolumePolicyOpType represents the ReconfigVolumePolicy operation.
+	PrometheusCnsReconfigVolumePolicyOpType = "reconfig-volume-policy"
 	// PrometheusQuerySnapshotsOpType represents QuerySnapshots operation.
 	PrometheusQuerySnapshotsOpType = "query-snapshots"
 	// PrometheusCnsCreateSnapshotOpType represents CreateSnapshot operation.
 	PrometheusCnsCreateSnapshotOpType = "create-snapshot"
 	// PrometheusCnsDeleteSnapshotOpType represents DeleteSnapshot operation.
 	PrometheusCnsDeleteSnapshotOpType = "delete-snapshot"
+	// PrometheusCnsCreateGroupSnapshotOpType represents CreateGroupSnapshot operation.
+	PrometheusCnsCreateGroupSnapshotOpType = "create-group-snapshot"
 	// PrometheusAccessibleVolumes represents accessible volumes.
 	PrometheusAccessibleVolumes = "accessible-volumes"
 	// PrometheusInaccessibleVolumes represents inaccessible volumes.
@@ -98,6 +118,9 @@ const (
 )
 
 var (
+	// VolumeMetricLabels are the labels of the per-volume gauge metrics.
+	VolumeMetricLabels = []string{"pv", "namespace", "pvc", "storage_class", "datastore_url", "storage_policy_id"}
+
 	// CsiInfo is a gauge metric to observe the CSI version.
 	CsiInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "vsphere_csi_info",
@@ -148,6 +171,31 @@ var (
 		// Possible volume_health_type - "accessible-volumes", "inaccessible-volumes"
 		[]string{"volume_health_type"})
 
+	// VolumeCapacityGaugeVec is a gauge metric to observe the provisioned capacity of a volume.
+	VolumeCapacityGaugeVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsphere_volume_capacity_bytes",
+		Help: "Provisioned capacity of the volume in bytes",
+	}, VolumeMetricLabels)
+
+	// VolumeHealthStatusGaugeVec is a gauge metric to observe the CNS health status of a volume.
+	VolumeHealthStatusGaugeVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsphere_volume_health_status",
+		Help: "CNS health status of the volume, 1 if accessible, 0 if inaccessible and -1 if unknown",
+	}, VolumeMetricLabels)
+
+	// VolumeSnapshotCountGaugeVec is a gauge metric to observe the number of snapshots of a volume.
+	VolumeSnapshotCountGaugeVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsphere_volume_snapshot_count",
+		Help: "Number of snapshots of the volume",
+	}, VolumeMetricLabels)
+
+	// VolumeAggregatedSnapshotSizeGaugeVec is a gauge metric to observe the aggregated size of
+	// the snapshots of a volume.
+	VolumeAggregatedSnapshotSizeGaugeVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsphere_volume_aggregated_snapshot_size_bytes",
+		Help: "Aggregated size of the snapshots of the volume in bytes",
+	}, VolumeMetricLabels)
+
 	// FullSyncOpsHistVec is a histogram vector metric to observe CSI Full Sync.
 	FullSyncOpsHistVec = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name: "vsphere_full_sync_ops_histogram",
@@ -165,4 +213,50 @@ var (
 		Help:    "Histogram vector for individual request to vCenter",
 		Buckets: []float64{2, 5, 10, 15, 20, 25, 30, 60, 120, 180},
 	}, []string{"request", "client", "status"})
+
+	// VCenterAPICircuitBreakerStateGaugeVec is a gauge metric to observe the state of the
+	// circuit breaker of the calls to a vCenter.
+	VCenterAPICircuitBreakerStateGaugeVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsphere_api_circuit_breaker_state",
+		Help: "State of the circuit breaker of the calls to vCenter, 0 if closed, 1 if open and 2 if half-open",
+	}, []string{"vcenter"})
+
+	// VCenterAPIRejectedRequestsCounterVec is a counter metric to observe the calls to a
+	// vCenter rejected by the client side limits.
+	VCenterAPIRejectedRequestsCounterVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "vsphere_api_rejected_requests_total",
+		Help: "Number of calls to vCenter rejected by the client side limits",
+	},
+		// Possible reason - "circuit-open", "wait-canceled"
+		[]string{"vcenter", "reason"})
+
+	// VCenterAPIInflightRequestsGaugeVec is a gauge metric to observe the number of calls
+	// to a vCenter in flight per operation class.
+	VCenterAPIInflightRequestsGaugeVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsphere_api_inflight_requests",
+		Help: "Number of calls to vCenter in flight",
+	},
+		// Possible class - "create", "attach", "query", "other"
+		[]string{"vcenter", "class"})
+
+	// VCenterAPILimiterWaitHistVec is a histogram vector metric to observe the time the
+	// calls to a vCenter wait for the rate limiter and the concurrency caps.
+	VCenterAPILimiterWaitHistVec = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "vsphere_api_limiter_wait_seconds",
+		Help:    "Time the calls to vCenter waited for the rate limiter and the concurrency caps",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30, 60},
+	}, []string{"vcenter", "class"})
+
+	// CsiGrpcRequestOpsHistVec is a histogram vector metric to observe the latency
+	// of the gRPC requests served by the CSI driver.
+	CsiGrpcRequestOpsHistVec = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "vsphere_csi_grpc_request_ops_histogram",
+		Help: "Histogram vector for gRPC requests served by the CSI driver.",
+		// Identity and node requests usually take milliseconds, while
+		// controller requests calling CNS take a few seconds.
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 15, 20, 25, 30, 60, 120, 180},
+	},
+		// Possible method - full gRPC method name, e.g. "/csi.v1.Controller/CreateVolume"
+		// Possible code - gRPC status code, e.g. "OK", "NotFound", "Internal"
+		[]string{"method", "code"})
 )
@@ -0,0 +1,109 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+const (
+	// EnvTracingExporter is the environment variable used to enable the
+	// export of OpenTelemetry spans. Valid values are:
+	// * otlp: export spans to the OTLP gRPC endpoint set in the standard
+	//   OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+	//   environment variables.
+	// * stdout: write spans to the standard output as JSON.
+	//
+	// Tracing is disabled if the variable is not set. The sampler can be
+	// configured with the standard OTEL_TRACES_SAMPLER and
+	// OTEL_TRACES_SAMPLER_ARG environment variables.
+	EnvTracingExporter = "CSI_TRACING_EXPORTER"
+
+	// TracingExporterOTLP exports spans to an OTLP gRPC endpoint.
+	TracingExporterOTLP = "otlp"
+	// TracingExporterStdout writes spans to the standard output.
+	TracingExporterStdout = "stdout"
+
+	// tracerName is the name of the tracer used for the spans created by the
+	// driver.
+	tracerName = "sigs.k8s.io/vsphere-csi-driver"
+)
+
+// InitTracing installs the global OpenTelemetry tracer provider according to
+// EnvTracingExporter. The returned function flushes the pending spans and
+// shuts the provider down. If tracing is disabled, the global no-op provider
+// is kept and the returned function does nothing.
+func InitTracing(ctx context.Context, serviceName string, serviceVersion string) (
+	func(context.Context) error, error) {
+	log := logger.GetLogger(ctx)
+	exporterType := strings.ToLower(strings.TrimSpace(os.Getenv(EnvTracingExporter)))
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch exporterType {
+	case "":
+		log.Infof("%s is not set. OpenTelemetry tracing is disabled.", EnvTracingExporter)
+		return func(context.Context) error { return nil }, nil
+	case TracingExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter. Err: %v", err)
+		}
+	case TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout trace exporter. Err: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid value %q specified for %s, expecting %q or %q",
+			exporterType, EnvTracingExporter, TracingExporterOTLP, TracingExporterStdout)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName), semconv.ServiceVersion(serviceVersion)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource. Err: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{},
+		propagation.Baggage{}))
+	log.Infof("OpenTelemetry tracing enabled with the %q exporter", exporterType)
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts a span with the given name as a child of the span in ctx,
+// if any, using the global tracer provider. The span must be ended by the
+// caller.
+func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+)
+
+func TestInitTracing(t *testing.T) {
+	ctx := context.Background()
+	origProvider := otel.GetTracerProvider()
+
+	t.Setenv(EnvTracingExporter, "")
+	shutdown, err := InitTracing(ctx, "test", "v0")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(ctx))
+	assert.Equal(t, origProvider, otel.GetTracerProvider())
+
+	t.Setenv(EnvTracingExporter, "invalid")
+	_, err = InitTracing(ctx, "test", "v0")
+	assert.Error(t, err)
+
+	t.Setenv(EnvTracingExporter, TracingExporterStdout)
+	shutdown, err = InitTracing(ctx, "test", "v0")
+	assert.NoError(t, err)
+	_, span := StartSpan(ctx, "test")
+	assert.True(t, span.SpanContext().IsValid())
+	span.End()
+	assert.NoError(t, shutdown(ctx))
+}
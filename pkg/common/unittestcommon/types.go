@@ -224,3 +224,13 @@ func (m *MockVolumeManager) SyncVolume(ctx context.Context,
 	syncVolumeSpecs []cnstypes.CnsSyncVolumeSpec) (string, error) {
 	return "", nil
 }
+
+func (m *MockVolumeManager) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string,
+	volumeIDs []string) ([]*cnsvolume.CnsSnapshotInfo, error) {
+	return nil, nil
+}
+
+func (m *MockVolumeManager) ReconfigVolumePolicy(ctx context.Context, volumeID string,
+	storagePolicyID string) (string, error) {
+	return "", nil
+}
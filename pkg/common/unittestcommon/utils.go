@@ -537,6 +537,16 @@ func (c *FakeK8SOrchestrator) UpdatePersistentVolumeLabel(ctx context.Context, p
 	return nil
 }
 
+func (c *FakeK8SOrchestrator) UpdateNodeAnnotation(ctx context.Context, nodeName string,
+	key string, value string) error {
+	return nil
+}
+
+func (c *FakeK8SOrchestrator) RecordNodeEvent(ctx context.Context, nodeName string,
+	eventType string, reason string, message string) error {
+	return nil
+}
+
 func (c *FakeK8SOrchestrator) GetActiveClustersForNamespaceInRequestedZones(ctx context.Context,
 	targetNS string, requestedZones []string) ([]string, error) {
 	return nil, nil
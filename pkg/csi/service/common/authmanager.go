@@ -337,7 +337,12 @@ func getDatastoresWithBlockVolumePrivs(ctx context.Context, vc *cnsvsphere.Virtu
 	authMgr := object.NewAuthorizationManager(vc.Client.Client)
 	privIds := []string{DsPriv, SysReadPriv}
 
-	userName := vc.Config.Username
+	credentials, err := vc.GetCredentials(ctx)
+	if err != nil {
+		log.Errorf("auth manager: failed to get the credentials of vCenter %q. err: %v", vc.Config.Host, err)
+		return nil, err
+	}
+	userName := credentials.Username
 	// Invoke authMgr function HasUserPrivilegeOnEntities.
 	result, err := authMgr.HasUserPrivilegeOnEntities(ctx, entities, userName, privIds) // entities empty -> error
 	if err != nil {
@@ -443,7 +448,12 @@ func getFSEnabledClustersWithPriv(ctx context.Context, vc *cnsvsphere.VirtualCen
 	// Get Clusters with HostConfigStoragePriv.
 	authMgr := object.NewAuthorizationManager(vc.Client.Client)
 	privIds := []string{HostConfigStoragePriv}
-	userName := vc.Config.Username
+	credentials, err := vc.GetCredentials(ctx)
+	if err != nil {
+		log.Errorf("auth manager: failed to get the credentials of vCenter %q. err: %v", vc.Config.Host, err)
+		return nil, err
+	}
+	userName := credentials.Username
 	var entities []vim25types.ManagedObjectReference
 	clusterComputeResourcesMap := make(map[string]*object.ClusterComputeResource)
 	for _, cluster := range clusterComputeResources {
@@ -30,6 +30,7 @@ import (
 	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/k8sorchestrator"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/nomadorchestrator"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/types"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 )
@@ -120,6 +121,10 @@ type COCommonInterface interface {
 	IsLinkedCloneRequest(ctx context.Context, pvcName string, pvcNamespace string) (bool, error)
 	// UpdatePersistentVolumeLabel Updates the PV label with the specified key value.
 	UpdatePersistentVolumeLabel(ctx context.Context, pvName string, key string, value string) error
+	// UpdateNodeAnnotation sets the annotation with the specified key to value on the given node.
+	UpdateNodeAnnotation(ctx context.Context, nodeName string, key string, value string) error
+	// RecordNodeEvent records an event of the given type, e.g. Normal or Warning, on the given node.
+	RecordNodeEvent(ctx context.Context, nodeName string, eventType string, reason string, message string) error
 	GetActiveClustersForNamespaceInRequestedZones(ctx context.Context, ns string, zones []string) ([]string, error)
 	// GetPvcObjectByName return PVC object for the given PVC name
 	GetPvcObjectByName(ctx context.Context, pvcName string, namespace string) (*v1.PersistentVolumeClaim, error)
@@ -143,6 +148,13 @@ func GetContainerOrchestratorInterface(ctx context.Context, orchestratorType int
 			return nil, err
 		}
 		return k8sOrchestratorInstance, nil
+	case common.Nomad:
+		nomadOrchestratorInstance, err := nomadorchestrator.NewNomadOrchestrator(ctx, clusterFlavor, params)
+		if err != nil {
+			log.Errorf("creating nomadOrchestratorInstance failed. Err: %v", err)
+			return nil, err
+		}
+		return nomadOrchestratorInstance, nil
 	default:
 		// If type is invalid, return an error.
 		return nil, fmt.Errorf("invalid orchestrator type")
@@ -19,6 +19,7 @@ package k8sorchestrator
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -69,6 +70,9 @@ const informerCreateRetryInterval = 5 * time.Minute
 // operationModeWebHookServer indicates container running as webhook server
 const operationModeWebHookServer = "WEBHOOK_SERVER"
 
+// serviceModeController is the service mode of the CSI controller.
+const serviceModeController = "controller"
+
 var (
 	k8sOrchestratorInstance            *K8sOrchestrator
 	k8sOrchestratorInstanceInitialized uint32
@@ -338,7 +342,7 @@ func Newk8sOrchestrator(ctx context.Context, controllerClusterFlavor cnstypes.Cn
 					return nil, fmt.Errorf("expected orchestrator params of type K8sVanillaInitParams, got %T instead", params)
 				}
 				operationMode = vanillaInitParams.OperationMode
-				k8sOrchestratorInstance.releasedVanillaFSS = getReleasedVanillaFSS()
+				k8sOrchestratorInstance.releasedVanillaFSS = common.GetReleasedVanillaFSS()
 			} else if controllerClusterFlavor == cnstypes.CnsClusterFlavorGuest {
 				guestInitParams, ok := params.(K8sGuestInitParams)
 				if !ok {
@@ -349,10 +353,15 @@ func Newk8sOrchestrator(ctx context.Context, controllerClusterFlavor cnstypes.Cn
 				return nil, fmt.Errorf("wrong orchestrator params type")
 			}
 
+			// ControllerGetVolume is served by the controllers of all flavors
+			// and looks up the nodes of a volume through the volume ID to PV
+			// name and PV name to nodes maps, so the maps are always
+			// initialized in the controllers.
+			isControllerService := serviceMode == serviceModeController
 			if ((controllerClusterFlavor == cnstypes.CnsClusterFlavorWorkload &&
 				k8sOrchestratorInstance.IsFSSEnabled(ctx, common.FakeAttach)) ||
 				(controllerClusterFlavor == cnstypes.CnsClusterFlavorVanilla &&
-					k8sOrchestratorInstance.IsFSSEnabled(ctx, common.ListVolumes))) &&
+					k8sOrchestratorInstance.IsFSSEnabled(ctx, common.ListVolumes)) || isControllerService) &&
 				(operationMode != operationModeWebHookServer) {
 				err := initVolumeHandleToPvcMap(ctx, controllerClusterFlavor)
 				if err != nil {
@@ -372,8 +381,9 @@ func Newk8sOrchestrator(ctx context.Context, controllerClusterFlavor cnstypes.Cn
 					return nil, fmt.Errorf("failed to create node ID to name map. Error: %v", err)
 				}
 			} else if operationMode != operationModeWebHookServer {
-				// Initialize the map for volumeName to nodes, for non-WCP flavors and when ListVolume FSS is on
-				if k8sOrchestratorInstance.IsFSSEnabled(ctx, common.ListVolumes) {
+				// Initialize the map for volumeName to nodes, for non-WCP flavors when ListVolume FSS is on
+				// or in the controllers.
+				if k8sOrchestratorInstance.IsFSSEnabled(ctx, common.ListVolumes) || isControllerService {
 					err := initVolumeNameToNodesMap(ctx, controllerClusterFlavor)
 					if err != nil {
 						return nil, fmt.Errorf("failed to create PV name to node names map. Error: %v", err)
@@ -389,19 +399,6 @@ func Newk8sOrchestrator(ctx context.Context, controllerClusterFlavor cnstypes.Cn
 	return k8sOrchestratorInstance, nil
 }
 
-func getReleasedVanillaFSS() map[string]struct{} {
-	return map[string]struct{}{
-		common.CSIMigration:                  {},
-		common.OnlineVolumeExtend:            {},
-		common.BlockVolumeSnapshot:           {},
-		common.CSIWindowsSupport:             {},
-		common.ListVolumes:                   {},
-		common.CnsMgrSuspendCreateVolume:     {},
-		common.CSIInternalGeneratedClusterID: {},
-		common.TopologyAwareFileVolume:       {},
-	}
-}
-
 // initFSS performs all the operations required to initialize the Feature
 // states map and keep a watch on it. NOTE: As initFSS is called during the
 // init of the driver and syncer components, raise an error only if the
@@ -930,6 +927,7 @@ func initVolumeHandleToPvcMap(ctx context.Context, controllerClusterFlavor cnsty
 	// Set up kubernetes resource listener to listen events on PersistentVolumes
 	// and PersistentVolumeClaims.
 	if (controllerClusterFlavor == cnstypes.CnsClusterFlavorVanilla && serviceMode != "node") ||
+		(controllerClusterFlavor == cnstypes.CnsClusterFlavorGuest && serviceMode == serviceModeController) ||
 		(controllerClusterFlavor == cnstypes.CnsClusterFlavorWorkload) {
 
 		err := k8sOrchestratorInstance.informerManager.AddPVListener(
@@ -1693,6 +1691,7 @@ func initVolumeNameToNodesMap(ctx context.Context, controllerClusterFlavor cnsty
 
 	// Set up kubernetes resource listener to listen events on volume attachments
 	if (controllerClusterFlavor == cnstypes.CnsClusterFlavorVanilla && serviceMode != "node") ||
+		(controllerClusterFlavor == cnstypes.CnsClusterFlavorGuest && serviceMode == serviceModeController) ||
 		(controllerClusterFlavor == cnstypes.CnsClusterFlavorWorkload) {
 
 		err := k8sOrchestratorInstance.informerManager.AddVolumeAttachmentListener(
@@ -2244,6 +2243,61 @@ func (c *K8sOrchestrator) UpdatePersistentVolumeLabel(ctx context.Context,
 	})
 }
 
+// UpdateNodeAnnotation sets the annotation with the specified key to value on
+// the given node.
+func (c *K8sOrchestrator) UpdateNodeAnnotation(ctx context.Context,
+	nodeName string, key string, value string) error {
+	log := logger.GetLogger(ctx)
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{key: value},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch for node %s: %w", nodeName, err)
+	}
+	_, err = c.k8sClient.CoreV1().Nodes().Patch(ctx, nodeName, k8stypes.MergePatchType, patch,
+		metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("error updating node %s with annotation %s/%s: %w", nodeName, key, value, err)
+	}
+	log.Debugf("Successfully updated node %s with annotation key:%s value:%s", nodeName, key, value)
+	return nil
+}
+
+// RecordNodeEvent records an event of the given type on the given node. Like
+// the events recorded by kubelet, the event is created in the default
+// namespace and refers to the node by its name.
+func (c *K8sOrchestrator) RecordNodeEvent(ctx context.Context, nodeName string,
+	eventType string, reason string, message string) error {
+	log := logger.GetLogger(ctx)
+	now := metav1.Now()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: nodeName + ".",
+			Namespace:    metav1.NamespaceDefault,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind: "Node",
+			Name: nodeName,
+			UID:  k8stypes.UID(nodeName),
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: "vsphere-csi-node", Host: nodeName},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := c.k8sClient.CoreV1().Events(metav1.NamespaceDefault).Create(ctx, event, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("error recording event %s on node %s: %w", reason, nodeName, err)
+	}
+	log.Debugf("Successfully recorded event %s on node %s", reason, nodeName)
+	return nil
+}
+
 // GetPVCNamespacedNameByUID returns the PVC's namespaced name (namespace/name) for the given UID.
 // If the PVC is not found in the cache, it returns an empty string and false.
 func (c *K8sOrchestrator) GetPVCNamespacedNameByUID(uid string) (k8stypes.NamespacedName, bool) {
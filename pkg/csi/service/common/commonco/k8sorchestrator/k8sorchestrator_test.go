@@ -217,7 +217,7 @@ func TestIsFSSEnabledInVanilla(t *testing.T) {
 	k8sOrchestrator := K8sOrchestrator{
 		clusterFlavor:      cnstypes.CnsClusterFlavorVanilla,
 		internalFSS:        internalFSSConfigMapInfo,
-		releasedVanillaFSS: getReleasedVanillaFSS(),
+		releasedVanillaFSS: common.GetReleasedVanillaFSS(),
 	}
 	// Feature state missing
 	isEnabled := k8sOrchestrator.IsFSSEnabled(ctx, "unknown-performance-feature")
@@ -0,0 +1,307 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomadorchestrator
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// nomadRequestTimeout is the timeout of a request to the Nomad HTTP API.
+	nomadRequestTimeout = 30 * time.Second
+	// nomadTokenHeader is the header of the ACL token of a request.
+	nomadTokenHeader = "X-Nomad-Token"
+)
+
+// nomadNode is a node returned by the /v1/node/:node_id endpoint.
+type nomadNode struct {
+	ID     string
+	Name   string
+	Status string
+	Meta   map[string]string
+}
+
+// nomadNodeListStub is a node returned by the /v1/nodes endpoint.
+type nomadNodeListStub struct {
+	ID     string
+	Name   string
+	Status string
+}
+
+// nomadCSIInfo is the fingerprint of a CSI plugin on a node.
+type nomadCSIInfo struct {
+	PluginID string
+	Healthy  bool
+	NodeInfo *nomadCSINodeInfo
+}
+
+// nomadCSINodeInfo is the result of NodeGetInfo of a CSI node plugin.
+type nomadCSINodeInfo struct {
+	// ID is the node ID returned by the node plugin, i.e. the node VM UUID.
+	ID                 string
+	MaxVolumes         int64
+	AccessibleTopology *nomadCSITopology
+}
+
+// nomadCSITopology is the accessible topology of a CSI node plugin.
+type nomadCSITopology struct {
+	Segments map[string]string
+}
+
+// nomadCSIPlugin is a CSI plugin returned by the /v1/plugin/csi/:plugin_id
+// endpoint.
+type nomadCSIPlugin struct {
+	ID string
+	// Nodes maps Nomad node IDs to the fingerprint of the node plugin.
+	Nodes map[string]*nomadCSIInfo
+}
+
+// nomadCSIVolumeListStub is a volume returned by the /v1/volumes endpoint.
+type nomadCSIVolumeListStub struct {
+	ID         string
+	Namespace  string
+	ExternalID string
+	PluginID   string
+}
+
+// nomadAllocListStub is an allocation claiming a CSI volume.
+type nomadAllocListStub struct {
+	ID           string
+	NodeID       string
+	NodeName     string
+	ClientStatus string
+}
+
+// nomadCSIVolume is a volume returned by the /v1/volume/csi/:volume_id
+// endpoint.
+type nomadCSIVolume struct {
+	ID          string
+	Namespace   string
+	ExternalID  string
+	PluginID    string
+	Allocations []*nomadAllocListStub
+}
+
+// nomadVariable is a variable of the /v1/var/:var_path endpoint.
+type nomadVariable struct {
+	Namespace string
+	Path      string
+	Items     map[string]string
+}
+
+// nomadVariableMetadata is a variable returned by the /v1/vars endpoint,
+// without its items.
+type nomadVariableMetadata struct {
+	Namespace string
+	Path      string
+}
+
+// nomadAPIError is the error of a request to the Nomad HTTP API answered with
+// an unexpected status code.
+type nomadAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *nomadAPIError) Error() string {
+	return fmt.Sprintf("unexpected response code %d: %s", e.StatusCode, e.Message)
+}
+
+// isNotFound returns true if the error is a 404 of the Nomad HTTP API.
+func isNotFound(err error) bool {
+	var apiErr *nomadAPIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// nomadClient is a minimal client of the Nomad HTTP API.
+type nomadClient struct {
+	address    *url.URL
+	token      string
+	namespace  string
+	httpClient *http.Client
+}
+
+// newNomadClient creates a client of the Nomad HTTP API at the address of the
+// init params. Addresses with the unix scheme, e.g. the task API socket
+// "unix:///secrets/api.sock", are dialed through the socket.
+func newNomadClient(params NomadInitParams) (*nomadClient, error) {
+	address, err := url.Parse(params.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Nomad address %q. Error: %v", params.Address, err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch address.Scheme {
+	case "unix":
+		socketPath := address.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+		address = &url.URL{Scheme: "http", Host: "localhost"}
+	case "http", "https":
+		tlsConfig := &tls.Config{InsecureSkipVerify: params.SkipVerify}
+		if params.CACert != "" {
+			caCert, err := os.ReadFile(params.CACert)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read Nomad CA certificate %q. Error: %v", params.CACert, err)
+			}
+			certPool := x509.NewCertPool()
+			if !certPool.AppendCertsFromPEM(caCert) {
+				return nil, fmt.Errorf("no certificate found in Nomad CA certificate %q", params.CACert)
+			}
+			tlsConfig.RootCAs = certPool
+		}
+		transport.TLSClientConfig = tlsConfig
+	default:
+		return nil, fmt.Errorf("invalid scheme %q of Nomad address %q", address.Scheme, params.Address)
+	}
+	return &nomadClient{
+		address:   address,
+		token:     params.Token,
+		namespace: params.Namespace,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   nomadRequestTimeout,
+		},
+	}, nil
+}
+
+// do sends a request to the endpoint and decodes the JSON response into out,
+// unless out is nil.
+func (c *nomadClient) do(ctx context.Context, method, path string, query url.Values,
+	in interface{}, out interface{}) error {
+	reqURL := *c.address
+	reqURL.Path = path
+	reqURL.RawQuery = query.Encode()
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set(nomadTokenHeader, c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &nomadAPIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// listNodes lists the nodes of the cluster.
+func (c *nomadClient) listNodes(ctx context.Context) ([]*nomadNodeListStub, error) {
+	var nodes []*nomadNodeListStub
+	err := c.do(ctx, http.MethodGet, "/v1/nodes", url.Values{}, nil, &nodes)
+	return nodes, err
+}
+
+// getNode returns the node with the given ID.
+func (c *nomadClient) getNode(ctx context.Context, nodeID string) (*nomadNode, error) {
+	node := &nomadNode{}
+	err := c.do(ctx, http.MethodGet, "/v1/node/"+url.PathEscape(nodeID), url.Values{}, nil, node)
+	return node, err
+}
+
+// getCSIPlugin returns the CSI plugin with the given ID.
+func (c *nomadClient) getCSIPlugin(ctx context.Context, pluginID string) (*nomadCSIPlugin, error) {
+	plugin := &nomadCSIPlugin{}
+	err := c.do(ctx, http.MethodGet, "/v1/plugin/csi/"+url.PathEscape(pluginID), url.Values{}, nil, plugin)
+	return plugin, err
+}
+
+// listCSIVolumes lists the CSI volumes of the plugin in all namespaces.
+func (c *nomadClient) listCSIVolumes(ctx context.Context, pluginID string) ([]*nomadCSIVolumeListStub, error) {
+	var volumes []*nomadCSIVolumeListStub
+	query := url.Values{"type": {"csi"}, "plugin_id": {pluginID}, "namespace": {"*"}}
+	err := c.do(ctx, http.MethodGet, "/v1/volumes", query, nil, &volumes)
+	return volumes, err
+}
+
+// getCSIVolume returns the CSI volume with the given ID in the namespace.
+func (c *nomadClient) getCSIVolume(ctx context.Context, namespace, volumeID string) (*nomadCSIVolume, error) {
+	volume := &nomadCSIVolume{}
+	err := c.do(ctx, http.MethodGet, "/v1/volume/csi/"+url.PathEscape(volumeID),
+		url.Values{"namespace": {namespace}}, nil, volume)
+	return volume, err
+}
+
+// getVariable returns the variable at the given path in the namespace of the
+// client.
+func (c *nomadClient) getVariable(ctx context.Context, path string) (*nomadVariable, error) {
+	variable := &nomadVariable{}
+	err := c.do(ctx, http.MethodGet, "/v1/var/"+path, url.Values{"namespace": {c.namespace}}, nil, variable)
+	return variable, err
+}
+
+// createVariable creates the variable at the given path in the namespace of
+// the client. It fails if the variable already exists.
+func (c *nomadClient) createVariable(ctx context.Context, path string, items map[string]string) error {
+	variable := &nomadVariable{Namespace: c.namespace, Path: path, Items: items}
+	// A check-and-set index of 0 only creates the variable if it does not exist.
+	query := url.Values{"namespace": {c.namespace}, "cas": {"0"}}
+	return c.do(ctx, http.MethodPut, "/v1/var/"+path, query, variable, nil)
+}
+
+// putVariable creates or replaces the variable at the given path in the
+// namespace of the client.
+func (c *nomadClient) putVariable(ctx context.Context, path string, items map[string]string) error {
+	variable := &nomadVariable{Namespace: c.namespace, Path: path, Items: items}
+	return c.do(ctx, http.MethodPut, "/v1/var/"+path, url.Values{"namespace": {c.namespace}}, variable, nil)
+}
+
+// deleteVariable deletes the variable at the given path in the namespace of
+// the client. Deleting a missing variable is not an error.
+func (c *nomadClient) deleteVariable(ctx context.Context, path string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/var/"+path, url.Values{"namespace": {c.namespace}}, nil, nil)
+}
+
+// listVariables lists the variables whose path starts with the given prefix in
+// the namespace of the client.
+func (c *nomadClient) listVariables(ctx context.Context, prefix string) ([]*nomadVariableMetadata, error) {
+	var variables []*nomadVariableMetadata
+	query := url.Values{"namespace": {c.namespace}, "prefix": {prefix}}
+	err := c.do(ctx, http.MethodGet, "/v1/vars", query, nil, &variables)
+	return variables, err
+}
@@ -0,0 +1,610 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomadorchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	restclient "k8s.io/client-go/rest"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+)
+
+const (
+	// EnvNomadAddress is the address of the Nomad HTTP API.
+	EnvNomadAddress = "NOMAD_ADDR"
+	// EnvNomadToken is the ACL token used for the Nomad HTTP API.
+	EnvNomadToken = "NOMAD_TOKEN"
+	// EnvNomadNamespace is the Nomad namespace of the variables of the driver.
+	EnvNomadNamespace = "NOMAD_NAMESPACE"
+	// EnvNomadCACert is the path of the CA certificate of the Nomad HTTP API.
+	EnvNomadCACert = "NOMAD_CACERT"
+	// EnvNomadSkipVerify disables the TLS verification of the Nomad HTTP API.
+	EnvNomadSkipVerify = "NOMAD_SKIP_VERIFY"
+	// EnvNomadCSIPluginID is the ID of the CSI plugin of the driver in Nomad.
+	EnvNomadCSIPluginID = "NOMAD_CSI_PLUGIN_ID"
+	// EnvFeatureStatesFile is the path of the feature states file.
+	EnvFeatureStatesFile = "FEATURE_STATES_FILE"
+
+	// DefaultNomadAddress is the default address of the Nomad HTTP API.
+	DefaultNomadAddress = "http://127.0.0.1:4646"
+	// DefaultNomadNamespace is the default Nomad namespace of the variables of
+	// the driver.
+	DefaultNomadNamespace = "default"
+	// DefaultFeatureStatesFile is the default path of the feature states file.
+	DefaultFeatureStatesFile = "/etc/vsphere-csi/feature-states.yaml"
+
+	// variablePathPrefix is the prefix of the paths of the Nomad variables
+	// storing the ConfigMaps of the driver.
+	variablePathPrefix = "vsphere-csi"
+	// notSupportedOnNomad is the message of the errors of the methods which are
+	// not supported on Nomad.
+	notSupportedOnNomad = "%s is not supported on Nomad"
+)
+
+var (
+	nomadOrchestratorInstance  *NomadOrchestrator
+	nomadOrchestratorInitMutex = &sync.Mutex{}
+)
+
+// NomadInitParams lists the set of parameters required to run the init for
+// NomadOrchestrator in Vanilla cluster.
+type NomadInitParams struct {
+	// Address is the address of the Nomad HTTP API, e.g.
+	// "https://nomad.example.com:4646" or "unix:///secrets/api.sock".
+	Address string
+	// Token is the ACL token used for the Nomad HTTP API.
+	Token string
+	// Namespace is the Nomad namespace of the variables of the driver.
+	Namespace string
+	// CACert is the path of the CA certificate of the Nomad HTTP API.
+	CACert string
+	// SkipVerify disables the TLS verification of the Nomad HTTP API.
+	SkipVerify bool
+	// PluginID is the ID of the CSI plugin of the driver in Nomad.
+	PluginID string
+	// FeatureStatesFile is the path of the YAML file mapping feature names to
+	// "true" or "false".
+	FeatureStatesFile string
+	ServiceMode       string
+}
+
+// String returns a string representation of NomadInitParams with the token
+// redacted.
+func (params NomadInitParams) String() string {
+	return fmt.Sprintf("{Address:%s Token:%s Namespace:%s CACert:%s SkipVerify:%t PluginID:%s "+
+		"FeatureStatesFile:%s ServiceMode:%s}", params.Address, strings.Repeat("*", len(params.Token)),
+		params.Namespace, params.CACert, params.SkipVerify, params.PluginID, params.FeatureStatesFile,
+		params.ServiceMode)
+}
+
+// GetNomadInitParams returns the NomadInitParams read from the environment.
+func GetNomadInitParams(serviceMode string) NomadInitParams {
+	params := NomadInitParams{
+		Address:           os.Getenv(EnvNomadAddress),
+		Token:             os.Getenv(EnvNomadToken),
+		Namespace:         os.Getenv(EnvNomadNamespace),
+		CACert:            os.Getenv(EnvNomadCACert),
+		PluginID:          os.Getenv(EnvNomadCSIPluginID),
+		FeatureStatesFile: os.Getenv(EnvFeatureStatesFile),
+		ServiceMode:       serviceMode,
+	}
+	params.SkipVerify, _ = strconv.ParseBool(os.Getenv(EnvNomadSkipVerify))
+	if params.Address == "" {
+		params.Address = DefaultNomadAddress
+	}
+	if params.Namespace == "" {
+		params.Namespace = DefaultNomadNamespace
+	}
+	if params.PluginID == "" {
+		params.PluginID = csitypes.Name
+	}
+	if params.FeatureStatesFile == "" {
+		params.FeatureStatesFile = DefaultFeatureStatesFile
+	}
+	return params
+}
+
+// NomadOrchestrator defines set of properties specific to Nomad.
+type NomadOrchestrator struct {
+	client             *nomadClient
+	pluginID           string
+	releasedVanillaFSS map[string]struct{}
+	featureStatesFile  string
+	featureStatesLock  *sync.RWMutex
+	featureStates      map[string]string
+	// volumeIDsLock protects volumeIDs.
+	volumeIDsLock *sync.Mutex
+	// volumeIDs are the volume IDs listed last, returned if Nomad cannot be
+	// reached.
+	volumeIDs []string
+}
+
+// NewNomadOrchestrator instantiates NomadOrchestrator object and returns this
+// object. Only the Vanilla flavor is supported on Nomad.
+func NewNomadOrchestrator(ctx context.Context, controllerClusterFlavor cnstypes.CnsClusterFlavor,
+	params interface{}) (*NomadOrchestrator, error) {
+	nomadOrchestratorInitMutex.Lock()
+	defer nomadOrchestratorInitMutex.Unlock()
+	if nomadOrchestratorInstance != nil {
+		return nomadOrchestratorInstance, nil
+	}
+	log := logger.GetLogger(ctx)
+	if controllerClusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+		return nil, fmt.Errorf("cluster flavor %q is not supported on Nomad", controllerClusterFlavor)
+	}
+	nomadInitParams, ok := params.(NomadInitParams)
+	if !ok {
+		return nil, fmt.Errorf("expected orchestrator params of type NomadInitParams, got %T instead", params)
+	}
+	log.Info("Initializing nomadOrchestratorInstance")
+	client, err := newNomadClient(nomadInitParams)
+	if err != nil {
+		log.Errorf("Creating Nomad client failed. Err: %v", err)
+		return nil, err
+	}
+	orchestrator := &NomadOrchestrator{
+		client:             client,
+		pluginID:           nomadInitParams.PluginID,
+		releasedVanillaFSS: common.GetReleasedVanillaFSS(),
+		featureStatesFile:  nomadInitParams.FeatureStatesFile,
+		featureStatesLock:  &sync.RWMutex{},
+		featureStates:      make(map[string]string),
+		volumeIDsLock:      &sync.Mutex{},
+	}
+	orchestrator.reloadFeatureStates(ctx)
+	orchestrator.watchFeatureStates(ctx)
+	nomadOrchestratorInstance = orchestrator
+	log.Infof("nomadOrchestratorInstance initialized with Nomad address %q and CSI plugin %q",
+		nomadInitParams.Address, nomadInitParams.PluginID)
+	return nomadOrchestratorInstance, nil
+}
+
+// reloadFeatureStates reads the feature states file. A missing file leaves
+// only the released features enabled.
+func (c *NomadOrchestrator) reloadFeatureStates(ctx context.Context) {
+	log := logger.GetLogger(ctx)
+	featureStates := make(map[string]string)
+	data, err := os.ReadFile(c.featureStatesFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed to read feature states file %q. Keeping the current feature states. Error: %v",
+				c.featureStatesFile, err)
+			return
+		}
+		log.Infof("Feature states file %q does not exist. Only released features are enabled",
+			c.featureStatesFile)
+	} else if err = yaml.Unmarshal(data, &featureStates); err != nil {
+		log.Errorf("failed to parse feature states file %q. Keeping the current feature states. Error: %v",
+			c.featureStatesFile, err)
+		return
+	}
+	c.featureStatesLock.Lock()
+	c.featureStates = featureStates
+	c.featureStatesLock.Unlock()
+	log.Infof("Feature states read from %q: %+v", c.featureStatesFile, featureStates)
+}
+
+// watchFeatureStates reads the feature states file again when it changes.
+func (c *NomadOrchestrator) watchFeatureStates(ctx context.Context) {
+	log := logger.GetLogger(ctx)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorf("failed to create fsnotify watcher for feature states file. err=%v", err)
+		return
+	}
+	// Nomad templates and mounted files may be replaced instead of written, so
+	// the directory is watched instead of the file.
+	dirPath := filepath.Dir(c.featureStatesFile)
+	if err := watcher.Add(dirPath); err != nil {
+		_ = watcher.Close()
+		log.Warnf("failed to watch on path: %q. Feature states will not be reloaded. err=%v", dirPath, err)
+		return
+	}
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				log.Debugf("fsnotify event for feature states file %q: %q", c.featureStatesFile, event.String())
+				c.reloadFeatureStates(ctx)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Errorf("fsnotify error while watching feature states file %q: %+v", c.featureStatesFile, err)
+			}
+		}
+	}()
+}
+
+// IsFSSEnabled returns true for released features, and the state of the
+// feature in the feature states file otherwise.
+func (c *NomadOrchestrator) IsFSSEnabled(ctx context.Context, featureName string) bool {
+	log := logger.GetLogger(ctx)
+	if _, isReleased := c.releasedVanillaFSS[featureName]; isReleased {
+		return true
+	}
+	c.featureStatesLock.RLock()
+	state, ok := c.featureStates[featureName]
+	c.featureStatesLock.RUnlock()
+	if !ok {
+		log.Debugf("Could not find the %s feature state in file %s. Setting the feature state to false",
+			featureName, c.featureStatesFile)
+		return false
+	}
+	featureState, err := strconv.ParseBool(state)
+	if err != nil {
+		log.Errorf("Error while converting %v feature state value: %v to boolean. "+
+			"Setting the feature state to false", featureName, state)
+		return false
+	}
+	return featureState
+}
+
+// IsCNSCSIFSSEnabled is not applicable on Nomad, which only runs Vanilla
+// clusters, and returns the feature state of the feature states file.
+func (c *NomadOrchestrator) IsCNSCSIFSSEnabled(ctx context.Context, featureName string) bool {
+	return c.IsFSSEnabled(ctx, featureName)
+}
+
+// IsPVCSIFSSEnabled is not applicable on Nomad, which only runs Vanilla
+// clusters, and returns the feature state of the feature states file.
+func (c *NomadOrchestrator) IsPVCSIFSSEnabled(ctx context.Context, featureName string) bool {
+	return c.IsFSSEnabled(ctx, featureName)
+}
+
+// EnableFSS is not supported on Nomad. Feature states are changed in the
+// feature states file.
+func (c *NomadOrchestrator) EnableFSS(ctx context.Context, featureName string) error {
+	log := logger.GetLogger(ctx)
+	return logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "EnableFSS")
+}
+
+// DisableFSS is not supported on Nomad. Feature states are changed in the
+// feature states file.
+func (c *NomadOrchestrator) DisableFSS(ctx context.Context, featureName string) error {
+	log := logger.GetLogger(ctx)
+	return logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "DisableFSS")
+}
+
+// IsFakeAttachAllowed returns false, as volumes are never fake attached on
+// Nomad.
+func (c *NomadOrchestrator) IsFakeAttachAllowed(ctx context.Context, volumeID string,
+	volumeManager cnsvolume.Manager) (bool, error) {
+	return false, nil
+}
+
+// MarkFakeAttached is not supported on Nomad.
+func (c *NomadOrchestrator) MarkFakeAttached(ctx context.Context, volumeID string) error {
+	log := logger.GetLogger(ctx)
+	return logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "MarkFakeAttached")
+}
+
+// ClearFakeAttached does nothing, as volumes are never fake attached on Nomad.
+func (c *NomadOrchestrator) ClearFakeAttached(ctx context.Context, volumeID string) error {
+	return nil
+}
+
+// GetFakeAttachedVolumes returns false for all the given volumes, as volumes
+// are never fake attached on Nomad.
+func (c *NomadOrchestrator) GetFakeAttachedVolumes(ctx context.Context, volumeIDs []string) map[string]bool {
+	fakeAttachedVolumes := make(map[string]bool)
+	for _, volumeID := range volumeIDs {
+		fakeAttachedVolumes[volumeID] = false
+	}
+	return fakeAttachedVolumes
+}
+
+// listVolumes returns the Nomad CSI volumes of the plugin keyed by their
+// volume ID in CNS.
+func (c *NomadOrchestrator) listVolumes(ctx context.Context) (map[string]*nomadCSIVolumeListStub, error) {
+	volumes, err := c.client.listCSIVolumes(ctx, c.pluginID)
+	if err != nil {
+		return nil, err
+	}
+	volumeIDToVolume := make(map[string]*nomadCSIVolumeListStub)
+	for _, volume := range volumes {
+		if volume.ExternalID != "" {
+			volumeIDToVolume[volume.ExternalID] = volume
+		}
+	}
+	return volumeIDToVolume, nil
+}
+
+// GetNodesForVolumes returns a map of the given volume IDs to the names of
+// the nodes of the allocations claiming them.
+func (c *NomadOrchestrator) GetNodesForVolumes(ctx context.Context, volumeIDs []string) map[string][]string {
+	log := logger.GetLogger(ctx)
+	volumeIDToNodeNames := make(map[string][]string)
+	volumeIDToVolume, err := c.listVolumes(ctx)
+	if err != nil {
+		log.Errorf("failed to list Nomad CSI volumes of plugin %q. Error: %v", c.pluginID, err)
+		return volumeIDToNodeNames
+	}
+	for _, volumeID := range volumeIDs {
+		stub, found := volumeIDToVolume[volumeID]
+		if !found {
+			continue
+		}
+		volume, err := c.client.getCSIVolume(ctx, stub.Namespace, stub.ID)
+		if err != nil {
+			log.Errorf("failed to get Nomad CSI volume %q in namespace %q. Error: %v",
+				stub.ID, stub.Namespace, err)
+			continue
+		}
+		seen := make(map[string]struct{})
+		for _, alloc := range volume.Allocations {
+			if _, exists := seen[alloc.NodeName]; exists || alloc.NodeName == "" {
+				continue
+			}
+			seen[alloc.NodeName] = struct{}{}
+			volumeIDToNodeNames[volumeID] = append(volumeIDToNodeNames[volumeID], alloc.NodeName)
+		}
+	}
+	return volumeIDToNodeNames
+}
+
+// ListCSINodes returns a map of the node IDs reported by the node plugins,
+// i.e. the node VM UUIDs, to the names of their Nomad nodes.
+func (c *NomadOrchestrator) ListCSINodes(ctx context.Context) (map[string]string, error) {
+	plugin, err := c.client.getCSIPlugin(ctx, c.pluginID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Nomad CSI plugin %q. Error: %v", c.pluginID, err)
+	}
+	nodes, err := c.client.listNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Nomad nodes. Error: %v", err)
+	}
+	nodeNames := make(map[string]string)
+	for _, node := range nodes {
+		nodeNames[node.ID] = node.Name
+	}
+	nodeIDToName := make(map[string]string)
+	for nomadNodeID, info := range plugin.Nodes {
+		nodeName, found := nodeNames[nomadNodeID]
+		if !found || info == nil || info.NodeInfo == nil || info.NodeInfo.ID == "" {
+			continue
+		}
+		nodeIDToName[info.NodeInfo.ID] = nodeName
+	}
+	return nodeIDToName, nil
+}
+
+// GetNodeIDtoNameMap returns a map of the node IDs reported by the node
+// plugins to the names of their Nomad nodes.
+func (c *NomadOrchestrator) GetNodeIDtoNameMap(ctx context.Context) map[string]string {
+	log := logger.GetLogger(ctx)
+	nodeIDToName, err := c.ListCSINodes(ctx)
+	if err != nil {
+		log.Error(err)
+		return make(map[string]string)
+	}
+	return nodeIDToName
+}
+
+// GetVolumeAttachment is not supported on Nomad.
+func (c *NomadOrchestrator) GetVolumeAttachment(ctx context.Context, volumeId string, nodeName string) (
+	*storagev1.VolumeAttachment, error) {
+	log := logger.GetLogger(ctx)
+	return nil, logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "GetVolumeAttachment")
+}
+
+// GetAllVolumes returns the volume IDs of the Nomad CSI volumes of the plugin.
+func (c *NomadOrchestrator) GetAllVolumes() []string {
+	ctx, log := logger.GetNewContextWithLogger()
+	volumeIDToVolume, err := c.listVolumes(ctx)
+	c.volumeIDsLock.Lock()
+	defer c.volumeIDsLock.Unlock()
+	if err != nil {
+		log.Errorf("failed to list Nomad CSI volumes of plugin %q. Returning the volumes listed last. Error: %v",
+			c.pluginID, err)
+		return append([]string{}, c.volumeIDs...)
+	}
+	volumeIDs := make([]string, 0, len(volumeIDToVolume))
+	for volumeID := range volumeIDToVolume {
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	c.volumeIDs = volumeIDs
+	return append([]string{}, volumeIDs...)
+}
+
+// GetAllK8sVolumes returns the volume IDs of the Nomad CSI volumes of the
+// plugin.
+func (c *NomadOrchestrator) GetAllK8sVolumes() []string {
+	return c.GetAllVolumes()
+}
+
+// AnnotateVolumeSnapshot is not supported on Nomad.
+func (c *NomadOrchestrator) AnnotateVolumeSnapshot(ctx context.Context, volumeSnapshotName string,
+	volumeSnapshotNamespace string, annotations map[string]string) (bool, error) {
+	log := logger.GetLogger(ctx)
+	return false, logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "AnnotateVolumeSnapshot")
+}
+
+// getVariablePath returns the path of the Nomad variable storing the
+// ConfigMap with the given name and namespace.
+func getVariablePath(name string, namespace string) string {
+	return variablePathPrefix + "/" + namespace + "/" + name
+}
+
+// GetConfigMap returns the items of the Nomad variable storing the ConfigMap
+// with the given name in the given namespace, or an error if it does not
+// exist.
+func (c *NomadOrchestrator) GetConfigMap(ctx context.Context, name string, namespace string) (
+	map[string]string, error) {
+	log := logger.GetLogger(ctx)
+	variable, err := c.client.getVariable(ctx, getVariablePath(name, namespace))
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("ConfigMap with name %s already exists in namespace %s", name, namespace)
+	return variable.Items, nil
+}
+
+// CreateConfigMap creates a Nomad variable storing the ConfigMap with the
+// given name, namespace and data. The variable is only created if it does not
+// exist, and Nomad variables cannot be made immutable.
+func (c *NomadOrchestrator) CreateConfigMap(ctx context.Context, name string, namespace string,
+	data map[string]string, isImmutable bool) error {
+	log := logger.GetLogger(ctx)
+	err := c.client.createVariable(ctx, getVariablePath(name, namespace), data)
+	if err != nil {
+		return logger.LogNewErrorf(log, "Error occurred while creating the ConfigMap %s in namespace %s, Err: %v",
+			name, namespace, err)
+	}
+	return nil
+}
+
+// GetCSINodeTopologyInstancesList returns nil, as there are no
+// CSINodeTopology instances on Nomad.
+func (c *NomadOrchestrator) GetCSINodeTopologyInstancesList() []interface{} {
+	return nil
+}
+
+// GetCSINodeTopologyInstanceByName returns no instance, as there are no
+// CSINodeTopology instances on Nomad.
+func (c *NomadOrchestrator) GetCSINodeTopologyInstanceByName(nodeName string) (
+	item interface{}, exists bool, err error) {
+	return nil, false, nil
+}
+
+// GetPVNameFromCSIVolumeID returns no name, as there are no PVs on Nomad.
+func (c *NomadOrchestrator) GetPVNameFromCSIVolumeID(volumeID string) (string, bool) {
+	return "", false
+}
+
+// GetPVCNameFromCSIVolumeID returns no name, as there are no PVCs on Nomad.
+func (c *NomadOrchestrator) GetPVCNameFromCSIVolumeID(volumeID string) (string, string, bool) {
+	return "", "", false
+}
+
+// GetVolumeIDFromPVCName returns no volume ID, as there are no PVCs on Nomad.
+func (c *NomadOrchestrator) GetVolumeIDFromPVCName(namespace string, pvcName string) (string, bool) {
+	return "", false
+}
+
+// InitializeCSINodes does nothing, as there are no CSINodes on Nomad.
+func (c *NomadOrchestrator) InitializeCSINodes(ctx context.Context) error {
+	return nil
+}
+
+// StartZonesInformer is not supported on Nomad.
+func (c *NomadOrchestrator) StartZonesInformer(ctx context.Context, restClientConfig *restclient.Config,
+	namespace string) error {
+	log := logger.GetLogger(ctx)
+	return logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "StartZonesInformer")
+}
+
+// GetZonesForNamespace returns nil, as there are no zones on Nomad.
+func (c *NomadOrchestrator) GetZonesForNamespace(ns string) map[string]struct{} {
+	return nil
+}
+
+// PreLinkedCloneCreateAction is not supported on Nomad.
+func (c *NomadOrchestrator) PreLinkedCloneCreateAction(ctx context.Context, pvcNamespace string,
+	pvcName string) error {
+	log := logger.GetLogger(ctx)
+	return logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "PreLinkedCloneCreateAction")
+}
+
+// GetLinkedCloneVolumeSnapshotSourceUUID is not supported on Nomad.
+func (c *NomadOrchestrator) GetLinkedCloneVolumeSnapshotSourceUUID(ctx context.Context, pvcName string,
+	pvcNamespace string) (string, error) {
+	log := logger.GetLogger(ctx)
+	return "", logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad,
+		"GetLinkedCloneVolumeSnapshotSourceUUID")
+}
+
+// GetVolumeSnapshotPVCSource is not supported on Nomad.
+func (c *NomadOrchestrator) GetVolumeSnapshotPVCSource(ctx context.Context, volumeSnapshotNamespace string,
+	volumeSnapshotName string) (*v1.PersistentVolumeClaim, error) {
+	log := logger.GetLogger(ctx)
+	return nil, logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad,
+		"GetVolumeSnapshotPVCSource")
+}
+
+// IsLinkedCloneRequest returns false, as there are no linked clones on Nomad.
+func (c *NomadOrchestrator) IsLinkedCloneRequest(ctx context.Context, pvcName string,
+	pvcNamespace string) (bool, error) {
+	return false, nil
+}
+
+// UpdatePersistentVolumeLabel is not supported on Nomad.
+func (c *NomadOrchestrator) UpdatePersistentVolumeLabel(ctx context.Context, pvName string,
+	key string, value string) error {
+	log := logger.GetLogger(ctx)
+	return logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "UpdatePersistentVolumeLabel")
+}
+
+// UpdateNodeAnnotation is not supported on Nomad.
+func (c *NomadOrchestrator) UpdateNodeAnnotation(ctx context.Context, nodeName string,
+	key string, value string) error {
+	log := logger.GetLogger(ctx)
+	return logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "UpdateNodeAnnotation")
+}
+
+// RecordNodeEvent is not supported on Nomad.
+func (c *NomadOrchestrator) RecordNodeEvent(ctx context.Context, nodeName string,
+	eventType string, reason string, message string) error {
+	log := logger.GetLogger(ctx)
+	return logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "RecordNodeEvent")
+}
+
+// GetActiveClustersForNamespaceInRequestedZones is not supported on Nomad.
+func (c *NomadOrchestrator) GetActiveClustersForNamespaceInRequestedZones(ctx context.Context,
+	ns string, zones []string) ([]string, error) {
+	log := logger.GetLogger(ctx)
+	return nil, logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad,
+		"GetActiveClustersForNamespaceInRequestedZones")
+}
+
+// GetPvcObjectByName is not supported on Nomad.
+func (c *NomadOrchestrator) GetPvcObjectByName(ctx context.Context, pvcName string,
+	namespace string) (*v1.PersistentVolumeClaim, error) {
+	log := logger.GetLogger(ctx)
+	return nil, logger.LogNewErrorCodef(log, codes.Unimplemented, notSupportedOnNomad, "GetPvcObjectByName")
+}
+
+// HandleLateEnablementOfCapability does nothing, as there are no capabilities
+// on Nomad.
+func (c *NomadOrchestrator) HandleLateEnablementOfCapability(ctx context.Context,
+	clusterFlavor cnstypes.CnsClusterFlavor, capability, gcPort, gcEndpoint string) {
+}
+
+// GetPVCNamespacedNameByUID returns no name, as there are no PVCs on Nomad.
+func (c *NomadOrchestrator) GetPVCNamespacedNameByUID(uid string) (k8stypes.NamespacedName, bool) {
+	return k8stypes.NamespacedName{}, false
+}
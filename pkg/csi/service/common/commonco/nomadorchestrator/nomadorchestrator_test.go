@@ -0,0 +1,322 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomadorchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+)
+
+const (
+	testPluginID = "vsphere-csi"
+	testToken    = "test-token"
+)
+
+// fakeNomad is a fake Nomad HTTP API serving the nodes, the CSI plugin, the
+// CSI volumes and the variables it holds.
+type fakeNomad struct {
+	lock      sync.Mutex
+	nodes     []*nomadNode
+	plugin    *nomadCSIPlugin
+	volumes   []*nomadCSIVolume
+	variables map[string]*nomadVariable
+}
+
+func newFakeNomad() *fakeNomad {
+	return &fakeNomad{
+		nodes: []*nomadNode{
+			{ID: "node-id-1", Name: "nomad-node-1", Status: "ready",
+				Meta: map[string]string{"topology.csi.vmware.com/k8s-zone": "zone-a", "rack": "r1"}},
+			{ID: "node-id-2", Name: "nomad-node-2", Status: "ready",
+				Meta: map[string]string{"topology.csi.vmware.com/k8s-zone": "zone-a"}},
+			{ID: "node-id-3", Name: "nomad-node-3", Status: "ready",
+				Meta: map[string]string{"topology.csi.vmware.com/k8s-zone": "zone-b"}},
+		},
+		plugin: &nomadCSIPlugin{
+			ID: testPluginID,
+			Nodes: map[string]*nomadCSIInfo{
+				"node-id-1": {PluginID: testPluginID, Healthy: true, NodeInfo: &nomadCSINodeInfo{ID: "vm-uuid-1",
+					AccessibleTopology: &nomadCSITopology{
+						Segments: map[string]string{"topology.csi.vmware.com/k8s-zone": "zone-a"}}}},
+				"node-id-2": {PluginID: testPluginID, Healthy: true, NodeInfo: &nomadCSINodeInfo{ID: "vm-uuid-2",
+					AccessibleTopology: &nomadCSITopology{
+						Segments: map[string]string{"topology.csi.vmware.com/k8s-zone": "zone-a"}}}},
+				"node-id-3": {PluginID: testPluginID, Healthy: true, NodeInfo: &nomadCSINodeInfo{ID: "vm-uuid-3",
+					AccessibleTopology: &nomadCSITopology{
+						Segments: map[string]string{"topology.csi.vmware.com/k8s-zone": "zone-b"}}}},
+			},
+		},
+		volumes: []*nomadCSIVolume{
+			{ID: "data", Namespace: "default", ExternalID: "fcd-1", PluginID: testPluginID,
+				Allocations: []*nomadAllocListStub{
+					{ID: "alloc-1", NodeID: "node-id-1", NodeName: "nomad-node-1"},
+					{ID: "alloc-2", NodeID: "node-id-1", NodeName: "nomad-node-1"},
+					{ID: "alloc-3", NodeID: "node-id-2", NodeName: "nomad-node-2"},
+				}},
+			{ID: "logs", Namespace: "apps", ExternalID: "fcd-2", PluginID: testPluginID},
+			{ID: "other", Namespace: "default", ExternalID: "other-1", PluginID: "other-plugin"},
+		},
+		variables: make(map[string]*nomadVariable),
+	}
+}
+
+func (f *fakeNomad) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if r.Header.Get(nomadTokenHeader) != testToken {
+		http.Error(w, "Permission denied", http.StatusForbidden)
+		return
+	}
+	query := r.URL.Query()
+	switch {
+	case r.URL.Path == "/v1/nodes":
+		var stubs []*nomadNodeListStub
+		for _, node := range f.nodes {
+			stubs = append(stubs, &nomadNodeListStub{ID: node.ID, Name: node.Name, Status: node.Status})
+		}
+		writeJSON(w, stubs)
+	case strings.HasPrefix(r.URL.Path, "/v1/node/"):
+		for _, node := range f.nodes {
+			if node.ID == strings.TrimPrefix(r.URL.Path, "/v1/node/") {
+				writeJSON(w, node)
+				return
+			}
+		}
+		http.Error(w, "node not found", http.StatusNotFound)
+	case r.URL.Path == "/v1/plugin/csi/"+testPluginID:
+		writeJSON(w, f.plugin)
+	case r.URL.Path == "/v1/volumes":
+		var stubs []*nomadCSIVolumeListStub
+		for _, volume := range f.volumes {
+			if volume.PluginID == query.Get("plugin_id") && query.Get("namespace") == "*" {
+				stubs = append(stubs, &nomadCSIVolumeListStub{ID: volume.ID, Namespace: volume.Namespace,
+					ExternalID: volume.ExternalID, PluginID: volume.PluginID})
+			}
+		}
+		writeJSON(w, stubs)
+	case strings.HasPrefix(r.URL.Path, "/v1/volume/csi/"):
+		for _, volume := range f.volumes {
+			if volume.ID == strings.TrimPrefix(r.URL.Path, "/v1/volume/csi/") &&
+				volume.Namespace == query.Get("namespace") {
+				writeJSON(w, volume)
+				return
+			}
+		}
+		http.Error(w, "volume not found", http.StatusNotFound)
+	case strings.HasPrefix(r.URL.Path, "/v1/var/"):
+		key := query.Get("namespace") + "/" + strings.TrimPrefix(r.URL.Path, "/v1/var/")
+		variable, exists := f.variables[key]
+		switch r.Method {
+		case http.MethodGet:
+			if !exists {
+				http.Error(w, "variable not found", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, variable)
+		case http.MethodPut:
+			if exists && query.Get("cas") == "0" {
+				w.WriteHeader(http.StatusConflict)
+				writeJSON(w, variable)
+				return
+			}
+			variable = &nomadVariable{}
+			if err := json.NewDecoder(r.Body).Decode(variable); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			f.variables[key] = variable
+			writeJSON(w, variable)
+		case http.MethodDelete:
+			delete(f.variables, key)
+		}
+	case r.URL.Path == "/v1/vars":
+		var variables []*nomadVariableMetadata
+		for _, variable := range f.variables {
+			if variable.Namespace == query.Get("namespace") &&
+				strings.HasPrefix(variable.Path, query.Get("prefix")) {
+				variables = append(variables, &nomadVariableMetadata{Namespace: variable.Namespace,
+					Path: variable.Path})
+			}
+		}
+		writeJSON(w, variables)
+	default:
+		http.Error(w, "unknown path", http.StatusNotFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newTestOrchestrator returns a NomadOrchestrator using the fake Nomad HTTP
+// API and the feature states file at the given path.
+func newTestOrchestrator(t *testing.T, fake *fakeNomad, featureStatesFile string) *NomadOrchestrator {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client, err := newNomadClient(NomadInitParams{Address: server.URL, Token: testToken, Namespace: "csi"})
+	assert.NoError(t, err)
+	return &NomadOrchestrator{
+		client:             client,
+		pluginID:           testPluginID,
+		releasedVanillaFSS: common.GetReleasedVanillaFSS(),
+		featureStatesFile:  featureStatesFile,
+		featureStatesLock:  &sync.RWMutex{},
+		featureStates:      make(map[string]string),
+		volumeIDsLock:      &sync.Mutex{},
+	}
+}
+
+func TestGetNomadInitParams(t *testing.T) {
+	t.Setenv(EnvNomadAddress, "")
+	t.Setenv(EnvNomadToken, "secret-token")
+	t.Setenv(EnvNomadSkipVerify, "true")
+	params := GetNomadInitParams("controller")
+	assert.Equal(t, DefaultNomadAddress, params.Address)
+	assert.Equal(t, DefaultNomadNamespace, params.Namespace)
+	assert.Equal(t, "csi.vsphere.vmware.com", params.PluginID)
+	assert.Equal(t, DefaultFeatureStatesFile, params.FeatureStatesFile)
+	assert.True(t, params.SkipVerify)
+	assert.NotContains(t, params.String(), "secret-token")
+}
+
+func TestNomadIsFSSEnabled(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "feature-states.yaml")
+	c := newTestOrchestrator(t, newFakeNomad(), path)
+
+	// Only the released features are enabled without a feature states file.
+	c.reloadFeatureStates(ctx)
+	assert.True(t, c.IsFSSEnabled(ctx, common.ListVolumes))
+	assert.False(t, c.IsFSSEnabled(ctx, common.CSITransactionSupport))
+
+	err := os.WriteFile(path, []byte("csi-transaction-support: true\nfile-volume-with-vm-service: \"false\"\n"+
+		"invalid-state: maybe\n"), 0600)
+	assert.NoError(t, err)
+	c.watchFeatureStates(ctx)
+	c.reloadFeatureStates(ctx)
+	assert.True(t, c.IsFSSEnabled(ctx, common.CSITransactionSupport))
+	assert.False(t, c.IsFSSEnabled(ctx, "file-volume-with-vm-service"))
+	assert.False(t, c.IsFSSEnabled(ctx, "invalid-state"))
+
+	// The file is read again when it changes.
+	err = os.WriteFile(path, []byte("csi-transaction-support: false\n"), 0600)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return !c.IsFSSEnabled(ctx, common.CSITransactionSupport)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The feature states are kept while the file is invalid.
+	err = os.WriteFile(path, []byte("csi-transaction-support: [true\n"), 0600)
+	assert.NoError(t, err)
+	c.reloadFeatureStates(ctx)
+	assert.False(t, c.IsFSSEnabled(ctx, common.CSITransactionSupport))
+	assert.True(t, c.IsFSSEnabled(ctx, common.ListVolumes))
+}
+
+func TestNomadGetNodesForVolumes(t *testing.T) {
+	ctx := context.Background()
+	c := newTestOrchestrator(t, newFakeNomad(), "")
+
+	nodes := c.GetNodesForVolumes(ctx, []string{"fcd-1", "fcd-2", "fcd-unknown"})
+	assert.Equal(t, map[string][]string{"fcd-1": {"nomad-node-1", "nomad-node-2"}}, nodes)
+
+	volumeIDs := c.GetAllK8sVolumes()
+	sort.Strings(volumeIDs)
+	assert.Equal(t, []string{"fcd-1", "fcd-2"}, volumeIDs)
+}
+
+func TestNomadGetAllVolumesWhenNomadIsUnreachable(t *testing.T) {
+	fake := newFakeNomad()
+	c := newTestOrchestrator(t, fake, "")
+	assert.Len(t, c.GetAllVolumes(), 2)
+
+	// The volumes listed last are returned if Nomad cannot be reached.
+	c.client.token = "invalid-token"
+	assert.Len(t, c.GetAllVolumes(), 2)
+}
+
+func TestNomadListCSINodes(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeNomad()
+	// Nodes without a fingerprint of the node plugin are skipped.
+	fake.plugin.Nodes["node-id-4"] = &nomadCSIInfo{PluginID: testPluginID}
+	c := newTestOrchestrator(t, fake, "")
+
+	nodeIDToName, err := c.ListCSINodes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"vm-uuid-1": "nomad-node-1",
+		"vm-uuid-2": "nomad-node-2",
+		"vm-uuid-3": "nomad-node-3",
+	}, nodeIDToName)
+	assert.Equal(t, nodeIDToName, c.GetNodeIDtoNameMap(ctx))
+
+	c.client.token = "invalid-token"
+	_, err = c.ListCSINodes(ctx)
+	assert.Error(t, err)
+	assert.Empty(t, c.GetNodeIDtoNameMap(ctx))
+}
+
+func TestNomadConfigMap(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeNomad()
+	c := newTestOrchestrator(t, fake, "")
+
+	_, err := c.GetConfigMap(ctx, "vsphere-csi-cluster-id", "vmware-system-csi")
+	assert.True(t, isNotFound(err))
+
+	data := map[string]string{"clusterID": "cluster-1"}
+	assert.NoError(t, c.CreateConfigMap(ctx, "vsphere-csi-cluster-id", "vmware-system-csi", data, true))
+	assert.Contains(t, fake.variables, "csi/vsphere-csi/vmware-system-csi/vsphere-csi-cluster-id")
+	cmData, err := c.GetConfigMap(ctx, "vsphere-csi-cluster-id", "vmware-system-csi")
+	assert.NoError(t, err)
+	assert.Equal(t, data, cmData)
+
+	// An existing ConfigMap is not overwritten.
+	err = c.CreateConfigMap(ctx, "vsphere-csi-cluster-id", "vmware-system-csi",
+		map[string]string{"clusterID": "cluster-2"}, true)
+	assert.Error(t, err)
+	cmData, err = c.GetConfigMap(ctx, "vsphere-csi-cluster-id", "vmware-system-csi")
+	assert.NoError(t, err)
+	assert.Equal(t, data, cmData)
+}
+
+func TestNewNomadClient(t *testing.T) {
+	_, err := newNomadClient(NomadInitParams{Address: "ftp://nomad.example.com"})
+	assert.Error(t, err)
+	_, err = newNomadClient(NomadInitParams{Address: "https://nomad.example.com:4646",
+		CACert: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+
+	client, err := newNomadClient(NomadInitParams{Address: "unix:///secrets/api.sock"})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost", client.address.String())
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomadorchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	// operationRequestPathPrefix is the prefix of the paths of the Nomad
+	// variables storing the details of the volume operations.
+	operationRequestPathPrefix = "vsphere-csi-volume-operation-requests/"
+	// operationRequestNameItem is the item of the variable holding the name
+	// of the operation.
+	operationRequestNameItem = "name"
+	// operationRequestDataItem is the item of the variable holding the
+	// details of the operation.
+	operationRequestDataItem = "details"
+)
+
+// getOperationRequestPath returns the path of the Nomad variable storing the
+// details of the operation with the given name. The name is hashed, as
+// operation names may contain characters or exceed lengths that are not
+// allowed in variable paths.
+func getOperationRequestPath(name string) string {
+	hash := sha256.Sum256([]byte(name))
+	return operationRequestPathPrefix + hex.EncodeToString(hash[:])
+}
+
+// GetVolumeOperationRequest returns the details of the volume operation with
+// the given name stored in a Nomad variable.
+func (c *NomadOrchestrator) GetVolumeOperationRequest(ctx context.Context, name string) (string, bool, error) {
+	variable, err := c.client.getVariable(ctx, getOperationRequestPath(name))
+	if isNotFound(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return variable.Items[operationRequestDataItem], true, nil
+}
+
+// ListVolumeOperationRequests returns the details of the volume operations
+// stored in Nomad variables, keyed by operation name.
+func (c *NomadOrchestrator) ListVolumeOperationRequests(ctx context.Context) (map[string]string, error) {
+	variables, err := c.client.listVariables(ctx, operationRequestPathPrefix)
+	if err != nil {
+		return nil, err
+	}
+	requests := make(map[string]string)
+	for _, metadata := range variables {
+		variable, err := c.client.getVariable(ctx, metadata.Path)
+		if isNotFound(err) {
+			// The variable was deleted since it was listed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		requests[variable.Items[operationRequestNameItem]] = variable.Items[operationRequestDataItem]
+	}
+	return requests, nil
+}
+
+// StoreVolumeOperationRequest stores the details of the volume operation with
+// the given name in a Nomad variable.
+func (c *NomadOrchestrator) StoreVolumeOperationRequest(ctx context.Context, name string, data string) error {
+	return c.client.putVariable(ctx, getOperationRequestPath(name), map[string]string{
+		operationRequestNameItem: name,
+		operationRequestDataItem: data,
+	})
+}
+
+// DeleteVolumeOperationRequest deletes the Nomad variable storing the details
+// of the volume operation with the given name, if any.
+func (c *NomadOrchestrator) DeleteVolumeOperationRequest(ctx context.Context, name string) error {
+	return c.client.deleteVariable(ctx, getOperationRequestPath(name))
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomadorchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest"
+)
+
+var _ cnsvolumeoperationrequest.VolumeOperationRequestPersister = &NomadOrchestrator{}
+
+func TestNomadVolumeOperationRequests(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeNomad()
+	c := newTestOrchestrator(t, fake, "")
+
+	_, found, err := c.GetVolumeOperationRequest(ctx, "pvc-1")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	// Operation names are not restricted to the characters of variable paths.
+	names := []string{"pvc-1", "snapshot+volume/1.2"}
+	for _, name := range names {
+		assert.NoError(t, c.StoreVolumeOperationRequest(ctx, name, `{"Name":"`+name+`"}`))
+	}
+	assert.NoError(t, c.StoreVolumeOperationRequest(ctx, "pvc-1", `{"Name":"pvc-1","VolumeID":"fcd-1"}`))
+	data, found, err := c.GetVolumeOperationRequest(ctx, "pvc-1")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, `{"Name":"pvc-1","VolumeID":"fcd-1"}`, data)
+	assert.Contains(t, fake.variables, "csi/"+getOperationRequestPath("pvc-1"))
+
+	// The ConfigMaps of the driver are not listed as operations.
+	assert.NoError(t, c.CreateConfigMap(ctx, "vsphere-csi-cluster-id", "vmware-system-csi",
+		map[string]string{"clusterID": "cluster-1"}, true))
+	requests, err := c.ListVolumeOperationRequests(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"pvc-1":               `{"Name":"pvc-1","VolumeID":"fcd-1"}`,
+		"snapshot+volume/1.2": `{"Name":"snapshot+volume/1.2"}`,
+	}, requests)
+
+	assert.NoError(t, c.DeleteVolumeOperationRequest(ctx, "pvc-1"))
+	_, found, err = c.GetVolumeOperationRequest(ctx, "pvc-1")
+	assert.NoError(t, err)
+	assert.False(t, found)
+	// Deleting a missing operation is not an error.
+	assert.NoError(t, c.DeleteVolumeOperationRequest(ctx, "pvc-1"))
+}
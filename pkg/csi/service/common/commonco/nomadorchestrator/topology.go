@@ -0,0 +1,387 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomadorchestrator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	vimtypes "github.com/vmware/govmomi/vim25/types"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	commoncotypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/types"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// TopologyMetaPrefix is the prefix of the keys of the Nomad node metadata
+// used as topology labels of the node, e.g.
+// "topology.csi.vmware.com/k8s-zone".
+const TopologyMetaPrefix = common.TopologyLabelsDomain + "/"
+
+// nodeVolumeTopology implements the commoncotypes.NodeTopologyService
+// interface with the metadata of the Nomad nodes.
+type nodeVolumeTopology struct {
+	client *nomadClient
+}
+
+// controllerVolumeTopology implements the
+// commoncotypes.ControllerTopologyService interface with the topology
+// reported by the node plugins to Nomad.
+type controllerVolumeTopology struct {
+	client   *nomadClient
+	pluginID string
+	nodeMgr  node.Manager
+}
+
+// nomadNodeTopology is the topology reported by the node plugin of a node.
+type nomadNodeTopology struct {
+	nodeName string
+	nodeUUID string
+	labels   map[string]string
+}
+
+// InitTopologyServiceInNode returns an implementation of the
+// commoncotypes.NodeTopologyService interface reading the topology labels of
+// the nodes from their Nomad metadata.
+func (c *NomadOrchestrator) InitTopologyServiceInNode(ctx context.Context) (
+	commoncotypes.NodeTopologyService, error) {
+	return &nodeVolumeTopology{client: c.client}, nil
+}
+
+// GetNodeTopologyLabels returns the metadata of the Nomad node with the name
+// of the NodeInfo whose key starts with TopologyMetaPrefix.
+func (volTopology *nodeVolumeTopology) GetNodeTopologyLabels(ctx context.Context,
+	nodeInfo *commoncotypes.NodeInfo) (map[string]string, error) {
+	log := logger.GetLogger(ctx)
+	nodes, err := volTopology.client.listNodes(ctx)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to list Nomad nodes. Error: %v", err)
+	}
+	var nodeID string
+	for _, nomadNode := range nodes {
+		if nomadNode.Name == nodeInfo.NodeName {
+			nodeID = nomadNode.ID
+			break
+		}
+	}
+	if nodeID == "" {
+		return nil, logger.LogNewErrorf(log, "failed to find a Nomad node with name %q", nodeInfo.NodeName)
+	}
+	nomadNode, err := volTopology.client.getNode(ctx, nodeID)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to get Nomad node %q. Error: %v", nodeID, err)
+	}
+	topologyLabels := make(map[string]string)
+	for key, value := range nomadNode.Meta {
+		if strings.HasPrefix(key, TopologyMetaPrefix) {
+			topologyLabels[key] = value
+		}
+	}
+	log.Infof("Topology labels of Nomad node %q: %+v", nodeInfo.NodeName, topologyLabels)
+	return topologyLabels, nil
+}
+
+// InitTopologyServiceInController returns an implementation of the
+// commoncotypes.ControllerTopologyService interface using the topology
+// reported by the node plugins to Nomad.
+func (c *NomadOrchestrator) InitTopologyServiceInController(ctx context.Context) (
+	commoncotypes.ControllerTopologyService, error) {
+	return &controllerVolumeTopology{
+		client:   c.client,
+		pluginID: c.pluginID,
+		// Node manager should already have been initialized in controller init.
+		nodeMgr: node.GetManager(ctx),
+	}, nil
+}
+
+// getNodeTopologies returns the topology reported by the node plugins.
+func (volTopology *controllerVolumeTopology) getNodeTopologies(ctx context.Context) (
+	[]*nomadNodeTopology, error) {
+	log := logger.GetLogger(ctx)
+	plugin, err := volTopology.client.getCSIPlugin(ctx, volTopology.pluginID)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to get Nomad CSI plugin %q. Error: %v",
+			volTopology.pluginID, err)
+	}
+	nodes, err := volTopology.client.listNodes(ctx)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to list Nomad nodes. Error: %v", err)
+	}
+	nodeNames := make(map[string]string)
+	for _, nomadNode := range nodes {
+		nodeNames[nomadNode.ID] = nomadNode.Name
+	}
+	var nodeTopologies []*nomadNodeTopology
+	for nomadNodeID, info := range plugin.Nodes {
+		nodeName, found := nodeNames[nomadNodeID]
+		if !found || info == nil || info.NodeInfo == nil || info.NodeInfo.ID == "" {
+			continue
+		}
+		labels := make(map[string]string)
+		if info.NodeInfo.AccessibleTopology != nil {
+			for key, value := range info.NodeInfo.AccessibleTopology.Segments {
+				labels[key] = value
+			}
+		}
+		nodeTopologies = append(nodeTopologies, &nomadNodeTopology{
+			nodeName: nodeName,
+			nodeUUID: info.NodeInfo.ID,
+			labels:   labels,
+		})
+	}
+	return nodeTopologies, nil
+}
+
+// GetSharedDatastoresInTopology returns the datastores shared by the nodes of
+// the preferred topology segments, or of the requisite ones if there are
+// none, and compatible with the storage policy if given.
+func (volTopology *controllerVolumeTopology) GetSharedDatastoresInTopology(ctx context.Context,
+	reqParams interface{}) ([]*cnsvsphere.DatastoreInfo, error) {
+	log := logger.GetLogger(ctx)
+	params := reqParams.(commoncotypes.VanillaTopologyFetchDSParams)
+	log.Debugf("Get shared datastores with topologyRequirement: %+v", params.TopologyRequirement)
+	nodeTopologies, err := volTopology.getNodeTopologies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var sharedDatastores []*cnsvsphere.DatastoreInfo
+
+	// Fetch shared datastores for the preferred topology requirement.
+	if params.TopologyRequirement.GetPreferred() != nil {
+		log.Debugf("Using preferred topology")
+		sharedDatastores, err = volTopology.getSharedDatastoresInTopology(ctx,
+			params.TopologyRequirement.GetPreferred(), nodeTopologies, params)
+		if err != nil {
+			log.Errorf("Error finding shared datastores using preferred topology: %+v",
+				params.TopologyRequirement.GetPreferred())
+			return nil, err
+		}
+	}
+	// If there are no shared datastores for the preferred topology requirement, fetch shared
+	// datastores for the requisite topology requirement instead.
+	if len(sharedDatastores) == 0 && params.TopologyRequirement.GetRequisite() != nil {
+		log.Debugf("Using requisite topology")
+		sharedDatastores, err = volTopology.getSharedDatastoresInTopology(ctx,
+			params.TopologyRequirement.GetRequisite(), nodeTopologies, params)
+		if err != nil {
+			log.Errorf("Error finding shared datastores using requisite topology: %+v",
+				params.TopologyRequirement.GetRequisite())
+			return nil, err
+		}
+	}
+	return sharedDatastores, nil
+}
+
+// getSharedDatastoresInTopology returns a list of shared accessible datastores
+// for requested topology.
+func (volTopology *controllerVolumeTopology) getSharedDatastoresInTopology(ctx context.Context,
+	topologyArr []*csi.Topology, nodeTopologies []*nomadNodeTopology,
+	params commoncotypes.VanillaTopologyFetchDSParams) ([]*cnsvsphere.DatastoreInfo, error) {
+	log := logger.GetLogger(ctx)
+
+	var sharedDatastores []*cnsvsphere.DatastoreInfo
+	for _, topology := range topologyArr {
+		segments := topology.GetSegments()
+		var matchingNodeVMs []*cnsvsphere.VirtualMachine
+		for _, nodeTopology := range getNodesMatchingSegments(nodeTopologies, segments) {
+			nodeVM, err := volTopology.nodeMgr.GetNodeVMAndUpdateCache(ctx, nodeTopology.nodeUUID, nil)
+			if err != nil {
+				log.Errorf("failed to retrieve NodeVM %q of Nomad node %q. Error - %+v",
+					nodeTopology.nodeUUID, nodeTopology.nodeName, err)
+				return nil, err
+			}
+			matchingNodeVMs = append(matchingNodeVMs, nodeVM)
+		}
+		if len(matchingNodeVMs) == 0 {
+			log.Warnf("No nodes in the cluster matched the topology requirement provided: %+v",
+				segments)
+			continue
+		}
+
+		// Fetch shared datastores for the matching nodeVMs.
+		log.Infof("Obtained list of nodeVMs %+v", matchingNodeVMs)
+		sharedDatastoresInTopology, err := cnsvsphere.GetSharedDatastoresForVMs(ctx, matchingNodeVMs)
+		if err != nil {
+			log.Errorf("failed to get shared datastores for nodes: %+v in topology segment %+v. Error: %+v",
+				matchingNodeVMs, segments, err)
+			return nil, err
+		}
+
+		// If storage policy name is mentioned in the volume parameters, filter
+		// the datastores compatible with it.
+		if params.StoragePolicyName != "" {
+			sharedDatastoresInTopology, err = filterCompatibleDatastores(ctx, params,
+				sharedDatastoresInTopology)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Update sharedDatastores with the list of datastores received.
+		// Duplicates will not be added.
+		for _, ds := range sharedDatastoresInTopology {
+			var found bool
+			for _, sharedDS := range sharedDatastores {
+				if sharedDS.Info.Url == ds.Info.Url {
+					found = true
+					break
+				}
+			}
+			if !found {
+				sharedDatastores = append(sharedDatastores, ds)
+			}
+		}
+	}
+	log.Infof("Obtained shared datastores: %+v", sharedDatastores)
+	return sharedDatastores, nil
+}
+
+// getNodesMatchingSegments returns the nodes whose topology labels match all
+// the given segments.
+func getNodesMatchingSegments(nodeTopologies []*nomadNodeTopology,
+	segments map[string]string) []*nomadNodeTopology {
+	var matchingNodes []*nomadNodeTopology
+	for _, nodeTopology := range nodeTopologies {
+		isMatch := true
+		for key, value := range segments {
+			if nodeTopology.labels[key] != value {
+				isMatch = false
+				break
+			}
+		}
+		if isMatch {
+			matchingNodes = append(matchingNodes, nodeTopology)
+		}
+	}
+	return matchingNodes
+}
+
+// filterCompatibleDatastores returns the datastores compatible with the
+// storage policy of the params.
+func filterCompatibleDatastores(ctx context.Context, params commoncotypes.VanillaTopologyFetchDSParams,
+	datastores []*cnsvsphere.DatastoreInfo) ([]*cnsvsphere.DatastoreInfo, error) {
+	log := logger.GetLogger(ctx)
+	storagePolicyID, err := params.Vc.GetStoragePolicyIDByName(ctx, params.StoragePolicyName)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "Error occurred while getting Profile Id "+
+			"from Storage Profile Name: %s. Error: %+v", params.StoragePolicyName, err)
+	}
+	var dsMoRefs []vimtypes.ManagedObjectReference
+	for _, ds := range datastores {
+		dsMoRefs = append(dsMoRefs, ds.Reference())
+	}
+	compat, err := params.Vc.PbmCheckCompatibility(ctx, dsMoRefs, storagePolicyID)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to find datastore compatibility "+
+			"with storage policy ID %q. Error: %+v", storagePolicyID, err)
+	}
+	compatibleDsMoids := make(map[string]struct{})
+	for _, ds := range compat.CompatibleDatastores() {
+		compatibleDsMoids[ds.HubId] = struct{}{}
+	}
+	log.Infof("Datastores compatible with storage policy %q are %+v", params.StoragePolicyName,
+		compatibleDsMoids)
+	var compatibleDatastores []*cnsvsphere.DatastoreInfo
+	for _, ds := range datastores {
+		if _, exists := compatibleDsMoids[ds.Reference().Value]; exists {
+			compatibleDatastores = append(compatibleDatastores, ds)
+		}
+	}
+	if len(compatibleDatastores) == 0 {
+		return nil, logger.LogNewErrorf(log, "No compatible shared datastores found "+
+			"for storage policy %q", params.StoragePolicyName)
+	}
+	return compatibleDatastores, nil
+}
+
+// GetTopologyInfoFromNodes returns the topology segments of the given nodes
+// with access to the selected datastore, keeping only the segments whose
+// nodes all have access to it.
+func (volTopology *controllerVolumeTopology) GetTopologyInfoFromNodes(ctx context.Context,
+	reqParams interface{}) ([]map[string]string, error) {
+	log := logger.GetLogger(ctx)
+	params := reqParams.(commoncotypes.VanillaRetrieveTopologyInfoParams)
+	nodeTopologies, err := volTopology.getNodeTopologies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accessibleNodeNames := make(map[string]struct{})
+	for _, nodeName := range params.NodeNames {
+		accessibleNodeNames[nodeName] = struct{}{}
+	}
+
+	var topologySegments []map[string]string
+	for _, nodeTopology := range nodeTopologies {
+		if _, accessible := accessibleNodeNames[nodeTopology.nodeName]; !accessible {
+			continue
+		}
+		if len(nodeTopology.labels) == 0 {
+			log.Infof("Node %q does not belong to any topology domain. Skipping it for node "+
+				"affinity calculation", nodeTopology.nodeName)
+			continue
+		}
+		var alreadyExists bool
+		for _, segments := range topologySegments {
+			if reflect.DeepEqual(segments, nodeTopology.labels) {
+				alreadyExists = true
+				break
+			}
+		}
+		if !alreadyExists {
+			topologySegments = append(topologySegments, nodeTopology.labels)
+		}
+	}
+	log.Infof("Topology segments retrieved from nodes accessible to datastore %q are: %+v",
+		params.DatastoreURL, topologySegments)
+
+	// Filter out the segments in which some nodes do not have access to the
+	// selected datastore.
+	var accessibleTopology []map[string]string
+	for _, segments := range topologySegments {
+		isAccessible := true
+		for _, nodeTopology := range getNodesMatchingSegments(nodeTopologies, segments) {
+			if _, accessible := accessibleNodeNames[nodeTopology.nodeName]; !accessible {
+				log.Infof("Node %q in topology segment %+v does not have access to datastore %q",
+					nodeTopology.nodeName, segments, params.DatastoreURL)
+				isAccessible = false
+				break
+			}
+		}
+		if isAccessible {
+			accessibleTopology = append(accessibleTopology, segments)
+		}
+	}
+	log.Infof("Accessible topology calculated for datastore %q is %+v",
+		params.DatastoreURL, accessibleTopology)
+	return accessibleTopology, nil
+}
+
+// GetAZClustersMap returns an empty map, as there are no availability zones
+// on Nomad.
+func (volTopology *controllerVolumeTopology) GetAZClustersMap(ctx context.Context) map[string][]string {
+	return make(map[string][]string)
+}
+
+// ZonesWithMultipleClustersExist returns false, as there are no availability
+// zones on Nomad.
+func (volTopology *controllerVolumeTopology) ZonesWithMultipleClustersExist(ctx context.Context) bool {
+	return false
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomadorchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	commoncotypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/types"
+)
+
+func TestNomadGetNodeTopologyLabels(t *testing.T) {
+	ctx := context.Background()
+	c := newTestOrchestrator(t, newFakeNomad(), "")
+	topologyService, err := c.InitTopologyServiceInNode(ctx)
+	assert.NoError(t, err)
+
+	// Only the metadata with the topology prefix are topology labels.
+	labels, err := topologyService.GetNodeTopologyLabels(ctx,
+		&commoncotypes.NodeInfo{NodeName: "nomad-node-1", NodeID: "vm-uuid-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"topology.csi.vmware.com/k8s-zone": "zone-a"}, labels)
+
+	_, err = topologyService.GetNodeTopologyLabels(ctx,
+		&commoncotypes.NodeInfo{NodeName: "unknown-node", NodeID: "vm-uuid-4"})
+	assert.Error(t, err)
+}
+
+func TestNomadGetTopologyInfoFromNodes(t *testing.T) {
+	ctx := context.Background()
+	c := newTestOrchestrator(t, newFakeNomad(), "")
+	topologyService, err := c.InitTopologyServiceInController(ctx)
+	assert.NoError(t, err)
+
+	// All the nodes of zone-a and zone-b have access to the datastore.
+	topology, err := topologyService.GetTopologyInfoFromNodes(ctx, commoncotypes.VanillaRetrieveTopologyInfoParams{
+		NodeNames:    []string{"nomad-node-1", "nomad-node-2", "nomad-node-3"},
+		DatastoreURL: "ds:///vmfs/volumes/shared/",
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{"topology.csi.vmware.com/k8s-zone": "zone-a"},
+		{"topology.csi.vmware.com/k8s-zone": "zone-b"},
+	}, topology)
+
+	// zone-a is skipped as nomad-node-2 does not have access to the datastore.
+	topology, err = topologyService.GetTopologyInfoFromNodes(ctx, commoncotypes.VanillaRetrieveTopologyInfoParams{
+		NodeNames:    []string{"nomad-node-1", "nomad-node-3"},
+		DatastoreURL: "ds:///vmfs/volumes/local/",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"topology.csi.vmware.com/k8s-zone": "zone-b"}}, topology)
+}
+
+func TestNomadGetNodesMatchingSegments(t *testing.T) {
+	nodeTopologies := []*nomadNodeTopology{
+		{nodeName: "node-1", labels: map[string]string{"region": "r1", "zone": "z1"}},
+		{nodeName: "node-2", labels: map[string]string{"region": "r1", "zone": "z2"}},
+		{nodeName: "node-3", labels: map[string]string{}},
+	}
+	assert.Len(t, getNodesMatchingSegments(nodeTopologies, map[string]string{"region": "r1"}), 2)
+	matching := getNodesMatchingSegments(nodeTopologies, map[string]string{"region": "r1", "zone": "z2"})
+	assert.Len(t, matching, 1)
+	assert.Equal(t, "node-2", matching[0].nodeName)
+	assert.Empty(t, getNodesMatchingSegments(nodeTopologies, map[string]string{"zone": "z3"}))
+}
@@ -18,12 +18,16 @@ package commonco
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"strings"
 
 	cnstypes "github.com/vmware/govmomi/cns/types"
 
 	csiconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/k8sorchestrator"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/nomadorchestrator"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 )
 
@@ -33,6 +37,19 @@ func SetInitParams(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavor,
 	supervisorFSSName, supervisorFSSNamespace, internalFSSName, internalFSSNamespace, serviceMode,
 	operationMode string) {
 	log := logger.GetLogger(ctx)
+	orchestratorType, err := GetContainerOrchestratorType(ctx)
+	if err != nil {
+		log.Fatalf("%v. Container orchestrator init params not initialized.", err)
+	}
+	if orchestratorType == common.Nomad {
+		if clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+			log.Fatalf("Cluster flavor %q is not supported on Nomad. "+
+				"Container orchestrator init params not initialized.", clusterFlavor)
+		}
+		*initParams = nomadorchestrator.GetNomadInitParams(serviceMode)
+		log.Debugf("Container orchestrator init params: %+v", *initParams)
+		return
+	}
 	// Set default values for FSS, if not given and initiate CO-agnostic init
 	// params.
 	switch clusterFlavor {
@@ -105,3 +122,17 @@ func SetInitParams(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavor,
 	}
 	log.Debugf("Container orchestrator init params: %+v", *initParams)
 }
+
+// GetContainerOrchestratorType returns the container orchestrator set in the
+// CONTAINER_ORCHESTRATOR environment variable. Kubernetes is the default.
+func GetContainerOrchestratorType(ctx context.Context) (int, error) {
+	orchestrator := strings.TrimSpace(os.Getenv(csiconfig.EnvContainerOrchestrator))
+	switch strings.ToLower(orchestrator) {
+	case "", "kubernetes":
+		return common.Kubernetes, nil
+	case "nomad":
+		return common.Nomad, nil
+	default:
+		return 0, fmt.Errorf("unrecognised container orchestrator %q", orchestrator)
+	}
+}
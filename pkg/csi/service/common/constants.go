@@ -57,6 +57,122 @@ const (
 	// For Example: StoragePolicyId: "251bce41-cb24-41df-b46b-7c75aed3c4ee".
 	AttributeStoragePolicyID = "storagepolicyid"
 
+	// AttributeDiskProvisioningType represents the provisioning type of the
+	// backing disk of a block volume in the Storage Class.
+	// For Example: DiskProvisioningType: "eagerZeroedThick".
+	AttributeDiskProvisioningType = "diskprovisioningtype"
+
+	// AttributeIopsLimit represents the storage I/O limit of a block volume
+	// in the Storage Class. For Example: IopsLimit: "1000".
+	AttributeIopsLimit = "iopslimit"
+
+	// AttributeIopsShares represents the storage I/O shares of a block volume
+	// in the Storage Class. The value is either a share level (low, normal,
+	// high) or the number of shares. For Example: IopsShares: "high".
+	AttributeIopsShares = "iopsshares"
+
+	// AttributeLuksEncryption represents whether block volumes of the Storage
+	// Class are encrypted with LUKS on the node. The passphrase is read from
+	// the node stage secret of the Storage Class.
+	// For Example: LuksEncryption: "true".
+	AttributeLuksEncryption = "luksencryption"
+
+	// LuksPassphraseSecretKey is the key of the LUKS passphrase in the node
+	// stage secret of a Storage Class with LUKS encryption enabled.
+	LuksPassphraseSecretKey = "luksPassphrase"
+
+	// AttributeFileShareProtocol represents the protocol of the vSAN file
+	// shares of a file volume Storage Class, either NFS or SMB.
+	// For Example: Protocol: "SMB".
+	AttributeFileShareProtocol = "protocol"
+
+	// NfsFileShareProtocol is the protocol of NFS file shares. It is the
+	// default protocol of file volumes.
+	NfsFileShareProtocol = "NFS"
+
+	// SmbFileShareProtocol is the protocol of SMB file shares.
+	SmbFileShareProtocol = "SMB"
+
+	// AttributeMkfsOptions represents the mkfs options used by the node to
+	// create the filesystem of block volumes of the Storage Class. Only the
+	// options in the allow-list of the volume's fstype are accepted.
+	// For Example: MkfsOptions: "-i 16384 -E lazy_itable_init=0".
+	AttributeMkfsOptions = "mkfsoptions"
+
+	// AttributeFsckMode represents whether the node checks, or checks and
+	// repairs, the filesystem of block volumes of the Storage Class before
+	// mounting it. For Example: FsckMode: "repair".
+	AttributeFsckMode = "fsckmode"
+
+	// FsckModeCheck makes the node check the filesystem before mounting it,
+	// without modifying it.
+	FsckModeCheck = "check"
+
+	// AttributeNfsVersion represents the NFS version used by the nodes to
+	// mount file volumes of the Storage Class, either 4.1 or 3.
+	// For Example: NfsVersion: "3".
+	AttributeNfsVersion = "nfsversion"
+
+	// NfsVersion41 is the default NFS version of file volumes.
+	NfsVersion41 = "4.1"
+
+	// NfsVersion3 is the NFS version 3 of file volumes.
+	NfsVersion3 = "3"
+
+	// AttributeNfsSecurity represents the NFS security flavor of the vSAN
+	// file shares of file volumes of the Storage Class, either sys, krb5,
+	// krb5i or krb5p. Kerberos requires NFS version 4.1 and nodes which are
+	// joined to the Kerberos realm of the vSAN file service domain.
+	// For Example: NfsSecurity: "krb5p".
+	AttributeNfsSecurity = "nfssecurity"
+
+	// NfsSecuritySys is the default NFS security flavor, which authenticates
+	// clients by their IP address.
+	NfsSecuritySys = "sys"
+
+	// NfsSecurityKrb5 authenticates clients with Kerberos.
+	NfsSecurityKrb5 = "krb5"
+
+	// NfsSecurityKrb5i authenticates clients with Kerberos and protects the
+	// integrity of the traffic.
+	NfsSecurityKrb5i = "krb5i"
+
+	// NfsSecurityKrb5p authenticates clients with Kerberos and encrypts the
+	// traffic.
+	NfsSecurityKrb5p = "krb5p"
+
+	// AttributeNfsMountOptions represents the NFS mount options used by the
+	// nodes to mount file volumes of the Storage Class. Only rsize, wsize and
+	// timeo are accepted.
+	// For Example: NfsMountOptions: "rsize=1048576,wsize=1048576,timeo=600".
+	AttributeNfsMountOptions = "nfsmountoptions"
+
+	// FsckModeRepair makes the node repair the errors of the filesystem which
+	// can be repaired safely before mounting it.
+	FsckModeRepair = "repair"
+
+	// SmbDomainNameSecretKey is the key of the vSAN file service domain, which
+	// is joined to the Active Directory serving the SMB file shares, in the
+	// provisioner secret of a Storage Class with the SMB protocol.
+	SmbDomainNameSecretKey = "domainName"
+
+	// SmbEncryptionSecretKey is the optional key of the SMB encryption of the
+	// file shares, either "disabled" or "mandatory", in the provisioner secret
+	// of a Storage Class with the SMB protocol.
+	SmbEncryptionSecretKey = "encryption"
+
+	// SmbUsernameSecretKey is the key of the Active Directory user mounting
+	// SMB file shares in the node publish secret.
+	SmbUsernameSecretKey = "username"
+
+	// SmbPasswordSecretKey is the key of the password of the Active Directory
+	// user mounting SMB file shares in the node publish secret.
+	SmbPasswordSecretKey = "password"
+
+	// SmbDomainSecretKey is the optional key of the Active Directory domain of
+	// the user mounting SMB file shares in the node publish secret.
+	SmbDomainSecretKey = "domain"
+
 	// AttributeSupervisorStorageClass represents name of the Storage Class.
 	// For example: StorageClassName: "silver".
 	AttributeSupervisorStorageClass = "svstorageclass"
@@ -90,6 +206,10 @@ const (
 	// AttributeStorageClassName represents name of the Storage Class.
 	AttributeStorageClassName = "csi.storage.k8s.io/sc/name"
 
+	// CSIParameterPrefix is the prefix of the StorageClass parameters which are
+	// reserved for the CSI sidecars.
+	CSIParameterPrefix = "csi.storage.k8s.io/"
+
 	// AttributeIsLinkedClone represents if this is a linked clone request
 	AttributeIsLinkedClone = "csi.vsphere.volume/fast-provisioning"
 
@@ -112,6 +232,9 @@ const (
 	// XFSType represents the xfs filesystem type for block volume.
 	XFSType = "xfs"
 
+	// BtrfsFsType represents the btrfs filesystem type for block volume.
+	BtrfsFsType = "btrfs"
+
 	// NfsV4FsType represents nfs4 mount type.
 	NfsV4FsType = "nfs4"
 
@@ -121,6 +244,10 @@ const (
 	// NfsFsType represents nfs mount type.
 	NfsFsType = "nfs"
 
+	// CifsFsType represents cifs mount type, which is used for SMB file
+	// shares.
+	CifsFsType = "cifs"
+
 	// ProviderPrefix is the prefix used for the ProviderID set on the node.
 	// Example: vsphere://4201794a-f26b-8914-d95a-edeb7ecc4a8f
 	ProviderPrefix = "vsphere://"
@@ -159,6 +286,24 @@ const (
 	// Nfsv4AccessPoint is the access point of file volume.
 	Nfsv4AccessPoint = "Nfsv4AccessPoint"
 
+	// SmbAccessPointKey is the key for SMB access point.
+	SmbAccessPointKey = "SMB"
+
+	// SmbAccessPoint is the SMB access point of file volume.
+	SmbAccessPoint = "SmbAccessPoint"
+
+	// Nfsv3AccessPoint is the NFSv3 access point of file volume, which is
+	// published instead of the NFSv4.1 access point for NFS version 3.
+	Nfsv3AccessPoint = "Nfsv3AccessPoint"
+
+	// NfsSecurity is the publish context key of the NFS security flavor used
+	// to mount the file volume.
+	NfsSecurity = "NfsSecurity"
+
+	// NfsMountOptions is the publish context key of the NFS mount options
+	// used to mount the file volume.
+	NfsMountOptions = "NfsMountOptions"
+
 	// MinSupportedVCenterMajor is the minimum, major version of vCenter
 	// on which CNS is supported.
 	MinSupportedVCenterMajor int = 6
@@ -247,6 +392,21 @@ const (
 	// IopslimitMigrationParam is raw vSAN Policy Parameter.
 	IopslimitMigrationParam = "iopslimit-migrationparam"
 
+	// ThinProvisioningType represents a thin provisioned backing disk.
+	ThinProvisioningType = "thin"
+
+	// LazyZeroedThickProvisioningType represents a thick provisioned backing
+	// disk whose blocks are zeroed out on first write.
+	LazyZeroedThickProvisioningType = "lazyzeroedthick"
+
+	// EagerZeroedThickProvisioningType represents a thick provisioned backing
+	// disk whose blocks are zeroed out at creation time.
+	EagerZeroedThickProvisioningType = "eagerzeroedthick"
+
+	// ZeroedThickMigrationDiskFormat is the in-tree vSphere volume plugin
+	// diskformat for lazy zeroed thick disks.
+	ZeroedThickMigrationDiskFormat = "zeroedthick"
+
 	// AnnMigratedTo annotation is added to a PVC and PV that is supposed to be
 	// provisioned/deleted by its corresponding CSI driver.
 	AnnMigratedTo = "pv.kubernetes.io/migrated-to"
@@ -289,6 +449,11 @@ const (
 	// AnnFakeAttached is the key for fake attach annotation on volume claim.
 	AnnFakeAttached = "csi.vmware.com/fake-attached"
 
+	// AnnAvailableVolumeSlots is the key for the annotation on nodes with the
+	// number of disks that can still be attached to the SCSI and NVMe
+	// controllers of the node VM.
+	AnnAvailableVolumeSlots = "csi.vsphere.vmware.com/available-volume-slots"
+
 	// VolHealthStatusAccessible is volume health status for accessible volume.
 	VolHealthStatusAccessible = "accessible"
 
@@ -404,6 +569,8 @@ const (
 const (
 	// Default container orchestrator for TKC, Supervisor Cluster and Vanilla K8s.
 	Kubernetes = iota
+	// Nomad is the container orchestrator for Vanilla HashiCorp Nomad clusters.
+	Nomad
 )
 
 // Constants related to Feature state
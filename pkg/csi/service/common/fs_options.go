@@ -0,0 +1,200 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// subOptions returns the pattern of a comma separated list of the given
+// sub-option patterns, e.g. the extended options of mkfs.ext4 -E.
+func subOptions(patterns ...string) *regexp.Regexp {
+	option := "(" + strings.Join(patterns, "|") + ")"
+	return regexp.MustCompile("^" + option + "(," + option + ")*$")
+}
+
+var (
+	// extMkfsOptions is the allow-list of the mkfs options of ext3 and ext4.
+	extMkfsOptions = map[string]*regexp.Regexp{
+		// Bytes per inode.
+		"-i": regexp.MustCompile(`^[0-9]+$`),
+		// Inode size.
+		"-I": regexp.MustCompile(`^(128|256|512|1024)$`),
+		// Percentage of blocks reserved for the super-user.
+		"-m": regexp.MustCompile(`^[0-9]{1,2}$`),
+		"-b": regexp.MustCompile(`^(1024|2048|4096)$`),
+		"-E": subOptions(`lazy_itable_init=[01]`, `lazy_journal_init=[01]`, `packed_meta_blocks=[01]`,
+			`discard`, `nodiscard`, `stride=[0-9]+`, `stripe_width=[0-9]+`),
+		"-O": subOptions(`\^?(dir_index|extent|has_journal|huge_file|large_file|metadata_csum|64bit|` +
+			`quota|sparse_super2|uninit_bg)`),
+	}
+
+	// mkfsOptions maps the fstypes to the allow-lists of their mkfs options.
+	// The allow-lists map the options to the pattern of their value, or to
+	// nil for options without a value. Only the options which cannot affect
+	// anything but the created filesystem are allowed.
+	mkfsOptions = map[string]map[string]*regexp.Regexp{
+		Ext3FsType: extMkfsOptions,
+		Ext4FsType: extMkfsOptions,
+		XFSType: {
+			"-m": subOptions(`crc=[01]`, `finobt=[01]`, `reflink=[01]`, `bigtime=[01]`, `inobtcount=[01]`),
+			"-i": subOptions(`size=(256|512|1024|2048)`, `maxpct=[0-9]{1,2}`),
+			"-b": subOptions(`size=(1024|2048|4096)`),
+			// Do not discard blocks at mkfs time.
+			"-K": nil,
+		},
+		BtrfsFsType: {
+			// Metadata and data profiles of single disk filesystems.
+			"-m": regexp.MustCompile(`^(single|dup)$`),
+			"-d": regexp.MustCompile(`^(single|dup)$`),
+			"-n": regexp.MustCompile(`^(4096|8192|16384|32768|65536|[48]k|16k|32k|64k)$`),
+			"-O": subOptions(`\^?(no-holes|free-space-tree|block-group-tree|squota)`),
+			"-K": nil,
+		},
+	}
+)
+
+// ParseMkfsOptions validates the given space separated mkfs options against
+// the allow-list of the given fstype and returns them as mkfs arguments.
+// Options which are not in the allow-list are rejected, so that nothing but
+// the options of the filesystem can be passed to mkfs.
+func ParseMkfsOptions(fsType string, options string) ([]string, error) {
+	args := strings.Fields(options)
+	if len(args) == 0 {
+		return nil, nil
+	}
+	if fsType == "" {
+		fsType = Ext4FsType
+	}
+	allowed, ok := mkfsOptions[fsType]
+	if !ok {
+		return nil, fmt.Errorf("mkfs options are not supported for fstype %q", fsType)
+	}
+	for i := 0; i < len(args); i++ {
+		pattern, ok := allowed[args[i]]
+		if !ok {
+			return nil, fmt.Errorf("mkfs option %q is not supported for fstype %q", args[i], fsType)
+		}
+		if pattern == nil {
+			continue
+		}
+		if i+1 == len(args) {
+			return nil, fmt.Errorf("mkfs option %q of fstype %q requires a value", args[i], fsType)
+		}
+		i++
+		if !pattern.MatchString(args[i]) {
+			return nil, fmt.Errorf("invalid value %q of mkfs option %q of fstype %q", args[i], args[i-1], fsType)
+		}
+	}
+	return args, nil
+}
+
+// ValidateFsckMode validates that the given fsck mode is supported for the
+// given fstype. btrfs filesystems can only be checked, as btrfs check
+// --repair is not safe to run unattended.
+func ValidateFsckMode(fsType string, mode string) error {
+	if mode == "" {
+		return nil
+	}
+	if _, err := parseFsckMode(mode); err != nil {
+		return err
+	}
+	if fsType == BtrfsFsType && mode == FsckModeRepair {
+		return fmt.Errorf("fsck mode %q is not supported for fstype %q", mode, fsType)
+	}
+	return nil
+}
+
+const (
+	// maxNfsIOSize is the maximum rsize and wsize of NFS mounts.
+	maxNfsIOSize = 1048576
+	// maxNfsTimeo is the maximum timeo of NFS mounts, in tenths of a second.
+	maxNfsTimeo = 6000
+)
+
+// ParseNfsVersion validates the given NFS version of file volumes.
+func ParseNfsVersion(value string) (string, error) {
+	if value != NfsVersion41 && value != NfsVersion3 {
+		return "", fmt.Errorf("supported NFS versions are %q and %q", NfsVersion41, NfsVersion3)
+	}
+	return value, nil
+}
+
+// ParseNfsSecurity validates the given NFS security flavor of file volumes.
+func ParseNfsSecurity(value string) (string, error) {
+	security := strings.ToLower(value)
+	switch security {
+	case NfsSecuritySys, NfsSecurityKrb5, NfsSecurityKrb5i, NfsSecurityKrb5p:
+		return security, nil
+	}
+	return "", fmt.Errorf("supported NFS security flavors are %q, %q, %q and %q",
+		NfsSecuritySys, NfsSecurityKrb5, NfsSecurityKrb5i, NfsSecurityKrb5p)
+}
+
+// ValidateNfsVersionAndSecurity validates that the given NFS security flavor
+// is supported with the given NFS version. vSAN file shares only support
+// Kerberos over NFS 4.1.
+func ValidateNfsVersionAndSecurity(version string, security string) error {
+	if version == NfsVersion3 && security != "" && security != NfsSecuritySys {
+		return fmt.Errorf("NFS security flavor %q is not supported with NFS version %q", security, version)
+	}
+	return nil
+}
+
+// ParseNfsMountOptions validates the given comma separated NFS mount options
+// and returns them as mount flags. Only rsize, wsize and timeo are accepted,
+// so that the options cannot override the NFS version and security flavor.
+func ParseNfsMountOptions(value string) ([]string, error) {
+	var options []string
+	seen := make(map[string]bool)
+	for _, option := range strings.Split(value, ",") {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(option, "=")
+		if !ok {
+			return nil, fmt.Errorf("NFS mount option %q requires a value", option)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("NFS mount option %q is specified more than once", key)
+		}
+		seen[key] = true
+		num, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q of NFS mount option %q", val, key)
+		}
+		switch key {
+		case "rsize", "wsize":
+			if num < 1024 || num > maxNfsIOSize || num%1024 != 0 {
+				return nil, fmt.Errorf("NFS mount option %q must be a multiple of 1024 between 1024 and %d",
+					key, maxNfsIOSize)
+			}
+		case "timeo":
+			if num < 1 || num > maxNfsTimeo {
+				return nil, fmt.Errorf("NFS mount option %q must be between 1 and %d", key, maxNfsTimeo)
+			}
+		default:
+			return nil, fmt.Errorf("NFS mount option %q is not supported", key)
+		}
+		options = append(options, key+"="+strconv.Itoa(num))
+	}
+	return options, nil
+}
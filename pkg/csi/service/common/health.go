@@ -0,0 +1,73 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+)
+
+const (
+	// HealthCheckVCenterSession is the name prefix of the checks of the
+	// vCenter sessions.
+	HealthCheckVCenterSession = "vcenter-session"
+	// HealthCheckListView is the name prefix of the checks of the listview
+	// of the volume managers.
+	HealthCheckListView = "listview"
+)
+
+// GetVCenterHealthChecks checks, for each vCenter host of the given volume
+// managers, that the session of the vCenter is authenticated and that the
+// listview of its volume manager is ready. The checks are sorted by host.
+func GetVCenterHealthChecks(ctx context.Context, vcManager cnsvsphere.VirtualCenterManager,
+	volumeManagers map[string]cnsvolume.Manager) []csitypes.HealthCheck {
+	hosts := make([]string, 0, len(volumeManagers))
+	for host := range volumeManagers {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var checks []csitypes.HealthCheck
+	for _, host := range hosts {
+		sessionCheck := csitypes.HealthCheck{
+			Name:    fmt.Sprintf("%s/%s", HealthCheckVCenterSession, host),
+			Healthy: true,
+		}
+		vcenter, err := vcManager.GetVirtualCenter(ctx, host)
+		if err == nil {
+			err = vcenter.CheckSession(ctx)
+		}
+		if err != nil {
+			sessionCheck.Healthy = false
+			sessionCheck.Message = fmt.Sprintf("vCenter %q is disconnected: %v", host, err)
+		}
+		listViewCheck := csitypes.HealthCheck{
+			Name:    fmt.Sprintf("%s/%s", HealthCheckListView, host),
+			Healthy: volumeManagers[host].IsListViewReady(),
+		}
+		if !listViewCheck.Healthy {
+			listViewCheck.Message = fmt.Sprintf("listview of vCenter %q is not ready", host)
+		}
+		checks = append(checks, sessionCheck, listViewCheck)
+	}
+	return checks
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 
 	vimtypes "github.com/vmware/govmomi/vim25/types"
+	"google.golang.org/grpc/codes"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -404,3 +405,59 @@ func GetTopologyInfoFromNodes(ctx context.Context, reqParams interface{}) (
 		params.DatastoreURL, accessibleTopology)
 	return accessibleTopology, nil
 }
+
+// NodeNameGetter returns the name of the Kubernetes node of a node VM.
+type NodeNameGetter interface {
+	GetNodeNameByUUID(ctx context.Context, nodeUUID string) (string, error)
+}
+
+// CalculateAccessibleTopologiesForDatastore figures out the list of topologies from
+// which the given datastore is accessible when multi-VC FSS is enabled. It is
+// also used to compute the node affinity of the volumes imported through
+// CnsRegisterVolume.
+func CalculateAccessibleTopologiesForDatastore(ctx context.Context, vcenter *cnsvsphere.VirtualCenter,
+	topologySegments []map[string]string, allNodeVMs []*cnsvsphere.VirtualMachine,
+	datastoreURL string, nodeMgr NodeNameGetter) (
+	[]map[string]string, error) {
+	log := logger.GetLogger(ctx)
+	var datastoreAccessibleTopology []map[string]string
+
+	// Find out all nodeVMs which have access to the chosen datastore among all the nodes in k8s cluster.
+	accessibleNodes, err := common.GetNodeVMsWithAccessToDatastore(ctx, vcenter, datastoreURL, allNodeVMs)
+	if err != nil || len(accessibleNodes) == 0 {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to find all the nodes from which the datastore %q is accessible", datastoreURL)
+	}
+
+	// Get node names for the accessible nodeVMs so that we can query CSINodeTopology CRs.
+	var accessibleNodeNames []string
+	for _, vmref := range accessibleNodes {
+		// Get UUID from VM reference.
+		vmUUID, err := cnsvsphere.GetUUIDFromVMReference(ctx, vcenter, vmref.Reference())
+		if err != nil {
+			return nil, logger.LogNewErrorCode(log, codes.Internal,
+				err.Error())
+		}
+		// Get NodeVM name from VM UUID.
+		nodeName, err := nodeMgr.GetNodeNameByUUID(ctx, vmUUID)
+		if err != nil {
+			return nil, logger.LogNewErrorCode(log, codes.Internal,
+				err.Error())
+		}
+		accessibleNodeNames = append(accessibleNodeNames, nodeName)
+	}
+
+	datastoreAccessibleTopology, err = GetTopologyInfoFromNodes(ctx,
+		VanillaRetrieveTopologyInfoParams{
+			VCHost:                    vcenter.Config.Host,
+			NodeNames:                 accessibleNodeNames,
+			DatastoreURL:              datastoreURL,
+			RequestedTopologySegments: topologySegments,
+		})
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to find accessible topologies for the nodes %v. Error: %+v",
+			accessibleNodeNames, err)
+	}
+	return datastoreAccessibleTopology, nil
+}
@@ -83,6 +83,7 @@ type CreateVolumeSpec struct {
 	VolumeType              string
 	VsanDatastoreURL        string // Datastore URL used by host local volumes (vSAN Direct/vSAN SNA)
 	ContentSourceSnapshotID string // SnapshotID from VolumeContentSource in CreateVolumeRequest
+	ContentSourceVolumeID   string // VolumeID from VolumeContentSource in CreateVolumeRequest
 	CryptoKeyID             *CryptoKeyID
 	IsLinkedCloneRequest    bool
 }
@@ -91,8 +92,48 @@ type CreateVolumeSpec struct {
 type StorageClassParams struct {
 	DatastoreURL      string
 	StoragePolicyName string
-	CSIMigration      string
-	Datastore         string
+	// StoragePolicyID overrides the storage policy of the volume with the
+	// given SPBM profile ID. It is mutually exclusive with StoragePolicyName.
+	StoragePolicyID string
+	CSIMigration    string
+	Datastore       string
+	// ProvisioningType is the provisioning type of the backing disk of block
+	// volumes. CNS creates thin disks, so disks of the other types are
+	// created through VSLM and then registered with CNS.
+	ProvisioningType string
+	// IopsLimit and IopsShares are the storage I/O allocation of the disk of
+	// block volumes, which is set when the volume is attached to a node VM.
+	IopsLimit int64
+	// IopsShares is either one of the predefined share levels (low, normal,
+	// high) or the number of shares for a custom share level.
+	IopsShares string
+	// LuksEncryption makes the node encrypt block volumes with LUKS before
+	// creating a filesystem on them.
+	LuksEncryption bool
+	// FileShareProtocol is the protocol of the vSAN file shares of file
+	// volumes, either NFS or SMB. NFS is used when it is empty.
+	FileShareProtocol string
+	// MkfsOptions are the mkfs options of block volumes. They are validated
+	// against the allow-list of the volume's fstype with ParseMkfsOptions.
+	MkfsOptions string
+	// FsckMode is either check or repair to run fsck on block volumes before
+	// mounting them. No fsck is run when it is empty.
+	FsckMode string
+	// NfsVersion is the NFS version of file volumes, either 4.1 or 3. 4.1 is
+	// used when it is empty.
+	NfsVersion string
+	// NfsSecurity is the NFS security flavor of file volumes. sys is used
+	// when it is empty.
+	NfsSecurity string
+	// NfsMountOptions are the validated NFS mount options of file volumes.
+	NfsMountOptions string
+}
+
+// ModifyVolumeParams represents the mutable parameters of a volume, which are
+// passed through the VolumeAttributesClass.
+type ModifyVolumeParams struct {
+	StoragePolicyName string
+	StoragePolicyID   string
 }
 
 type CryptoKeyID struct {
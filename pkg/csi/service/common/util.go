@@ -202,9 +202,10 @@ func validateVolumeCapabilities(volCaps []*csi.VolumeCapability,
 
 		if volCap.AccessMode.Mode == csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER {
 			// For ReadWriteOnce access mode we only support following filesystems:
-			// ext3, ext4, xfs for Linux and ntfs for Windows.
+			// ext3, ext4, xfs, btrfs for Linux and ntfs for Windows.
 			if volCap.GetMount() != nil && !(volCap.GetMount().FsType == Ext4FsType ||
 				volCap.GetMount().FsType == Ext3FsType || volCap.GetMount().FsType == XFSType ||
+				volCap.GetMount().FsType == BtrfsFsType ||
 				strings.ToLower(volCap.GetMount().FsType) == NTFSFsType || volCap.GetMount().FsType == "") {
 				return fmt.Errorf("fstype %s not supported for ReadWriteOnce volume creation",
 					volCap.GetMount().FsType)
@@ -248,62 +249,208 @@ func ParseStorageClassParams(ctx context.Context, params map[string]string,
 		DatastoreURL:      "",
 		StoragePolicyName: "",
 	}
-	if !csiMigrationFeatureState {
-		for param, value := range params {
-			param = strings.ToLower(param)
-			if param == AttributeDatastoreURL {
-				scParams.DatastoreURL = value
-			} else if param == AttributeStoragePolicyName {
-				scParams.StoragePolicyName = value
-			} else if param == AttributeFsType {
-				log.Warnf("param 'fstype' is deprecated, please use 'csi.storage.k8s.io/fstype' instead")
-			} else {
+	otherParams := make(map[string]string)
+	for param, value := range params {
+		param = strings.ToLower(param)
+		var err error
+		switch param {
+		case AttributeDatastoreURL:
+			scParams.DatastoreURL = value
+		case AttributeStoragePolicyName:
+			scParams.StoragePolicyName = value
+		case AttributeStoragePolicyID:
+			scParams.StoragePolicyID = value
+		case AttributeFsType:
+			log.Warnf("param 'fstype' is deprecated, please use 'csi.storage.k8s.io/fstype' instead")
+		case AttributeDiskProvisioningType:
+			scParams.ProvisioningType, err = parseDiskProvisioningType(value)
+		case AttributeIopsLimit:
+			scParams.IopsLimit, err = parseIopsLimit(value)
+		case AttributeIopsShares:
+			scParams.IopsShares, err = parseIopsShares(value)
+		case AttributeLuksEncryption:
+			scParams.LuksEncryption, err = strconv.ParseBool(value)
+		case AttributeFileShareProtocol:
+			scParams.FileShareProtocol, err = parseFileShareProtocol(value)
+		case AttributeMkfsOptions:
+			scParams.MkfsOptions = value
+		case AttributeFsckMode:
+			scParams.FsckMode, err = parseFsckMode(value)
+		case AttributeNfsVersion:
+			scParams.NfsVersion, err = ParseNfsVersion(value)
+		case AttributeNfsSecurity:
+			scParams.NfsSecurity, err = ParseNfsSecurity(value)
+		case AttributeNfsMountOptions:
+			var options []string
+			options, err = ParseNfsMountOptions(value)
+			scParams.NfsMountOptions = strings.Join(options, ",")
+		default:
+			if !csiMigrationFeatureState {
 				return nil, fmt.Errorf("invalid param: %q and value: %q", param, value)
 			}
-		}
-	} else {
-		otherParams := make(map[string]string)
-		for param, value := range params {
-			param = strings.ToLower(param)
-			if param == AttributeDatastoreURL {
-				scParams.DatastoreURL = value
-			} else if param == AttributeStoragePolicyName {
-				scParams.StoragePolicyName = value
-			} else if param == AttributeFsType {
-				log.Warnf("param 'fstype' is deprecated, please use 'csi.storage.k8s.io/fstype' instead")
-			} else if param == CSIMigrationParams {
+			if param == CSIMigrationParams {
 				scParams.CSIMigration = value
 			} else {
 				otherParams[param] = value
 			}
 		}
-		// check otherParams belongs to in-tree migrated Parameters.
-		if scParams.CSIMigration == "true" {
-			for param, value := range otherParams {
-				param = strings.ToLower(param)
-				if param == DatastoreMigrationParam {
-					scParams.Datastore = value
-				} else if param == DiskFormatMigrationParam && value == "thin" {
-					continue
-				} else if param == HostFailuresToTolerateMigrationParam ||
-					param == ForceProvisioningMigrationParam || param == CacheReservationMigrationParam ||
-					param == DiskstripesMigrationParam || param == ObjectspacereservationMigrationParam ||
-					param == IopslimitMigrationParam {
-					return nil, fmt.Errorf("vSphere CSI driver does not support creating volume using "+
-						"in-tree vSphere volume plugin parameter key:%v, value:%v", param, value)
-				} else {
-					return nil, fmt.Errorf("invalid parameter. key:%v, value:%v", param, value)
-				}
+		if err != nil {
+			return nil, fmt.Errorf("invalid param: %q and value: %q. Error: %v", param, value, err)
+		}
+	}
+	if len(otherParams) != 0 && scParams.CSIMigration != "true" {
+		return nil, fmt.Errorf("invalid parameters :%v", otherParams)
+	}
+	// check otherParams belongs to in-tree migrated Parameters.
+	for param, value := range otherParams {
+		if param == DatastoreMigrationParam {
+			scParams.Datastore = value
+		} else if param == DiskFormatMigrationParam {
+			// in-tree vSphere volume plugin calls lazy zeroed thick disks "zeroedthick".
+			if strings.ToLower(value) == ZeroedThickMigrationDiskFormat {
+				value = LazyZeroedThickProvisioningType
 			}
-		} else {
-			if len(otherParams) != 0 {
-				return nil, fmt.Errorf("invalid parameters :%v", otherParams)
+			provisioningType, err := parseDiskProvisioningType(value)
+			if err != nil {
+				return nil, fmt.Errorf("vSphere CSI driver does not support creating volume using "+
+					"in-tree vSphere volume plugin parameter key:%v, value:%v", param, value)
 			}
+			scParams.ProvisioningType = provisioningType
+		} else if param == IopslimitMigrationParam {
+			iopsLimit, err := parseIopsLimit(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parameter. key:%v, value:%v. Error: %v", param, value, err)
+			}
+			scParams.IopsLimit = iopsLimit
+		} else if param == HostFailuresToTolerateMigrationParam ||
+			param == ForceProvisioningMigrationParam || param == CacheReservationMigrationParam ||
+			param == DiskstripesMigrationParam || param == ObjectspacereservationMigrationParam {
+			return nil, fmt.Errorf("vSphere CSI driver does not support creating volume using "+
+				"in-tree vSphere volume plugin parameter key:%v, value:%v", param, value)
+		} else {
+			return nil, fmt.Errorf("invalid parameter. key:%v, value:%v", param, value)
 		}
 	}
+	if err := ValidateNfsVersionAndSecurity(scParams.NfsVersion, scParams.NfsSecurity); err != nil {
+		return nil, err
+	}
+	if scParams.StoragePolicyName != "" && scParams.StoragePolicyID != "" {
+		return nil, fmt.Errorf("only one of %q and %q can be specified",
+			AttributeStoragePolicyName, AttributeStoragePolicyID)
+	}
 	return scParams, nil
 }
 
+// parseDiskProvisioningType validates the given disk provisioning type.
+func parseDiskProvisioningType(value string) (string, error) {
+	provisioningType := strings.ToLower(value)
+	switch provisioningType {
+	case ThinProvisioningType, LazyZeroedThickProvisioningType, EagerZeroedThickProvisioningType:
+		return provisioningType, nil
+	}
+	return "", fmt.Errorf("supported disk provisioning types are %q, %q and %q",
+		ThinProvisioningType, LazyZeroedThickProvisioningType, EagerZeroedThickProvisioningType)
+}
+
+// parseIopsLimit validates the given storage I/O limit.
+func parseIopsLimit(value string) (int64, error) {
+	iopsLimit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || iopsLimit <= 0 {
+		return 0, fmt.Errorf("iops limit must be a positive integer")
+	}
+	return iopsLimit, nil
+}
+
+// parseIopsShares validates the given storage I/O shares, which is either a
+// share level or the number of shares.
+func parseIopsShares(value string) (string, error) {
+	shares := strings.ToLower(value)
+	switch types.SharesLevel(shares) {
+	case types.SharesLevelLow, types.SharesLevelNormal, types.SharesLevelHigh:
+		return shares, nil
+	}
+	if numShares, err := strconv.ParseInt(shares, 10, 32); err == nil && numShares > 0 {
+		return shares, nil
+	}
+	return "", fmt.Errorf("iops shares must be one of %q, %q, %q or a positive integer",
+		types.SharesLevelLow, types.SharesLevelNormal, types.SharesLevelHigh)
+}
+
+// GetStorageIOAllocation returns the storage I/O allocation of the disk of a
+// block volume from the iopslimit and iopsshares entries of its volume
+// context, or nil if neither is set.
+func GetStorageIOAllocation(volumeContext map[string]string) (*types.StorageIOAllocationInfo, error) {
+	var allocation *types.StorageIOAllocationInfo
+	if value, ok := volumeContext[AttributeIopsLimit]; ok {
+		iopsLimit, err := parseIopsLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q: %q. Error: %v", AttributeIopsLimit, value, err)
+		}
+		allocation = &types.StorageIOAllocationInfo{Limit: &iopsLimit}
+	}
+	if value, ok := volumeContext[AttributeIopsShares]; ok {
+		shares, err := parseIopsShares(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q: %q. Error: %v", AttributeIopsShares, value, err)
+		}
+		if allocation == nil {
+			allocation = &types.StorageIOAllocationInfo{}
+		}
+		allocation.Shares = &types.SharesInfo{Level: types.SharesLevel(shares)}
+		if numShares, err := strconv.ParseInt(shares, 10, 32); err == nil {
+			allocation.Shares = &types.SharesInfo{Level: types.SharesLevelCustom, Shares: int32(numShares)}
+		}
+	}
+	return allocation, nil
+}
+
+// parseFileShareProtocol validates the given file share protocol.
+func parseFileShareProtocol(value string) (string, error) {
+	protocol := strings.ToUpper(value)
+	if protocol != NfsFileShareProtocol && protocol != SmbFileShareProtocol {
+		return "", fmt.Errorf("supported file share protocols are %q and %q",
+			NfsFileShareProtocol, SmbFileShareProtocol)
+	}
+	return protocol, nil
+}
+
+// parseFsckMode validates the given fsck mode.
+func parseFsckMode(value string) (string, error) {
+	mode := strings.ToLower(value)
+	if mode != FsckModeCheck && mode != FsckModeRepair {
+		return "", fmt.Errorf("supported fsck modes are %q and %q", FsckModeCheck, FsckModeRepair)
+	}
+	return mode, nil
+}
+
+// ParseModifyVolumeParams parses the mutable parameters in the CSI
+// ControllerModifyVolume API call back to ModifyVolumeParams structure.
+func ParseModifyVolumeParams(ctx context.Context, params map[string]string) (*ModifyVolumeParams, error) {
+	modifyParams := &ModifyVolumeParams{}
+	if len(params) == 0 {
+		return nil, fmt.Errorf("mutable parameters must be specified")
+	}
+	for param, value := range params {
+		switch strings.ToLower(param) {
+		case AttributeStoragePolicyName:
+			modifyParams.StoragePolicyName = value
+		case AttributeStoragePolicyID:
+			modifyParams.StoragePolicyID = value
+		default:
+			return nil, fmt.Errorf("invalid mutable param: %q and value: %q", param, value)
+		}
+	}
+	if modifyParams.StoragePolicyName == "" && modifyParams.StoragePolicyID == "" {
+		return nil, fmt.Errorf("value of %q or %q must not be empty",
+			AttributeStoragePolicyName, AttributeStoragePolicyID)
+	}
+	if modifyParams.StoragePolicyName != "" && modifyParams.StoragePolicyID != "" {
+		return nil, fmt.Errorf("only one of %q and %q can be specified",
+			AttributeStoragePolicyName, AttributeStoragePolicyID)
+	}
+	return modifyParams, nil
+}
+
 // GetK8sCloudOperatorServicePort return the port to connect the
 // K8sCloudOperator gRPC service.
 // If environment variable POD_LISTENER_SERVICE_PORT is set and valid,
@@ -354,6 +501,42 @@ func ConvertVolumeHealthStatus(ctx context.Context, volID string, volHealthStatu
 	}
 }
 
+// GetVolumeCondition returns the CSI VolumeCondition for the given CNS volume
+// health status. An empty or unknown health status is not reported as abnormal,
+// as CNS may not have computed the health of the volume yet.
+func GetVolumeCondition(ctx context.Context, volID string, volHealthStatus string) *csi.VolumeCondition {
+	if volHealthStatus == "" || volHealthStatus == string(pbmtypes.PbmHealthStatusForEntityUnknown) {
+		return &csi.VolumeCondition{
+			Abnormal: false,
+			Message:  "volume health status is unknown",
+		}
+	}
+	status, _ := ConvertVolumeHealthStatus(ctx, volID, volHealthStatus)
+	return GetVolumeConditionFromHealthAnnotation(status)
+}
+
+// GetVolumeConditionFromHealthAnnotation returns the CSI VolumeCondition for
+// the given value of the volume health annotation on a PVC.
+func GetVolumeConditionFromHealthAnnotation(volHealthStatus string) *csi.VolumeCondition {
+	switch volHealthStatus {
+	case VolHealthStatusAccessible:
+		return &csi.VolumeCondition{
+			Abnormal: false,
+			Message:  "volume is accessible",
+		}
+	case VolHealthStatusInaccessible:
+		return &csi.VolumeCondition{
+			Abnormal: true,
+			Message:  "volume is inaccessible",
+		}
+	default:
+		return &csi.VolumeCondition{
+			Abnormal: false,
+			Message:  "volume health status is unknown",
+		}
+	}
+}
+
 // ParseCSISnapshotID parses the SnapshotID from CSI RPC such as DeleteSnapshot, CreateVolume from snapshot
 // into a pair of CNS VolumeID and CNS SnapshotID.
 func ParseCSISnapshotID(csiSnapshotID string) (string, string, error) {
@@ -535,3 +718,18 @@ func GetCNSVolumeInfoPatch(ctx context.Context, CapacityInMb int64, volumeId str
 	}
 	return patch, nil
 }
+
+// GetReleasedVanillaFSS returns the feature states which are released, and so
+// always enabled, in Vanilla clusters.
+func GetReleasedVanillaFSS() map[string]struct{} {
+	return map[string]struct{}{
+		CSIMigration:                  {},
+		OnlineVolumeExtend:            {},
+		BlockVolumeSnapshot:           {},
+		CSIWindowsSupport:             {},
+		ListVolumes:                   {},
+		CnsMgrSuspendCreateVolume:     {},
+		CSIInternalGeneratedClusterID: {},
+		TopologyAwareFileVolume:       {},
+	}
+}
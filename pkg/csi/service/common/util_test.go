@@ -24,6 +24,8 @@ import (
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/onsi/gomega"
+	pbmtypes "github.com/vmware/govmomi/pbm/types"
+	"github.com/vmware/govmomi/vim25/types"
 	"k8s.io/client-go/dynamic"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -498,6 +500,328 @@ func TestParseStorageClassParamsWithMigrationDisabled(t *testing.T) {
 	t.Logf("expected err received. err: %v", err)
 }
 
+func TestParseStorageClassParamsWithStoragePolicyID(t *testing.T) {
+	params := map[string]string{
+		AttributeStoragePolicyID: "policy-id",
+	}
+	scParam, err := ParseStorageClassParams(ctx, params, false)
+	if err != nil {
+		t.Fatalf("failed to parse params: %+v, err: %+v", params, err)
+	}
+	assert.Equal(t, &StorageClassParams{StoragePolicyID: "policy-id"}, scParam)
+
+	params[AttributeStoragePolicyName] = "policy1"
+	scParam, err = ParseStorageClassParams(ctx, params, false)
+	if err == nil {
+		t.Errorf("error expected but not received. scParam received from ParseStorageClassParams: %v", scParam)
+	}
+}
+
+func TestParseStorageClassParamsWithProvisioningAndIOParams(t *testing.T) {
+	params := map[string]string{
+		AttributeStoragePolicyID:      "policy-id",
+		AttributeDiskProvisioningType: "EagerZeroedThick",
+		AttributeIopsLimit:            "1000",
+		AttributeIopsShares:           "High",
+	}
+	expectedScParams := &StorageClassParams{
+		StoragePolicyID:  "policy-id",
+		ProvisioningType: EagerZeroedThickProvisioningType,
+		IopsLimit:        1000,
+		IopsShares:       "high",
+	}
+	scParam, err := ParseStorageClassParams(ctx, params, false)
+	if err != nil {
+		t.Fatalf("failed to parse params: %+v, err: %+v", params, err)
+	}
+	assert.Equal(t, expectedScParams, scParam)
+}
+
+func TestParseStorageClassParamsWithProvisioningAndIOParamsNegative(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+	}{
+		{
+			name: "InvalidProvisioningType",
+			params: map[string]string{
+				AttributeDiskProvisioningType: "thick",
+			},
+		},
+		{
+			name: "InvalidIopsLimit",
+			params: map[string]string{
+				AttributeIopsLimit: "0",
+			},
+		},
+		{
+			name: "InvalidIopsShares",
+			params: map[string]string{
+				AttributeIopsShares: "highest",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scParam, err := ParseStorageClassParams(ctx, test.params, false)
+			if err == nil {
+				t.Errorf("error expected but not received. scParam received from ParseStorageClassParams: %v",
+					scParam)
+			}
+		})
+	}
+}
+
+func TestParseStorageClassParamsWithMigratedDiskFormatAndIopsLimit(t *testing.T) {
+	params := map[string]string{
+		CSIMigrationParams:         "true",
+		AttributeStoragePolicyName: "policy1",
+		DiskFormatMigrationParam:   "zeroedthick",
+		IopslimitMigrationParam:    "16",
+	}
+	scParam, err := ParseStorageClassParams(ctx, params, true)
+	if err != nil {
+		t.Fatalf("failed to parse params: %+v, err: %+v", params, err)
+	}
+	assert.Equal(t, LazyZeroedThickProvisioningType, scParam.ProvisioningType)
+	assert.Equal(t, int64(16), scParam.IopsLimit)
+}
+
+func TestGetStorageIOAllocation(t *testing.T) {
+	allocation, err := GetStorageIOAllocation(map[string]string{AttributeDiskType: DiskTypeBlockVolume})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, allocation)
+
+	allocation, err = GetStorageIOAllocation(map[string]string{AttributeIopsLimit: "1000", AttributeIopsShares: "high"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := int64(1000)
+	assert.Equal(t, &types.StorageIOAllocationInfo{
+		Limit:  &limit,
+		Shares: &types.SharesInfo{Level: types.SharesLevelHigh},
+	}, allocation)
+
+	allocation, err = GetStorageIOAllocation(map[string]string{AttributeIopsShares: "2000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &types.StorageIOAllocationInfo{
+		Shares: &types.SharesInfo{Level: types.SharesLevelCustom, Shares: 2000},
+	}, allocation)
+
+	allocation, err = GetStorageIOAllocation(map[string]string{AttributeIopsLimit: "-1"})
+	if err == nil {
+		t.Errorf("error expected but not received. allocation received from GetStorageIOAllocation: %v", allocation)
+	}
+}
+
+func TestParseStorageClassParamsWithLuksEncryption(t *testing.T) {
+	scParam, err := ParseStorageClassParams(ctx, map[string]string{AttributeLuksEncryption: "true"}, false)
+	if err != nil {
+		t.Fatalf("failed to parse params, err: %+v", err)
+	}
+	assert.True(t, scParam.LuksEncryption)
+
+	scParam, err = ParseStorageClassParams(ctx, map[string]string{AttributeLuksEncryption: "yes"}, false)
+	if err == nil {
+		t.Errorf("error expected but not received. scParam received from ParseStorageClassParams: %v", scParam)
+	}
+}
+
+func TestParseStorageClassParamsWithFileShareProtocol(t *testing.T) {
+	scParam, err := ParseStorageClassParams(ctx, map[string]string{AttributeFileShareProtocol: "smb"}, false)
+	if err != nil {
+		t.Fatalf("failed to parse params, err: %+v", err)
+	}
+	assert.Equal(t, SmbFileShareProtocol, scParam.FileShareProtocol)
+
+	scParam, err = ParseStorageClassParams(ctx, map[string]string{AttributeFileShareProtocol: "iscsi"}, false)
+	if err == nil {
+		t.Errorf("error expected but not received. scParam received from ParseStorageClassParams: %v", scParam)
+	}
+}
+
+func TestParseStorageClassParamsWithFsOptions(t *testing.T) {
+	scParam, err := ParseStorageClassParams(ctx, map[string]string{
+		AttributeMkfsOptions: "-i 16384 -E lazy_itable_init=0",
+		AttributeFsckMode:    "Repair",
+	}, false)
+	if err != nil {
+		t.Fatalf("failed to parse params, err: %+v", err)
+	}
+	assert.Equal(t, "-i 16384 -E lazy_itable_init=0", scParam.MkfsOptions)
+	assert.Equal(t, FsckModeRepair, scParam.FsckMode)
+
+	scParam, err = ParseStorageClassParams(ctx, map[string]string{AttributeFsckMode: "always"}, false)
+	if err == nil {
+		t.Errorf("error expected but not received. scParam received from ParseStorageClassParams: %v", scParam)
+	}
+}
+
+func TestParseMkfsOptions(t *testing.T) {
+	tests := []struct {
+		fsType       string
+		options      string
+		expectedArgs []string
+		expectErr    bool
+	}{
+		{fsType: "", options: "", expectedArgs: nil},
+		{fsType: "", options: "-i 16384  -E lazy_itable_init=0,lazy_journal_init=0",
+			expectedArgs: []string{"-i", "16384", "-E", "lazy_itable_init=0,lazy_journal_init=0"}},
+		{fsType: Ext3FsType, options: "-O ^has_journal", expectedArgs: []string{"-O", "^has_journal"}},
+		{fsType: XFSType, options: "-m reflink=1,crc=1 -K", expectedArgs: []string{"-m", "reflink=1,crc=1", "-K"}},
+		{fsType: BtrfsFsType, options: "-m dup -O no-holes", expectedArgs: []string{"-m", "dup", "-O", "no-holes"}},
+		// Options of other fstypes, unknown options and injected commands are
+		// rejected.
+		{fsType: XFSType, options: "-i 16384", expectErr: true},
+		{fsType: Ext4FsType, options: "-i", expectErr: true},
+		{fsType: Ext4FsType, options: "-F", expectErr: true},
+		{fsType: Ext4FsType, options: "-E lazy_itable_init=0;reboot", expectErr: true},
+		{fsType: Ext4FsType, options: "-i 16384 /dev/sda", expectErr: true},
+		{fsType: NTFSFsType, options: "-Q", expectErr: true},
+	}
+	for _, test := range tests {
+		args, err := ParseMkfsOptions(test.fsType, test.options)
+		if test.expectErr {
+			assert.Error(t, err, "fstype %q options %q", test.fsType, test.options)
+			continue
+		}
+		assert.NoError(t, err, "fstype %q options %q", test.fsType, test.options)
+		assert.Equal(t, test.expectedArgs, args)
+	}
+}
+
+func TestValidateFsckMode(t *testing.T) {
+	assert.NoError(t, ValidateFsckMode(Ext4FsType, ""))
+	assert.NoError(t, ValidateFsckMode(XFSType, FsckModeRepair))
+	assert.NoError(t, ValidateFsckMode(BtrfsFsType, FsckModeCheck))
+	assert.Error(t, ValidateFsckMode(BtrfsFsType, FsckModeRepair))
+}
+
+func TestParseStorageClassParamsWithNfsParams(t *testing.T) {
+	scParam, err := ParseStorageClassParams(ctx, map[string]string{
+		AttributeNfsVersion:      NfsVersion41,
+		AttributeNfsSecurity:     "KRB5I",
+		AttributeNfsMountOptions: " rsize=65536, wsize=065536,",
+	}, false)
+	if err != nil {
+		t.Fatalf("failed to parse params, err: %+v", err)
+	}
+	assert.Equal(t, NfsVersion41, scParam.NfsVersion)
+	assert.Equal(t, NfsSecurityKrb5i, scParam.NfsSecurity)
+	assert.Equal(t, "rsize=65536,wsize=65536", scParam.NfsMountOptions)
+
+	for _, params := range []map[string]string{
+		{AttributeNfsVersion: "4"},
+		{AttributeNfsSecurity: "ntlm"},
+		{AttributeNfsVersion: NfsVersion3, AttributeNfsSecurity: NfsSecurityKrb5p},
+		{AttributeNfsMountOptions: "sec=sys"},
+	} {
+		scParam, err = ParseStorageClassParams(ctx, params, false)
+		if err == nil {
+			t.Errorf("error expected but not received for params %v. scParam received: %v", params, scParam)
+		}
+	}
+}
+
+func TestParseNfsMountOptions(t *testing.T) {
+	options, err := ParseNfsMountOptions(" rsize=1048576,wsize=4096 ,timeo=600,")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rsize=1048576", "wsize=4096", "timeo=600"}, options)
+
+	for _, value := range []string{
+		"rsize", "rsize=1000", "wsize=2097152", "timeo=0", "timeo=600,timeo=300", "rsize=0x1000", "nolock=1",
+	} {
+		_, err := ParseNfsMountOptions(value)
+		assert.Error(t, err, "NFS mount options %q", value)
+	}
+}
+
+func TestParseModifyVolumeParams(t *testing.T) {
+	tests := []struct {
+		name           string
+		params         map[string]string
+		expectedParams *ModifyVolumeParams
+		expectErr      bool
+	}{
+		{
+			name:           "StoragePolicyName",
+			params:         map[string]string{"storagePolicyName": "raid-5"},
+			expectedParams: &ModifyVolumeParams{StoragePolicyName: "raid-5"},
+		},
+		{
+			name:           "StoragePolicyID",
+			params:         map[string]string{AttributeStoragePolicyID: "policy-id"},
+			expectedParams: &ModifyVolumeParams{StoragePolicyID: "policy-id"},
+		},
+		{
+			name:      "EmptyParams",
+			params:    map[string]string{},
+			expectErr: true,
+		},
+		{
+			name:      "EmptyValue",
+			params:    map[string]string{AttributeStoragePolicyName: ""},
+			expectErr: true,
+		},
+		{
+			name: "BothPolicyNameAndID",
+			params: map[string]string{
+				AttributeStoragePolicyName: "raid-5",
+				AttributeStoragePolicyID:   "policy-id",
+			},
+			expectErr: true,
+		},
+		{
+			name:      "InvalidParam",
+			params:    map[string]string{AttributeDatastoreURL: "ds1"},
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modifyParams, err := ParseModifyVolumeParams(ctx, test.params)
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("error expected but not received. params received: %+v", modifyParams)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse params: %+v, err: %+v", test.params, err)
+			}
+			if *modifyParams != *test.expectedParams {
+				t.Errorf("Expected: %+v\n Actual: %+v", test.expectedParams, modifyParams)
+			}
+		})
+	}
+}
+
+func TestGetVolumeCondition(t *testing.T) {
+	tests := []struct {
+		healthStatus     string
+		expectedAbnormal bool
+	}{
+		{healthStatus: "", expectedAbnormal: false},
+		{healthStatus: string(pbmtypes.PbmHealthStatusForEntityUnknown), expectedAbnormal: false},
+		{healthStatus: string(pbmtypes.PbmHealthStatusForEntityGreen), expectedAbnormal: false},
+		{healthStatus: string(pbmtypes.PbmHealthStatusForEntityYellow), expectedAbnormal: false},
+		{healthStatus: string(pbmtypes.PbmHealthStatusForEntityRed), expectedAbnormal: true},
+	}
+	for _, test := range tests {
+		t.Run(test.healthStatus, func(t *testing.T) {
+			condition := GetVolumeCondition(ctx, "volume-id", test.healthStatus)
+			if condition.Abnormal != test.expectedAbnormal {
+				t.Errorf("health status %q: expected abnormal %t, got %+v", test.healthStatus,
+					test.expectedAbnormal, condition)
+			}
+		})
+	}
+}
+
 func TestParseCSISnapshotID(t *testing.T) {
 	type args struct {
 		ctx           context.Context
@@ -92,6 +92,8 @@ func CreateBlockVolumeUtil(
 			// Currently, just return csi.fault.Internal.
 			return nil, csifault.CSIInternalFault, err
 		}
+	} else if spec.ScParams.StoragePolicyID != "" {
+		spec.StoragePolicyID = spec.ScParams.StoragePolicyID
 	}
 
 	var clusterMorefs []vim25types.ManagedObjectReference
@@ -532,6 +534,21 @@ func CreateBlockVolumeUtilForMultiVC(ctx context.Context, reqParams interface{},
 				"object in vCenter %q for datastore URL %q associated with snapshot %q",
 				params.Vcenter.Config.Host, params.SnapshotDatastoreURL, params.Spec.ContentSourceSnapshotID)
 		}
+		if params.Spec.ContentSourceVolumeID != "" && params.StoragePolicyID != "" {
+			// A clone is created on the datastore of the source volume, which needs
+			// to be compatible with the storage policy requested for the clone.
+			compat, err := params.Vcenter.PbmCheckCompatibility(ctx, getDatastoreMoRefs(datastoreInfoObjList),
+				params.StoragePolicyID)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log, "failed to find datastore "+
+					"compatibility with storage policy ID %q. Error: %+v", params.StoragePolicyID, err)
+			}
+			if len(compat.CompatibleDatastores()) == 0 {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+					"datastore %q of source volume %q is not compatible with storage policy ID %q",
+					params.SnapshotDatastoreURL, params.Spec.ContentSourceVolumeID, params.StoragePolicyID)
+			}
+		}
 
 		log.Infof("Overwrite the datastores field in create spec %+v", createSpec.Datastores)
 		createSpec.Datastores = nil
@@ -543,6 +560,16 @@ func CreateBlockVolumeUtilForMultiVC(ctx context.Context, reqParams interface{},
 		}
 	}
 
+	if params.Spec.ScParams != nil && params.Spec.ScParams.ProvisioningType != "" &&
+		params.Spec.ScParams.ProvisioningType != ThinProvisioningType {
+		if createSpec.VolumeSource != nil {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorf(log,
+				"disk provisioning type %q is not supported for volumes created from a snapshot or a volume",
+				params.Spec.ScParams.ProvisioningType)
+		}
+		return createBlockVolumeWithBackingDisk(ctx, params, createSpec)
+	}
+
 	log.Debugf("vSphere CSI driver creating volume %s with create spec %+v", params.Spec.Name, spew.Sdump(createSpec))
 	volumeInfo, faultType, err := params.VolumeManager.CreateVolume(ctx, createSpec, nil)
 	if err != nil {
@@ -570,6 +597,100 @@ func CreateBlockVolumeUtilForMultiVC(ctx context.Context, reqParams interface{},
 	return volumeInfo, "", nil
 }
 
+// createBlockVolumeWithBackingDisk creates a block volume whose backing disk is
+// not thin provisioned. CNS only creates thin disks, so the disk is created
+// through VSLM on the compatible datastore with the most free space and then
+// registered with CNS, which makes the ID of the disk the volume ID.
+func createBlockVolumeWithBackingDisk(ctx context.Context, params VanillaCreateBlockVolParamsForMultiVC,
+	createSpec *cnstypes.CnsVolumeCreateSpec) (*cnsvolume.CnsVolumeInfo, string, error) {
+	log := logger.GetLogger(ctx)
+	// The volume ID is not known before the disk is created, so a volume
+	// registered by an earlier attempt of this request is looked up by name.
+	queryFilter := cnstypes.CnsQueryFilter{
+		Names:               []string{createSpec.Name},
+		ContainerClusterIds: []string{createSpec.Metadata.ContainerCluster.ClusterId},
+	}
+	queryResult, err := utils.QueryVolumeUtil(ctx, params.VolumeManager, queryFilter, nil)
+	if err != nil {
+		return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+			"failed to query volume %q on vCenter %q. Error: %+v", createSpec.Name, params.Vcenter.Config.Host, err)
+	}
+	if len(queryResult.Volumes) > 0 {
+		volume := queryResult.Volumes[0]
+		log.Infof("Volume %q with name %q is already registered", volume.VolumeId.Id, createSpec.Name)
+		return &cnsvolume.CnsVolumeInfo{VolumeID: volume.VolumeId, DatastoreURL: volume.DatastoreUrl}, "", nil
+	}
+
+	candidates := params.SharedDatastores
+	if params.StoragePolicyID != "" {
+		compat, err := params.Vcenter.PbmCheckCompatibility(ctx, getDatastoreMoRefs(candidates),
+			params.StoragePolicyID)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log, "failed to find datastore "+
+				"compatibility with storage policy ID %q. Error: %+v", params.StoragePolicyID, err)
+		}
+		compatibleDatastores := make(map[string]bool)
+		for _, hub := range compat.CompatibleDatastores() {
+			compatibleDatastores[hub.HubId] = true
+		}
+		candidates = nil
+		for _, ds := range params.SharedDatastores {
+			if compatibleDatastores[ds.Reference().Value] {
+				candidates = append(candidates, ds)
+			}
+		}
+	}
+	var datastore *vsphere.DatastoreInfo
+	for _, ds := range candidates {
+		if datastore == nil || ds.Info.FreeSpace > datastore.Info.FreeSpace {
+			datastore = ds
+		}
+	}
+	if datastore == nil {
+		return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+			"no compatible datastore found to create volume %q on vCenter %q",
+			createSpec.Name, params.Vcenter.Config.Host)
+	}
+
+	provisioningType := vim25types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeLazyZeroedThick
+	if params.Spec.ScParams.ProvisioningType == EagerZeroedThickProvisioningType {
+		provisioningType = vim25types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeEagerZeroedThick
+	}
+	disk, err := params.Vcenter.CreateDisk(ctx, vim25types.VslmCreateSpec{
+		Name:              createSpec.Name,
+		KeepAfterDeleteVm: vim25types.NewBool(true),
+		CapacityInMB:      createSpec.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb,
+		Profile:           createSpec.Profile,
+		BackingSpec: &vim25types.VslmCreateSpecDiskFileBackingSpec{
+			VslmCreateSpecBackingSpec: vim25types.VslmCreateSpecBackingSpec{
+				Datastore: datastore.Reference(),
+			},
+			ProvisioningType: string(provisioningType),
+		},
+	})
+	if err != nil {
+		return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+			"failed to create %s disk for volume %q on datastore %q. Error: %+v",
+			params.Spec.ScParams.ProvisioningType, createSpec.Name, datastore.Info.Url, err)
+	}
+	diskID := disk.Config.Id.Id
+
+	createSpec.VolumeId = nil
+	createSpec.Datastores = []vim25types.ManagedObjectReference{datastore.Reference()}
+	createSpec.BackingObjectDetails.(*cnstypes.CnsBlockBackingDetails).BackingDiskId = diskID
+	log.Debugf("vSphere CSI driver registering disk %q with create spec %+v", diskID, spew.Sdump(createSpec))
+	volumeInfo, faultType, err := params.VolumeManager.CreateVolume(ctx, createSpec, nil)
+	if err != nil {
+		log.Errorf("failed to register disk %q for volume %q on vCenter %q with error %+v faultType %q",
+			diskID, createSpec.Name, params.Vcenter.Config.Host, err, faultType)
+		if deleteErr := params.Vcenter.DeleteDisk(ctx, diskID); deleteErr != nil {
+			log.Errorf("failed to delete disk %q. Error: %+v", diskID, deleteErr)
+		}
+		return nil, faultType, err
+	}
+	return volumeInfo, "", nil
+}
+
 // CreateFileVolumeUtil is the helper function to create CNS file volume with
 // datastores.
 func CreateFileVolumeUtil(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavor,
@@ -589,6 +710,8 @@ func CreateFileVolumeUtil(ctx context.Context, clusterFlavor cnstypes.CnsCluster
 			// Currently, just return csi.fault.Internal.
 			return nil, csifault.CSIInternalFault, err
 		}
+	} else if spec.ScParams.StoragePolicyID != "" {
+		spec.StoragePolicyID = spec.ScParams.StoragePolicyID
 	}
 
 	var datastoreMorefs []vim25types.ManagedObjectReference
@@ -833,6 +956,148 @@ func ExpandVolumeUtil(ctx context.Context, vCenterManager vsphere.VirtualCenterM
 	return "", nil
 }
 
+// ModifyVolumePolicyUtil is the helper function to change the storage policy of
+// a block volume to the given storage policy ID. The policy is applied in place
+// if the datastore on which the volume resides is compatible with it.
+// Otherwise, the volume is relocated to the compatible datastore with the most
+// free space among candidateDatastores, and the policy is applied as part of
+// the relocation.
+func ModifyVolumePolicyUtil(ctx context.Context, vc *vsphere.VirtualCenter, volumeManager cnsvolume.Manager,
+	volumeID string, storagePolicyID string, candidateDatastores []*vsphere.DatastoreInfo) (string, error) {
+	log := logger.GetLogger(ctx)
+	querySelection := &cnstypes.CnsQuerySelection{
+		Names: []string{
+			string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+			string(cnstypes.QuerySelectionNameTypePolicyId),
+		},
+	}
+	volume, err := QueryVolumeByID(ctx, volumeManager, volumeID, querySelection)
+	if err != nil {
+		if err == ErrNotFound {
+			return csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+				"volume %q not found", volumeID)
+		}
+		return csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to query volume %q. Error: %+v", volumeID, err)
+	}
+	if volume.StoragePolicyId == storagePolicyID {
+		log.Infof("Volume %q is already associated with storage policy %q. Modification not required.",
+			volumeID, storagePolicyID)
+		return "", nil
+	}
+	datastoreInfos, err := getDatastoreInfoObjList(ctx, vc, volume.DatastoreUrl)
+	if err != nil {
+		return csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to find datastore %q of volume %q. Error: %+v", volume.DatastoreUrl, volumeID, err)
+	}
+	compat, err := vc.PbmCheckCompatibility(ctx, getDatastoreMoRefs(datastoreInfos), storagePolicyID)
+	if err != nil {
+		return csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to find datastore compatibility with storage policy ID %q. Error: %+v", storagePolicyID, err)
+	}
+	if len(compat.CompatibleDatastores()) != 0 {
+		log.Infof("Datastore %q of volume %q is compatible with storage policy %q. Reconfiguring the policy.",
+			volume.DatastoreUrl, volumeID, storagePolicyID)
+		faultType, err := volumeManager.ReconfigVolumePolicy(ctx, volumeID, storagePolicyID)
+		if err != nil {
+			return faultType, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to reconfigure storage policy of volume %q to %q. Error: %+v",
+				volumeID, storagePolicyID, err)
+		}
+		return "", nil
+	}
+
+	// The current datastore can not satisfy the new policy, so pick the
+	// compatible candidate datastore with the most free space.
+	log.Infof("Datastore %q of volume %q is not compatible with storage policy %q. Relocating the volume.",
+		volume.DatastoreUrl, volumeID, storagePolicyID)
+	var targetDatastore *vsphere.DatastoreInfo
+	if len(candidateDatastores) != 0 {
+		compat, err = vc.PbmCheckCompatibility(ctx, getDatastoreMoRefs(candidateDatastores), storagePolicyID)
+		if err != nil {
+			return csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to find datastore compatibility with storage policy ID %q. Error: %+v",
+				storagePolicyID, err)
+		}
+		compatibleDsMoids := make(map[string]struct{})
+		for _, ds := range compat.CompatibleDatastores() {
+			compatibleDsMoids[ds.HubId] = struct{}{}
+		}
+		for _, ds := range candidateDatastores {
+			if _, exists := compatibleDsMoids[ds.Reference().Value]; !exists {
+				continue
+			}
+			if targetDatastore == nil || ds.Info.FreeSpace > targetDatastore.Info.FreeSpace {
+				targetDatastore = ds
+			}
+		}
+	}
+	if targetDatastore == nil {
+		return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+			"no datastore compatible with storage policy %q is available to relocate volume %q",
+			storagePolicyID, volumeID)
+	}
+	relocateSpec := cnstypes.NewCnsBlockVolumeRelocateSpec(volumeID, targetDatastore.Reference(),
+		&vim25types.VirtualMachineDefinedProfileSpec{ProfileId: storagePolicyID})
+	task, err := volumeManager.RelocateVolume(ctx, relocateSpec)
+	if err != nil {
+		return cnsvolume.ExtractFaultTypeFromErr(ctx, err), logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to relocate volume %q to datastore %q. Error: %+v", volumeID, targetDatastore.Info.Url, err)
+	}
+	taskInfo, err := task.WaitForResultEx(ctx)
+	if err != nil {
+		return cnsvolume.ExtractFaultTypeFromErr(ctx, err), logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to relocate volume %q to datastore %q. Error: %+v", volumeID, targetDatastore.Info.Url, err)
+	}
+	results, ok := taskInfo.Result.(cnstypes.CnsVolumeOperationBatchResult)
+	if !ok {
+		return csifault.CSITaskResultEmptyFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"unexpected result %+v of relocate task for volume %q", taskInfo.Result, volumeID)
+	}
+	for _, result := range results.VolumeResults {
+		if fault := result.GetCnsVolumeOperationResult().Fault; fault != nil {
+			return cnsvolume.ExtractFaultTypeFromVolumeResponseResult(ctx, result.GetCnsVolumeOperationResult()),
+				logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to relocate volume %q to datastore %q. Fault: %+v", volumeID,
+					targetDatastore.Info.Url, spew.Sdump(fault))
+		}
+	}
+	log.Infof("Volume %q relocated to datastore %q with storage policy %q", volumeID,
+		targetDatastore.Info.Url, storagePolicyID)
+	return "", nil
+}
+
+// GetVolumeUtil is the helper function to query the capacity and health status
+// of the CNS volume for given volumeId. It returns the capacity of the volume
+// in bytes along with the CSI VolumeCondition mapped from the CNS health status.
+func GetVolumeUtil(ctx context.Context, volumeManager cnsvolume.Manager, volumeID string) (
+	*cnstypes.CnsVolume, int64, *csi.VolumeCondition, string, error) {
+	log := logger.GetLogger(ctx)
+	querySelection := &cnstypes.CnsQuerySelection{
+		Names: []string{
+			string(cnstypes.QuerySelectionNameTypeVolumeType),
+			string(cnstypes.QuerySelectionNameTypeBackingObjectDetails),
+			string(cnstypes.QuerySelectionNameTypeHealthStatus),
+		},
+	}
+	volume, err := QueryVolumeByID(ctx, volumeManager, volumeID, querySelection)
+	if err != nil {
+		if err == ErrNotFound {
+			return nil, 0, nil, csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+				"volume %q not found", volumeID)
+		}
+		return nil, 0, nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to query volume %q. Error: %+v", volumeID, err)
+	}
+	var capacityInBytes int64
+	if volume.BackingObjectDetails != nil && volume.BackingObjectDetails.GetCnsBackingObjectDetails() != nil {
+		capacityInBytes = volume.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb * MbInBytes
+	}
+	volumeCondition := GetVolumeCondition(ctx, volumeID, volume.HealthStatus)
+	log.Debugf("Volume %q has capacity %d bytes and condition %+v", volumeID, capacityInBytes, volumeCondition)
+	return volume, capacityInBytes, volumeCondition, "", nil
+}
+
 func ListSnapshotsUtil(ctx context.Context, volManager cnsvolume.Manager, volumeID string, snapshotID string,
 	token string, maxEntries int64) ([]*csi.Snapshot, string, error) {
 	log := logger.GetLogger(ctx)
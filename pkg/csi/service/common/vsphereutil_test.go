@@ -131,6 +131,16 @@ func (m *mockVolumeManager) SyncVolume(ctx context.Context,
 	syncVolumeSpecs []cnstypes.CnsSyncVolumeSpec) (string, error) {
 	return "", nil
 }
+
+func (m *mockVolumeManager) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string,
+	volumeIDs []string) ([]*cnsvolume.CnsSnapshotInfo, error) {
+	return nil, nil
+}
+
+func (m *mockVolumeManager) ReconfigVolumePolicy(ctx context.Context, volumeID string,
+	storagePolicyID string) (string, error) {
+	return "", nil
+}
 func TestQueryVolumeSnapshotsByVolumeIDWithQuerySnapshotsCnsVolumeNotFoundFault(t *testing.T) {
 	// Skip test on ARM64 due to gomonkey limitations
 	if runtime.GOARCH == "arm64" {
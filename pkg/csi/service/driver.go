@@ -121,9 +121,14 @@ func (driver *vsphereCSIDriver) BeforeServe(ctx context.Context) error {
 		cfg *cnsconfig.Config
 	)
 
+	orchestratorType, err := commonco.GetContainerOrchestratorType(ctx)
+	if err != nil {
+		log.Errorf("Failed to get the container orchestrator. Error: %v", err)
+		return err
+	}
 	// Initialize CO utility in Nodes.
 	commonco.ContainerOrchestratorUtility, err = commonco.GetContainerOrchestratorInterface(
-		ctx, common.Kubernetes, clusterFlavor, COInitParams)
+		ctx, orchestratorType, clusterFlavor, COInitParams)
 	if err != nil {
 		log.Errorf("Failed to create CO agnostic interface. Error: %v", err)
 		return err
@@ -213,6 +218,10 @@ func (driver *vsphereCSIDriver) Run(ctx context.Context, endpoint string) {
 		os.Exit(1)
 	}
 
+	if address := os.Getenv(csitypes.EnvVarHealthAddress); address != "" {
+		driver.startHealthServer(ctx, address)
+	}
+
 	//Start the nonblocking GRPC
 	grpc := NewNonBlockingGRPCServer()
 	grpc.Start(endpoint, driver, controllerServer, driver)
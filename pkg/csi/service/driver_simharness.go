@@ -0,0 +1,38 @@
+//go:build simharness
+// +build simharness
+
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/osutils"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+)
+
+// NewDriverWithController returns a new Driver which serves the given CNS
+// controller and uses the given OsUtils for the node service. The controller
+// must already be initialized, so the Driver does not need BeforeServe. This
+// is used to serve the driver against a vCenter simulator.
+func NewDriverWithController(cnscs csitypes.CnsController, osUtils *osutils.OsUtils) Driver {
+	return &vsphereCSIDriver{
+		cnscs:       cnscs,
+		osUtils:     osUtils,
+		volumeLocks: node.NewVolumeLocks(),
+	}
+}
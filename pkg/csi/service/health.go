@@ -0,0 +1,174 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+
+	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+)
+
+const (
+	// healthCheckSystemUUID is the name of the check of the system UUID of
+	// the node.
+	healthCheckSystemUUID = "system-uuid"
+	// healthCheckTopology is the name of the check of the topology service of
+	// the node.
+	healthCheckTopology = "topology-service"
+	// healthCheckTimeout is the timeout of the checks run for a request to
+	// the health endpoints.
+	healthCheckTimeout = 10 * time.Second
+)
+
+// healthResponse is the body of the responses of the health endpoints.
+type healthResponse struct {
+	Healthy bool                   `json:"healthy"`
+	Checks  []csitypes.HealthCheck `json:"checks"`
+}
+
+// healthChecks runs the health checks of the service mode of the driver. In
+// controller mode, the checks are run by the CnsController if it implements
+// csitypes.HealthChecker.
+func (driver *vsphereCSIDriver) healthChecks(ctx context.Context) []csitypes.HealthCheck {
+	if strings.EqualFold(driver.mode, "node") {
+		return driver.nodeHealthChecks(ctx)
+	}
+	if healthChecker, ok := driver.cnscs.(csitypes.HealthChecker); ok {
+		return healthChecker.HealthChecks(ctx)
+	}
+	return nil
+}
+
+// livenessChecks runs the health checks of the local process only, so that
+// the driver is not restarted when a vCenter or the API server is
+// unreachable. In node mode, the system UUID of the node must be readable.
+// In controller mode, the process is alive as long as it serves the request.
+func (driver *vsphereCSIDriver) livenessChecks(ctx context.Context) []csitypes.HealthCheck {
+	if !strings.EqualFold(driver.mode, "node") {
+		return nil
+	}
+	clusterFlavor, err := cnsconfig.GetClusterFlavor(ctx)
+	if err != nil {
+		return []csitypes.HealthCheck{{Name: "cluster-flavor", Message: err.Error()}}
+	}
+	if clusterFlavor == cnstypes.CnsClusterFlavorGuest {
+		return nil
+	}
+	return []csitypes.HealthCheck{driver.systemUUIDHealthCheck(ctx)}
+}
+
+// nodeHealthChecks checks that the system UUID of the node can be read and
+// that the topology service of the node can be initialized, for the cluster
+// flavors whose NodeGetInfo needs them.
+func (driver *vsphereCSIDriver) nodeHealthChecks(ctx context.Context) []csitypes.HealthCheck {
+	clusterFlavor, err := cnsconfig.GetClusterFlavor(ctx)
+	if err != nil {
+		return []csitypes.HealthCheck{{Name: "cluster-flavor", Message: err.Error()}}
+	}
+	var checks []csitypes.HealthCheck
+	if clusterFlavor != cnstypes.CnsClusterFlavorGuest {
+		checks = append(checks, driver.systemUUIDHealthCheck(ctx))
+	}
+	if clusterFlavor == cnstypes.CnsClusterFlavorVanilla || (clusterFlavor == cnstypes.CnsClusterFlavorGuest &&
+		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.TKGsHA)) {
+		check := csitypes.HealthCheck{Name: healthCheckTopology, Healthy: true}
+		if err := initVolumeTopologyService(ctx); err != nil {
+			check.Healthy = false
+			check.Message = err.Error()
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// systemUUIDHealthCheck checks that the system UUID of the node can be read.
+func (driver *vsphereCSIDriver) systemUUIDHealthCheck(ctx context.Context) csitypes.HealthCheck {
+	check := csitypes.HealthCheck{Name: healthCheckSystemUUID, Healthy: true}
+	if _, err := driver.osUtils.GetSystemUUID(ctx); err != nil {
+		check.Healthy = false
+		check.Message = fmt.Sprintf("failed to get system uuid for node VM: %v", err)
+	}
+	return check
+}
+
+// unhealthyReason returns the messages of the failed checks, or an empty
+// string if all the checks passed.
+func unhealthyReason(checks []csitypes.HealthCheck) string {
+	var reasons []string
+	for _, check := range checks {
+		if !check.Healthy {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// healthHandler returns an HTTP handler running the given health checks. It
+// responds with the result of every check, with status 200 if all the checks
+// passed and 503 otherwise.
+func healthHandler(healthChecks func(ctx context.Context) []csitypes.HealthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(logger.NewContextWithLogger(r.Context()), healthCheckTimeout)
+		defer cancel()
+		log := logger.GetLogger(ctx)
+		checks := healthChecks(ctx)
+		resp := healthResponse{Healthy: unhealthyReason(checks) == "", Checks: checks}
+		if resp.Checks == nil {
+			resp.Checks = []csitypes.HealthCheck{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !resp.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Errorf("failed to write the response of %s. Err: %v", r.URL.Path, err)
+		}
+	}
+}
+
+// startHealthServer starts the HTTP server exposing the /healthz and /readyz
+// endpoints at the given address. /healthz is meant for the liveness probe
+// and only runs the checks of the local process, while /readyz runs all the
+// checks, including the ones of the vCenters in controller mode.
+func (driver *vsphereCSIDriver) startHealthServer(ctx context.Context, address string) {
+	log := logger.GetLogger(ctx)
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", healthHandler(driver.livenessChecks))
+	mux.Handle("/readyz", healthHandler(driver.healthChecks))
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: healthCheckTimeout,
+	}
+	go func() {
+		log.Infof("Starting the http server to expose the health endpoints at %s", address)
+		if err := server.ListenAndServe(); err != nil {
+			log.Errorf("Http server that exposes the health endpoints exited with err: %+v", err)
+		}
+	}()
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+)
+
+// fakeHealthCheckController is a CnsController reporting the given checks.
+type fakeHealthCheckController struct {
+	csi.UnimplementedControllerServer
+	checks []csitypes.HealthCheck
+}
+
+func (c *fakeHealthCheckController) Init(config *config.Config, version string) error {
+	return nil
+}
+
+func (c *fakeHealthCheckController) HealthChecks(ctx context.Context) []csitypes.HealthCheck {
+	return c.checks
+}
+
+func TestProbe(t *testing.T) {
+	ctx := context.Background()
+	cnscs := &fakeHealthCheckController{
+		checks: []csitypes.HealthCheck{
+			{Name: "vcenter-session/vc1", Healthy: true},
+			{Name: "listview/vc1", Healthy: true},
+		},
+	}
+	driver := &vsphereCSIDriver{cnscs: cnscs}
+	driver.mode = "controller"
+
+	resp, err := driver.Probe(ctx, &csi.ProbeRequest{})
+	assert.NoError(t, err)
+	assert.True(t, resp.GetReady().GetValue())
+
+	// The driver is not restarted by the livenessprobe sidecar when a vCenter
+	// is unhealthy.
+	cnscs.checks[1] = csitypes.HealthCheck{Name: "listview/vc1", Message: "listview of vCenter \"vc1\" is not ready"}
+	resp, err = driver.Probe(ctx, &csi.ProbeRequest{})
+	assert.NoError(t, err)
+	assert.True(t, resp.GetReady().GetValue())
+}
+
+func TestHealthHandler(t *testing.T) {
+	checks := []csitypes.HealthCheck{{Name: "vcenter-session/vc1", Healthy: true}}
+	handler := healthHandler(func(ctx context.Context) []csitypes.HealthCheck { return checks })
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var resp healthResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, healthResponse{Healthy: true, Checks: checks}, resp)
+
+	checks = append(checks, csitypes.HealthCheck{Name: "vcenter-session/vc2", Message: "vCenter \"vc2\" is disconnected"})
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	resp = healthResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, healthResponse{Healthy: false, Checks: checks}, resp)
+	assert.Equal(t, "vcenter-session/vc2: vCenter \"vc2\" is disconnected", unhealthyReason(checks))
+}
+
+func TestLivenessChecks(t *testing.T) {
+	cnscs := &fakeHealthCheckController{
+		checks: []csitypes.HealthCheck{
+			{Name: "vcenter-session/vc1", Message: "vCenter \"vc1\" is disconnected"},
+		},
+	}
+	driver := &vsphereCSIDriver{cnscs: cnscs}
+	driver.mode = "controller"
+
+	// A disconnected vCenter makes the controller not ready, but it does not
+	// fail the liveness probe.
+	recorder := httptest.NewRecorder()
+	healthHandler(driver.healthChecks)(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	recorder = httptest.NewRecorder()
+	healthHandler(driver.livenessChecks)(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
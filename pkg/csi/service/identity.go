@@ -20,18 +20,33 @@ import (
 	"context"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
 )
 
 // Version of the driver. This should be set via ldflags.
 var Version string
 
+// Probe reports the driver as not ready if any of its liveness checks fails,
+// e.g. if the system UUID cannot be read in node mode. The livenessprobe
+// sidecar restarts the driver when Probe fails, so the health of the vCenter
+// servers is only reported by /readyz, metrics and events.
 func (driver *vsphereCSIDriver) Probe(
 	ctx context.Context,
 	req *csi.ProbeRequest) (
 	*csi.ProbeResponse, error) {
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
 
-	return &csi.ProbeResponse{}, nil
+	if reason := unhealthyReason(driver.livenessChecks(ctx)); reason != "" {
+		log.Warnf("Probe: driver is not ready. Reason: %s", reason)
+		return &csi.ProbeResponse{Ready: wrapperspb.Bool(false)}, nil
+	}
+	return &csi.ProbeResponse{Ready: wrapperspb.Bool(true)}, nil
 }
 
 func (driver *vsphereCSIDriver) GetPluginInfo(
@@ -68,5 +83,28 @@ func (driver *vsphereCSIDriver) GetPluginCapabilities(
 			},
 		},
 	}
+	// The SnapshotMetadata service is advertised only when block volume
+	// snapshots are enabled, as it serves Unimplemented otherwise.
+	if _, ok := driver.cnscs.(csi.SnapshotMetadataServer); ok &&
+		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+		rep.Capabilities = append(rep.Capabilities, &csi.PluginCapability{
+			Type: &csi.PluginCapability_Service_{
+				Service: &csi.PluginCapability_Service{
+					Type: csi.PluginCapability_Service_SNAPSHOT_METADATA_SERVICE,
+				},
+			},
+		})
+	}
+	// Likewise for the GroupController service.
+	if _, ok := driver.cnscs.(csi.GroupControllerServer); ok &&
+		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+		rep.Capabilities = append(rep.Capabilities, &csi.PluginCapability{
+			Type: &csi.PluginCapability_Service_{
+				Service: &csi.PluginCapability_Service{
+					Type: csi.PluginCapability_Service_GROUP_CONTROLLER_SERVICE,
+				},
+			},
+		})
+	}
 	return rep, nil
 }
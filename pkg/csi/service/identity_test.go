@@ -0,0 +1,64 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+)
+
+// fakeSnapshotController is a CnsController which also serves the
+// SnapshotMetadata and GroupController services.
+type fakeSnapshotController struct {
+	fakeHealthCheckController
+	csi.UnimplementedSnapshotMetadataServer
+	csi.UnimplementedGroupControllerServer
+}
+
+func TestGetPluginCapabilitiesSnapshotServices(t *testing.T) {
+	ctx := context.Background()
+	co, err := unittestcommon.GetFakeContainerOrchestratorInterface(common.Kubernetes)
+	assert.NoError(t, err)
+	origCO := commonco.ContainerOrchestratorUtility
+	commonco.ContainerOrchestratorUtility = co
+	defer func() { commonco.ContainerOrchestratorUtility = origCO }()
+	driver := &vsphereCSIDriver{cnscs: &fakeSnapshotController{}}
+
+	hasService := func(serviceType csi.PluginCapability_Service_Type) bool {
+		resp, err := driver.GetPluginCapabilities(ctx, &csi.GetPluginCapabilitiesRequest{})
+		assert.NoError(t, err)
+		for _, c := range resp.GetCapabilities() {
+			if c.GetService().GetType() == serviceType {
+				return true
+			}
+		}
+		return false
+	}
+	assert.NoError(t, co.EnableFSS(ctx, common.BlockVolumeSnapshot))
+	assert.True(t, hasService(csi.PluginCapability_Service_SNAPSHOT_METADATA_SERVICE))
+	assert.True(t, hasService(csi.PluginCapability_Service_GROUP_CONTROLLER_SERVICE))
+	assert.NoError(t, co.DisableFSS(ctx, common.BlockVolumeSnapshot))
+	assert.False(t, hasService(csi.PluginCapability_Service_SNAPSHOT_METADATA_SERVICE))
+	assert.False(t, hasService(csi.PluginCapability_Service_GROUP_CONTROLLER_SERVICE))
+}
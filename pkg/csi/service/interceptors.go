@@ -0,0 +1,199 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/tracing"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// unaryServerInterceptors returns the interceptors chained around every unary
+// RPC served by the CSI driver. The first interceptor is the outermost one.
+func unaryServerInterceptors() []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		traceUnaryServerInterceptor,
+		metricsUnaryServerInterceptor,
+		recoveryUnaryServerInterceptor,
+	}
+}
+
+// streamServerInterceptors returns the interceptors chained around every
+// streaming RPC served by the CSI driver, e.g. the SnapshotMetadata RPCs.
+func streamServerInterceptors() []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		traceStreamServerInterceptor,
+		metricsStreamServerInterceptor,
+		recoveryStreamServerInterceptor,
+	}
+}
+
+// traceUnaryServerInterceptor starts a server span for the RPC and sets a
+// logger with the trace ID of the RPC in its context.
+func traceUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := startRPCSpan(ctx, info.FullMethod)
+	defer span.End()
+	resp, err := handler(ctx, req)
+	endRPCSpan(span, err)
+	return resp, err
+}
+
+// metricsUnaryServerInterceptor records the latency and status code of the
+// RPC.
+func metricsUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeRPC(info.FullMethod, start, err)
+	return resp, err
+}
+
+// recoveryUnaryServerInterceptor converts a panic of the RPC handler into a
+// codes.Internal error instead of crashing the driver.
+func recoveryUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredRPCError(ctx, info.FullMethod, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// traceStreamServerInterceptor is the streaming counterpart of
+// traceUnaryServerInterceptor.
+func traceStreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	ctx, span := startRPCSpan(ss.Context(), info.FullMethod)
+	defer span.End()
+	err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	endRPCSpan(span, err)
+	return err
+}
+
+// metricsStreamServerInterceptor is the streaming counterpart of
+// metricsUnaryServerInterceptor.
+func metricsStreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeRPC(info.FullMethod, start, err)
+	return err
+}
+
+// recoveryStreamServerInterceptor is the streaming counterpart of
+// recoveryUnaryServerInterceptor.
+func recoveryStreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredRPCError(ss.Context(), info.FullMethod, r)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// startRPCSpan starts the server span of the given RPC and returns a context
+// carrying the span and a logger with the trace ID of the RPC. The span is a
+// child of the span propagated by the caller in the gRPC metadata, if any.
+// The trace ID is the OpenTelemetry trace ID if tracing is enabled, and a new
+// UUID otherwise.
+func startRPCSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	}
+	ctx, span := tracing.StartSpan(ctx, method, trace.WithSpanKind(trace.SpanKindServer))
+	traceID := uuid.New().String()
+	if spanContext := span.SpanContext(); spanContext.HasTraceID() {
+		traceID = spanContext.TraceID().String()
+	}
+	return logger.NewContextWithTraceID(ctx, traceID), span
+}
+
+// endRPCSpan sets the status of the server span of an RPC from its error.
+func endRPCSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, status.Code(err).String())
+	}
+}
+
+// observeRPC records the latency and status code of the given RPC.
+func observeRPC(method string, start time.Time, err error) {
+	prometheus.CsiGrpcRequestOpsHistVec.WithLabelValues(method, status.Code(err).String()).
+		Observe(time.Since(start).Seconds())
+}
+
+// recoveredRPCError logs the panic recovered from the handler of the given
+// RPC and returns the codes.Internal error returned to the client.
+func recoveredRPCError(ctx context.Context, method string, r interface{}) error {
+	log := logger.GetLogger(ctx)
+	log.Errorf("Recovered from panic in %s: %v\n%s", method, r, debug.Stack())
+	return status.Errorf(codes.Internal, "panic in %s: %v", method, r)
+}
+
+// metadataCarrier adapts the gRPC metadata to the OpenTelemetry
+// propagation.TextMapCarrier interface.
+type metadataCarrier metadata.MD
+
+// Get returns the first value of the given key.
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set sets the value of the given key.
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys returns the keys of the metadata.
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// contextServerStream is a grpc.ServerStream with a replaced context.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream.
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
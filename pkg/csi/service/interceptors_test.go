@@ -0,0 +1,138 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// chainUnaryServerInterceptors invokes the handler through the unary server
+// interceptors the way the gRPC server does.
+func chainUnaryServerInterceptors(ctx context.Context, method string,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	interceptors := unaryServerInterceptors()
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+	return handler(ctx, nil)
+}
+
+// rpcSampleCount returns the number of RPCs with the given method and status
+// code observed by the RPC metrics.
+func rpcSampleCount(t *testing.T, method string, code codes.Code) uint64 {
+	metric := &dto.Metric{}
+	observer := prometheus.CsiGrpcRequestOpsHistVec.WithLabelValues(method, code.String())
+	if err := observer.(promclient.Metric).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestUnaryServerInterceptorsTraceID(t *testing.T) {
+	var traceIDs []string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		// The per-RPC logger of the controllers keeps the trace ID.
+		ctx = logger.NewContextWithLogger(ctx)
+		traceIDs = append(traceIDs, logger.GetTraceID(ctx))
+		return "ok", nil
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := chainUnaryServerInterceptors(context.Background(), "/test.Service/TraceID", handler)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+	}
+	assert.Len(t, traceIDs, 2)
+	assert.NotEmpty(t, traceIDs[0])
+	assert.NotEqual(t, traceIDs[0], traceIDs[1])
+}
+
+func TestUnaryServerInterceptorsRecovery(t *testing.T) {
+	method := "/test.Service/Panic"
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("test panic")
+	}
+	_, err := chainUnaryServerInterceptors(context.Background(), method, handler)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, err.Error(), "test panic")
+	assert.Equal(t, uint64(1), rpcSampleCount(t, method, codes.Internal))
+}
+
+func TestUnaryServerInterceptorsMetrics(t *testing.T) {
+	method := "/test.Service/Metrics"
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	_, err := chainUnaryServerInterceptors(context.Background(), method, handler)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	_, err = chainUnaryServerInterceptors(context.Background(), method, handler)
+	assert.NoError(t, err)
+
+	for _, code := range []codes.Code{codes.NotFound, codes.OK} {
+		assert.Equal(t, uint64(1), rpcSampleCount(t, method, code))
+	}
+}
+
+func TestUnaryServerInterceptorsTraceContext(t *testing.T) {
+	origProvider, origPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(origProvider)
+		otel.SetTextMapPropagator(origPropagator)
+	}()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	// The span of the RPC joins the trace propagated by the caller.
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"traceparent", "00-"+traceID+"-00f067aa0ba902b7-01"))
+	var rpcTraceID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		rpcTraceID = logger.GetTraceID(ctx)
+		return "ok", nil
+	}
+	_, err := chainUnaryServerInterceptors(ctx, "/test.Service/TraceContext", handler)
+	assert.NoError(t, err)
+	assert.Equal(t, traceID, rpcTraceID)
+
+	// A new trace is started if the caller did not propagate one.
+	_, err = chainUnaryServerInterceptors(context.Background(), "/test.Service/TraceContext", handler)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, rpcTraceID)
+	assert.NotEqual(t, traceID, rpcTraceID)
+}
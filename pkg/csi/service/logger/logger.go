@@ -36,6 +36,10 @@ var (
 // loggerKey holds the context key used for loggers.
 type loggerKey struct{}
 
+// traceIDKey holds the context key used for the trace ID set by
+// NewContextWithTraceID.
+type traceIDKey struct{}
+
 // SetLoggerLevel helps set defaultLogLevel, using which newLogger func helps
 // create either development logger or production logger
 func SetLoggerLevel(logLevel LogLevel) {
@@ -62,12 +66,31 @@ func GetLogger(ctx context.Context) *zap.SugaredLogger {
 }
 
 // NewContextWithLogger returns a new child context with context UUID set
-// using key CtxId.
+// using key CtxId. If ctx already has a trace ID set by NewContextWithTraceID,
+// e.g. by the gRPC interceptors of the CSI server, ctx is returned as is so
+// that all the logs of a request share the same trace ID.
 func NewContextWithLogger(ctx context.Context) context.Context {
+	if GetTraceID(ctx) != "" {
+		return ctx
+	}
 	newCtx := withFields(ctx, zap.String(LogCtxIDKey, uuid.New().String()))
 	return newCtx
 }
 
+// NewContextWithTraceID returns a new child context with a logger logging the
+// given trace ID using key LogCtxIDKey.
+func NewContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	newCtx := withFields(ctx, zap.String(LogCtxIDKey, traceID))
+	return context.WithValue(newCtx, traceIDKey{}, traceID)
+}
+
+// GetTraceID returns the trace ID set in ctx by NewContextWithTraceID, or an
+// empty string if there is none.
+func GetTraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
 // GetNewContextWithLogger creates a new context with context UUID and logger
 // set func returns both context and logger to the caller.
 func GetNewContextWithLogger() (context.Context, *zap.SugaredLogger) {
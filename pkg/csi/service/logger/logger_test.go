@@ -17,6 +17,7 @@ limitations under the License.
 package logger
 
 import (
+	"context"
 	"testing"
 
 	"google.golang.org/grpc/codes"
@@ -54,6 +55,20 @@ func TestLogNewErrorCodef(t *testing.T) {
 	}
 }
 
+func TestNewContextWithTraceID(t *testing.T) {
+	ctx := NewContextWithTraceID(context.Background(), "trace-1")
+	if traceID := GetTraceID(ctx); traceID != "trace-1" {
+		t.Errorf("Expected trace ID trace-1, got %q", traceID)
+	}
+	// NewContextWithLogger should keep the trace ID of the request.
+	if newCtx := NewContextWithLogger(ctx); newCtx != ctx {
+		t.Error("NewContextWithLogger replaced the context with a trace ID")
+	}
+	if traceID := GetTraceID(NewContextWithLogger(context.Background())); traceID != "" {
+		t.Errorf("Expected no trace ID, got %q", traceID)
+	}
+}
+
 func BenchmarkLogNewError(b *testing.B) {
 	log := GetLoggerWithNoContext()
 	b.ResetTimer()
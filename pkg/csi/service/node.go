@@ -25,6 +25,7 @@ import (
 	cnstypes "github.com/vmware/govmomi/cns/types"
 	"github.com/vmware/govmomi/units"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
 
 	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
@@ -41,6 +42,10 @@ const (
 	// If Customer is using vSphere 8.0, they are allowed to set MAX_VOLUMES_PER_NODE to 255
 	// when CSI is released with feature-gate - max-pvscsi-targets-per-vm enabled
 	maxAllowedBlockVolumesPerNodeInvSphere8 = 255
+	// defaultReservedVolumeSlotsPerNode is the number of disk slots of the
+	// Node VM reserved for the OS disk, unless RESERVED_VOLUME_SLOTS_PER_NODE
+	// is set.
+	defaultReservedVolumeSlotsPerNode = 1
 )
 
 var topologyService commoncotypes.NodeTopologyService
@@ -51,7 +56,7 @@ func (driver *vsphereCSIDriver) NodeStageVolume(
 	*csi.NodeStageVolumeResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodeStageVolume: called with args %+v", req)
+	log.Infof("NodeStageVolume: called with args %+v", redactSecrets(req))
 
 	volumeID := req.GetVolumeId()
 	volCap := req.GetVolumeCapability()
@@ -103,10 +108,49 @@ func (driver *vsphereCSIDriver) NodeStageVolume(
 		if _, err = driver.osUtils.VerifyTargetDir(ctx, params.StagingTarget, true); err != nil {
 			return nil, err
 		}
+
+		if req.GetVolumeContext()[common.AttributeLuksEncryption] == "true" {
+			if req.GetSecrets()[common.LuksPassphraseSecretKey] == "" {
+				return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"NodeStageVolume failed: secret key %q is required for LUKS encrypted volume %q",
+					common.LuksPassphraseSecretKey, volumeID)
+			}
+			params.LuksEncrypted = true
+		}
+		// The filesystem options are validated once more, as they are passed
+		// to mkfs and fsck on the node.
+		params.MkfsOptions, err = common.ParseMkfsOptions(params.FsType,
+			req.GetVolumeContext()[common.AttributeMkfsOptions])
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"NodeStageVolume failed: invalid %q of volume %q. Err: %v", common.AttributeMkfsOptions, volumeID, err)
+		}
+		params.FsckMode = req.GetVolumeContext()[common.AttributeFsckMode]
+		if err = common.ValidateFsckMode(params.FsType, params.FsckMode); err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"NodeStageVolume failed: invalid %q of volume %q. Err: %v", common.AttributeFsckMode, volumeID, err)
+		}
 	}
 	return driver.osUtils.NodeStageBlockVolume(ctx, req, params)
 }
 
+// redactSecrets returns a copy of the request with the values of its secrets
+// redacted, so that the request can be logged.
+func redactSecrets[T interface {
+	GetSecrets() map[string]string
+	proto.Message
+}](req T) T {
+	if len(req.GetSecrets()) == 0 {
+		return req
+	}
+	redacted := proto.Clone(req).(T)
+	secrets := redacted.GetSecrets()
+	for key := range secrets {
+		secrets[key] = "***stripped***"
+	}
+	return redacted
+}
+
 func (driver *vsphereCSIDriver) NodeUnstageVolume(
 	ctx context.Context,
 	req *csi.NodeUnstageVolumeRequest) (
@@ -178,7 +222,7 @@ func (driver *vsphereCSIDriver) NodePublishVolume(
 	*csi.NodePublishVolumeResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodePublishVolume: called with args %+v", req)
+	log.Infof("NodePublishVolume: called with args %+v", redactSecrets(req))
 	var err error
 	volumeID := req.GetVolumeId()
 	if len(volumeID) == 0 {
@@ -297,6 +341,13 @@ func (driver *vsphereCSIDriver) NodeGetVolumeStats(
 			"received empty targetpath %q", targetPath)
 	}
 
+	// Check the condition of the volume before collecting metrics, as the
+	// metrics can not be collected from a stale or missing mount.
+	volumeCondition := driver.osUtils.GetVolumeCondition(ctx, targetPath)
+	if volumeCondition.Abnormal {
+		return &csi.NodeGetVolumeStatsResponse{VolumeCondition: volumeCondition}, nil
+	}
+
 	volMetrics, err := driver.osUtils.GetMetrics(ctx, targetPath)
 	if err != nil {
 		return nil, logger.LogNewErrorCode(log, codes.Internal, err.Error())
@@ -341,6 +392,7 @@ func (driver *vsphereCSIDriver) NodeGetVolumeStats(
 				Unit:      csi.VolumeUsage_INODES,
 			},
 		},
+		VolumeCondition: volumeCondition,
 	}, nil
 }
 
@@ -372,6 +424,13 @@ func (driver *vsphereCSIDriver) NodeGetCapabilities(
 					},
 				},
 			},
+			{
+				Type: &csi.NodeServiceCapability_Rpc{
+					Rpc: &csi.NodeServiceCapability_RPC{
+						Type: csi.NodeServiceCapability_RPC_VOLUME_CONDITION,
+					},
+				},
+			},
 		},
 	}, nil
 }
@@ -380,10 +439,10 @@ func (driver *vsphereCSIDriver) NodeGetCapabilities(
 // `NodeId` and `AccessibleTopology`. However, for sending `MaxVolumesPerNode`
 // in the response, it is not straight forward since vSphere CSI driver
 // supports both block and file volume. For block volume, max volumes to be
-// attached is deterministic by inspecting SCSI controllers of the VM, but for
-// file volume, this is not deterministic. We can not set this limit on
-// MaxVolumesPerNode, since single driver is used for both block and file
-// volumes.
+// attached is deterministic by inspecting SCSI and NVMe controllers of the VM,
+// but for file volume, this is not deterministic. Unless MAX_VOLUMES_PER_NODE
+// is set, MaxVolumesPerNode is computed from the controllers of the VM, as
+// file volumes do not take any of their slots.
 func (driver *vsphereCSIDriver) NodeGetInfo(
 	ctx context.Context,
 	req *csi.NodeGetInfoRequest) (
@@ -440,6 +499,11 @@ func (driver *vsphereCSIDriver) NodeGetInfo(
 			return nil, logger.LogNewErrorCodef(log, codes.Internal,
 				"NodeGetInfo: MAX_VOLUMES_PER_NODE set in env variable %v is invalid", v)
 		}
+	} else {
+		maxVolumesPerNode, err = driver.getMaxVolumesFromVolumeSlots(ctx, maxAllowedVolumesPerNode)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var (
@@ -497,6 +561,42 @@ func (driver *vsphereCSIDriver) NodeGetInfo(
 	return nodeInfoResponse, nil
 }
 
+// getMaxVolumesFromVolumeSlots returns the number of volumes that can be
+// attached to the Node VM, which is the number of disks its SCSI and NVMe
+// controllers can hold at least, less the slots reserved for the OS and other
+// non-CSI disks. 0, i.e. no limit, is returned when the controllers are not
+// discovered.
+func (driver *vsphereCSIDriver) getMaxVolumesFromVolumeSlots(ctx context.Context,
+	maxAllowedVolumesPerNode int64) (int64, error) {
+	log := logger.GetLogger(ctx)
+	slots, err := driver.osUtils.GetVolumeSlots(ctx)
+	if err != nil {
+		log.Warnf("NodeGetInfo: failed to discover the volume slots of the node VM. Error: %v", err)
+		return 0, nil
+	}
+	if slots == 0 {
+		log.Infof("NodeGetInfo: no volume slots discovered on the node VM")
+		return 0, nil
+	}
+	var reserved int64 = defaultReservedVolumeSlotsPerNode
+	if v := os.Getenv("RESERVED_VOLUME_SLOTS_PER_NODE"); v != "" {
+		reserved, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || reserved < 0 {
+			return 0, logger.LogNewErrorCodef(log, codes.Internal,
+				"NodeGetInfo: RESERVED_VOLUME_SLOTS_PER_NODE set in env variable %v is invalid", v)
+		}
+	}
+	if reserved >= slots {
+		return 0, logger.LogNewErrorCodef(log, codes.Internal,
+			"NodeGetInfo: RESERVED_VOLUME_SLOTS_PER_NODE set in env variable %v leaves none of the %v "+
+				"volume slots of the node VM", reserved, slots)
+	}
+	maxVolumesPerNode := min(slots-reserved, maxAllowedVolumesPerNode)
+	log.Infof("NodeGetInfo: %v volume slots discovered on the node VM with %v reserved. "+
+		"MaxVolumesPerNode is set to %v", slots, reserved, maxVolumesPerNode)
+	return maxVolumesPerNode, nil
+}
+
 // initVolumeTopologyService is a helper method to initialize
 // TopologyService in node.
 func initVolumeTopologyService(ctx context.Context) error {
@@ -560,11 +660,22 @@ func (driver *vsphereCSIDriver) NodeExpandVolume(
 	log.Debugf("NodeExpandVolume: staging target path %s, getDevFromMount %+v", volumePath, *dev)
 
 	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.OnlineVolumeExtend) {
+		// The size of a LUKS device only changes once it is resized, so the
+		// disk backing it is checked and rescanned instead.
+		rescanDev := dev
+		backingDev, err := driver.osUtils.GetLuksBackingDevice(ctx, dev.RealDev)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"error getting disk backing device %s: %v", dev.RealDev, err)
+		}
+		if backingDev != nil {
+			rescanDev = backingDev
+		}
 		// Fetch the current block size.
-		currentBlockSizeBytes, err := driver.osUtils.GetBlockSizeBytes(ctx, dev.RealDev)
+		currentBlockSizeBytes, err := driver.osUtils.GetBlockSizeBytes(ctx, rescanDev.RealDev)
 		if err != nil {
 			return nil, logger.LogNewErrorCodef(log, codes.Internal,
-				"error when getting size of block volume at path %s: %v", dev.RealDev, err)
+				"error when getting size of block volume at path %s: %v", rescanDev.RealDev, err)
 		}
 		// Check if a rescan is required.
 		if currentBlockSizeBytes < reqVolSizeBytes {
@@ -572,7 +683,7 @@ func (driver *vsphereCSIDriver) NodeExpandVolume(
 			// rescan the device on the guest OS in order to see the modified size
 			// on the Guest OS.
 			// Refer to https://kb.vmware.com/s/article/1006371
-			err = driver.osUtils.RescanDevice(ctx, dev)
+			err = driver.osUtils.RescanDevice(ctx, rescanDev)
 			if err != nil {
 				return nil, logger.LogNewErrorCode(log, codes.Internal, err.Error())
 			}
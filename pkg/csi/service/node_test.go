@@ -367,6 +367,7 @@ func TestNodeGetCapabilities(t *testing.T) {
 		csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
 		csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
 		csi.NodeServiceCapability_RPC_EXPAND_VOLUME,
+		csi.NodeServiceCapability_RPC_VOLUME_CONDITION,
 	}
 
 	actualCaps := make([]csi.NodeServiceCapability_RPC_Type, 0)
@@ -452,3 +453,24 @@ func TestOsUtilsInitialization(t *testing.T) {
 	// OsUtils is not initialized when CO initialization fails because it happens after CO init
 	assert.Nil(t, driver.osUtils, "OsUtils should not be initialized when CO initialization fails")
 }
+
+func TestRedactSecrets(t *testing.T) {
+	stageReq := &csi.NodeStageVolumeRequest{
+		VolumeId: "vol-1",
+		Secrets:  map[string]string{"passphrase": "secret"},
+	}
+	redacted := redactSecrets(stageReq)
+	assert.Equal(t, "vol-1", redacted.VolumeId)
+	assert.Equal(t, map[string]string{"passphrase": "***stripped***"}, redacted.Secrets)
+	// The request itself is left unchanged.
+	assert.Equal(t, "secret", stageReq.Secrets["passphrase"])
+
+	publishReq := &csi.NodePublishVolumeRequest{
+		VolumeId: "vol-2",
+		Secrets:  map[string]string{"password": "secret"},
+	}
+	assert.Equal(t, map[string]string{"password": "***stripped***"}, redactSecrets(publishReq).Secrets)
+
+	noSecretsReq := &csi.NodeStageVolumeRequest{VolumeId: "vol-3"}
+	assert.Same(t, noSecretsReq, redactSecrets(noSecretsReq))
+}
@@ -0,0 +1,184 @@
+//go:build darwin || linux
+// +build darwin linux
+
+/*
+Copyright 2025 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	utilexec "k8s.io/utils/exec"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+const (
+	// fsckEventReasonSucceeded is the reason of the node event of a fsck
+	// which found no errors.
+	fsckEventReasonSucceeded = "FsckSucceeded"
+	// fsckEventReasonRepaired is the reason of the node event of a fsck
+	// which repaired errors of the filesystem.
+	fsckEventReasonRepaired = "FsckRepaired"
+	// fsckEventReasonFailed is the reason of the node event of a fsck which
+	// found errors it could not repair.
+	fsckEventReasonFailed = "FsckFailed"
+	// maxFsckEventOutputLen is the maximum length of the fsck output
+	// included in node events.
+	maxFsckEventOutputLen = 1024
+)
+
+// fsckResult is the outcome of a fsck run.
+type fsckResult struct {
+	// repaired is true if errors of the filesystem were repaired.
+	repaired bool
+	// failed is true if errors were found which were not repaired.
+	failed bool
+	// output is the combined output of the fsck command.
+	output string
+}
+
+// fsckCommand returns the fsck command and its arguments to check, or check
+// and repair, a filesystem of the given fstype.
+func fsckCommand(fsType string, mode string, devicePath string) (string, []string, error) {
+	repair := mode == common.FsckModeRepair
+	switch fsType {
+	case common.Ext3FsType, common.Ext4FsType:
+		// -f checks the filesystem even if it is marked clean, which e2fsck
+		// otherwise skips.
+		if repair {
+			// Automatically repair the errors which can be repaired safely.
+			return "e2fsck", []string{"-f", "-p", devicePath}, nil
+		}
+		return "e2fsck", []string{"-f", "-n", devicePath}, nil
+	case common.XFSType:
+		if repair {
+			return "xfs_repair", []string{devicePath}, nil
+		}
+		return "xfs_repair", []string{"-n", devicePath}, nil
+	case common.BtrfsFsType:
+		if repair {
+			return "", nil, fmt.Errorf("fsck mode %q is not supported for fstype %q", mode, fsType)
+		}
+		return "btrfs", []string{"check", "--readonly", devicePath}, nil
+	}
+	return "", nil, fmt.Errorf("fsck is not supported for fstype %q", fsType)
+}
+
+// parseFsckExitStatus interprets the exit status of the fsck command of the
+// given fstype.
+func parseFsckExitStatus(fsType string, status int) fsckResult {
+	switch fsType {
+	case common.Ext3FsType, common.Ext4FsType:
+		// e2fsck exits with 1 if errors were corrected, with 2 if errors were
+		// corrected and the system should be rebooted, and with 4 or higher
+		// if errors were left uncorrected or fsck failed.
+		return fsckResult{repaired: status == 1 || status == 2, failed: status >= 4}
+	case common.XFSType:
+		// xfs_repair exits with 2 if the log of the filesystem is dirty. The
+		// log is replayed when the filesystem is mounted, so it is not
+		// considered a failure.
+		return fsckResult{failed: status != 0 && status != 2}
+	}
+	return fsckResult{failed: status != 0}
+}
+
+// fsck runs fsck in the given mode on the filesystem of the given device.
+func (osUtils *OsUtils) fsck(ctx context.Context, devicePath string, fsType string,
+	mode string) (fsckResult, error) {
+	log := logger.GetLogger(ctx)
+	cmd, args, err := fsckCommand(fsType, mode, devicePath)
+	if err != nil {
+		return fsckResult{}, err
+	}
+	log.Infof("fsck: running %s with args: %v", cmd, args)
+	output, err := osUtils.Mounter.Exec.Command(cmd, args...).CombinedOutput()
+	status := 0
+	if err != nil {
+		exit, ok := err.(utilexec.ExitError)
+		if !ok {
+			return fsckResult{}, fmt.Errorf("failed to run %s on device %q: %v", cmd, devicePath, err)
+		}
+		status = exit.ExitStatus()
+	}
+	result := parseFsckExitStatus(fsType, status)
+	result.output = string(output)
+	log.Infof("fsck: %s on device %q exited with status %d. Output: %s", cmd, devicePath, status, result.output)
+	return result, nil
+}
+
+// fsckDevice runs fsck on the filesystem of the given device before it is
+// mounted, and reports the result as a node event. Unformatted devices and
+// devices formatted with another fstype are skipped. Read-only volumes are
+// only checked, never repaired.
+func (osUtils *OsUtils) fsckDevice(ctx context.Context, devicePath string, params NodeStageParams) error {
+	log := logger.GetLogger(ctx)
+	if err := common.ValidateFsckMode(params.FsType, params.FsckMode); err != nil {
+		return err
+	}
+	existingFormat, err := osUtils.getDiskFormat(ctx, devicePath)
+	if err != nil {
+		return fmt.Errorf("failed to get disk format of disk %q: %v", devicePath, err)
+	}
+	if existingFormat != params.FsType {
+		log.Infof("fsckDevice: skipping fsck of disk %q of volume %q with format %q",
+			devicePath, params.VolID, existingFormat)
+		return nil
+	}
+	mode := params.FsckMode
+	if params.Ro {
+		mode = common.FsckModeCheck
+	}
+	result, err := osUtils.fsck(ctx, devicePath, params.FsType, mode)
+	if err != nil {
+		return err
+	}
+	eventType, reason := v1.EventTypeNormal, fsckEventReasonSucceeded
+	if result.failed {
+		eventType, reason = v1.EventTypeWarning, fsckEventReasonFailed
+	} else if result.repaired {
+		eventType, reason = v1.EventTypeWarning, fsckEventReasonRepaired
+	}
+	output := result.output
+	if len(output) > maxFsckEventOutputLen {
+		output = output[len(output)-maxFsckEventOutputLen:]
+	}
+	reportFsckEvent(ctx, eventType, reason,
+		fmt.Sprintf("fsck (%s) of volume %q: %s", mode, params.VolID, output))
+	if result.failed {
+		return fmt.Errorf("filesystem of volume %q has errors which were not repaired: %s",
+			params.VolID, result.output)
+	}
+	return nil
+}
+
+// reportFsckEvent records the result of a fsck as an event on the node. The
+// result is only logged if the event cannot be recorded.
+func reportFsckEvent(ctx context.Context, eventType string, reason string, message string) {
+	log := logger.GetLogger(ctx)
+	nodeName := os.Getenv("NODE_NAME")
+	if nodeName == "" || commonco.ContainerOrchestratorUtility == nil {
+		log.Infof("reportFsckEvent: %s: %s", reason, message)
+		return
+	}
+	err := commonco.ContainerOrchestratorUtility.RecordNodeEvent(ctx, nodeName, eventType, reason, message)
+	if err != nil {
+		log.Warnf("reportFsckEvent: failed to record event %s on node %s. Err: %v", reason, nodeName, err)
+	}
+}
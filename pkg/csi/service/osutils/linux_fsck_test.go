@@ -0,0 +1,114 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package osutils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/mount-utils"
+	utilexec "k8s.io/utils/exec"
+	testingexec "k8s.io/utils/exec/testing"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+)
+
+// fakeOutputCommand returns a fake command which prints the given output,
+// exits with the given status and records its arguments.
+func fakeOutputCommand(output string, status int, argv *[][]string) testingexec.FakeCommandAction {
+	return func(cmd string, args ...string) utilexec.Cmd {
+		fakeCmd := &testingexec.FakeCmd{}
+		fakeCmd.CombinedOutputScript = []testingexec.FakeAction{
+			func() ([]byte, []byte, error) {
+				*argv = append(*argv, append([]string{cmd}, args...))
+				if status != 0 {
+					return []byte(output), nil, &testingexec.FakeExitError{Status: status}
+				}
+				return []byte(output), nil, nil
+			},
+		}
+		return testingexec.InitFakeCmd(fakeCmd, cmd, args...)
+	}
+}
+
+func TestFsckDevice(t *testing.T) {
+	ctx := context.Background()
+	const devicePath = "/dev/sdb"
+	blkidArgv := []string{"blkid", "-p", "-s", "TYPE", "-s", "PTTYPE", "-o", "export", devicePath}
+	tests := []struct {
+		name         string
+		params       NodeStageParams
+		blkidOutput  string
+		blkidStatus  int
+		fsckStatus   int
+		expectedArgv [][]string
+		expectErr    bool
+	}{
+		{
+			name:         "UnformattedDisk",
+			params:       NodeStageParams{FsType: common.Ext4FsType, FsckMode: common.FsckModeRepair},
+			blkidStatus:  2,
+			expectedArgv: [][]string{blkidArgv},
+		},
+		{
+			name:         "OtherFsType",
+			params:       NodeStageParams{FsType: common.Ext4FsType, FsckMode: common.FsckModeRepair},
+			blkidOutput:  "TYPE=xfs\n",
+			expectedArgv: [][]string{blkidArgv},
+		},
+		{
+			name:         "Ext4Repaired",
+			params:       NodeStageParams{FsType: common.Ext4FsType, FsckMode: common.FsckModeRepair},
+			blkidOutput:  "TYPE=ext4\n",
+			fsckStatus:   1,
+			expectedArgv: [][]string{blkidArgv, {"e2fsck", "-f", "-p", devicePath}},
+		},
+		{
+			name:         "Ext4ReadOnlyCheckFailed",
+			params:       NodeStageParams{FsType: common.Ext4FsType, FsckMode: common.FsckModeRepair, Ro: true},
+			blkidOutput:  "TYPE=ext4\n",
+			fsckStatus:   4,
+			expectedArgv: [][]string{blkidArgv, {"e2fsck", "-f", "-n", devicePath}},
+			expectErr:    true,
+		},
+		{
+			name:         "XfsDirtyLog",
+			params:       NodeStageParams{FsType: common.XFSType, FsckMode: common.FsckModeCheck},
+			blkidOutput:  "TYPE=xfs\n",
+			fsckStatus:   2,
+			expectedArgv: [][]string{blkidArgv, {"xfs_repair", "-n", devicePath}},
+		},
+		{
+			name:         "BtrfsCheck",
+			params:       NodeStageParams{FsType: common.BtrfsFsType, FsckMode: common.FsckModeCheck},
+			blkidOutput:  "TYPE=btrfs\n",
+			expectedArgv: [][]string{blkidArgv, {"btrfs", "check", "--readonly", devicePath}},
+		},
+		{
+			name:      "BtrfsRepair",
+			params:    NodeStageParams{FsType: common.BtrfsFsType, FsckMode: common.FsckModeRepair},
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var argv [][]string
+			fakeExec := &testingexec.FakeExec{CommandScript: []testingexec.FakeCommandAction{
+				fakeOutputCommand(test.blkidOutput, test.blkidStatus, &argv),
+				fakeOutputCommand("", test.fsckStatus, &argv),
+			}}
+			osUtils := &OsUtils{Mounter: &mount.SafeFormatAndMount{Exec: fakeExec}}
+			err := osUtils.fsckDevice(ctx, devicePath, test.params)
+			if test.expectErr && err == nil {
+				t.Errorf("expected error, got none")
+			} else if !test.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(argv, test.expectedArgv) {
+				t.Errorf("expected commands %v, got %v", test.expectedArgv, argv)
+			}
+		})
+	}
+}
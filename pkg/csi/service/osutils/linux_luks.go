@@ -0,0 +1,189 @@
+//go:build darwin || linux
+// +build darwin linux
+
+/*
+Copyright 2025 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	utilexec "k8s.io/utils/exec"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+const (
+	// luksMapperPrefix is the prefix of the device mapper names of the LUKS
+	// devices opened for volumes.
+	luksMapperPrefix = "luks-"
+	// luksDMUUIDPrefix is the prefix of the device mapper UUIDs of LUKS
+	// devices opened by cryptsetup.
+	luksDMUUIDPrefix = "CRYPT-LUKS"
+)
+
+var (
+	// devMapperDir is the directory of the device mapper devices.
+	devMapperDir = "/dev/mapper"
+	// sysClassBlockDir is the sysfs directory of the block devices.
+	sysClassBlockDir = "/sys/class/block"
+)
+
+// luksMapperName returns the device mapper name of the LUKS device of the
+// given volume.
+func luksMapperName(volID string) string {
+	return luksMapperPrefix + volID
+}
+
+// openLuksDevice opens the LUKS device on the given disk and returns the path
+// of the opened device. Disks without a LUKS header are formatted with LUKS2
+// first, unless they are mounted read-only or hold other data.
+func (osUtils *OsUtils) openLuksDevice(ctx context.Context, devicePath string, volID string,
+	passphrase string, readOnly bool) (string, error) {
+	log := logger.GetLogger(ctx)
+	mapperName := luksMapperName(volID)
+	mapperPath := filepath.Join(devMapperDir, mapperName)
+	if _, err := os.Stat(mapperPath); err == nil {
+		log.Infof("openLuksDevice: LUKS device of volume %q is already open at %q", volID, mapperPath)
+		return mapperPath, nil
+	}
+
+	isLuks, err := osUtils.isLuksDevice(ctx, devicePath)
+	if err != nil {
+		return "", err
+	}
+	if !isLuks {
+		if readOnly {
+			return "", fmt.Errorf("disk %q of volume %q is not LUKS formatted and cannot be formatted "+
+				"in read-only mode", devicePath, volID)
+		}
+		existingFormat, err := osUtils.getDiskFormat(ctx, devicePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to get disk format of disk %q: %v", devicePath, err)
+		}
+		if existingFormat != "" {
+			return "", fmt.Errorf("disk %q of volume %q is formatted as %q and cannot be LUKS formatted",
+				devicePath, volID, existingFormat)
+		}
+		log.Infof("openLuksDevice: Formatting disk %q of volume %q with LUKS2", devicePath, volID)
+		if err := osUtils.runCryptsetup(ctx, passphrase, "luksFormat", "--type", "luks2", "--batch-mode",
+			"--key-file", "-", devicePath); err != nil {
+			return "", err
+		}
+	}
+
+	// The volume key is kept in the device mapper table instead of the kernel
+	// keyring, so that the device can be resized without the passphrase.
+	args := []string{"luksOpen", "--disable-keyring", "--key-file", "-"}
+	if readOnly {
+		args = append(args, "--readonly")
+	}
+	args = append(args, devicePath, mapperName)
+	if err := osUtils.runCryptsetup(ctx, passphrase, args...); err != nil {
+		return "", err
+	}
+	log.Infof("openLuksDevice: Opened LUKS device of volume %q at %q", volID, mapperPath)
+	return mapperPath, nil
+}
+
+// closeLuksDevice closes the LUKS device of the given volume, if it is open.
+func (osUtils *OsUtils) closeLuksDevice(ctx context.Context, volID string) error {
+	log := logger.GetLogger(ctx)
+	mapperName := luksMapperName(volID)
+	if _, err := os.Stat(filepath.Join(devMapperDir, mapperName)); os.IsNotExist(err) {
+		return nil
+	}
+	if err := osUtils.runCryptsetup(ctx, "", "luksClose", mapperName); err != nil {
+		return err
+	}
+	log.Infof("closeLuksDevice: Closed LUKS device of volume %q", volID)
+	return nil
+}
+
+// isLuksDevice checks whether the given disk has a LUKS header.
+func (osUtils *OsUtils) isLuksDevice(ctx context.Context, devicePath string) (bool, error) {
+	log := logger.GetLogger(ctx)
+	output, err := osUtils.Mounter.Exec.Command("cryptsetup", "isLuks", devicePath).CombinedOutput()
+	if err != nil {
+		if exit, ok := err.(utilexec.ExitError); ok && exit.ExitStatus() == 1 {
+			return false, nil
+		}
+		log.Errorf("Could not determine if disk %q is LUKS formatted (%v). Output: %q", devicePath, err, output)
+		return false, err
+	}
+	return true, nil
+}
+
+// runCryptsetup runs cryptsetup with the given arguments. The passphrase, if
+// any, is passed on stdin so that it never shows up in the process list.
+func (osUtils *OsUtils) runCryptsetup(ctx context.Context, passphrase string, args ...string) error {
+	log := logger.GetLogger(ctx)
+	cmd := osUtils.Mounter.Exec.Command("cryptsetup", args...)
+	if passphrase != "" {
+		cmd.SetStdin(strings.NewReader(passphrase))
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return logger.LogNewErrorf(log, "cryptsetup %s failed: errcode:(%v) output:(%v)",
+			args[0], err, string(output))
+	}
+	return nil
+}
+
+// getLuksMapping returns the device mapper name and the backing disk of the
+// given device if it is an open LUKS device, or empty strings otherwise.
+func getLuksMapping(devicePath string) (string, string, error) {
+	realPath, err := filepath.EvalSymlinks(devicePath)
+	if err != nil {
+		return "", "", err
+	}
+	dmDir := filepath.Join(sysClassBlockDir, filepath.Base(realPath), "dm")
+	dmUUID, err := os.ReadFile(filepath.Join(dmDir, "uuid"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Not a device mapper device.
+			return "", "", nil
+		}
+		return "", "", err
+	}
+	if !strings.HasPrefix(string(dmUUID), luksDMUUIDPrefix) {
+		return "", "", nil
+	}
+	dmName, err := os.ReadFile(filepath.Join(dmDir, "name"))
+	if err != nil {
+		return "", "", err
+	}
+	slaves, err := os.ReadDir(filepath.Join(sysClassBlockDir, filepath.Base(realPath), "slaves"))
+	if err != nil {
+		return "", "", err
+	}
+	if len(slaves) != 1 {
+		return "", "", fmt.Errorf("expected a single disk backing LUKS device %q, found %d", devicePath, len(slaves))
+	}
+	return strings.TrimSpace(string(dmName)), filepath.Join("/dev", slaves[0].Name()), nil
+}
+
+// GetLuksBackingDevice returns the disk backing the given device if it is an
+// open LUKS device, or nil otherwise.
+func (osUtils *OsUtils) GetLuksBackingDevice(ctx context.Context, devicePath string) (*Device, error) {
+	_, backingDevicePath, err := getLuksMapping(devicePath)
+	if err != nil || backingDevicePath == "" {
+		return nil, err
+	}
+	return osUtils.GetDevice(ctx, backingDevicePath)
+}
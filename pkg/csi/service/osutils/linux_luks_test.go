@@ -0,0 +1,155 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package osutils
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/mount-utils"
+	utilexec "k8s.io/utils/exec"
+	testingexec "k8s.io/utils/exec/testing"
+)
+
+// fakeCommand returns a fake command which exits with the given status and
+// records its arguments and stdin.
+func fakeCommand(status int, argv *[][]string, stdin *[]string) testingexec.FakeCommandAction {
+	return func(cmd string, args ...string) utilexec.Cmd {
+		fakeCmd := &testingexec.FakeCmd{}
+		fakeCmd.CombinedOutputScript = []testingexec.FakeAction{
+			func() ([]byte, []byte, error) {
+				*argv = append(*argv, append([]string{cmd}, args...))
+				input := ""
+				if fakeCmd.Stdin != nil {
+					data, _ := io.ReadAll(fakeCmd.Stdin)
+					input = string(data)
+				}
+				*stdin = append(*stdin, input)
+				if status != 0 {
+					return nil, nil, &testingexec.FakeExitError{Status: status}
+				}
+				return nil, nil, nil
+			},
+		}
+		return testingexec.InitFakeCmd(fakeCmd, cmd, args...)
+	}
+}
+
+func TestOpenLuksDevice(t *testing.T) {
+	ctx := context.Background()
+	defer func(dir string) { devMapperDir = dir }(devMapperDir)
+	devMapperDir = t.TempDir()
+	const (
+		devicePath = "/dev/sdb"
+		volID      = "vol-1"
+		passphrase = "secret"
+	)
+	tests := []struct {
+		name          string
+		statuses      []int
+		readOnly      bool
+		expectedArgv  [][]string
+		expectedStdin []string
+		expectErr     bool
+	}{
+		{
+			name:     "UnformattedDisk",
+			statuses: []int{1, 2, 0, 0},
+			expectedArgv: [][]string{
+				{"cryptsetup", "isLuks", devicePath},
+				{"blkid", "-p", "-s", "TYPE", "-s", "PTTYPE", "-o", "export", devicePath},
+				{"cryptsetup", "luksFormat", "--type", "luks2", "--batch-mode", "--key-file", "-", devicePath},
+				{"cryptsetup", "luksOpen", "--disable-keyring", "--key-file", "-", devicePath, "luks-" + volID},
+			},
+			expectedStdin: []string{"", "", passphrase, passphrase},
+		},
+		{
+			name:     "LuksFormattedDiskReadOnly",
+			statuses: []int{0, 0},
+			readOnly: true,
+			expectedArgv: [][]string{
+				{"cryptsetup", "isLuks", devicePath},
+				{"cryptsetup", "luksOpen", "--disable-keyring", "--key-file", "-", "--readonly", devicePath,
+					"luks-" + volID},
+			},
+			expectedStdin: []string{"", passphrase},
+		},
+		{
+			name:     "UnformattedDiskReadOnly",
+			statuses: []int{1},
+			readOnly: true,
+			expectedArgv: [][]string{
+				{"cryptsetup", "isLuks", devicePath},
+			},
+			expectedStdin: []string{""},
+			expectErr:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var argv [][]string
+			var stdin []string
+			fakeExec := &testingexec.FakeExec{}
+			for _, status := range test.statuses {
+				fakeExec.CommandScript = append(fakeExec.CommandScript, fakeCommand(status, &argv, &stdin))
+			}
+			osUtils := &OsUtils{Mounter: &mount.SafeFormatAndMount{Exec: fakeExec}}
+			mapperPath, err := osUtils.openLuksDevice(ctx, devicePath, volID, passphrase, test.readOnly)
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got LUKS device %q", mapperPath)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if mapperPath != filepath.Join(devMapperDir, "luks-"+volID) {
+				t.Errorf("unexpected LUKS device path %q", mapperPath)
+			}
+			if !reflect.DeepEqual(argv, test.expectedArgv) {
+				t.Errorf("expected commands %v, got %v", test.expectedArgv, argv)
+			}
+			if !reflect.DeepEqual(stdin, test.expectedStdin) {
+				t.Errorf("expected stdin %q, got %q", test.expectedStdin, stdin)
+			}
+		})
+	}
+}
+
+func TestGetLuksMapping(t *testing.T) {
+	defer func(dir string) { sysClassBlockDir = dir }(sysClassBlockDir)
+	sysClassBlockDir = t.TempDir()
+	devDir := t.TempDir()
+	writeFile := func(path string, data string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(devDir, "dm-0"), "")
+	writeFile(filepath.Join(sysClassBlockDir, "dm-0", "dm", "uuid"), "CRYPT-LUKS2-1234-luks-vol-1\n")
+	writeFile(filepath.Join(sysClassBlockDir, "dm-0", "dm", "name"), "luks-vol-1\n")
+	writeFile(filepath.Join(sysClassBlockDir, "dm-0", "slaves", "sdb"), "")
+	writeFile(filepath.Join(devDir, "dm-1"), "")
+	writeFile(filepath.Join(sysClassBlockDir, "dm-1", "dm", "uuid"), "LVM-1234\n")
+	writeFile(filepath.Join(devDir, "sdc"), "")
+
+	mapperName, backingDevicePath, err := getLuksMapping(filepath.Join(devDir, "dm-0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mapperName != "luks-vol-1" || backingDevicePath != "/dev/sdb" {
+		t.Errorf("unexpected LUKS mapping %q of %q", mapperName, backingDevicePath)
+	}
+	for _, device := range []string{"dm-1", "sdc"} {
+		mapperName, _, err := getLuksMapping(filepath.Join(devDir, device))
+		if err != nil || mapperName != "" {
+			t.Errorf("expected %s not to be a LUKS device, got %q, err: %v", device, mapperName, err)
+		}
+	}
+}
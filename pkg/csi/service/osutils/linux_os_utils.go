@@ -23,6 +23,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"syscall"
@@ -38,20 +39,42 @@ import (
 	"k8s.io/mount-utils"
 	utilexec "k8s.io/utils/exec"
 
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/mounter"
 )
 
 const (
-	devDiskID   = "/dev/disk/by-id"
 	blockPrefix = "wwn-0x"
 	dmiDir      = "/sys/class/dmi"
 	UUIDPrefix  = "VMware-"
 )
 
-// defaultFileMountOptions are the mount flag options used by default while publishing a file volume.
-var defaultFileMountOptions = []string{"hard", "sec=sys", "vers=4", "minorversion=1"}
+// devDiskID is the directory of the links to the disks by their IDs.
+var devDiskID = "/dev/disk/by-id"
+
+// pciDriversDir is the sysfs directory of the PCI drivers, which links the
+// PCI devices bound to each driver.
+var pciDriversDir = "/sys/bus/pci/drivers"
+
+// hostEtcDir is the /etc directory of the host, mounted in the node plugin.
+// NFS mounts with Kerberos security are authenticated by rpc.gssd of the host,
+// with the Kerberos configuration and keytab of the host.
+var hostEtcDir = "/host/etc"
+
+// volumeSlotsPerDriver maps the kernel drivers of the SCSI and NVMe
+// controllers of vSphere VMs to the number of disks that can be attached to
+// each controller. The limits of the paravirtual SCSI and NVMe controllers
+// are raised by the hardware version of the VM, which isn't visible from the
+// guest, so their lowest limits are used.
+var volumeSlotsPerDriver = map[string]int64{
+	"vmw_pvscsi": cnsvsphere.MaxDisksPerSCSIController,
+	"mptspi":     cnsvsphere.MaxDisksPerSCSIController,
+	"mptsas":     cnsvsphere.MaxDisksPerSCSIController,
+	"BusLogic":   cnsvsphere.MaxDisksPerSCSIController,
+	"nvme":       cnsvsphere.MaxDisksPerLegacyNVMEController,
+}
 
 // NewOsUtils creates OsUtils with a linux specific mounter
 func NewOsUtils(ctx context.Context) (*OsUtils, error) {
@@ -149,9 +172,10 @@ func (osUtils *OsUtils) getDiskFormat(ctx context.Context, disk string) (string,
 	return fstype, nil
 }
 
-// xfsFormatAndMount mounts volume to the staging path for xfs fstype
-func (osUtils *OsUtils) xfsFormatAndMount(ctx context.Context, source string, target string,
-	fstype string, opts ...string) error {
+// formatAndMount formats the volume with the given mkfs options if it is
+// unformatted, and mounts it to the staging path.
+func (osUtils *OsUtils) formatAndMount(ctx context.Context, source string, target string,
+	fstype string, mkfsOpts []string, opts ...string) error {
 	log := logger.GetLogger(ctx)
 	// Check if the disk is already formatted
 	existingFormat, err := osUtils.getDiskFormat(ctx, source)
@@ -169,27 +193,33 @@ func (osUtils *OsUtils) xfsFormatAndMount(ctx context.Context, source string, ta
 		// These options are compatible with Linux kernel versions 5.10 and later.
 		// To create a new filesystem that will be compatible with the older kernel versions, we need to disable
 		// these new features by adding -m bigtime=0,inobtcount=0 to the mkfs.xfs command.
-		kernel, major, err := getKernelVersion(ctx)
-		if err != nil {
-			log.Errorf("xfsFormatAndMount: error while getting kernel version, err: %v", err)
-			return err
-		}
 		var args []string
-		if kernel >= 5 && major >= 10 {
-			args = []string{
-				source,
+		switch fstype {
+		case common.XFSType:
+			kernel, major, err := getKernelVersion(ctx)
+			if err != nil {
+				log.Errorf("formatAndMount: error while getting kernel version, err: %v", err)
+				return err
 			}
-		} else {
-			args = []string{
-				"-m",
-				"bigtime=0",
-				"-m",
-				"inobtcount=0",
-				source,
+			if kernel < 5 || major < 10 {
+				args = []string{
+					"-m",
+					"bigtime=0",
+					"-m",
+					"inobtcount=0",
+				}
 			}
+		case common.Ext3FsType, common.Ext4FsType:
+			// No blocks are reserved for the super-user by default, unless
+			// the options of the StorageClass set -m.
+			args = []string{"-F", "-m0"}
 		}
+		// The options of the StorageClass come after the defaults, so that
+		// they take precedence.
+		args = append(args, mkfsOpts...)
+		args = append(args, source)
 
-		log.Infof("xfsFormatAndMount: Disk %q appears to be unformatted, attempting to format as type: %q "+
+		log.Infof("formatAndMount: Disk %q appears to be unformatted, attempting to format as type: %q "+
 			"with options: %v", source, fstype, args)
 		output, err := osUtils.Mounter.Exec.Command("mkfs."+fstype, args...).CombinedOutput()
 		if err != nil {
@@ -199,14 +229,14 @@ func (osUtils *OsUtils) xfsFormatAndMount(ctx context.Context, source string, ta
 			return errors.New(detailedErr)
 		}
 
-		log.Infof("xfsFormatAndMount: Disk successfully formatted (mkfs): %s - %s %s", fstype, source, target)
+		log.Infof("formatAndMount: Disk successfully formatted (mkfs): %s - %s %s", fstype, source, target)
 	}
 
 	// Mount the disk
-	log.Infof("xfsFormatAndMount: Attempting to mount disk %s in %s format at %s", source, fstype, target)
+	log.Infof("formatAndMount: Attempting to mount disk %s in %s format at %s", source, fstype, target)
 	err = osUtils.Mounter.Mount(source, target, fstype, opts)
 	if err != nil {
-		log.Errorf("xfsFormatAndMount: mount of disk %s failed: type:(%q) target:(%q) errcode:(%v)",
+		log.Errorf("formatAndMount: mount of disk %s failed: type:(%q) target:(%q) errcode:(%v)",
 			source, fstype, target, err)
 		return errors.New(err.Error())
 	}
@@ -255,6 +285,21 @@ func (osUtils *OsUtils) NodeStageBlockVolume(
 	}
 
 	// Mount Volume.
+	if params.LuksEncrypted {
+		// The filesystem is created on and mounted from the LUKS device.
+		mapperPath, err := osUtils.openLuksDevice(ctx, dev.FullPath, params.VolID,
+			req.GetSecrets()[common.LuksPassphraseSecretKey], params.Ro)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"error opening LUKS device of volume %q. err: %v", params.VolID, err)
+		}
+		dev, err = osUtils.GetDevice(ctx, mapperPath)
+		if err != nil || dev == nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"error getting LUKS device %q of volume %q. err: %v", mapperPath, params.VolID, err)
+		}
+		log.Debugf("nodeStageBlockVolume: LUKS device %+v", *dev)
+	}
 	// Fetch dev mounts to check if the device is already staged.
 	log.Debugf("nodeStageBlockVolume: Fetching device mounts")
 	mnts, err := gofsutil.GetDevMounts(ctx, dev.RealDev)
@@ -265,6 +310,12 @@ func (osUtils *OsUtils) NodeStageBlockVolume(
 
 	if len(mnts) == 0 {
 		// Device isn't mounted anywhere, stage the volume.
+		if params.FsckMode != "" {
+			if err := osUtils.fsckDevice(ctx, dev.FullPath, params); err != nil {
+				return nil, logger.LogNewErrorCodef(log, codes.Internal,
+					"fsck of volume %q failed. err: %v", params.VolID, err)
+			}
+		}
 		// If access mode is read-only, we don't allow formatting.
 		if params.Ro {
 			log.Debugf("nodeStageBlockVolume: Mounting %q at %q in read-only mode with mount flags %v",
@@ -281,10 +332,11 @@ func (osUtils *OsUtils) NodeStageBlockVolume(
 		// Format and mount the device.
 		log.Debugf("nodeStageBlockVolume: Format and mount the device %q at %q with mount flags %v",
 			dev.FullPath, params.StagingTarget, params.MntFlags)
-		if params.FsType == "xfs" {
-			// use internal function for XFS mount, as we want to provide few parameters for mkfs command
-			// which are specific to XFS filesystem
-			err := osUtils.xfsFormatAndMount(ctx, dev.FullPath, params.StagingTarget, params.FsType, params.MntFlags...)
+		if params.FsType == common.XFSType || params.FsType == common.BtrfsFsType || len(params.MkfsOptions) != 0 {
+			// use internal function for XFS and btrfs mount, and when mkfs options are given,
+			// as we want to provide parameters for mkfs command which are specific to the filesystem
+			err := osUtils.formatAndMount(ctx, dev.FullPath, params.StagingTarget, params.FsType,
+				params.MkfsOptions, params.MntFlags...)
 			if err != nil {
 				return nil, logger.LogNewErrorCodef(log, codes.Internal,
 					"error in formating and mounting volume. Parameters: %v err: %v", params, err)
@@ -343,6 +395,9 @@ func (osUtils *OsUtils) CleanupStagePath(ctx context.Context, stagingTarget stri
 				"error unmounting stagingTarget: %v", err)
 		}
 	}
+	if err := osUtils.closeLuksDevice(ctx, volID); err != nil {
+		return fmt.Errorf("error closing LUKS device of volume %q: %v", volID, err)
+	}
 	return nil
 }
 
@@ -738,14 +793,25 @@ func (osUtils *OsUtils) PublishFileVol(
 	if params.Ro {
 		mntFlags = append(mntFlags, "ro")
 	}
-	// Add defaultFileMountOptions to the mntFlags.
-	mntFlags = append(mntFlags, defaultFileMountOptions...)
-	// Retrieve the file share access point from publish context.
-	mntSrc, ok := req.GetPublishContext()[common.Nfsv4AccessPoint]
-	if !ok {
-		return nil, logger.LogNewErrorCode(log, codes.Internal,
-			"nfs v4 accesspoint not set in publish context")
+	if smbAccessPoint, ok := req.GetPublishContext()[common.SmbAccessPoint]; ok {
+		return osUtils.publishSmbFileVol(ctx, req, params, smbAccessPoint, mntFlags)
+	}
+	// Retrieve the file share access point and its mount options from
+	// publish context.
+	mntSrc, fsType, nfsMntFlags, err := getNfsMountParams(req.GetPublishContext(), fsType)
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"invalid publish context of file volume %q: %v", params.VolID, err)
+	}
+	for _, flag := range nfsMntFlags {
+		if strings.HasPrefix(flag, "sec=krb5") {
+			if err := checkNfsKerberosSetup(); err != nil {
+				return nil, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+					"cannot mount file volume %q with %s: %v", params.VolID, flag, err)
+			}
+		}
 	}
+	mntFlags = append(mntFlags, nfsMntFlags...)
 	// Directly mount the file share volume to the pod. No bind mount required.
 	log.Debugf("PublishFileVolume: Attempting to mount %q to %q with fstype %q and mountflags %v",
 		mntSrc, params.Target, fsType, mntFlags)
@@ -757,6 +823,140 @@ func (osUtils *OsUtils) PublishFileVol(
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
+// getNfsMountParams returns the NFS access point in the given publish context,
+// along with the fstype and the mount flags to mount it with. NFS 4.1 with the
+// sys security flavor is used unless the publish context has an NFSv3 access
+// point or another security flavor.
+func getNfsMountParams(pubCtx map[string]string, fsType string) (string, string, []string, error) {
+	mntFlags := []string{"hard"}
+	mntSrc, ok := pubCtx[common.Nfsv3AccessPoint]
+	if ok {
+		// nfs4 mounts cannot use NFS version 3.
+		fsType = common.NfsFsType
+		mntFlags = append(mntFlags, "sec="+common.NfsSecuritySys, "vers=3")
+	} else {
+		if mntSrc, ok = pubCtx[common.Nfsv4AccessPoint]; !ok {
+			return "", "", nil, errors.New("nfs v4 accesspoint not set in publish context")
+		}
+		security := common.NfsSecuritySys
+		if value, ok := pubCtx[common.NfsSecurity]; ok {
+			var err error
+			if security, err = common.ParseNfsSecurity(value); err != nil {
+				return "", "", nil, err
+			}
+		}
+		mntFlags = append(mntFlags, "sec="+security, "vers=4", "minorversion=1")
+	}
+	// The tuning options are validated once more, as they are passed to mount.
+	tuningFlags, err := common.ParseNfsMountOptions(pubCtx[common.NfsMountOptions])
+	if err != nil {
+		return "", "", nil, err
+	}
+	return mntSrc, fsType, append(mntFlags, tuningFlags...), nil
+}
+
+// checkNfsKerberosSetup checks that the host is set up to mount NFS file
+// shares with Kerberos security, i.e. it has a Kerberos configuration and a
+// keytab with the credentials of the node in the realm of the vSAN file
+// service domain. rpc.gssd must also run on the host.
+func checkNfsKerberosSetup() error {
+	for _, name := range []string{"krb5.conf", "krb5.keytab"} {
+		file := filepath.Join(hostEtcDir, name)
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to find %s of the host at %q: %v", name, file, err)
+		}
+		if info.Size() == 0 {
+			return fmt.Errorf("%s of the host at %q is empty", name, file)
+		}
+	}
+	return nil
+}
+
+// publishSmbFileVol mounts the SMB file share at the given access point to
+// the publish target with cifs, using the credentials of the node publish
+// secret.
+func (osUtils *OsUtils) publishSmbFileVol(
+	ctx context.Context,
+	req *csi.NodePublishVolumeRequest,
+	params NodePublishParams,
+	accessPoint string,
+	mntFlags []string) (
+	*csi.NodePublishVolumeResponse, error) {
+	log := logger.GetLogger(ctx)
+	secrets := req.GetSecrets()
+	username, password := secrets[common.SmbUsernameSecretKey], secrets[common.SmbPasswordSecretKey]
+	domain := secrets[common.SmbDomainSecretKey]
+	if username == "" || password == "" {
+		return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"node publish secret keys %q and %q are required to mount SMB file volume %q",
+			common.SmbUsernameSecretKey, common.SmbPasswordSecretKey, params.VolID)
+	}
+	// The credentials are written to the lines of the credentials file.
+	if strings.ContainsAny(username+password, "\r\n\x00") {
+		return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"node publish secret keys %q and %q of SMB file volume %q must not contain line breaks",
+			common.SmbUsernameSecretKey, common.SmbPasswordSecretKey, params.VolID)
+	}
+	if domain != "" && !smbDomainPattern.MatchString(domain) {
+		return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"node publish secret key %q of SMB file volume %q must be a domain name",
+			common.SmbDomainSecretKey, params.VolID)
+	}
+	// The credentials are passed to cifs in a file rather than in the mount
+	// options, which are visible to the other processes of the node.
+	credentialsFile, err := writeSmbCredentialsFile(username, password, domain)
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to write the credentials of SMB file volume %q: %v", params.VolID, err)
+	}
+	defer func() {
+		if err := os.Remove(credentialsFile); err != nil {
+			log.Warnf("failed to remove the credentials file %q: %v", credentialsFile, err)
+		}
+	}()
+	mntFlags = append(mntFlags, "credentials="+credentialsFile)
+	// vSAN file service returns SMB access points as UNC paths, e.g.
+	// \\server\share, while cifs expects //server/share.
+	mntSrc := strings.ReplaceAll(accessPoint, `\`, "/")
+	log.Debugf("PublishFileVolume: Attempting to mount %q to %q with fstype %q and mountflags %v",
+		mntSrc, params.Target, common.CifsFsType, mntFlags)
+	err = osUtils.Mounter.Mount(mntSrc, params.Target, common.CifsFsType, mntFlags)
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"error publish volume to target path: %v", err)
+	}
+	log.Infof("NodePublishVolume successful to path %q", params.Target)
+	return &csi.NodePublishVolumeResponse{}, nil
+}
+
+// smbDomainPattern matches the Active Directory domains of the users mounting
+// SMB file shares, either as DNS or NetBIOS names.
+var smbDomainPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+
+// writeSmbCredentialsFile writes the given credentials to a new file, which
+// only its owner can read, in the format of the cifs credentials option and
+// returns its path.
+func writeSmbCredentialsFile(username, password, domain string) (string, error) {
+	f, err := os.CreateTemp("", "smb-credentials-")
+	if err != nil {
+		return "", err
+	}
+	content := fmt.Sprintf("username=%s\npassword=%s\n", username, password)
+	if domain != "" {
+		content += fmt.Sprintf("domain=%s\n", domain)
+	}
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
 // GetDevice returns a Device struct with info about the given device, or
 // an error if it doesn't exist or is not a block device.
 func (osUtils *OsUtils) GetDevice(ctx context.Context, path string) (*Device, error) {
@@ -1027,6 +1227,30 @@ func (osUtils *OsUtils) GetSystemUUID(ctx context.Context) (string, error) {
 	return uuid, nil
 }
 
+// GetVolumeSlots returns the number of disks that can be attached to the SCSI
+// and NVMe controllers of the node VM, which are discovered from sysfs.
+func (osUtils *OsUtils) GetVolumeSlots(ctx context.Context) (int64, error) {
+	log := logger.GetLogger(ctx)
+	var slots int64
+	for driver, slotsPerController := range volumeSlotsPerDriver {
+		entries, err := os.ReadDir(filepath.Join(pciDriversDir, driver))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, err
+		}
+		for _, entry := range entries {
+			// PCI devices are named after their address, e.g. 0000:03:00.0.
+			if strings.Count(entry.Name(), ":") == 2 {
+				log.Debugf("Found %s controller at PCI address %s", driver, entry.Name())
+				slots += slotsPerController
+			}
+		}
+	}
+	return slots, nil
+}
+
 // convertUUID helps convert UUID to vSphere format, for example,
 // Input uuid:    6B8C2042-0DD1-D037-156F-435F999D94C1
 // Returned uuid: 42208c6b-d10d-37d0-156f-435f999d94c1
@@ -1140,12 +1364,13 @@ func (osUtils *OsUtils) GetVolumeCapabilityFsType(ctx context.Context,
 		}
 	} else {
 		// For Block volumes we only support following filesystems:
-		// ext3, ext4 and xfs for Linux.
+		// ext3, ext4, xfs and btrfs for Linux.
 		if fsType == "" {
 			log.Infof("empty string fstype observed for block volume. Defaulting to: %s",
 				common.Ext4FsType)
 			fsType = common.Ext4FsType
-		} else if !(fsType == common.Ext4FsType || fsType == common.Ext3FsType || fsType == common.XFSType) {
+		} else if !(fsType == common.Ext4FsType || fsType == common.Ext3FsType || fsType == common.XFSType ||
+			fsType == common.BtrfsFsType) {
 			return "", logger.LogNewErrorCodef(log, codes.FailedPrecondition,
 				"unsupported fsType %q observed for block volume", fsType)
 		}
@@ -1156,17 +1381,35 @@ func (osUtils *OsUtils) GetVolumeCapabilityFsType(ctx context.Context,
 // ResizeVolume resizes the volume
 func (osUtils *OsUtils) ResizeVolume(ctx context.Context, devicePath, volumePath string, reqVolSizeBytes int64) error {
 	log := logger.GetLogger(ctx)
+	sizeDevicePath := devicePath
+	mapperName, backingDevicePath, err := getLuksMapping(devicePath)
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"error when checking if %s is a LUKS device: %v", devicePath, err)
+	}
+	if mapperName != "" {
+		// Grow the LUKS device to the size of the expanded disk first. The
+		// LUKS header takes up part of the disk, so the size of the disk is
+		// checked against the requested size.
+		if err := osUtils.runCryptsetup(ctx, "", "resize", mapperName); err != nil {
+			return logger.LogNewErrorCodef(log, codes.Internal,
+				"error when resizing LUKS device %s: %v", mapperName, err)
+		}
+		sizeDevicePath = backingDevicePath
+	}
+	// ext3 and ext4 are grown with resize2fs, while xfs and btrfs are grown
+	// online through the path they are mounted at.
 	resizer := mount.NewResizeFs(osUtils.Mounter.Exec)
-	_, err := resizer.Resize(devicePath, volumePath)
+	_, err = resizer.Resize(devicePath, volumePath)
 	if err != nil {
 		return fmt.Errorf(
 			"error when resizing filesystem on devicePath %s and volumePath %s, err: %v ", devicePath, volumePath, err)
 	}
 	// Check the block size.
-	currentBlockSizeBytes, err := osUtils.GetBlockSizeBytes(ctx, devicePath)
+	currentBlockSizeBytes, err := osUtils.GetBlockSizeBytes(ctx, sizeDevicePath)
 	if err != nil {
 		return logger.LogNewErrorCodef(log, codes.Internal,
-			"error when getting size of block volume at path %s: %v", devicePath, err)
+			"error when getting size of block volume at path %s: %v", sizeDevicePath, err)
 	}
 	// NOTE(xyang): Make sure new size is greater than or equal to the
 	// requested size. It is possible for volume size to be rounded up
@@ -1275,3 +1518,121 @@ func (osUtils *OsUtils) IsBlockDevice(ctx context.Context, volumePath string) (b
 	}
 	return deviceInfo.Mode()&os.ModeDevice == os.ModeDevice, nil
 }
+
+// GetVolumeCondition checks the mount at volumePath and returns an abnormal
+// VolumeCondition if the volume can no longer be used by the workload, e.g.
+// the mount is gone or stale, the backing disk was removed from the node or
+// the filesystem was remounted read-only after I/O errors.
+func (osUtils *OsUtils) GetVolumeCondition(ctx context.Context, volumePath string) *csi.VolumeCondition {
+	log := logger.GetLogger(ctx)
+	if _, err := os.Stat(volumePath); err != nil {
+		if mount.IsCorruptedMnt(err) {
+			return abnormalVolumeCondition(ctx, "mount at %q is stale or corrupted: %v", volumePath, err)
+		}
+		if os.IsNotExist(err) {
+			return abnormalVolumeCondition(ctx, "volume path %q does not exist", volumePath)
+		}
+		return abnormalVolumeCondition(ctx, "failed to stat volume path %q: %v", volumePath, err)
+	}
+	mnts, err := gofsutil.GetMounts(ctx)
+	if err != nil {
+		log.Warnf("failed to get mounts to check the condition of volume path %q. Err: %v", volumePath, err)
+		return &csi.VolumeCondition{Abnormal: false, Message: "volume condition could not be determined"}
+	}
+	var mnt *gofsutil.Info
+	for i := range mnts {
+		if unescape(ctx, mnts[i].Path) == volumePath {
+			mnt = &mnts[i]
+			break
+		}
+	}
+	if mnt == nil {
+		return abnormalVolumeCondition(ctx, "volume path %q is not mounted", volumePath)
+	}
+	return osUtils.getMountCondition(ctx, volumePath, mnt)
+}
+
+// getMountCondition returns the VolumeCondition of the volume mounted at
+// volumePath by the given mount. The disks backing block volumes, or the
+// device mapper devices opened on them, need to still be attached to the node.
+func (osUtils *OsUtils) getMountCondition(ctx context.Context, volumePath string,
+	mnt *gofsutil.Info) *csi.VolumeCondition {
+	switch mnt.Type {
+	case common.NfsFsType, common.NfsV4FsType, common.CifsFsType:
+		// File volumes are not backed by a device on the node.
+	default:
+		devicePath := mnt.Device
+		if mnt.Device == "udev" || mnt.Device == "devtmpfs" {
+			devicePath = mnt.Source
+		}
+		dev, err := osUtils.GetDevice(ctx, devicePath)
+		if err != nil || dev == nil {
+			return abnormalVolumeCondition(ctx, "device %q backing volume path %q is missing", devicePath,
+				volumePath)
+		}
+		if disk := getMissingBackingDisk(dev.RealDev); disk != "" {
+			return abnormalVolumeCondition(ctx, "disk %q backing volume path %q is no longer present in %s",
+				disk, volumePath, devDiskID)
+		}
+		devMnts, err := osUtils.GetDevMounts(ctx, dev)
+		if err == nil && !isTargetInMounts(ctx, volumePath, devMnts) {
+			return abnormalVolumeCondition(ctx, "volume path %q is not among the mounts of device %q",
+				volumePath, dev.RealDev)
+		}
+	}
+	// A filesystem mounted read-write which no longer accepts writes was
+	// remounted read-only by the kernel, typically after I/O errors.
+	if common.Contains(mnt.Opts, "rw") && errors.Is(unix.Access(volumePath, unix.W_OK), unix.EROFS) {
+		return abnormalVolumeCondition(ctx, "filesystem at volume path %q was remounted read-only", volumePath)
+	}
+	return &csi.VolumeCondition{Abnormal: false, Message: "volume is healthy"}
+}
+
+// getMissingBackingDisk returns the disk backing the given device which is no
+// longer present in devDiskID, if any. Device mapper devices, e.g. the LUKS
+// devices of encrypted volumes, are backed by the disks listed as their slaves
+// in sysfs. Other devices are the disks themselves.
+func getMissingBackingDisk(realDev string) string {
+	disks := []string{realDev}
+	slaves, err := os.ReadDir(filepath.Join(sysClassBlockDir, filepath.Base(realDev), "slaves"))
+	if err == nil && len(slaves) != 0 {
+		disks = nil
+		for _, slave := range slaves {
+			disks = append(disks, filepath.Join("/dev", slave.Name()))
+		}
+	}
+	for _, disk := range disks {
+		if !isDiskPresent(disk) {
+			return disk
+		}
+	}
+	return ""
+}
+
+// isDiskPresent returns true if one of the disks in devDiskID resolves to
+// the given device.
+func isDiskPresent(realDev string) bool {
+	devs, err := os.ReadDir(devDiskID)
+	if err != nil {
+		return false
+	}
+	for _, f := range devs {
+		if !strings.HasPrefix(f.Name(), blockPrefix) {
+			continue
+		}
+		d, err := filepath.EvalSymlinks(filepath.Join(devDiskID, f.Name()))
+		if err == nil && d == realDev {
+			return true
+		}
+	}
+	return false
+}
+
+// abnormalVolumeCondition logs the given message and returns it as an
+// abnormal VolumeCondition.
+func abnormalVolumeCondition(ctx context.Context, format string, args ...interface{}) *csi.VolumeCondition {
+	log := logger.GetLogger(ctx)
+	msg := fmt.Sprintf(format, args...)
+	log.Warn(msg)
+	return &csi.VolumeCondition{Abnormal: true, Message: msg}
+}
@@ -0,0 +1,30 @@
+//go:build simharness && (darwin || linux)
+// +build simharness
+// +build darwin linux
+
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osutils
+
+// SetDiskIDDir sets the directory the disks attached to the node are looked
+// up in by their IDs and returns the previous one. This lets the vCenter
+// simulator harness stage volumes on links to devices it controls.
+func SetDiskIDDir(dir string) string {
+	prev := devDiskID
+	devDiskID = dir
+	return prev
+}
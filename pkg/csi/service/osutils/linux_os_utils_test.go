@@ -5,8 +5,20 @@ package osutils
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/akutz/gofsutil"
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"k8s.io/mount-utils"
+	testingexec "k8s.io/utils/exec/testing"
+
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 )
 
 func TestUnescape(t *testing.T) {
@@ -52,3 +64,323 @@ func TestUnescape(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVolumeCondition(t *testing.T) {
+	ctx := context.Background()
+	osUtils := &OsUtils{}
+
+	// A volume path which does not exist is abnormal.
+	condition := osUtils.GetVolumeCondition(ctx, filepath.Join(t.TempDir(), "missing"))
+	if !condition.Abnormal {
+		t.Errorf("expected missing volume path to be abnormal, got %+v", condition)
+	}
+
+	// A volume path which is not a mount point is abnormal.
+	condition = osUtils.GetVolumeCondition(ctx, t.TempDir())
+	if !condition.Abnormal {
+		t.Errorf("expected unmounted volume path to be abnormal, got %+v", condition)
+	}
+
+	// File volumes are healthy without a backing device.
+	volumePath := t.TempDir()
+	for _, fsType := range []string{common.NfsFsType, common.NfsV4FsType, common.CifsFsType} {
+		condition = osUtils.getMountCondition(ctx, volumePath, &gofsutil.Info{
+			Device: "//server/share", Path: volumePath, Type: fsType, Opts: []string{"rw"}})
+		if condition.Abnormal {
+			t.Errorf("expected %s volume to be healthy, got %+v", fsType, condition)
+		}
+	}
+
+	// A block volume whose device is gone is abnormal.
+	condition = osUtils.getMountCondition(ctx, volumePath, &gofsutil.Info{
+		Device: filepath.Join(t.TempDir(), "sdb"), Path: volumePath, Type: "ext4", Opts: []string{"rw"}})
+	if !condition.Abnormal {
+		t.Errorf("expected block volume with missing device to be abnormal, got %+v", condition)
+	}
+}
+
+func TestGetMissingBackingDisk(t *testing.T) {
+	defer func(dir string) { devDiskID = dir }(devDiskID)
+	defer func(dir string) { sysClassBlockDir = dir }(sysClassBlockDir)
+	devDiskID = t.TempDir()
+	sysClassBlockDir = t.TempDir()
+	devDir := t.TempDir()
+	disk := filepath.Join(devDir, "sdb")
+	if err := os.WriteFile(disk, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(disk, filepath.Join(devDiskID, blockPrefix+"1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A disk is backed by itself.
+	if missing := getMissingBackingDisk(disk); missing != "" {
+		t.Errorf("expected disk %q to be present, got missing %q", disk, missing)
+	}
+	if missing := getMissingBackingDisk(filepath.Join(devDir, "sdc")); missing == "" {
+		t.Errorf("expected disk sdc to be missing")
+	}
+
+	// A device mapper device, e.g. a LUKS device, is backed by its slaves.
+	// /dev/null stands in for a present slave, as slaves are resolved in /dev.
+	if err := os.Symlink("/dev/null", filepath.Join(devDiskID, blockPrefix+"2")); err != nil {
+		t.Fatal(err)
+	}
+	for dm, slave := range map[string]string{"dm-0": "null", "dm-1": "sdd"} {
+		if err := os.MkdirAll(filepath.Join(sysClassBlockDir, dm, "slaves", slave), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if missing := getMissingBackingDisk(filepath.Join(devDir, "dm-0")); missing != "" {
+		t.Errorf("expected the slave of dm-0 to be present, got missing %q", missing)
+	}
+	if missing := getMissingBackingDisk(filepath.Join(devDir, "dm-1")); missing != "/dev/sdd" {
+		t.Errorf("expected slave /dev/sdd of dm-1 to be missing, got %q", missing)
+	}
+}
+
+func TestGetVolumeSlots(t *testing.T) {
+	defer func(dir string) { pciDriversDir = dir }(pciDriversDir)
+	pciDriversDir = t.TempDir()
+	for _, dev := range []string{
+		"vmw_pvscsi/0000:03:00.0",
+		"vmw_pvscsi/0000:0b:00.0",
+		"vmw_pvscsi/bind",
+		"mptspi/0000:00:10.0",
+		"nvme/0000:1b:00.0",
+	} {
+		if err := os.MkdirAll(filepath.Join(pciDriversDir, dev), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dev, err)
+		}
+	}
+	osUtils := &OsUtils{}
+	slots, err := osUtils.GetVolumeSlots(context.Background())
+	if err != nil {
+		t.Fatalf("GetVolumeSlots failed: %v", err)
+	}
+	expected := int64(3*cnsvsphere.MaxDisksPerSCSIController + cnsvsphere.MaxDisksPerLegacyNVMEController)
+	if slots != expected {
+		t.Errorf("expected %d volume slots, got %d", expected, slots)
+	}
+}
+
+func TestFormatAndMount(t *testing.T) {
+	ctx := context.Background()
+	const (
+		devicePath = "/dev/sdb"
+		target     = "/staging"
+	)
+	for _, test := range []struct {
+		fsType       string
+		mkfsOpts     []string
+		expectedMkfs []string
+	}{
+		{fsType: "ext4", mkfsOpts: []string{"-i", "16384"},
+			expectedMkfs: []string{"mkfs.ext4", "-F", "-m0", "-i", "16384", devicePath}},
+		// mke2fs takes the last -m, so the StorageClass overrides -m0.
+		{fsType: "ext4", mkfsOpts: []string{"-m", "5"},
+			expectedMkfs: []string{"mkfs.ext4", "-F", "-m0", "-m", "5", devicePath}},
+		{fsType: "btrfs", mkfsOpts: []string{"-m", "dup"},
+			expectedMkfs: []string{"mkfs.btrfs", "-m", "dup", devicePath}},
+	} {
+		var argv [][]string
+		fakeExec := &testingexec.FakeExec{CommandScript: []testingexec.FakeCommandAction{
+			fakeOutputCommand("", 2, &argv),
+			fakeOutputCommand("", 0, &argv),
+		}}
+		fakeMounter := mount.NewFakeMounter(nil)
+		osUtils := &OsUtils{Mounter: &mount.SafeFormatAndMount{Interface: fakeMounter, Exec: fakeExec}}
+		if err := osUtils.formatAndMount(ctx, devicePath, target, test.fsType, test.mkfsOpts); err != nil {
+			t.Fatalf("unexpected error formatting %s: %v", test.fsType, err)
+		}
+		if len(argv) != 2 || !reflect.DeepEqual(argv[1], test.expectedMkfs) {
+			t.Errorf("expected mkfs command %v, got commands %v", test.expectedMkfs, argv)
+		}
+		mounts, _ := fakeMounter.List()
+		if len(mounts) != 1 || mounts[0].Path != target || mounts[0].Type != test.fsType {
+			t.Errorf("expected %s mount at %s, got %v", test.fsType, target, mounts)
+		}
+	}
+}
+
+func TestResizeVolumeBtrfs(t *testing.T) {
+	ctx := context.Background()
+	defer func(dir string) { sysClassBlockDir = dir }(sysClassBlockDir)
+	sysClassBlockDir = t.TempDir()
+	devicePath := filepath.Join(t.TempDir(), "sdb")
+	if err := os.WriteFile(devicePath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	const volumePath = "/var/lib/kubelet/pods/pod-1/volumes/vol-1/mount"
+	var argv [][]string
+	fakeExec := &testingexec.FakeExec{CommandScript: []testingexec.FakeCommandAction{
+		fakeOutputCommand("TYPE=btrfs\n", 0, &argv),
+		fakeOutputCommand("", 0, &argv),
+		fakeOutputCommand("2147483648\n", 0, &argv),
+	}}
+	osUtils := &OsUtils{Mounter: &mount.SafeFormatAndMount{Exec: fakeExec}}
+	if err := osUtils.ResizeVolume(ctx, devicePath, volumePath, 2147483648); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// btrfs is grown online through its mount path.
+	if len(argv) != 3 || !reflect.DeepEqual(argv[1], []string{"btrfs", "filesystem", "resize", "max", volumePath}) {
+		t.Errorf("unexpected commands %v", argv)
+	}
+}
+
+func TestGetNfsMountParams(t *testing.T) {
+	tests := []struct {
+		name             string
+		pubCtx           map[string]string
+		expectedSrc      string
+		expectedFsType   string
+		expectedMntFlags []string
+		expectErr        bool
+	}{
+		{
+			name:             "Default",
+			pubCtx:           map[string]string{common.Nfsv4AccessPoint: "server:/share"},
+			expectedSrc:      "server:/share",
+			expectedFsType:   common.NfsV4FsType,
+			expectedMntFlags: []string{"hard", "sec=sys", "vers=4", "minorversion=1"},
+		},
+		{
+			name: "Kerberos",
+			pubCtx: map[string]string{common.Nfsv4AccessPoint: "server:/share",
+				common.NfsSecurity: common.NfsSecurityKrb5p, common.NfsMountOptions: "rsize=1048576,timeo=600"},
+			expectedSrc:    "server:/share",
+			expectedFsType: common.NfsV4FsType,
+			expectedMntFlags: []string{"hard", "sec=krb5p", "vers=4", "minorversion=1",
+				"rsize=1048576", "timeo=600"},
+		},
+		{
+			name:             "NFSv3",
+			pubCtx:           map[string]string{common.Nfsv3AccessPoint: "server:/nfs3/share"},
+			expectedSrc:      "server:/nfs3/share",
+			expectedFsType:   common.NfsFsType,
+			expectedMntFlags: []string{"hard", "sec=sys", "vers=3"},
+		},
+		{
+			name:      "NoAccessPoint",
+			pubCtx:    map[string]string{},
+			expectErr: true,
+		},
+		{
+			name: "InvalidMountOptions",
+			pubCtx: map[string]string{common.Nfsv4AccessPoint: "server:/share",
+				common.NfsMountOptions: "sec=sys"},
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mntSrc, fsType, mntFlags, err := getNfsMountParams(test.pubCtx, common.NfsV4FsType)
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got source %q", mntSrc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mntSrc != test.expectedSrc || fsType != test.expectedFsType ||
+				!reflect.DeepEqual(mntFlags, test.expectedMntFlags) {
+				t.Errorf("expected %q %q %v, got %q %q %v", test.expectedSrc, test.expectedFsType,
+					test.expectedMntFlags, mntSrc, fsType, mntFlags)
+			}
+		})
+	}
+}
+
+// credentialsReadingMounter records the content of the cifs credentials file
+// of the mounts, which is removed once mounted.
+type credentialsReadingMounter struct {
+	*mount.FakeMounter
+	credentials string
+}
+
+func (m *credentialsReadingMounter) Mount(source, target, fstype string, options []string) error {
+	for _, option := range options {
+		if path, ok := strings.CutPrefix(option, "credentials="); ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			m.credentials = string(content)
+		}
+	}
+	return m.FakeMounter.Mount(source, target, fstype, options)
+}
+
+func TestPublishSmbFileVol(t *testing.T) {
+	ctx := context.Background()
+	params := NodePublishParams{VolID: "file:vol-1", Target: "/target"}
+	newRequest := func(secrets map[string]string) *csi.NodePublishVolumeRequest {
+		return &csi.NodePublishVolumeRequest{VolumeId: params.VolID, TargetPath: params.Target, Secrets: secrets}
+	}
+
+	mounter := &credentialsReadingMounter{FakeMounter: mount.NewFakeMounter(nil)}
+	osUtils := &OsUtils{Mounter: &mount.SafeFormatAndMount{Interface: mounter}}
+	_, err := osUtils.publishSmbFileVol(ctx, newRequest(map[string]string{
+		common.SmbUsernameSecretKey: "user",
+		common.SmbPasswordSecretKey: "pass,word",
+		common.SmbDomainSecretKey:   "corp.example.com",
+	}), params, `\\fs-1.example.com\pvc-1`, []string{"ro"})
+	if err != nil {
+		t.Fatalf("unexpected error publishing SMB file volume: %v", err)
+	}
+	mounts, _ := mounter.List()
+	if len(mounts) != 1 || mounts[0].Device != "//fs-1.example.com/pvc-1" || mounts[0].Type != common.CifsFsType {
+		t.Fatalf("expected cifs mount of //fs-1.example.com/pvc-1, got %v", mounts)
+	}
+	credentialsFile := ""
+	for _, option := range mounts[0].Opts {
+		if strings.Contains(option, "pass,word") || strings.HasPrefix(option, "domain=") {
+			t.Errorf("expected the credentials not to be passed in the mount options, got %v", mounts[0].Opts)
+		}
+		if path, ok := strings.CutPrefix(option, "credentials="); ok {
+			credentialsFile = path
+		}
+	}
+	if mounter.credentials != "username=user\npassword=pass,word\ndomain=corp.example.com\n" {
+		t.Errorf("unexpected credentials file content %q", mounter.credentials)
+	}
+	if _, err := os.Stat(credentialsFile); credentialsFile == "" || !os.IsNotExist(err) {
+		t.Errorf("expected the credentials file %q to be removed, got %v", credentialsFile, err)
+	}
+
+	for _, secrets := range []map[string]string{
+		{common.SmbUsernameSecretKey: "user"},
+		{common.SmbUsernameSecretKey: "user\ndomain=evil", common.SmbPasswordSecretKey: "password"},
+		{common.SmbUsernameSecretKey: "user", common.SmbPasswordSecretKey: "password",
+			common.SmbDomainSecretKey: "corp,sec=none"},
+	} {
+		if _, err := osUtils.publishSmbFileVol(ctx, newRequest(secrets), params, `\\fs-1\pvc-1`, nil); err == nil {
+			t.Errorf("expected error for node publish secret %v", secrets)
+		}
+	}
+}
+
+func TestCheckNfsKerberosSetup(t *testing.T) {
+	defer func(dir string) { hostEtcDir = dir }(hostEtcDir)
+	hostEtcDir = t.TempDir()
+	if err := checkNfsKerberosSetup(); err == nil {
+		t.Errorf("expected an error without krb5.conf and krb5.keytab")
+	}
+	if err := os.WriteFile(filepath.Join(hostEtcDir, "krb5.conf"), []byte("[libdefaults]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hostEtcDir, "krb5.keytab"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkNfsKerberosSetup(); err == nil {
+		t.Errorf("expected an error with an empty krb5.keytab")
+	}
+	if err := os.WriteFile(filepath.Join(hostEtcDir, "krb5.keytab"), []byte{0x05, 0x02}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkNfsKerberosSetup(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -41,6 +41,14 @@ type NodeStageParams struct {
 	MntFlags []string
 	// Read-only flag.
 	Ro bool
+	// LuksEncrypted makes the volume get mounted through a LUKS device,
+	// opened with the passphrase in the node stage secrets.
+	LuksEncrypted bool
+	// MkfsOptions are the validated mkfs options used to format the volume.
+	MkfsOptions []string
+	// FsckMode is either check or repair to run fsck before mounting the
+	// volume. No fsck is run when it is empty.
+	FsckMode string
 }
 
 // struct to hold params required for NodePublish operation
@@ -84,6 +84,10 @@ func (osUtils *OsUtils) NodeStageBlockVolume(
 		return nil, logger.LogNewErrorCodef(log, codes.Internal,
 			"Stage for raw block Volume access type is currently not supported for windows node")
 	}
+	if params.LuksEncrypted {
+		return nil, logger.LogNewErrorCode(log, codes.InvalidArgument,
+			"LUKS encryption is not supported for windows node")
+	}
 
 	// Block Volume with Mount access type.
 	pubCtx := req.GetPublishContext()
@@ -514,3 +518,27 @@ func (osUtils *OsUtils) ShouldContinue(ctx context.Context) {
 func (osUtils *OsUtils) IsBlockDevice(ctx context.Context, volumePath string) (bool, error) {
 	return false, nil
 }
+
+// GetLuksBackingDevice returns nil, as LUKS devices are not supported on
+// windows nodes.
+func (osUtils *OsUtils) GetLuksBackingDevice(ctx context.Context, devicePath string) (*Device, error) {
+	return nil, nil
+}
+
+// GetVolumeSlots returns 0, as the controllers of windows node VMs are not
+// discovered.
+func (osUtils *OsUtils) GetVolumeSlots(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// GetVolumeCondition returns an abnormal VolumeCondition if the volume path
+// no longer exists on the node.
+func (osUtils *OsUtils) GetVolumeCondition(ctx context.Context, volumePath string) *csi.VolumeCondition {
+	log := logger.GetLogger(ctx)
+	if _, err := os.Stat(volumePath); err != nil {
+		msg := fmt.Sprintf("failed to stat volume path %q: %v", volumePath, err)
+		log.Warn(msg)
+		return &csi.VolumeCondition{Abnormal: true, Message: msg}
+	}
+	return &csi.VolumeCondition{Abnormal: false, Message: "volume is healthy"}
+}
@@ -113,7 +113,10 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ids csi.IdentityServer,
 		return logger.LogNewErrorf(log, "failed to listen: %v", err)
 	}
 
-	server := grpc.NewServer()
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryServerInterceptors()...),
+		grpc.ChainStreamInterceptor(streamServerInterceptors()...),
+	)
 	s.server = server
 
 	// Register the CSI services.
@@ -138,6 +141,18 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ids csi.IdentityServer,
 		}
 		csi.RegisterControllerServer(s.server, cs)
 		log.Info("controller service registered")
+		// Register the SnapshotMetadata service alongside the controller
+		// service if the controller implements it.
+		if sms, ok := cs.(csi.SnapshotMetadataServer); ok {
+			csi.RegisterSnapshotMetadataServer(s.server, sms)
+			log.Info("snapshot metadata service registered")
+		}
+		// Register the GroupController service alongside the controller
+		// service if the controller implements it.
+		if gcs, ok := cs.(csi.GroupControllerServer); ok {
+			csi.RegisterGroupControllerServer(s.server, gcs)
+			log.Info("group controller service registered")
+		}
 	} else if strings.EqualFold(mode, "node") {
 		if ns == nil {
 			return logger.LogNewError(log, "node service required when running in node mode")
@@ -0,0 +1,362 @@
+//go:build simharness
+// +build simharness
+
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simharness
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+)
+
+const (
+	// volumeSizeBytes is the size of the volumes created by the checks.
+	volumeSizeBytes = 1 * common.GbInBytes
+	// expandedVolumeSizeBytes is the size the volumes are expanded to.
+	expandedVolumeSizeBytes = 2 * common.GbInBytes
+)
+
+// mountVolumeCapability is the capability of a block volume mounted on a
+// single node.
+var mountVolumeCapability = &csi.VolumeCapability{
+	AccessType: &csi.VolumeCapability_Mount{
+		Mount: &csi.VolumeCapability_MountVolume{FsType: common.Ext4FsType},
+	},
+	AccessMode: &csi.VolumeCapability_AccessMode{
+		Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+	},
+}
+
+// xfsVolumeCapability is the capability of a block volume mounted with XFS on
+// a single node. Unlike ext4, XFS is formatted and mounted through the
+// mounter of the node service, which is faked by the harness.
+var xfsVolumeCapability = &csi.VolumeCapability{
+	AccessType: &csi.VolumeCapability_Mount{
+		Mount: &csi.VolumeCapability_MountVolume{FsType: common.XFSType},
+	},
+	AccessMode: &csi.VolumeCapability_AccessMode{
+		Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+	},
+}
+
+// rawBlockVolumeCapability is the capability of a raw block volume on a
+// single node.
+var rawBlockVolumeCapability = &csi.VolumeCapability{
+	AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+	AccessMode: &csi.VolumeCapability_AccessMode{
+		Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+	},
+}
+
+// RunConformance runs csi-sanity style conformance checks against the driver
+// served by the harness. Every check runs as a subtest of t. The node service
+// is checked up to staging, which formats and mounts the volume through the
+// fake mounter. Publishing is not checked, as it looks the staged volume up
+// in the mount table of the machine running the harness.
+func RunConformance(t *testing.T, h *Harness) {
+	ctx := context.Background()
+	t.Run("Identity", func(t *testing.T) { checkIdentity(ctx, t, h) })
+	t.Run("ControllerCapabilities", func(t *testing.T) { checkControllerCapabilities(ctx, t, h) })
+	t.Run("NodeCapabilities", func(t *testing.T) { checkNodeCapabilities(ctx, t, h) })
+	t.Run("CreateVolumeArguments", func(t *testing.T) { checkCreateVolumeArguments(ctx, t, h) })
+	t.Run("NodeStageVolumeArguments", func(t *testing.T) { checkNodeStageVolumeArguments(ctx, t, h) })
+	t.Run("VolumeLifecycle", func(t *testing.T) { checkVolumeLifecycle(ctx, t, h) })
+}
+
+func checkIdentity(ctx context.Context, t *testing.T, h *Harness) {
+	client := h.IdentityClient()
+	info, err := client.GetPluginInfo(ctx, &csi.GetPluginInfoRequest{})
+	if err != nil {
+		t.Fatalf("GetPluginInfo failed. Error: %+v", err)
+	}
+	if info.GetName() != csitypes.Name {
+		t.Errorf("expected plugin name %q, got %q", csitypes.Name, info.GetName())
+	}
+	caps, err := client.GetPluginCapabilities(ctx, &csi.GetPluginCapabilitiesRequest{})
+	if err != nil {
+		t.Fatalf("GetPluginCapabilities failed. Error: %+v", err)
+	}
+	hasControllerService := false
+	for _, c := range caps.GetCapabilities() {
+		if c.GetService().GetType() == csi.PluginCapability_Service_CONTROLLER_SERVICE {
+			hasControllerService = true
+		}
+	}
+	if !hasControllerService {
+		t.Errorf("expected CONTROLLER_SERVICE plugin capability in %+v", caps.GetCapabilities())
+	}
+	probe, err := client.Probe(ctx, &csi.ProbeRequest{})
+	if err != nil {
+		t.Errorf("Probe failed. Error: %+v", err)
+	} else if probe.GetReady() != nil && !probe.GetReady().GetValue() {
+		t.Errorf("expected Probe to report the driver as ready")
+	}
+}
+
+func checkControllerCapabilities(ctx context.Context, t *testing.T, h *Harness) {
+	resp, err := h.ControllerClient().ControllerGetCapabilities(ctx, &csi.ControllerGetCapabilitiesRequest{})
+	if err != nil {
+		t.Fatalf("ControllerGetCapabilities failed. Error: %+v", err)
+	}
+	caps := make(map[csi.ControllerServiceCapability_RPC_Type]bool)
+	for _, c := range resp.GetCapabilities() {
+		caps[c.GetRpc().GetType()] = true
+	}
+	for _, expected := range []csi.ControllerServiceCapability_RPC_Type{
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
+		csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
+		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+	} {
+		if !caps[expected] {
+			t.Errorf("expected controller capability %q in %+v", expected, resp.GetCapabilities())
+		}
+	}
+}
+
+func checkNodeCapabilities(ctx context.Context, t *testing.T, h *Harness) {
+	resp, err := h.NodeClient().NodeGetCapabilities(ctx, &csi.NodeGetCapabilitiesRequest{})
+	if err != nil {
+		t.Fatalf("NodeGetCapabilities failed. Error: %+v", err)
+	}
+	caps := make(map[csi.NodeServiceCapability_RPC_Type]bool)
+	for _, c := range resp.GetCapabilities() {
+		caps[c.GetRpc().GetType()] = true
+	}
+	for _, expected := range []csi.NodeServiceCapability_RPC_Type{
+		csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
+		csi.NodeServiceCapability_RPC_EXPAND_VOLUME,
+	} {
+		if !caps[expected] {
+			t.Errorf("expected node capability %q in %+v", expected, resp.GetCapabilities())
+		}
+	}
+}
+
+func checkCreateVolumeArguments(ctx context.Context, t *testing.T, h *Harness) {
+	client := h.ControllerClient()
+	_, err := client.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name: newVolumeName(),
+		VolumeCapabilities: []*csi.VolumeCapability{{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_UNKNOWN},
+		}},
+	})
+	expectCode(t, "CreateVolume with unsupported access mode", err, codes.InvalidArgument)
+	_, err = client.DeleteVolume(ctx, &csi.DeleteVolumeRequest{})
+	expectCode(t, "DeleteVolume without volume ID", err, codes.InvalidArgument)
+}
+
+func checkNodeStageVolumeArguments(ctx context.Context, t *testing.T, h *Harness) {
+	client := h.NodeClient()
+	_, err := client.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
+		VolumeId:          uuid.New().String(),
+		StagingTargetPath: h.StagingTargetPath,
+	})
+	expectCode(t, "NodeStageVolume without volume capability", err, codes.InvalidArgument)
+}
+
+// checkNodeStageVolume stages the published volume with the given publish
+// context as a raw block and as an XFS mount volume, and unstages it again.
+func checkNodeStageVolume(ctx context.Context, t *testing.T, h *Harness, volumeID string,
+	publishContext map[string]string) {
+	client := h.NodeClient()
+	diskUUID := publishContext[common.AttributeFirstClassDiskUUID]
+	stageReq := &csi.NodeStageVolumeRequest{
+		VolumeId:          volumeID,
+		PublishContext:    publishContext,
+		StagingTargetPath: h.StagingTargetPath,
+		VolumeCapability:  xfsVolumeCapability,
+	}
+	_, err := client.NodeStageVolume(ctx, stageReq)
+	expectCode(t, "NodeStageVolume of a disk not attached to the node", err, codes.NotFound)
+
+	if err := h.LinkDisk(diskUUID); err != nil {
+		t.Fatalf("failed to link disk %q. Error: %+v", diskUUID, err)
+	}
+	defer func() {
+		if err := h.UnlinkDisk(diskUUID); err != nil {
+			t.Errorf("failed to unlink disk %q. Error: %+v", diskUUID, err)
+		}
+	}()
+	if _, err := client.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
+		VolumeId:          volumeID,
+		PublishContext:    publishContext,
+		StagingTargetPath: h.StagingTargetPath,
+		VolumeCapability:  rawBlockVolumeCapability,
+	}); err != nil {
+		t.Errorf("NodeStageVolume of a raw block volume failed. Error: %+v", err)
+	}
+
+	if _, err := client.NodeStageVolume(ctx, stageReq); err != nil {
+		t.Fatalf("NodeStageVolume failed. Error: %+v", err)
+	}
+	mountPoints, err := h.Mounter.List()
+	if err != nil {
+		t.Fatalf("failed to list mount points. Error: %+v", err)
+	}
+	staged := false
+	for _, mp := range mountPoints {
+		if mp.Path == h.StagingTargetPath && mp.Type == common.XFSType {
+			staged = true
+		}
+	}
+	if !staged {
+		t.Errorf("expected an %s mount at %q, got %+v", common.XFSType, h.StagingTargetPath, mountPoints)
+	}
+	// The staged volume is not in the mount table of the machine, so unstage
+	// takes the path of an already unstaged volume.
+	if _, err := client.NodeUnstageVolume(ctx, &csi.NodeUnstageVolumeRequest{
+		VolumeId:          volumeID,
+		StagingTargetPath: h.StagingTargetPath,
+	}); err != nil {
+		t.Errorf("NodeUnstageVolume failed. Error: %+v", err)
+	}
+}
+
+// checkVolumeLifecycle creates a volume, publishes and stages it on a node,
+// expands it, snapshots it and deletes everything again, checking idempotency
+// of the create and delete calls on the way.
+func checkVolumeLifecycle(ctx context.Context, t *testing.T, h *Harness) {
+	client := h.ControllerClient()
+	createReq := &csi.CreateVolumeRequest{
+		Name:               newVolumeName(),
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: volumeSizeBytes},
+		VolumeCapabilities: []*csi.VolumeCapability{mountVolumeCapability},
+	}
+	createResp, err := client.CreateVolume(ctx, createReq)
+	if err != nil {
+		t.Fatalf("CreateVolume failed. Error: %+v", err)
+	}
+	volumeID := createResp.GetVolume().GetVolumeId()
+	if volumeID == "" {
+		t.Fatalf("CreateVolume returned no volume ID in %+v", createResp)
+	}
+	if createResp.GetVolume().GetCapacityBytes() < volumeSizeBytes {
+		t.Errorf("expected capacity of at least %d bytes, got %d", volumeSizeBytes,
+			createResp.GetVolume().GetCapacityBytes())
+	}
+	deleted := false
+	defer func() {
+		if !deleted {
+			_, _ = client.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID})
+		}
+	}()
+	createResp, err = client.CreateVolume(ctx, createReq)
+	if err != nil {
+		t.Fatalf("repeated CreateVolume failed. Error: %+v", err)
+	}
+	if createResp.GetVolume().GetVolumeId() != volumeID {
+		t.Errorf("repeated CreateVolume returned volume %q instead of %q",
+			createResp.GetVolume().GetVolumeId(), volumeID)
+	}
+
+	publishReq := &csi.ControllerPublishVolumeRequest{
+		VolumeId:         volumeID,
+		NodeId:           h.NodeIDs[0],
+		VolumeCapability: mountVolumeCapability,
+	}
+	publishResp, err := client.ControllerPublishVolume(ctx, publishReq)
+	if err != nil {
+		t.Fatalf("ControllerPublishVolume failed. Error: %+v", err)
+	}
+	if publishResp.GetPublishContext()[common.AttributeFirstClassDiskUUID] == "" {
+		t.Errorf("expected %q in publish context %+v", common.AttributeFirstClassDiskUUID,
+			publishResp.GetPublishContext())
+	}
+	checkNodeStageVolume(ctx, t, h, volumeID, publishResp.GetPublishContext())
+	// The simulator does not add the attached disks to the VM hardware, so
+	// repeated publish and unpublish calls are not checked for idempotency.
+	if _, err := client.ControllerUnpublishVolume(ctx, &csi.ControllerUnpublishVolumeRequest{
+		VolumeId: volumeID,
+		NodeId:   h.NodeIDs[0],
+	}); err != nil {
+		t.Fatalf("ControllerUnpublishVolume failed. Error: %+v", err)
+	}
+
+	expandResp, err := client.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+		VolumeId:         volumeID,
+		CapacityRange:    &csi.CapacityRange{RequiredBytes: expandedVolumeSizeBytes},
+		VolumeCapability: mountVolumeCapability,
+	})
+	if err != nil {
+		t.Fatalf("ControllerExpandVolume failed. Error: %+v", err)
+	}
+	if expandResp.GetCapacityBytes() < expandedVolumeSizeBytes {
+		t.Errorf("expected capacity of at least %d bytes after expansion, got %d", expandedVolumeSizeBytes,
+			expandResp.GetCapacityBytes())
+	}
+
+	snapshotResp, err := client.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		SourceVolumeId: volumeID,
+		Name:           "snapshot-" + uuid.New().String(),
+	})
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed. Error: %+v", err)
+	}
+	snapshotID := snapshotResp.GetSnapshot().GetSnapshotId()
+	if snapshotResp.GetSnapshot().GetSourceVolumeId() != volumeID {
+		t.Errorf("expected snapshot of volume %q, got %+v", volumeID, snapshotResp.GetSnapshot())
+	}
+	listResp, err := client.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SourceVolumeId: volumeID})
+	if err != nil {
+		t.Fatalf("ListSnapshots failed. Error: %+v", err)
+	}
+	found := false
+	for _, entry := range listResp.GetEntries() {
+		if entry.GetSnapshot().GetSnapshotId() == snapshotID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected snapshot %q in %+v", snapshotID, listResp.GetEntries())
+	}
+	if _, err := client.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: snapshotID}); err != nil {
+		t.Fatalf("DeleteSnapshot failed. Error: %+v", err)
+	}
+
+	if _, err := client.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID}); err != nil {
+		t.Fatalf("DeleteVolume failed. Error: %+v", err)
+	}
+	deleted = true
+	if _, err := client.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID}); err != nil {
+		t.Errorf("repeated DeleteVolume failed. Error: %+v", err)
+	}
+}
+
+// expectCode reports an error if err does not carry the expected gRPC code.
+func expectCode(t *testing.T, op string, err error, expected codes.Code) {
+	t.Helper()
+	if code := status.Code(err); code != expected {
+		t.Errorf("%s: expected code %q, got %q. Error: %+v", op, expected, code, err)
+	}
+}
+
+// newVolumeName returns a unique volume name in the format of the names
+// generated by the external-provisioner.
+func newVolumeName() string {
+	return "pvc-" + uuid.New().String()
+}
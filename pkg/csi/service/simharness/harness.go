@@ -0,0 +1,424 @@
+//go:build simharness
+// +build simharness
+
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simharness runs the vSphere CSI driver end to end against a vCenter
+// simulator. It starts a vcsim instance with the CNS, PBM and VSLM endpoints,
+// serves the vanilla controller and the node service of the driver over a
+// Unix socket and provides csi-sanity style conformance checks to drive it.
+//
+// The controller talks to the simulator exactly as it would to a vCenter
+// server, while the Kubernetes backed services are replaced by their fakes
+// from unittestcommon. The node service uses a fake mounter and a fake exec,
+// so block devices are never formatted or mounted on the machine running the
+// harness. Disks attached to a node are staged on a link to /dev/null created
+// by LinkDisk.
+//
+// The package is built with the simharness build tag only, as it relies on
+// hooks of the driver which are not part of the production build.
+//
+// The driver relies on process wide singletons, so only one Harness may run
+// at a time in a process.
+package simharness
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	cnssim "github.com/vmware/govmomi/cns/simulator"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"github.com/vmware/govmomi/find"
+	pbmsim "github.com/vmware/govmomi/pbm/simulator"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/simulator/vpx"
+	_ "github.com/vmware/govmomi/vslm/simulator" // Registers the VSLM endpoint with vcsim.
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/mount-utils"
+	testingexec "k8s.io/utils/exec/testing"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/osutils"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/vanilla"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+)
+
+const (
+	// clusterID is the CNS cluster ID used for the volumes created by the
+	// harness.
+	clusterID = "simharness-cluster"
+	// socketName is the name of the Unix socket the driver is served on.
+	socketName = "csi.sock"
+	// configName is the name of the vSphere config file of the driver.
+	configName = "csi-vsphere.conf"
+	// nodeNamePrefix is the prefix of the names of the simulated nodes.
+	nodeNamePrefix = "simharness-node-"
+	// diskIDDirName is the name of the directory the links to the disks
+	// attached to the node are created in.
+	diskIDDirName = "by-id"
+	// stagingDirName is the name of the directory volumes are staged at.
+	stagingDirName = "globalmount"
+	// diskIDPrefix is the prefix of the links to the disks, by their UUIDs.
+	diskIDPrefix = "wwn-0x"
+	// nullDevice is the device the links to the disks point to.
+	nullDevice = "/dev/null"
+)
+
+// DefaultVcsimParams describes the inventory used when the harness is started
+// with a zero VcsimParams.
+var DefaultVcsimParams = unittestcommon.VcsimParams{
+	Datacenters:     1,
+	Clusters:        1,
+	HostsPerCluster: 2,
+	VMsPerCluster:   2,
+	StandaloneHosts: 0,
+	Datastores:      1,
+	Version:         "7.0.3",
+	ApiVersion:      "7.0",
+}
+
+// Harness is a vSphere CSI driver served over a Unix socket against a vCenter
+// simulator.
+type Harness struct {
+	// Config is the vSphere configuration of the driver.
+	Config *cnsconfig.Config
+	// Endpoint is the CSI endpoint the driver is served on.
+	Endpoint string
+	// VirtualCenter is the connection of the driver to the simulator.
+	VirtualCenter *cnsvsphere.VirtualCenter
+	// NodeIDs are the CSI node IDs of the simulated node VMs.
+	NodeIDs []string
+	// Mounter is the fake mounter of the node service.
+	Mounter *mount.FakeMounter
+	// StagingTargetPath is an existing directory volumes can be staged at.
+	StagingTargetPath string
+
+	model       *simulator.Model
+	vcsim       *simulator.Server
+	server      *grpc.Server
+	conn        *grpc.ClientConn
+	workDir     string
+	diskIDDir   string
+	prevDiskDir string
+}
+
+// Start starts a vCenter simulator with the given inventory and serves the
+// vSphere CSI driver against it. Stop must be called to release the
+// simulator and the socket.
+func Start(ctx context.Context, vcsimParams unittestcommon.VcsimParams) (*Harness, error) {
+	log := logger.GetLogger(ctx)
+	if vcsimParams == (unittestcommon.VcsimParams{}) {
+		vcsimParams = DefaultVcsimParams
+	}
+	h := &Harness{}
+	var err error
+	defer func() {
+		if err != nil {
+			h.Stop(ctx)
+		}
+	}()
+
+	h.workDir, err = os.MkdirTemp("", "simharness")
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to create work directory. Error: %+v", err)
+	}
+	h.diskIDDir = filepath.Join(h.workDir, diskIDDirName)
+	h.StagingTargetPath = filepath.Join(h.workDir, stagingDirName)
+	for _, dir := range []string{h.diskIDDir, h.StagingTargetPath} {
+		if err = os.Mkdir(dir, 0750); err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to create directory %q. Error: %+v", dir, err)
+		}
+	}
+	h.Config, err = h.startVcsim(vcsimParams)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to start vCenter simulator. Error: %+v", err)
+	}
+	// The vCenter connection re-reads its credentials from the config file.
+	if err = h.writeConfig(); err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to write vSphere config. Error: %+v", err)
+	}
+	commonco.ContainerOrchestratorUtility, err =
+		unittestcommon.GetFakeContainerOrchestratorInterface(common.Kubernetes)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to create fake CO interface. Error: %+v", err)
+	}
+
+	vcenterconfig, err := cnsvsphere.GetVirtualCenterConfig(ctx, h.Config)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to get vCenter config. Error: %+v", err)
+	}
+	vcManager := cnsvsphere.GetVirtualCenterManager(ctx)
+	h.VirtualCenter, err = vcManager.RegisterVirtualCenter(ctx, vcenterconfig)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to register vCenter %q. Error: %+v",
+			vcenterconfig.Host, err)
+	}
+	if err = h.VirtualCenter.ConnectCns(ctx); err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to connect to CNS. Error: %+v", err)
+	}
+	operationStore, err := unittestcommon.InitFakeVolumeOperationRequestInterface()
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to create fake operation store. Error: %+v", err)
+	}
+	volumeManager, err := cnsvolume.GetManager(ctx, h.VirtualCenter, operationStore, true, true, false,
+		cnstypes.CnsClusterFlavorVanilla)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to create volume manager. Error: %+v", err)
+	}
+	// The volume manager is cached per vCenter host, so make sure it talks to
+	// this simulator and not to the one of a previous harness.
+	if err = volumeManager.ResetManager(ctx, h.VirtualCenter); err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to reset volume manager. Error: %+v", err)
+	}
+	managers := &common.Managers{
+		VcenterConfigs: map[string]*cnsvsphere.VirtualCenterConfig{vcenterconfig.Host: vcenterconfig},
+		CnsConfig:      h.Config,
+		VolumeManagers: map[string]cnsvolume.Manager{vcenterconfig.Host: volumeManager},
+		VcenterManager: vcManager,
+	}
+
+	nodeMgr, err := h.newNodeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	controller, err := vanilla.NewWithManagers(ctx, managers, nodeMgr)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to create controller. Error: %+v", err)
+	}
+	h.Mounter = mount.NewFakeMounter(nil)
+	osUtils := &osutils.OsUtils{
+		Mounter: &mount.SafeFormatAndMount{
+			Interface: h.Mounter,
+			// Every command succeeds without output, so disks are found
+			// unformatted and formatted by mkfs.
+			Exec: &testingexec.FakeExec{DisableScripts: true},
+		},
+	}
+	h.prevDiskDir = osutils.SetDiskIDDir(h.diskIDDir)
+	if err = h.serve(ctx, service.NewDriverWithController(controller, osUtils), controller); err != nil {
+		return nil, err
+	}
+	log.Infof("vSphere CSI driver is served at %q against vCenter simulator %q", h.Endpoint, h.vcsim.URL)
+	return h, nil
+}
+
+// startVcsim starts the vCenter simulator and returns the vSphere config to
+// connect to it.
+func (h *Harness) startVcsim(vcsimParams unittestcommon.VcsimParams) (*cnsconfig.Config, error) {
+	h.model = simulator.VPX()
+	h.model.Datacenter = vcsimParams.Datacenters
+	h.model.Cluster = vcsimParams.Clusters
+	h.model.ClusterHost = vcsimParams.HostsPerCluster
+	h.model.Host = vcsimParams.StandaloneHosts
+	h.model.Datastore = vcsimParams.Datastores
+	h.model.Machine = vcsimParams.VMsPerCluster
+	serviceContent := vpx.ServiceContent
+	serviceContent.About.Version = vcsimParams.Version
+	serviceContent.About.ApiVersion = vcsimParams.ApiVersion
+	h.model.ServiceContent = serviceContent
+	if err := h.model.Create(); err != nil {
+		return nil, err
+	}
+	h.model.Service.RegisterEndpoints = true
+	h.model.Service.TLS = new(tls.Config)
+	h.vcsim = h.model.Service.NewServer()
+	h.model.Service.RegisterSDK(cnssim.New())
+	h.model.Service.RegisterSDK(pbmsim.New())
+
+	password, _ := h.vcsim.URL.User.Password()
+	datacenters := "DC0"
+	for i := 1; i < vcsimParams.Datacenters; i++ {
+		datacenters = datacenters + ", DC" + strconv.Itoa(i)
+	}
+	cfg := &cnsconfig.Config{}
+	cfg.Global.ClusterID = clusterID
+	cfg.Global.InsecureFlag = true
+	cfg.Global.VCenterIP = h.vcsim.URL.Hostname()
+	cfg.Global.VCenterPort = h.vcsim.URL.Port()
+	cfg.Global.User = h.vcsim.URL.User.Username() + "@vsphere.local"
+	cfg.Global.Password = password
+	cfg.Global.Datacenters = datacenters
+	cfg.Global.CSIAuthCheckIntervalInMin = cnsconfig.DefaultCSIAuthCheckIntervalInMin
+	cfg.Snapshot.GlobalMaxSnapshotsPerBlockVolume = cnsconfig.DefaultGlobalMaxSnapshotsPerBlockVolume
+	cfg.VirtualCenter = map[string]*cnsconfig.VirtualCenterConfig{
+		cfg.Global.VCenterIP: {
+			User:         cfg.Global.User,
+			Password:     cfg.Global.Password,
+			VCenterPort:  cfg.Global.VCenterPort,
+			InsecureFlag: cfg.Global.InsecureFlag,
+			Datacenters:  cfg.Global.Datacenters,
+		},
+	}
+	return cfg, nil
+}
+
+// writeConfig writes the vSphere config of the driver to the work directory
+// and points the driver to it.
+func (h *Harness) writeConfig() error {
+	cfgPath := filepath.Join(h.workDir, configName)
+	conf := fmt.Sprintf("[Global]\ncluster-id = \"%s\"\ninsecure-flag = \"%t\"\n"+
+		"[VirtualCenter \"%s\"]\nuser = \"%s\"\npassword = \"%s\"\ndatacenters = \"%s\"\nport = \"%s\"\n",
+		h.Config.Global.ClusterID, h.Config.Global.InsecureFlag, h.Config.Global.VCenterIP, h.Config.Global.User,
+		h.Config.Global.Password, h.Config.Global.Datacenters, h.Config.Global.VCenterPort)
+	if err := os.WriteFile(cfgPath, []byte(conf), 0600); err != nil {
+		return err
+	}
+	return os.Setenv(cnsconfig.EnvVSphereCSIConfig, cfgPath)
+}
+
+// newNodeManager registers every VM of the simulator as a Kubernetes node.
+func (h *Harness) newNodeManager(ctx context.Context) (*nodeManager, error) {
+	log := logger.GetLogger(ctx)
+	vms, err := find.NewFinder(h.VirtualCenter.Client.Client).VirtualMachineList(ctx, "*")
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to list VMs of the simulator. Error: %+v", err)
+	}
+	nodeMgr := &nodeManager{
+		vcHost: h.VirtualCenter.Config.Host,
+		nodes:  make(map[string]*cnsvsphere.VirtualMachine),
+	}
+	for i, vm := range vms {
+		nodeVM, err := cnsvsphere.GetVirtualMachineByUUID(ctx, vm.UUID(ctx), false)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to get node VM %q. Error: %+v", vm.Name(), err)
+		}
+		nodeMgr.nodes[nodeNamePrefix+strconv.Itoa(i)] = nodeVM
+		h.NodeIDs = append(h.NodeIDs, nodeVM.UUID)
+	}
+	if len(h.NodeIDs) == 0 {
+		return nil, logger.LogNewError(log, "no VMs found in the simulator to use as nodes")
+	}
+	return nodeMgr, nil
+}
+
+// serve serves the identity and node services of the driver and the given
+// controller on a Unix socket in the work directory.
+func (h *Harness) serve(ctx context.Context, driver service.Driver, controller csitypes.CnsController) error {
+	log := logger.GetLogger(ctx)
+	addr := filepath.Join(h.workDir, socketName)
+	listener, err := net.Listen("unix", addr)
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to listen on %q. Error: %+v", addr, err)
+	}
+	h.Endpoint = service.UnixSocketPrefix + addr
+	h.server = grpc.NewServer()
+	csi.RegisterIdentityServer(h.server, driver)
+	csi.RegisterControllerServer(h.server, controller)
+	if sms, ok := controller.(csi.SnapshotMetadataServer); ok {
+		csi.RegisterSnapshotMetadataServer(h.server, sms)
+	}
+	csi.RegisterNodeServer(h.server, driver)
+	go func() {
+		if err := h.server.Serve(listener); err != nil {
+			log.Errorf("failed to serve on %q. Error: %+v", addr, err)
+		}
+	}()
+	h.conn, err = grpc.NewClient(h.Endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to connect to %q. Error: %+v", h.Endpoint, err)
+	}
+	return nil
+}
+
+// IdentityClient returns a client of the identity service of the driver.
+func (h *Harness) IdentityClient() csi.IdentityClient {
+	return csi.NewIdentityClient(h.conn)
+}
+
+// ControllerClient returns a client of the controller service of the driver.
+func (h *Harness) ControllerClient() csi.ControllerClient {
+	return csi.NewControllerClient(h.conn)
+}
+
+// NodeClient returns a client of the node service of the driver.
+func (h *Harness) NodeClient() csi.NodeClient {
+	return csi.NewNodeClient(h.conn)
+}
+
+// LinkDisk makes the disk with the given UUID, as found in the publish
+// context of ControllerPublishVolume, visible to the node service. The
+// simulator does not attach disks to anything, so the disk is linked to
+// /dev/null.
+func (h *Harness) LinkDisk(diskUUID string) error {
+	link := filepath.Join(h.diskIDDir, diskIDPrefix+diskUUID)
+	if err := os.Symlink(nullDevice, link); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+// UnlinkDisk removes the link created by LinkDisk for the disk with the given
+// UUID.
+func (h *Harness) UnlinkDisk(diskUUID string) error {
+	err := os.Remove(filepath.Join(h.diskIDDir, diskIDPrefix+diskUUID))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Stop stops serving the driver, unregisters the vCenter and stops the
+// simulator.
+func (h *Harness) Stop(ctx context.Context) {
+	log := logger.GetLogger(ctx)
+	if h.conn != nil {
+		if err := h.conn.Close(); err != nil {
+			log.Warnf("failed to close connection to %q. Error: %+v", h.Endpoint, err)
+		}
+	}
+	if h.server != nil {
+		h.server.Stop()
+	}
+	if h.VirtualCenter != nil {
+		err := cnsvsphere.GetVirtualCenterManager(ctx).UnregisterVirtualCenter(ctx, h.VirtualCenter.Config.Host)
+		if err != nil {
+			log.Warnf("failed to unregister vCenter %q. Error: %+v", h.VirtualCenter.Config.Host, err)
+		}
+	}
+	if h.vcsim != nil {
+		// The task listview of the volume manager keeps a WaitForUpdates call
+		// pending, which the simulator only returns from on updates. Closing
+		// the simulator would wait for it forever, so just stop accepting
+		// connections and close the open ones.
+		h.vcsim.Listener.Close()
+		h.vcsim.CloseClientConnections()
+	}
+	if h.model != nil {
+		h.model.Remove()
+	}
+	if h.prevDiskDir != "" {
+		osutils.SetDiskIDDir(h.prevDiskDir)
+	}
+	if h.workDir != "" {
+		os.Unsetenv(cnsconfig.EnvVSphereCSIConfig)
+		os.RemoveAll(h.workDir)
+	}
+}
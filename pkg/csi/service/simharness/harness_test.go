@@ -0,0 +1,37 @@
+//go:build simharness
+// +build simharness
+
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simharness
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
+)
+
+func TestConformance(t *testing.T) {
+	ctx := context.Background()
+	h, err := Start(ctx, unittestcommon.VcsimParams{})
+	if err != nil {
+		t.Fatalf("failed to start harness. Error: %+v", err)
+	}
+	defer h.Stop(ctx)
+	RunConformance(t, h)
+}
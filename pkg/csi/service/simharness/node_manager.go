@@ -0,0 +1,109 @@
+//go:build simharness
+// +build simharness
+
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simharness
+
+import (
+	"context"
+
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// nodeManager implements vanilla.NodeManagerInterface for a fixed set of
+// simulator VMs, which stand in for the Kubernetes nodes of the cluster.
+type nodeManager struct {
+	vcHost string
+	// nodes maps the node names to their VMs.
+	nodes map[string]*cnsvsphere.VirtualMachine
+}
+
+// Initialize is a no-op as the nodes are registered when the harness starts.
+func (m *nodeManager) Initialize(ctx context.Context) error {
+	return nil
+}
+
+// GetSharedDatastoresInK8SCluster returns the datastores accessible to all
+// the node VMs.
+func (m *nodeManager) GetSharedDatastoresInK8SCluster(ctx context.Context) ([]*cnsvsphere.DatastoreInfo, error) {
+	nodeVMs, err := m.GetAllNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cnsvsphere.GetSharedDatastoresForVMs(ctx, nodeVMs)
+}
+
+// GetNodeVMByNameAndUpdateCache returns the VM of the given node.
+func (m *nodeManager) GetNodeVMByNameAndUpdateCache(ctx context.Context,
+	nodeName string) (*cnsvsphere.VirtualMachine, error) {
+	log := logger.GetLogger(ctx)
+	if vm, ok := m.nodes[nodeName]; ok {
+		return vm, nil
+	}
+	return nil, logger.LogNewErrorf(log, "node %q is not registered", nodeName)
+}
+
+// GetNodeVMByNameOrUUID returns the VM of the node with the given name or
+// VM UUID.
+func (m *nodeManager) GetNodeVMByNameOrUUID(ctx context.Context,
+	nodeNameOrUUID string) (*cnsvsphere.VirtualMachine, error) {
+	if vm, ok := m.nodes[nodeNameOrUUID]; ok {
+		return vm, nil
+	}
+	return m.GetNodeVMByUuid(ctx, nodeNameOrUUID)
+}
+
+// GetNodeNameByUUID returns the name of the node with the given VM UUID.
+func (m *nodeManager) GetNodeNameByUUID(ctx context.Context, nodeUUID string) (string, error) {
+	log := logger.GetLogger(ctx)
+	for nodeName, vm := range m.nodes {
+		if vm.UUID == nodeUUID {
+			return nodeName, nil
+		}
+	}
+	return "", logger.LogNewErrorf(log, "node with UUID %q is not registered", nodeUUID)
+}
+
+// GetNodeVMByUuid returns the VM of the node with the given VM UUID.
+func (m *nodeManager) GetNodeVMByUuid(ctx context.Context, nodeUUID string) (*cnsvsphere.VirtualMachine, error) {
+	log := logger.GetLogger(ctx)
+	for _, vm := range m.nodes {
+		if vm.UUID == nodeUUID {
+			return vm, nil
+		}
+	}
+	return nil, logger.LogNewErrorf(log, "node with UUID %q is not registered", nodeUUID)
+}
+
+// GetAllNodes returns the VMs of all the nodes.
+func (m *nodeManager) GetAllNodes(ctx context.Context) ([]*cnsvsphere.VirtualMachine, error) {
+	nodeVMs := make([]*cnsvsphere.VirtualMachine, 0, len(m.nodes))
+	for _, vm := range m.nodes {
+		nodeVMs = append(nodeVMs, vm)
+	}
+	return nodeVMs, nil
+}
+
+// GetAllNodesByVC returns the VMs of all the nodes in the given vCenter.
+func (m *nodeManager) GetAllNodesByVC(ctx context.Context, vcHost string) ([]*cnsvsphere.VirtualMachine, error) {
+	if vcHost != m.vcHost {
+		return nil, nil
+	}
+	return m.GetAllNodes(ctx)
+}
@@ -20,11 +20,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
@@ -36,7 +38,9 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/migration"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
@@ -128,7 +132,7 @@ func (d *defaultTopologyCalculator) CalculateAccessibleTopology(ctx context.Cont
 	}
 
 	// Find datastore topology from the retrieved datastoreURL.
-	return calculateAccessibleTopologiesForDatastore(ctx, params.VCenter,
+	return placementengine.CalculateAccessibleTopologiesForDatastore(ctx, params.VCenter,
 		params.TopologySegmentsMap[params.VCHost], allNodeVMs, datastoreURL, params.NodeManager)
 }
 
@@ -137,14 +141,22 @@ type controller struct {
 	// To be removed after multi vCenter support is added
 	manager  *common.Manager
 	managers *common.Managers
-	nodeMgr  NodeManagerInterface
+	// managersLock protects the maps of managers updated by
+	// ReloadConfiguration from the readers running concurrently with it.
+	managersLock sync.RWMutex
+	nodeMgr      NodeManagerInterface
 	// Deprecated
 	// To be removed after multi vCenter support is added
 	authMgr     common.AuthorizationService
 	authMgrs    map[string]*common.AuthManager
 	topologyMgr commoncotypes.ControllerTopologyService
 	csi.UnimplementedControllerServer
+	csi.UnimplementedSnapshotMetadataServer
+	csi.UnimplementedGroupControllerServer
 	topologyCalc TopologyCalculatorInterface
+	// volumeSlotsQueue holds the IDs of the nodes whose available volume
+	// slots are to be annotated.
+	volumeSlotsQueue workqueue.TypedDelayingInterface[string]
 }
 
 var (
@@ -188,12 +200,28 @@ func (c *controller) Init(config *cnsconfig.Config, version string) error {
 	var err error
 	var operationStore cnsvolumeoperationrequest.VolumeOperationRequest
 
-	operationStore, err = cnsvolumeoperationrequest.InitVolumeOperationRequestInterface(ctx,
-		config.Global.CnsVolumeOperationRequestCleanupIntervalInMin,
-		func() bool {
-			return commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot)
-		}, false,
-		false)
+	orchestratorType, err := commonco.GetContainerOrchestratorType(ctx)
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to get the container orchestrator. err=%v", err)
+	}
+	if orchestratorType == common.Nomad {
+		// There is no API server to persist CnsVolumeOperationRequest instances,
+		// the container orchestrator persists the operation details instead.
+		co := commonco.ContainerOrchestratorUtility
+		persister, ok := co.(cnsvolumeoperationrequest.VolumeOperationRequestPersister)
+		if !ok {
+			return logger.LogNewError(log, "container orchestrator cannot persist the volume operation details")
+		}
+		operationStore, err = cnsvolumeoperationrequest.InitPersistentVolumeOperationRequestInterface(ctx,
+			config.Global.CnsVolumeOperationRequestCleanupIntervalInMin, persister)
+	} else {
+		operationStore, err = cnsvolumeoperationrequest.InitVolumeOperationRequestInterface(ctx,
+			config.Global.CnsVolumeOperationRequestCleanupIntervalInMin,
+			func() bool {
+				return commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot)
+			}, false,
+			false)
+	}
 	if err != nil {
 		log.Errorf("failed to initialize VolumeOperationRequestInterface with error: %v", err)
 		return err
@@ -308,13 +336,15 @@ func (c *controller) Init(config *cnsconfig.Config, version string) error {
 		}
 	}
 
-	c.nodeMgr = &node.Nodes{}
-	err = c.nodeMgr.Initialize(ctx)
+	c.nodeMgr, err = initNodeManager(ctx)
 	if err != nil {
 		log.Errorf("failed to initialize nodeMgr. err=%v", err)
 		return err
 	}
 
+	c.volumeSlotsQueue = workqueue.NewTypedDelayingQueue[string]()
+	go c.runVolumeSlotsWorker()
+
 	go cnsvolume.ClearInvalidTasksFromListView(true)
 	cfgPath := cnsconfig.GetConfigPath(ctx)
 
@@ -394,6 +424,18 @@ func (c *controller) Init(config *cnsconfig.Config, version string) error {
 	return nil
 }
 
+// HealthChecks checks the sessions of the vCenters of the controller and the
+// listview of their volume managers.
+func (c *controller) HealthChecks(ctx context.Context) []csitypes.HealthCheck {
+	if c.managers == nil {
+		return []csitypes.HealthCheck{{Name: "controller", Message: "controller is not initialized"}}
+	}
+	c.managersLock.RLock()
+	volumeManagers := maps.Clone(c.managers.VolumeManagers)
+	c.managersLock.RUnlock()
+	return common.GetVCenterHealthChecks(ctx, c.managers.VcenterManager, volumeManagers)
+}
+
 // ReloadConfiguration reloads configuration from the secret, and update
 // controller's config cache and VolumeManager's VC Config cache.
 func (c *controller) ReloadConfiguration() error {
@@ -407,6 +449,8 @@ func (c *controller) ReloadConfiguration() error {
 	if err != nil {
 		return logger.LogNewErrorf(log, "failed to get VirtualCenterConfigs. err=%v", err)
 	}
+	c.managersLock.Lock()
+	defer c.managersLock.Unlock()
 	for _, newVCConfig := range newVcenterConfigs {
 		newVCConfig.ReloadVCConfigForNewClient = true
 		if c.managers.VolumeManagers[newVCConfig.Host] == nil {
@@ -440,8 +484,7 @@ func (c *controller) ReloadConfiguration() error {
 	}
 	// Re-Initialize Node Manager to cache latest vCenter config.
 	log.Debug("Re-Initializing node manager")
-	c.nodeMgr = &node.Nodes{}
-	err = c.nodeMgr.Initialize(ctx)
+	c.nodeMgr, err = initNodeManager(ctx)
 	if err != nil {
 		log.Errorf("failed to re-initialize nodeMgr. err=%v", err)
 		return err
@@ -449,6 +492,17 @@ func (c *controller) ReloadConfiguration() error {
 	return nil
 }
 
+// initNodeManager initializes a node manager registering the nodes of the
+// CSINode instances on Kubernetes, or the nodes listed by the container
+// orchestrator otherwise.
+func initNodeManager(ctx context.Context) (*node.Nodes, error) {
+	nodeMgr := &node.Nodes{}
+	if lister, ok := commonco.ContainerOrchestratorUtility.(node.NodeLister); ok {
+		return nodeMgr, nodeMgr.InitializeWithNodeLister(ctx, lister)
+	}
+	return nodeMgr, nodeMgr.Initialize(ctx)
+}
+
 func (c *controller) filterDatastores(ctx context.Context, sharedDatastores []*cnsvsphere.DatastoreInfo,
 	vcHost string) ([]*cnsvsphere.DatastoreInfo, error) {
 	log := logger.GetLogger(ctx)
@@ -495,6 +549,45 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
 			"parsing storage class parameters failed with error: %+v", err)
 	}
+	if scParams.LuksEncryption {
+		// LUKS devices are opened on the node before the filesystem is
+		// created, so raw block volumes cannot be encrypted.
+		for _, capability := range req.GetVolumeCapabilities() {
+			if capability.GetBlock() != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"parameter %q is not supported for raw block volumes", common.AttributeLuksEncryption)
+			}
+		}
+	}
+	if scParams.FileShareProtocol != "" {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported for block volumes", common.AttributeFileShareProtocol)
+	}
+	if scParams.NfsVersion != "" || scParams.NfsSecurity != "" || scParams.NfsMountOptions != "" {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameters %q, %q and %q are not supported for block volumes",
+			common.AttributeNfsVersion, common.AttributeNfsSecurity, common.AttributeNfsMountOptions)
+	}
+	if scParams.MkfsOptions != "" || scParams.FsckMode != "" {
+		// The filesystem options are validated against the fstype here, and
+		// once more by the node before running mkfs or fsck.
+		for _, capability := range req.GetVolumeCapabilities() {
+			if capability.GetBlock() != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"parameters %q and %q are not supported for raw block volumes",
+					common.AttributeMkfsOptions, common.AttributeFsckMode)
+			}
+			fsType := strings.ToLower(capability.GetMount().GetFsType())
+			if _, err := common.ParseMkfsOptions(fsType, scParams.MkfsOptions); err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"invalid parameter %q: %v", common.AttributeMkfsOptions, err)
+			}
+			if err := common.ValidateFsckMode(fsType, scParams.FsckMode); err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"invalid parameter %q: %v", common.AttributeFsckMode, err)
+			}
+		}
+	}
 
 	if scParams.CSIMigration == "true" {
 		if len(c.managers.VcenterConfigs) > 1 {
@@ -548,21 +641,34 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 			}
 		}
 	}
-	// Check if requested volume size and source snapshot size matches.
+	// Check if requested volume size and source snapshot size matches, or if
+	// requested volume size is not smaller than the source volume size.
 	volumeSource := req.GetVolumeContentSource()
-	var contentSourceSnapshotID, snapshotDatastoreURL string
+	var contentSourceSnapshotID, contentSourceVolumeID, snapshotDatastoreURL string
+	var sourceVolumeManager cnsvolume.Manager
+	createSizeMB := volSizeMB
 	if volumeSource != nil {
-		sourceSnapshot := volumeSource.GetSnapshot()
-		if sourceSnapshot == nil {
+		var cnsVolumeID, sourceType string
+		if sourceSnapshot := volumeSource.GetSnapshot(); sourceSnapshot != nil {
+			sourceType = "snapshot"
+			contentSourceSnapshotID = sourceSnapshot.GetSnapshotId()
+			cnsVolumeID, _, err = common.ParseCSISnapshotID(contentSourceSnapshotID)
+			if err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log,
+					codes.InvalidArgument, err.Error())
+			}
+		} else if sourceVolume := volumeSource.GetVolume(); sourceVolume != nil {
+			sourceType = "volume"
+			contentSourceVolumeID = sourceVolume.GetVolumeId()
+			if contentSourceVolumeID == "" || strings.Contains(contentSourceVolumeID, ".vmdk") {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"cloning of volume %q is not supported", contentSourceVolumeID)
+			}
+			cnsVolumeID = contentSourceVolumeID
+		} else {
 			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
 				"unsupported VolumeContentSource type")
 		}
-		contentSourceSnapshotID = sourceSnapshot.GetSnapshotId()
-		cnsVolumeID, _, err := common.ParseCSISnapshotID(contentSourceSnapshotID)
-		if err != nil {
-			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log,
-				codes.InvalidArgument, err.Error())
-		}
 		// Get VC, volumeManager for given volumeID.
 		vCenterHost, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, cnsVolumeID,
 			volumeInfoService)
@@ -570,6 +676,9 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
 				"failed to get vCenter/volume manager for volumeID: %q. Error: %+v", cnsVolumeID, err)
 		}
+		sourceVolumeManager = volumeManager
+		// Clones are created from a temporary CNS snapshot of the source volume,
+		// so both operations need CNS snapshot support on the vCenter.
 		isCnsSnapshotSupported, err := c.managers.VcenterManager.IsCnsSnapshotSupported(ctx, vCenterHost)
 		if err != nil {
 			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
@@ -581,7 +690,7 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 				"VC %q does not support snapshot operations", vCenterHost)
 		}
 
-		// Query capacity in MB and datastore url for block volume snapshot.
+		// Query capacity in MB and datastore url for block volume snapshot or source volume.
 		volumeIds := []cnstypes.CnsVolumeId{{Id: cnsVolumeID}}
 		cnsVolumeDetailsMap, err := utils.QueryVolumeDetailsUtil(ctx, volumeManager, volumeIds)
 		if err != nil {
@@ -589,35 +698,53 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 				"failed to retrieve volume details for ID %q. Error: %+v", cnsVolumeID, err)
 		}
 		if _, ok := cnsVolumeDetailsMap[cnsVolumeID]; !ok {
+			if contentSourceVolumeID != "" {
+				return nil, csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+					"source volume %q not found", cnsVolumeID)
+			}
 			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
 				"CNS query volume failed to find the volume: %q", cnsVolumeID)
 		}
-		snapshotSizeInMB := cnsVolumeDetailsMap[cnsVolumeID].SizeInMB
-		snapshotSizeInBytes := snapshotSizeInMB * common.MbInBytes
-		if volSizeBytes != snapshotSizeInBytes {
+		if contentSourceVolumeID != "" && cnsVolumeDetailsMap[cnsVolumeID].VolumeType != common.BlockVolumeType {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"source volume %q is not a block volume", cnsVolumeID)
+		}
+		sourceSizeInMB := cnsVolumeDetailsMap[cnsVolumeID].SizeInMB
+		sourceSizeInBytes := sourceSizeInMB * common.MbInBytes
+		if contentSourceVolumeID != "" {
+			if volSizeBytes < sourceSizeInBytes {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"requested volume size: %d is smaller than source volume size: %d",
+					volSizeBytes, sourceSizeInBytes)
+			}
+			// CNS creates the clone with the size of the source volume. The clone is
+			// expanded to the requested size once it is created.
+			createSizeMB = sourceSizeInMB
+		} else if volSizeBytes != sourceSizeInBytes {
 			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
 				"snapshot size mismatch, requested volume size: %d but source snapshot size: %d",
-				volSizeBytes, snapshotSizeInBytes)
+				volSizeBytes, sourceSizeInBytes)
 		}
-		// Store the datastoreURL of snapshot for future use.
+		// Store the datastoreURL of snapshot or source volume for future use.
 		snapshotDatastoreURL = cnsVolumeDetailsMap[cnsVolumeID].DatastoreUrl
 		// If DatastoreURL parameter is given in StorageClass, check if
-		// snapshot datastore URL is same as DatastoreURL.
+		// snapshot or source volume datastore URL is same as DatastoreURL.
 		if scParams.DatastoreURL != "" {
 			if strings.TrimSpace(snapshotDatastoreURL) != strings.TrimSpace(scParams.DatastoreURL) {
 				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
-					"datastore URL %q given in storage class does not match the snapshot datastore URL %q.",
-					scParams.DatastoreURL, snapshotDatastoreURL)
+					"datastore URL %q given in storage class does not match the %s datastore URL %q.",
+					scParams.DatastoreURL, sourceType, snapshotDatastoreURL)
 			}
 		}
 	}
 
 	var createVolumeSpec = common.CreateVolumeSpec{
-		CapacityMB:              volSizeMB,
+		CapacityMB:              createSizeMB,
 		Name:                    req.Name,
 		ScParams:                scParams,
 		VolumeType:              common.BlockVolumeType,
 		ContentSourceSnapshotID: contentSourceSnapshotID,
+		ContentSourceVolumeID:   contentSourceVolumeID,
 	}
 	// Check if vCenter task for this volume is already registered as part of
 	// improved idempotency CR.
@@ -782,6 +909,20 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 	}
 
 	if !volTaskAlreadyRegistered {
+		if contentSourceVolumeID != "" {
+			// CNS clones a volume by creating the new volume from a snapshot of the
+			// source volume. The snapshot is named after the request, so that retries
+			// of the request reuse the snapshot created by the previous attempts.
+			createVolumeSpec.ContentSourceSnapshotID, _, err = common.CreateSnapshotUtil(ctx,
+				sourceVolumeManager, contentSourceVolumeID, getCloneSnapshotName(req.Name),
+				&cnsvolume.CreateSnapshotExtraParams{
+					IsCSITransactionSupportEnabled: isCSITransactionSupportEnabled,
+				})
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to create snapshot on source volume %q with error: %v", contentSourceVolumeID, err)
+			}
+		}
 		// Iterate through each VC and its accessibility requirements to try and create a volume.
 		// If it fails for any reason, move to the next VC in list.
 		if topologyRequirement != nil {
@@ -795,7 +936,7 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 				}
 
 				// If Storage policy is given, check if it exists in the VC. If not found, continue to next VC.
-				var storagePolicyID string
+				storagePolicyID := scParams.StoragePolicyID
 				if scParams.StoragePolicyName != "" {
 					storagePolicyID, err = vcenter.GetStoragePolicyIDByName(ctx, scParams.StoragePolicyName)
 					if err != nil {
@@ -919,7 +1060,7 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 
 			// If Storage policy is given, check if it exists in the VC.
 			// If not found, fail Volume Creation
-			var storagePolicyID string
+			storagePolicyID := scParams.StoragePolicyID
 			if scParams.StoragePolicyName != "" {
 				storagePolicyID, err = vcenter.GetStoragePolicyIDByName(ctx, scParams.StoragePolicyName)
 				if err != nil {
@@ -996,9 +1137,47 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 		return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
 			"failed to create volume. Errors encountered: %+v", combinedErrMssgs)
 	}
+	if contentSourceVolumeID != "" {
+		// The snapshot the clone was created from is only deleted once the clone
+		// is created, so that retries of a failed or timed out request can reuse it.
+		deleteCloneSnapshot(ctx, sourceVolumeManager, operationStore, contentSourceVolumeID,
+			createVolumeSpec.ContentSourceSnapshotID, req.Name)
+		if volSizeMB > createSizeMB {
+			if volumeMgr == nil {
+				volumeMgr, err = GetVolumeManagerFromVCHost(ctx, c.managers, vcHost)
+				if err != nil {
+					return nil, csifault.CSIInternalFault, logger.LogNewErrorCode(log, codes.Internal, err.Error())
+				}
+			}
+			faultType, err = common.ExpandVolumeUtil(ctx, c.managers.VcenterManager, vcHost, volumeMgr,
+				volumeInfo.VolumeID.Id, volSizeMB, nil)
+			if err != nil {
+				return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to expand volume %q cloned from volume %q to size: %d Mb. Error: %+v",
+					volumeInfo.VolumeID.Id, contentSourceVolumeID, volSizeMB, err)
+			}
+		}
+	}
 
 	attributes := make(map[string]string)
 	attributes[common.AttributeDiskType] = common.DiskTypeBlockVolume
+	if scParams.LuksEncryption {
+		attributes[common.AttributeLuksEncryption] = "true"
+	}
+	if scParams.MkfsOptions != "" {
+		attributes[common.AttributeMkfsOptions] = scParams.MkfsOptions
+	}
+	if scParams.FsckMode != "" {
+		attributes[common.AttributeFsckMode] = scParams.FsckMode
+	}
+	// The storage I/O allocation is a setting of the disk on the node VM, so
+	// it is applied by ControllerPublishVolume.
+	if scParams.IopsLimit > 0 {
+		attributes[common.AttributeIopsLimit] = strconv.FormatInt(scParams.IopsLimit, 10)
+	}
+	if scParams.IopsShares != "" {
+		attributes[common.AttributeIopsShares] = scParams.IopsShares
+	}
 
 	if scParams.CSIMigration == "true" {
 		volumePath, err := volumeMigrationService.GetVolumePath(ctx, volumeInfo.VolumeID.Id)
@@ -1062,6 +1241,16 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 			},
 		}
 	}
+	// Set the Volume VolumeContentSource in the CreateVolumeResponse
+	if contentSourceVolumeID != "" {
+		resp.Volume.ContentSource = &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Volume{
+				Volume: &csi.VolumeContentSource_VolumeSource{
+					VolumeId: contentSourceVolumeID,
+				},
+			},
+		}
+	}
 	if len(c.managers.VcenterConfigs) > 1 {
 		// Create CNSVolumeInfo CR for the volume ID.
 		err = volumeInfoService.CreateVolumeInfo(ctx, volumeInfo.VolumeID.Id, vcHost)
@@ -1074,55 +1263,6 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 	return resp, "", nil
 }
 
-// calculateAccessibleTopologiesForDatastore figures out the list of topologies from
-// which the given datastore is accessible when multi-VC FSS is enabled.
-func calculateAccessibleTopologiesForDatastore(ctx context.Context, vcenter *cnsvsphere.VirtualCenter,
-	topologySegments []map[string]string, allNodeVMs []*cnsvsphere.VirtualMachine,
-	datastoreURL string, nodeMgr NodeManagerInterface) (
-	[]map[string]string, error) {
-	log := logger.GetLogger(ctx)
-	var datastoreAccessibleTopology []map[string]string
-
-	// Find out all nodeVMs which have access to the chosen datastore among all the nodes in k8s cluster.
-	accessibleNodes, err := common.GetNodeVMsWithAccessToDatastore(ctx, vcenter, datastoreURL, allNodeVMs)
-	if err != nil || len(accessibleNodes) == 0 {
-		return nil, logger.LogNewErrorCodef(log, codes.Internal,
-			"failed to find all the nodes from which the datastore %q is accessible", datastoreURL)
-	}
-
-	// Get node names for the accessible nodeVMs so that we can query CSINodeTopology CRs.
-	var accessibleNodeNames []string
-	for _, vmref := range accessibleNodes {
-		// Get UUID from VM reference.
-		vmUUID, err := cnsvsphere.GetUUIDFromVMReference(ctx, vcenter, vmref.Reference())
-		if err != nil {
-			return nil, logger.LogNewErrorCode(log, codes.Internal,
-				err.Error())
-		}
-		// Get NodeVM name from VM UUID.
-		nodeName, err := nodeMgr.GetNodeNameByUUID(ctx, vmUUID)
-		if err != nil {
-			return nil, logger.LogNewErrorCode(log, codes.Internal,
-				err.Error())
-		}
-		accessibleNodeNames = append(accessibleNodeNames, nodeName)
-	}
-
-	datastoreAccessibleTopology, err = placementengine.GetTopologyInfoFromNodes(ctx,
-		placementengine.VanillaRetrieveTopologyInfoParams{
-			VCHost:                    vcenter.Config.Host,
-			NodeNames:                 accessibleNodeNames,
-			DatastoreURL:              datastoreURL,
-			RequestedTopologySegments: topologySegments,
-		})
-	if err != nil {
-		return nil, logger.LogNewErrorCodef(log, codes.Internal,
-			"failed to find accessible topologies for the nodes %v. Error: %+v",
-			accessibleNodeNames, err)
-	}
-	return datastoreAccessibleTopology, nil
-}
-
 // createFileVolume creates a file volume based on the CreateVolumeRequest.
 func (c *controller) createFileVolume(ctx context.Context, req *csi.CreateVolumeRequest) (
 	*csi.CreateVolumeResponse, string, error) {
@@ -1145,6 +1285,33 @@ func (c *controller) createFileVolume(ctx context.Context, req *csi.CreateVolume
 		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
 			"parsing storage class parameters failed with error: %+v", err)
 	}
+	if scParams.LuksEncryption {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported for file volumes", common.AttributeLuksEncryption)
+	}
+	if scParams.MkfsOptions != "" || scParams.FsckMode != "" {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameters %q and %q are not supported for file volumes",
+			common.AttributeMkfsOptions, common.AttributeFsckMode)
+	}
+	if scParams.ProvisioningType != "" || scParams.IopsLimit != 0 || scParams.IopsShares != "" {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameters %q, %q and %q are not supported for file volumes",
+			common.AttributeDiskProvisioningType, common.AttributeIopsLimit, common.AttributeIopsShares)
+	}
+	var smbShareConfig *cnsvsphere.SmbFileShareConfig
+	if scParams.FileShareProtocol == common.SmbFileShareProtocol {
+		if scParams.NfsVersion != "" || scParams.NfsSecurity != "" || scParams.NfsMountOptions != "" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"parameters %q, %q and %q are not supported for %s file volumes", common.AttributeNfsVersion,
+				common.AttributeNfsSecurity, common.AttributeNfsMountOptions, common.SmbFileShareProtocol)
+		}
+		smbShareConfig, err = getSmbFileShareConfig(req.GetSecrets(), c.managers.CnsConfig.NetPermissions)
+		if err != nil {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"invalid provisioner secret for SMB file volume %q. Error: %v", req.Name, err)
+		}
+	}
 
 	var (
 		volTaskAlreadyRegistered bool
@@ -1287,7 +1454,7 @@ func (c *controller) createFileVolume(ctx context.Context, req *csi.CreateVolume
 						"failed to get vCenter instance for host %q. Error: %+v", vcHost, err)
 				}
 				// If Storage policy is given, check if it exists in the VC. If not found, continue to next VC.
-				var storagePolicyID string
+				storagePolicyID := scParams.StoragePolicyID
 				if scParams.StoragePolicyName != "" {
 					storagePolicyID, err = vcenter.GetStoragePolicyIDByName(ctx, scParams.StoragePolicyName)
 					if err != nil {
@@ -1350,7 +1517,7 @@ func (c *controller) createFileVolume(ctx context.Context, req *csi.CreateVolume
 				log.Infof("fsEnabledCandidateDatastores %v", fsEnabledCandidateDatastores)
 
 				// Filter Storage policy compatible datastores from candidate datastores list.
-				if scParams.StoragePolicyName != "" {
+				if storagePolicyID != "" {
 					// Check storage policy compatibility.
 					var candidateDSMoRef []types.ManagedObjectReference
 					for _, ds := range fsEnabledCandidateDatastores {
@@ -1452,8 +1619,37 @@ func (c *controller) createFileVolume(ctx context.Context, req *csi.CreateVolume
 		}
 	}
 
+	if scParams.NfsSecurity != "" && scParams.NfsSecurity != common.NfsSecuritySys {
+		// CNS creates file shares with the SYS security, so the matching
+		// security is set once the share is created. This is also done when
+		// a retried request finds the volume already created.
+		if err = configureNfsFileShareSecurity(ctx, c, volumeID, scParams.NfsSecurity); err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to configure NFS security of file share of volume %q. Error: %+v", volumeID, err)
+		}
+	}
+	if smbShareConfig != nil {
+		// CNS creates NFS file shares, which are reconfigured to SMB once
+		// created. This is also done when a retried request finds the volume
+		// already created, in case the previous request failed in between.
+		if err = configureSmbFileShare(ctx, c, volumeID, smbShareConfig); err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to configure SMB file share of volume %q. Error: %+v", volumeID, err)
+		}
+	}
+
 	attributes := make(map[string]string)
 	attributes[common.AttributeDiskType] = common.DiskTypeFileVolume
+	// The NFS settings are passed to the nodes through the publish context.
+	if scParams.NfsVersion != "" {
+		attributes[common.AttributeNfsVersion] = scParams.NfsVersion
+	}
+	if scParams.NfsSecurity != "" {
+		attributes[common.AttributeNfsSecurity] = scParams.NfsSecurity
+	}
+	if scParams.NfsMountOptions != "" {
+		attributes[common.AttributeNfsMountOptions] = scParams.NfsMountOptions
+	}
 
 	resp := &csi.CreateVolumeResponse{
 		Volume: &csi.Volume{
@@ -1755,18 +1951,37 @@ func (c *controller) ControllerPublishVolume(ctx context.Context, req *csi.Contr
 			vSANFileBackingDetails :=
 				queryResult.Volumes[0].BackingObjectDetails.(*cnstypes.CnsVsanFileShareBackingDetails)
 			publishInfo[common.AttributeDiskType] = common.DiskTypeFileVolume
-			nfsv4AccessPointFound := false
+			nfsPublishInfo, err := getNfsPublishInfo(req.GetVolumeContext())
+			if err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"invalid NFS settings of volume %q. Error: %v", req.VolumeId, err)
+			}
+			// File shares of Storage Classes with the SMB protocol only have an
+			// SMB access point.
+			nfsAccessPointKey, nfsAccessPoint := common.Nfsv4AccessPointKey, common.Nfsv4AccessPoint
+			if req.GetVolumeContext()[common.AttributeNfsVersion] == common.NfsVersion3 {
+				nfsAccessPointKey, nfsAccessPoint = common.Nfsv3AccessPointKey, common.Nfsv3AccessPoint
+			}
+			accessPointFound := false
 			for _, kv := range vSANFileBackingDetails.AccessPoints {
-				if kv.Key == common.Nfsv4AccessPointKey {
-					publishInfo[common.Nfsv4AccessPoint] = kv.Value
-					nfsv4AccessPointFound = true
+				if kv.Key == nfsAccessPointKey {
+					publishInfo[nfsAccessPoint] = kv.Value
+					for key, value := range nfsPublishInfo {
+						publishInfo[key] = value
+					}
+					accessPointFound = true
+					break
+				}
+				if kv.Key == common.SmbAccessPointKey {
+					publishInfo[common.SmbAccessPoint] = kv.Value
+					accessPointFound = true
 					break
 				}
 			}
-			if !nfsv4AccessPointFound {
+			if !accessPointFound {
 				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
-					"failed to get NFSv4 access point for volume: %q. Returned vSAN file backing details: %+v",
-					req.VolumeId, vSANFileBackingDetails)
+					"failed to get %s or SMB access point for volume: %q. Returned vSAN file backing details: %+v",
+					nfsAccessPointKey, req.VolumeId, vSANFileBackingDetails)
 			}
 		} else {
 			// Block Volume.
@@ -1819,8 +2034,22 @@ func (c *controller) ControllerPublishVolume(ctx context.Context, req *csi.Contr
 				return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
 					"failed to attach disk: %+q with node: %q err %+v", req.VolumeId, req.NodeId, err)
 			}
+			storageIOAllocation, err := common.GetStorageIOAllocation(req.VolumeContext)
+			if err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"invalid volume context of volume %q. Error: %v", req.VolumeId, err)
+			}
+			if storageIOAllocation != nil {
+				err = nodevm.SetDiskStorageIOAllocation(ctx, req.VolumeId, storageIOAllocation)
+				if err != nil {
+					return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+						"failed to set storage I/O allocation of volume %q on node %q. Error: %v",
+						req.VolumeId, req.NodeId, err)
+				}
+			}
 			publishInfo[common.AttributeDiskType] = common.DiskTypeBlockVolume
 			publishInfo[common.AttributeFirstClassDiskUUID] = common.FormatDiskUUID(diskUUID)
+			c.queueVolumeSlotsUpdate(req.NodeId)
 		}
 		log.Infof("ControllerPublishVolume successful with publish context: %v", publishInfo)
 		return &csi.ControllerPublishVolumeResponse{
@@ -1961,6 +2190,7 @@ func (c *controller) ControllerUnpublishVolume(ctx context.Context, req *csi.Con
 			return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
 				"failed to detach disk: %+q from node: %q err %+v", req.VolumeId, req.NodeId, err)
 		}
+		c.queueVolumeSlotsUpdate(req.NodeId)
 		log.Infof("ControllerUnpublishVolume successful for volume ID: %s", req.VolumeId)
 		return &csi.ControllerUnpublishVolumeResponse{}, "", nil
 	}
@@ -2372,12 +2602,201 @@ func (c *controller) processQueryResultsListVolumes(ctx context.Context, startin
 	return entries, nextToken, volumeType, nil
 }
 
+// GetCapacity returns the aggregated free space of the shared datastores
+// which are accessible from the topology segment given in the request and are
+// compatible with the storage policy given in the StorageClass parameters.
 func (c *controller) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (
 	*csi.GetCapacityResponse, error) {
+	start := time.Now()
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("GetCapacity: called with args %+v", req)
-	return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "getCapacity")
+	volumeType := prometheus.PrometheusBlockVolumeType
+
+	getCapacityInternal := func() (*csi.GetCapacityResponse, string, error) {
+		log.Infof("GetCapacity: called with args %+v", req)
+		volumeCapabilities := req.GetVolumeCapabilities()
+		if len(volumeCapabilities) != 0 {
+			if err := common.IsValidVolumeCapabilities(ctx, volumeCapabilities); err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"volume capability not supported. Err: %+v", err)
+			}
+			if common.IsFileVolumeRequest(ctx, volumeCapabilities) {
+				volumeType = prometheus.PrometheusFileVolumeType
+				return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCode(log, codes.Unimplemented,
+					"getCapacity is not supported for file volumes")
+			}
+		}
+		// The external-provisioner passes the StorageClass parameters as they
+		// are, so drop the ones reserved for the sidecars before parsing them.
+		params := make(map[string]string)
+		for param, value := range req.GetParameters() {
+			if !strings.HasPrefix(param, common.CSIParameterPrefix) {
+				params[param] = value
+			}
+		}
+		scParams, err := common.ParseStorageClassParams(ctx, params, csiMigrationEnabled)
+		if err != nil {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"parsing storage class parameters failed with error: %+v", err)
+		}
+		if scParams.CSIMigration == "true" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"getCapacity is not supported for migrated in-tree vSphere volumes")
+		}
+
+		// Group the requested topology segments by the vCenter they belong to.
+		vcTopologySegmentsMap := make(map[string][]map[string]string)
+		accessibleTopology := req.GetAccessibleTopology()
+		if accessibleTopology != nil {
+			if c.managers.CnsConfig.Labels.TopologyCategories == "" && c.managers.CnsConfig.Labels.Zone == "" &&
+				c.managers.CnsConfig.Labels.Region == "" {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+					"topology category names not specified in the vsphere config secret")
+			}
+			if len(c.managers.VcenterConfigs) > 1 {
+				vcTopologySegmentsMap, err = common.GetAccessibilityRequirementsByVC(ctx,
+					&csi.TopologyRequirement{Preferred: []*csi.Topology{accessibleTopology}})
+				if err != nil {
+					return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+						"failed to get accessibility requirements by VC. Error: %+v", err)
+				}
+			} else {
+				vcTopologySegmentsMap[c.managers.CnsConfig.Global.VCenterIP] = []map[string]string{
+					accessibleTopology.GetSegments()}
+			}
+		} else {
+			if len(c.managers.VcenterConfigs) > 1 {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+					"accessible topology cannot be nil for a multi-VC environment")
+			}
+			vcTopologySegmentsMap[c.managers.CnsConfig.Global.VCenterIP] = nil
+		}
+		log.Debugf("Topology segments per VC are %+v", vcTopologySegmentsMap)
+
+		// Datastores are keyed by URL so that a datastore which is matched by
+		// more than one topology segment is only counted once.
+		capacityDatastores := make(map[string]*cnsvsphere.DatastoreInfo)
+		for vcHost, topologySegmentsList := range vcTopologySegmentsMap {
+			datastores, faultType, err := c.getCapacityDatastoresForVC(ctx, vcHost, topologySegmentsList, scParams)
+			if err != nil {
+				return nil, faultType, err
+			}
+			for _, ds := range datastores {
+				capacityDatastores[ds.Info.Url] = ds
+			}
+		}
+		availableCapacity, maximumVolumeSize := getAvailableCapacity(capacityDatastores)
+		log.Infof("GetCapacity: available capacity %d bytes and maximum volume size %d bytes across datastores %v",
+			availableCapacity, maximumVolumeSize, capacityDatastores)
+		return &csi.GetCapacityResponse{
+			AvailableCapacity: availableCapacity,
+			MaximumVolumeSize: wrapperspb.Int64(maximumVolumeSize),
+		}, "", nil
+	}
+	resp, faultType, err := getCapacityInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetCapacityOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetCapacityOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetCapacityOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// getCapacityDatastoresForVC returns the datastores in the given vCenter whose
+// free space counts towards the capacity reported by GetCapacity. When
+// topology segments are given, the shared datastores are computed per segment
+// by the placement engine. Otherwise, the datastores shared across all the
+// nodes in the cluster are used.
+func (c *controller) getCapacityDatastoresForVC(ctx context.Context, vcHost string,
+	topologySegmentsList []map[string]string, scParams *common.StorageClassParams) (
+	[]*cnsvsphere.DatastoreInfo, string, error) {
+	log := logger.GetLogger(ctx)
+	vcenter, err := common.GetVCenterFromVCHost(ctx, c.managers.VcenterManager, vcHost)
+	if err != nil {
+		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to get vCenter instance for host %q. Error: %+v", vcHost, err)
+	}
+	storagePolicyID := scParams.StoragePolicyID
+	if scParams.StoragePolicyName != "" {
+		storagePolicyID, err = vcenter.GetStoragePolicyIDByName(ctx, scParams.StoragePolicyName)
+		if err != nil {
+			// TODO: As govmomi doesn't support locale and all error messages are
+			// in English, we are temporarily resorting to a error message check.
+			errMssgFromPBM := fmt.Sprintf("no pbm profile found with name: %q", scParams.StoragePolicyName)
+			if err.Error() == errMssgFromPBM {
+				log.Infof("Storage policy name %q not found in VC %q. No capacity available in this VC.",
+					scParams.StoragePolicyName, vcHost)
+				return nil, "", nil
+			}
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get policy ID for storage policy name %q. Error: %+v",
+				scParams.StoragePolicyName, err)
+		}
+	}
+
+	var datastores []*cnsvsphere.DatastoreInfo
+	if len(topologySegmentsList) != 0 {
+		// Storage policy compatibility is checked by the placement engine.
+		datastores, err = placementengine.GetSharedDatastores(ctx,
+			placementengine.VanillaSharedDatastoresParams{
+				Vcenter:              vcenter,
+				TopologySegmentsList: topologySegmentsList,
+				StoragePolicyID:      storagePolicyID,
+			})
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get shared datastores for topology segments %+v in vCenter %q. Error: %+v",
+				topologySegmentsList, vcHost, err)
+		}
+	} else {
+		datastores, err = c.nodeMgr.GetSharedDatastoresInK8SCluster(ctx)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get shared datastores in kubernetes cluster. Error: %+v", err)
+		}
+		if storagePolicyID != "" {
+			datastores, err = filterDatastoresByStoragePolicy(ctx, vcenter, datastores, storagePolicyID)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to filter datastores by storage policy %q in vCenter %q. Error: %+v",
+					scParams.StoragePolicyName, vcHost, err)
+			}
+		}
+	}
+	if scParams.DatastoreURL != "" {
+		var matchingDatastores []*cnsvsphere.DatastoreInfo
+		for _, ds := range datastores {
+			if strings.TrimSpace(ds.Info.Url) == strings.TrimSpace(scParams.DatastoreURL) {
+				matchingDatastores = append(matchingDatastores, ds)
+			}
+		}
+		datastores = matchingDatastores
+	}
+	if len(datastores) == 0 {
+		log.Infof("No compatible datastores found for topology segments %+v in vCenter %q",
+			topologySegmentsList, vcHost)
+		return nil, "", nil
+	}
+	// Filter datastores based on user access.
+	datastores, err = c.filterDatastores(ctx, datastores, vcHost)
+	if err != nil {
+		if err == errAllDSFilteredOut {
+			log.Infof("authorization service filtered out all the compatible datastores in vCenter %q", vcHost)
+			return nil, "", nil
+		}
+		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to filter datastores based on authorisation check in vCenter %q. Error: %+v",
+			vcHost, err)
+	}
+	return datastores, "", nil
 }
 
 // initVolumeMigrationService is a helper method to initialize
@@ -2423,12 +2842,19 @@ func (c *controller) ControllerGetCapabilities(ctx context.Context, req *csi.Con
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
 		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+		csi.ControllerServiceCapability_RPC_CLONE_VOLUME,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
 	}
 
 	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.ListVolumes) {
 		controllerCaps = append(controllerCaps, csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
 			csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES)
 	}
+	if c.isVolumePolicyReconfigSupported(ctx) {
+		controllerCaps = append(controllerCaps, csi.ControllerServiceCapability_RPC_MODIFY_VOLUME)
+	}
 	var caps []*csi.ControllerServiceCapability
 	for _, cap := range controllerCaps {
 		c := &csi.ControllerServiceCapability{
@@ -2448,13 +2874,10 @@ func (c *controller) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshot
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
 	var (
-		vCenterHost                              string
-		vCenterManager                           cnsvsphere.VirtualCenterManager
-		volumeManager                            cnsvolume.Manager
-		err                                      error
-		maxSnapshotsPerBlockVolume               int
-		granularMaxSnapshotsPerBlockVolumeInVSAN int
-		granularMaxSnapshotsPerBlockVolumeInVVOL int
+		vCenterHost    string
+		vCenterManager cnsvsphere.VirtualCenterManager
+		volumeManager  cnsvolume.Manager
+		err            error
 	)
 	log.Infof("CreateSnapshot: called with args %+v", req)
 
@@ -2512,47 +2935,8 @@ func (c *controller) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshot
 				"queried volume doesn't have the expected volume type. Expected VolumeType: %v. "+
 					"Queried VolumeType: %v", volumeType, cnsVolumeDetailsMap[volumeID].VolumeType)
 		}
-		// Check if snapshots number of this volume reaches the granular limit on VSAN/VVOL
-		maxSnapshotsPerBlockVolume = c.managers.CnsConfig.Snapshot.GlobalMaxSnapshotsPerBlockVolume
-		granularMaxSnapshotsPerBlockVolumeInVSAN =
-			c.managers.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVSAN
-		granularMaxSnapshotsPerBlockVolumeInVVOL =
-			c.managers.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVVOL
-		log.Infof("The limit of the maximum number of snapshots per block volume is "+
-			"set to the global maximum (%v) by default.", maxSnapshotsPerBlockVolume)
-
-		if granularMaxSnapshotsPerBlockVolumeInVSAN > 0 || granularMaxSnapshotsPerBlockVolumeInVVOL > 0 {
-			var isGranularMaxEnabled bool
-			if strings.Contains(datastoreUrl, strings.ToLower(string(types.HostFileSystemVolumeFileSystemTypeVsan))) {
-				if granularMaxSnapshotsPerBlockVolumeInVSAN > 0 {
-					maxSnapshotsPerBlockVolume = granularMaxSnapshotsPerBlockVolumeInVSAN
-					isGranularMaxEnabled = true
-				}
-			} else if strings.Contains(datastoreUrl, strings.ToLower(string(types.HostFileSystemVolumeFileSystemTypeVVOL))) {
-				if granularMaxSnapshotsPerBlockVolumeInVVOL > 0 {
-					maxSnapshotsPerBlockVolume = granularMaxSnapshotsPerBlockVolumeInVVOL
-					isGranularMaxEnabled = true
-				}
-			}
-
-			if isGranularMaxEnabled {
-				log.Infof("The limit of the maximum number of snapshots per block volume on datastore %q is "+
-					"overridden by the granular maximum (%v).", datastoreUrl, maxSnapshotsPerBlockVolume)
-			}
-		}
-
-		// Check if snapshots number of this volume reaches the limit
-		snapshotList, _, err := common.QueryVolumeSnapshotsByVolumeID(ctx, volumeManager, volumeID,
-			common.QuerySnapshotLimit)
-		if err != nil {
-			return nil, logger.LogNewErrorCodef(log, codes.Internal,
-				"failed to query snapshots of volume %s for the limit check. Error: %v", volumeID, err)
-		}
-
-		if len(snapshotList) >= maxSnapshotsPerBlockVolume {
-			return nil, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
-				"the number of snapshots on the source volume %s reaches the configured maximum (%v)",
-				volumeID, maxSnapshotsPerBlockVolume)
+		if err := c.checkSnapshotLimit(ctx, volumeManager, volumeID, datastoreUrl); err != nil {
+			return nil, err
 		}
 
 		// the returned snapshotID below is a combination of CNS VolumeID and CNS SnapshotID concatenated by the "+"
@@ -2603,6 +2987,56 @@ func (c *controller) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshot
 	return resp, err
 }
 
+// checkSnapshotLimit fails with FailedPrecondition if the number of snapshots
+// of the given volume reached the configured maximum for its datastore.
+func (c *controller) checkSnapshotLimit(ctx context.Context, volumeManager cnsvolume.Manager,
+	volumeID string, datastoreURL string) error {
+	log := logger.GetLogger(ctx)
+	// Check if snapshots number of this volume reaches the granular limit on VSAN/VVOL
+	maxSnapshotsPerBlockVolume := c.managers.CnsConfig.Snapshot.GlobalMaxSnapshotsPerBlockVolume
+	granularMaxSnapshotsPerBlockVolumeInVSAN :=
+		c.managers.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVSAN
+	granularMaxSnapshotsPerBlockVolumeInVVOL :=
+		c.managers.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVVOL
+	log.Infof("The limit of the maximum number of snapshots per block volume is "+
+		"set to the global maximum (%v) by default.", maxSnapshotsPerBlockVolume)
+
+	if granularMaxSnapshotsPerBlockVolumeInVSAN > 0 || granularMaxSnapshotsPerBlockVolumeInVVOL > 0 {
+		var isGranularMaxEnabled bool
+		if strings.Contains(datastoreURL, strings.ToLower(string(types.HostFileSystemVolumeFileSystemTypeVsan))) {
+			if granularMaxSnapshotsPerBlockVolumeInVSAN > 0 {
+				maxSnapshotsPerBlockVolume = granularMaxSnapshotsPerBlockVolumeInVSAN
+				isGranularMaxEnabled = true
+			}
+		} else if strings.Contains(datastoreURL, strings.ToLower(string(types.HostFileSystemVolumeFileSystemTypeVVOL))) {
+			if granularMaxSnapshotsPerBlockVolumeInVVOL > 0 {
+				maxSnapshotsPerBlockVolume = granularMaxSnapshotsPerBlockVolumeInVVOL
+				isGranularMaxEnabled = true
+			}
+		}
+
+		if isGranularMaxEnabled {
+			log.Infof("The limit of the maximum number of snapshots per block volume on datastore %q is "+
+				"overridden by the granular maximum (%v).", datastoreURL, maxSnapshotsPerBlockVolume)
+		}
+	}
+
+	// Check if snapshots number of this volume reaches the limit
+	snapshotList, _, err := common.QueryVolumeSnapshotsByVolumeID(ctx, volumeManager, volumeID,
+		common.QuerySnapshotLimit)
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to query snapshots of volume %s for the limit check. Error: %v", volumeID, err)
+	}
+
+	if len(snapshotList) >= maxSnapshotsPerBlockVolume {
+		return logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+			"the number of snapshots on the source volume %s reaches the configured maximum (%v)",
+			volumeID, maxSnapshotsPerBlockVolume)
+	}
+	return nil
+}
+
 func (c *controller) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (
 	*csi.DeleteSnapshotResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
@@ -2947,18 +3381,217 @@ func processQueryResultsListSnapshots(ctx context.Context, startingToken int,
 	return snapEntries, nextToken, nil
 }
 
+// ControllerGetVolume returns the capacity, the published nodes and the
+// condition of the volume. The condition is derived from the health status of
+// the volume reported by CNS.
 func (c *controller) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (
 	*csi.ControllerGetVolumeResponse, error) {
+	start := time.Now()
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("ControllerGetVolume: called with args %+v", req)
-	return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "controllerGetVolume")
+	volumeType := prometheus.PrometheusUnknownVolumeType
+	controllerGetVolumeInternal := func() (
+		*csi.ControllerGetVolumeResponse, string, error) {
+		var err error
+		log.Infof("ControllerGetVolume: called with args %+v", req)
+		if req.VolumeId == "" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"volume ID is a required parameter")
+		}
+		volumeID := req.VolumeId
+		if strings.Contains(req.VolumeId, ".vmdk") {
+			if err := initVolumeMigrationService(ctx, c); err != nil {
+				// Error is already wrapped in CSI error code.
+				return nil, csifault.CSIInternalFault, err
+			}
+			volumeID, err = volumeMigrationService.GetVolumeID(ctx,
+				&migration.VolumeSpec{VolumePath: req.VolumeId}, false)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get VolumeID from volumeMigrationService for volumePath: %q", req.VolumeId)
+			}
+		}
+		_, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volume Id: %q. Error: %v", volumeID, err)
+		}
+		volume, capacityInBytes, volumeCondition, faultType, err := common.GetVolumeUtil(ctx,
+			volumeManager, volumeID)
+		if err != nil {
+			return nil, faultType, err
+		}
+		if volume.VolumeType == common.FileVolumeType {
+			volumeType = prometheus.PrometheusFileVolumeType
+		} else {
+			volumeType = prometheus.PrometheusBlockVolumeType
+		}
+		// Node IDs in the vanilla flavor are the UUIDs of the node VMs.
+		var publishedNodeIDs []string
+		nodeNames := commonco.ContainerOrchestratorUtility.GetNodesForVolumes(ctx, []string{volumeID})
+		for _, nodeName := range nodeNames[volumeID] {
+			nodeVM, err := c.nodeMgr.GetNodeVMByNameAndUpdateCache(ctx, nodeName)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get node VM for node %q. Error: %v", nodeName, err)
+			}
+			publishedNodeIDs = append(publishedNodeIDs, nodeVM.UUID)
+		}
+		resp := &csi.ControllerGetVolumeResponse{
+			Volume: &csi.Volume{
+				VolumeId:      req.VolumeId,
+				CapacityBytes: capacityInBytes,
+			},
+			Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+				PublishedNodeIds: publishedNodeIDs,
+				VolumeCondition:  volumeCondition,
+			},
+		}
+		return resp, "", nil
+	}
+
+	resp, faultType, err := controllerGetVolumeInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetVolumeOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetVolumeOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetVolumeOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
 }
 
+// ControllerModifyVolume changes the storage policy of a block volume to the
+// one given in the mutable parameters of the VolumeAttributesClass.
 func (c *controller) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (
 	*csi.ControllerModifyVolumeResponse, error) {
+	start := time.Now()
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("ControllerModifyVolume: called with args %+v", req)
-	return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "ControllerModifyVolume")
+	volumeType := prometheus.PrometheusUnknownVolumeType
+
+	controllerModifyVolumeInternal := func() (*csi.ControllerModifyVolumeResponse, string, error) {
+		log.Infof("ControllerModifyVolume: called with args %+v", req)
+		volumeID := req.GetVolumeId()
+		if volumeID == "" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"volume ID must be provided")
+		}
+		if strings.Contains(volumeID, ".vmdk") {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"cannot modify migrated in-tree vSphere volume %q", volumeID)
+		}
+		modifyParams, err := common.ParseModifyVolumeParams(ctx, req.GetMutableParameters())
+		if err != nil {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"parsing mutable parameters failed with error: %+v", err)
+		}
+
+		vCenterHost, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID,
+			volumeInfoService)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volume Id: %q. Error: %v", volumeID, err)
+		}
+		isPolicyReconfigSupported, err := getVCenterManagerForVCenter(ctx, c).
+			IsCnsVolumePolicyReconfigSupported(ctx, vCenterHost)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to check if storage policy change is supported due to error: %v", err)
+		}
+		if !isPolicyReconfigSupported {
+			return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCodef(log, codes.Unimplemented,
+				"changing the storage policy of a volume is not supported on vCenter %q", vCenterHost)
+		}
+		cnsVolumeType, err := common.GetCnsVolumeType(ctx, volumeManager, volumeID)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to determine CNS volume type for volume: %q. Error: %+v", volumeID, err)
+		}
+		if cnsVolumeType == common.FileVolumeType {
+			volumeType = prometheus.PrometheusFileVolumeType
+			return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCodef(log, codes.Unimplemented,
+				"changing the storage policy of file volume %q is not supported", volumeID)
+		}
+		volumeType = prometheus.PrometheusBlockVolumeType
+
+		vcenter, err := common.GetVCenterFromVCHost(ctx, c.managers.VcenterManager, vCenterHost)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter instance for host %q. Error: %+v", vCenterHost, err)
+		}
+		storagePolicyID := modifyParams.StoragePolicyID
+		if modifyParams.StoragePolicyName != "" {
+			storagePolicyID, err = vcenter.GetStoragePolicyIDByName(ctx, modifyParams.StoragePolicyName)
+			if err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"failed to get policy ID for storage policy name %q. Error: %+v",
+					modifyParams.StoragePolicyName, err)
+			}
+		}
+
+		// Only the datastores shared across all the nodes are considered for
+		// relocation, so that the volume stays accessible from every node it
+		// could have been provisioned for. On multi vCenter deployments, a
+		// volume can only be accessed from the nodes of its own vCenter.
+		var candidateDatastores []*cnsvsphere.DatastoreInfo
+		if len(c.managers.VcenterConfigs) == 1 {
+			candidateDatastores, err = c.nodeMgr.GetSharedDatastoresInK8SCluster(ctx)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get shared datastores in kubernetes cluster. Error: %+v", err)
+			}
+		} else {
+			nodeVMs, err := c.nodeMgr.GetAllNodesByVC(ctx, vCenterHost)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get the nodes of vCenter %q. Error: %+v", vCenterHost, err)
+			}
+			if len(nodeVMs) == 0 {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"no nodes found on vCenter %q", vCenterHost)
+			}
+			candidateDatastores, err = cnsvsphere.GetSharedDatastoresForVMs(ctx, nodeVMs)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get shared datastores of the nodes of vCenter %q. Error: %+v", vCenterHost, err)
+			}
+		}
+		candidateDatastores, err = c.filterDatastores(ctx, candidateDatastores, vCenterHost)
+		if err != nil && err != errAllDSFilteredOut {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to filter datastores based on authorisation check. Error: %+v", err)
+		}
+		faultType, err := common.ModifyVolumePolicyUtil(ctx, vcenter, volumeManager, volumeID,
+			storagePolicyID, candidateDatastores)
+		if err != nil {
+			// Error is already wrapped in CSI error code.
+			return nil, faultType, err
+		}
+		return &csi.ControllerModifyVolumeResponse{}, "", nil
+	}
+
+	resp, faultType, err := controllerModifyVolumeInternal()
+	if err != nil {
+		log.Debugf("controllerModifyVolumeInternal: returns fault %q for volume %q", faultType, req.VolumeId)
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusModifyVolumeOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusModifyVolumeOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		log.Infof("Volume %q modified successfully.", req.VolumeId)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusModifyVolumeOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
 }
@@ -19,24 +19,32 @@ package vanilla
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	cnstypes "github.com/vmware/govmomi/cns/types"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
 	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeinfo"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest"
 )
 
 // validateVanillaDeleteVolumeRequest is the helper function to validate
@@ -272,3 +280,327 @@ func GetVolumeManagerFromVCHost(ctx context.Context, managers *common.Managers,
 	}
 	return volumeMgr, nil
 }
+
+// filterDatastoresByStoragePolicy returns the datastores which are compatible
+// with the given storage policy ID.
+func filterDatastoresByStoragePolicy(ctx context.Context, vcenter *vsphere.VirtualCenter,
+	datastores []*vsphere.DatastoreInfo, storagePolicyID string) ([]*vsphere.DatastoreInfo, error) {
+	log := logger.GetLogger(ctx)
+	if len(datastores) == 0 {
+		return nil, nil
+	}
+	var dsMoRefs []types.ManagedObjectReference
+	for _, ds := range datastores {
+		dsMoRefs = append(dsMoRefs, ds.Reference())
+	}
+	compat, err := vcenter.PbmCheckCompatibility(ctx, dsMoRefs, storagePolicyID)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to find datastore compatibility "+
+			"with storage policy ID %q. Error: %+v", storagePolicyID, err)
+	}
+	compatibleDsMoids := make(map[string]struct{})
+	for _, ds := range compat.CompatibleDatastores() {
+		compatibleDsMoids[ds.HubId] = struct{}{}
+	}
+	var compatibleDatastores []*vsphere.DatastoreInfo
+	for _, ds := range datastores {
+		if _, exists := compatibleDsMoids[ds.Reference().Value]; exists {
+			compatibleDatastores = append(compatibleDatastores, ds)
+		}
+	}
+	log.Debugf("Datastores compatible with storage policy %q are %+v", storagePolicyID, compatibleDatastores)
+	return compatibleDatastores, nil
+}
+
+// getAvailableCapacity returns the sum of the free space of the given
+// datastores and the size of the largest volume which can be placed on any
+// one of them.
+func getAvailableCapacity(datastores map[string]*vsphere.DatastoreInfo) (int64, int64) {
+	var availableCapacity, maximumVolumeSize int64
+	for _, ds := range datastores {
+		if ds.Info == nil || ds.Info.FreeSpace <= 0 {
+			continue
+		}
+		availableCapacity += ds.Info.FreeSpace
+		volumeSize := ds.Info.FreeSpace
+		if ds.Info.MaxVirtualDiskCapacity > 0 && ds.Info.MaxVirtualDiskCapacity < volumeSize {
+			volumeSize = ds.Info.MaxVirtualDiskCapacity
+		}
+		if volumeSize > maximumVolumeSize {
+			maximumVolumeSize = volumeSize
+		}
+	}
+	return availableCapacity, maximumVolumeSize
+}
+
+// getCloneSnapshotName returns the name of the snapshot of the source volume
+// a volume is cloned from, for the CreateVolume request with the given name.
+func getCloneSnapshotName(volumeName string) string {
+	return volumeName + "-clone"
+}
+
+// deleteCloneSnapshot deletes the snapshot of the source volume sourceVolumeID
+// the volume requested by CreateVolume request volumeName was cloned from. If
+// snapshotID is empty, the snapshot is looked up in the operation store, as it
+// has been created by a previous attempt of the request. The snapshot is
+// deleted on a context detached from the request, so that it is not leaked if
+// the request times out. Failures are only logged, as the clone is usable.
+func deleteCloneSnapshot(ctx context.Context, volumeManager cnsvolume.Manager,
+	operationStore cnsvolumeoperationrequest.VolumeOperationRequest, sourceVolumeID string,
+	snapshotID string, volumeName string) {
+	ctx = context.WithoutCancel(ctx)
+	log := logger.GetLogger(ctx)
+	if snapshotID == "" {
+		// CreateSnapshot persists its details with the snapshot name suffixed by
+		// the ID of the volume.
+		instanceName := getCloneSnapshotName(volumeName) + "-" + sourceVolumeID
+		snapshotDetails, err := operationStore.GetRequestDetails(ctx, instanceName)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Errorf("failed to get details of snapshot %q of volume %q. Error: %+v",
+					getCloneSnapshotName(volumeName), sourceVolumeID, err)
+			}
+			return
+		}
+		if snapshotDetails.SnapshotID == "" {
+			return
+		}
+		snapshotID = sourceVolumeID + common.VSphereCSISnapshotIdDelimiter + snapshotDetails.SnapshotID
+	}
+	_, err := common.DeleteSnapshotUtil(ctx, volumeManager, snapshotID, nil)
+	if err != nil {
+		log.Errorf("failed to delete snapshot %q created to clone volume %q. Error: %+v",
+			snapshotID, sourceVolumeID, err)
+		return
+	}
+	log.Infof("Deleted snapshot %q created to clone volume %q", snapshotID, sourceVolumeID)
+}
+
+// isVolumePolicyReconfigSupported returns true if changing the storage policy
+// of a volume is supported on all the vCenters the driver is configured with.
+func (c *controller) isVolumePolicyReconfigSupported(ctx context.Context) bool {
+	log := logger.GetLogger(ctx)
+	vCenterManager := getVCenterManagerForVCenter(ctx, c)
+	for vCenterHost := range c.managers.VcenterConfigs {
+		isSupported, err := vCenterManager.IsCnsVolumePolicyReconfigSupported(ctx, vCenterHost)
+		if err != nil {
+			log.Errorf("failed to check if storage policy change is supported on vCenter %q. Error: %v",
+				vCenterHost, err)
+			return false
+		}
+		if !isSupported {
+			return false
+		}
+	}
+	return true
+}
+
+// smbDomainNamePattern matches the names of vSAN file service domains.
+var smbDomainNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+
+// getSmbFileShareConfig returns the SMB configuration of the file shares from
+// the given provisioner secret. The shares get the same net permissions as
+// NFS file shares, from the given vSphere config.
+func getSmbFileShareConfig(secrets map[string]string,
+	netPermissions map[string]*cnsconfig.NetPermissionConfig) (*vsphere.SmbFileShareConfig, error) {
+	shareConfig := &vsphere.SmbFileShareConfig{
+		DomainName: secrets[common.SmbDomainNameSecretKey],
+		Encryption: strings.ToLower(secrets[common.SmbEncryptionSecretKey]),
+	}
+	if shareConfig.DomainName == "" {
+		return nil, fmt.Errorf("provisioner secret key %q is required for %s file volumes",
+			common.SmbDomainNameSecretKey, common.SmbFileShareProtocol)
+	}
+	if !smbDomainNamePattern.MatchString(shareConfig.DomainName) {
+		return nil, fmt.Errorf("provisioner secret key %q must be a vSAN file service domain name, got %q",
+			common.SmbDomainNameSecretKey, shareConfig.DomainName)
+	}
+	switch vsantypes.VsanFileShareSmbEncryptionType(shareConfig.Encryption) {
+	case "", vsantypes.VsanFileShareSmbEncryptionTypedisabled, vsantypes.VsanFileShareSmbEncryptionTypemandatory:
+	default:
+		return nil, fmt.Errorf("provisioner secret key %q must be either %q or %q", common.SmbEncryptionSecretKey,
+			vsantypes.VsanFileShareSmbEncryptionTypedisabled, vsantypes.VsanFileShareSmbEncryptionTypemandatory)
+	}
+	for _, netPerm := range netPermissions {
+		allowRoot := !netPerm.RootSquash
+		shareConfig.Permissions = append(shareConfig.Permissions, vsantypes.VsanFileShareNetPermission{
+			Ips:         netPerm.Ips,
+			Permissions: string(netPerm.Permissions),
+			AllowRoot:   &allowRoot,
+		})
+	}
+	return shareConfig, nil
+}
+
+// configureSmbFileShare reconfigures the vSAN file share of the given file
+// volume to be served over SMB, unless it already has an SMB access point.
+func configureSmbFileShare(ctx context.Context, c *controller, volumeID string,
+	shareConfig *vsphere.SmbFileShareConfig) error {
+	log := logger.GetLogger(ctx)
+	vcHost, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+	if err != nil {
+		return err
+	}
+	volume, err := common.QueryVolumeByID(ctx, volumeManager, volumeID, &cnstypes.CnsQuerySelection{
+		Names: []string{
+			string(cnstypes.QuerySelectionNameTypeBackingObjectDetails),
+			string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+		},
+	})
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to query file volume %q. Error: %+v", volumeID, err)
+	}
+	if backingDetails, ok := volume.BackingObjectDetails.(*cnstypes.CnsVsanFileShareBackingDetails); ok {
+		for _, kv := range backingDetails.AccessPoints {
+			if kv.Key == common.SmbAccessPointKey {
+				log.Debugf("File share of volume %q is already served over SMB", volumeID)
+				return nil
+			}
+		}
+	}
+	vcenter, cluster, err := getFileShareCluster(ctx, c, vcHost, volumeID, volume.DatastoreUrl)
+	if err != nil {
+		return err
+	}
+	return vcenter.ConfigureSmbFileShare(ctx, cluster, volumeID, *shareConfig)
+}
+
+// configureNfsFileShareSecurity reconfigures the vSAN file share of the given
+// file volume with the given NFS security flavor.
+func configureNfsFileShareSecurity(ctx context.Context, c *controller, volumeID string, security string) error {
+	log := logger.GetLogger(ctx)
+	vcHost, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+	if err != nil {
+		return err
+	}
+	volume, err := common.QueryVolumeByID(ctx, volumeManager, volumeID, &cnstypes.CnsQuerySelection{
+		Names: []string{string(cnstypes.QuerySelectionNameTypeDataStoreUrl)},
+	})
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to query file volume %q. Error: %+v", volumeID, err)
+	}
+	vcenter, cluster, err := getFileShareCluster(ctx, c, vcHost, volumeID, volume.DatastoreUrl)
+	if err != nil {
+		return err
+	}
+	return vcenter.ConfigureNfsFileShareSecurity(ctx, cluster, volumeID,
+		vsantypes.VsanFileShareNfsSecType(strings.ToUpper(security)))
+}
+
+// getFileShareCluster returns the vCenter and the vSAN file service enabled
+// cluster of the datastore of the given file volume, in which its file share
+// is reconfigured.
+func getFileShareCluster(ctx context.Context, c *controller, vcHost string, volumeID string,
+	datastoreURL string) (*vsphere.VirtualCenter, types.ManagedObjectReference, error) {
+	log := logger.GetLogger(ctx)
+	var clusterID string
+	for id, datastores := range c.authMgrs[vcHost].GetFsEnabledClusterToDsMap(ctx) {
+		for _, ds := range datastores {
+			if ds.Info.Url == datastoreURL {
+				clusterID = id
+			}
+		}
+	}
+	if clusterID == "" {
+		return nil, types.ManagedObjectReference{}, logger.LogNewErrorf(log,
+			"failed to find the vSAN file service enabled cluster of datastore %q of volume %q",
+			datastoreURL, volumeID)
+	}
+	vcenter, err := common.GetVCenterFromVCHost(ctx, c.managers.VcenterManager, vcHost)
+	if err != nil {
+		return nil, types.ManagedObjectReference{}, logger.LogNewErrorf(log,
+			"failed to get vCenter instance for host %q. Error: %+v", vcHost, err)
+	}
+	return vcenter, types.ManagedObjectReference{Type: "ClusterComputeResource", Value: clusterID}, nil
+}
+
+// getNfsPublishInfo validates the NFS settings in the volume context of a file
+// volume and returns the publish context entries passing them to the nodes.
+// The settings of statically provisioned volumes are validated here as they
+// do not go through CreateVolume.
+func getNfsPublishInfo(volumeContext map[string]string) (map[string]string, error) {
+	publishInfo := make(map[string]string)
+	version := volumeContext[common.AttributeNfsVersion]
+	if version != "" {
+		if _, err := common.ParseNfsVersion(version); err != nil {
+			return nil, err
+		}
+	}
+	security := volumeContext[common.AttributeNfsSecurity]
+	if security != "" {
+		var err error
+		if security, err = common.ParseNfsSecurity(security); err != nil {
+			return nil, err
+		}
+		publishInfo[common.NfsSecurity] = security
+	}
+	if err := common.ValidateNfsVersionAndSecurity(version, security); err != nil {
+		return nil, err
+	}
+	if mountOptions := volumeContext[common.AttributeNfsMountOptions]; mountOptions != "" {
+		options, err := common.ParseNfsMountOptions(mountOptions)
+		if err != nil {
+			return nil, err
+		}
+		publishInfo[common.NfsMountOptions] = strings.Join(options, ",")
+	}
+	return publishInfo, nil
+}
+
+// volumeSlotsUpdateDelay is the delay before the available volume slots of a
+// node are annotated after a volume is attached to or detached from it, which
+// coalesces the updates of bursts of attaches and detaches.
+const volumeSlotsUpdateDelay = 10 * time.Second
+
+// queueVolumeSlotsUpdate queues the annotation of the available volume slots
+// of the given node, which is made in the background so that attaches and
+// detaches don't wait for it.
+func (c *controller) queueVolumeSlotsUpdate(nodeID string) {
+	if c.volumeSlotsQueue != nil {
+		c.volumeSlotsQueue.AddAfter(nodeID, volumeSlotsUpdateDelay)
+	}
+}
+
+// runVolumeSlotsWorker annotates the nodes queued by queueVolumeSlotsUpdate
+// until the queue is shut down.
+func (c *controller) runVolumeSlotsWorker() {
+	for {
+		nodeID, shutdown := c.volumeSlotsQueue.Get()
+		if shutdown {
+			return
+		}
+		ctx, _ := logger.GetNewContextWithLogger()
+		updateAvailableVolumeSlots(ctx, c, nodeID)
+		c.volumeSlotsQueue.Done(nodeID)
+	}
+}
+
+// updateAvailableVolumeSlots annotates the given node with the number of
+// disks that can still be attached to its VM. Failures are only logged, as
+// the annotation is informational.
+func updateAvailableVolumeSlots(ctx context.Context, c *controller, nodeID string) {
+	log := logger.GetLogger(ctx)
+	nodevm, err := c.nodeMgr.GetNodeVMByNameOrUUID(ctx, nodeID)
+	if err == node.ErrNodeNotFound {
+		nodevm, err = c.nodeMgr.GetNodeVMByUuid(ctx, nodeID)
+	}
+	if err != nil {
+		log.Warnf("failed to find VirtualMachine of node %q to annotate its volume slots. Error: %v", nodeID, err)
+		return
+	}
+	total, used, err := nodevm.GetVolumeSlots(ctx)
+	if err != nil {
+		log.Warnf("failed to get volume slots of node %q. Error: %v", nodeID, err)
+		return
+	}
+	nodeName, err := c.nodeMgr.GetNodeNameByUUID(ctx, nodeID)
+	if err != nil {
+		log.Warnf("failed to get name of node %q to annotate its volume slots. Error: %v", nodeID, err)
+		return
+	}
+	err = commonco.ContainerOrchestratorUtility.UpdateNodeAnnotation(ctx, nodeName,
+		common.AnnAvailableVolumeSlots, strconv.Itoa(total-used))
+	if err != nil {
+		log.Warnf("failed to annotate volume slots of node %q. Error: %v", nodeName, err)
+	}
+}
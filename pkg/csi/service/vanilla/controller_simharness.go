@@ -0,0 +1,79 @@
+//go:build simharness
+// +build simharness
+
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+)
+
+// NewWithManagers creates a CNS controller which uses the given managers and
+// node manager instead of building them in Init. It is meant for running the
+// controller against a vCenter simulator, where the Kubernetes backed services
+// initialized by Init are not available. The returned controller must not be
+// initialized again with Init.
+func NewWithManagers(ctx context.Context, managers *common.Managers,
+	nodeMgr NodeManagerInterface) (csitypes.CnsController, error) {
+	log := logger.GetLogger(ctx)
+	csiMigrationEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.CSIMigration)
+	filterSuspendedDatastores = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
+		common.CnsMgrSuspendCreateVolume)
+	isTopologyAwareFileVolumeEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
+		common.TopologyAwareFileVolume)
+	vCenters, err := common.GetVCenters(ctx, managers)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to get vcenters. err=%v", err)
+	}
+	// Datastore maps are computed once instead of being refreshed periodically
+	// as the inventory of the simulator does not change underneath it.
+	authMgrs := make(map[string]*common.AuthManager)
+	for _, vc := range vCenters {
+		dsMap, err := common.GenerateDatastoreMapForBlockVolumes(ctx, vc)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to get datastores for block volumes in vCenter %q. err=%v",
+				vc.Config.Host, err)
+		}
+		fsEnabledClusterToDsMap, err := common.GenerateFSEnabledClustersToDsMap(ctx, vc)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to get vSAN file service enabled clusters in vCenter %q. "+
+				"err=%v", vc.Config.Host, err)
+		}
+		authMgrs[vc.Config.Host], err = common.GetAuthorizationServiceForTesting(ctx, vc, dsMap,
+			fsEnabledClusterToDsMap)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to initialize authMgr. err=%v", err)
+		}
+	}
+	topologyMgr, err := commonco.ContainerOrchestratorUtility.InitTopologyServiceInController(ctx)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to initialize topology service. Error: %+v", err)
+	}
+	return &controller{
+		managers:     managers,
+		nodeMgr:      nodeMgr,
+		authMgrs:     authMgrs,
+		topologyMgr:  topologyMgr,
+		topologyCalc: &defaultTopologyCalculator{},
+	}, nil
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,6 +34,8 @@ import (
 	"github.com/vmware/govmomi/pbm"
 	"github.com/vmware/govmomi/pbm/types"
 	"github.com/vmware/govmomi/vim25"
+	vimtypes "github.com/vmware/govmomi/vim25/types"
+	_ "github.com/vmware/govmomi/vslm/simulator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/prototext"
@@ -630,6 +633,76 @@ func TestCreateVolumeWithMultipleDatastores(t *testing.T) {
 	}
 }
 
+// TestCreateVolumeWithThickDiskProvisioningType creates a volume with an eager
+// zeroed thick backing disk and checks that the disk is created with that
+// provisioning type, that the storage I/O allocation is passed on in the
+// volume context and that retrying the request returns the same volume.
+func TestCreateVolumeWithThickDiskProvisioningType(t *testing.T) {
+	// Create context.
+	ct := getControllerTest(t)
+
+	// Create.
+	params := map[string]string{
+		common.AttributeDiskProvisioningType: "eagerZeroedThick",
+		common.AttributeIopsLimit:            "1000",
+		common.AttributeIopsShares:           "high",
+	}
+
+	capabilities := []*csi.VolumeCapability{
+		{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			},
+		},
+	}
+
+	reqCreate := &csi.CreateVolumeRequest{
+		Name: testVolumeName + "-" + uuid.New().String(),
+		CapacityRange: &csi.CapacityRange{
+			RequiredBytes: 1 * common.GbInBytes,
+		},
+		Parameters:         params,
+		VolumeCapabilities: capabilities,
+	}
+
+	respCreate, err := ct.controller.CreateVolume(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volID := respCreate.Volume.VolumeId
+	volumeContext := respCreate.Volume.VolumeContext
+	if volumeContext[common.AttributeIopsLimit] != "1000" || volumeContext[common.AttributeIopsShares] != "high" {
+		t.Fatalf("expected iops limit and shares in the volume context, got %v", volumeContext)
+	}
+
+	vso, err := ct.vcenter.RetrieveVStorageObject(ctx, volID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backing := vso.Config.Backing.(*vimtypes.BaseConfigInfoDiskFileBackingInfo)
+	if backing.ProvisioningType != string(vimtypes.BaseConfigInfoDiskFileBackingInfoProvisioningTypeEagerZeroedThick) {
+		t.Fatalf("expected an eager zeroed thick disk for volume %q, got %q", volID, backing.ProvisioningType)
+	}
+
+	// A retried request returns the registered volume.
+	respCreate, err = ct.controller.CreateVolume(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if respCreate.Volume.VolumeId != volID {
+		t.Fatalf("expected volume %q for the retried request, got %q", volID, respCreate.Volume.VolumeId)
+	}
+
+	// Delete.
+	reqDelete := &csi.DeleteVolumeRequest{
+		VolumeId: volID,
+	}
+	_, err = ct.controller.DeleteVolume(ctx, reqDelete)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestCreateVolumeWithInvalidAccessibilityRequirements(t *testing.T) {
 	// Create context.
 	ct := getControllerTest(t)
@@ -1612,6 +1685,143 @@ func TestCreateVolumeFromSnapshot(t *testing.T) {
 	}
 }
 
+func TestCreateVolumeFromVolume(t *testing.T) {
+	ct := getControllerTest(t)
+
+	// Create the source volume.
+	params := make(map[string]string)
+	if v := os.Getenv("VSPHERE_DATASTORE_URL"); v != "" {
+		params[common.AttributeDatastoreURL] = v
+	}
+	capabilities := []*csi.VolumeCapability{
+		{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			},
+		},
+	}
+	reqCreate := &csi.CreateVolumeRequest{
+		Name: testVolumeName + "-" + uuid.New().String(),
+		CapacityRange: &csi.CapacityRange{
+			RequiredBytes: 1 * common.GbInBytes,
+		},
+		Parameters:         params,
+		VolumeCapabilities: capabilities,
+	}
+	respCreate, err := ct.controller.CreateVolume(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volID := respCreate.Volume.VolumeId
+	defer func() {
+		_, err = ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Clone the source volume.
+	reqCreateFromVolume := &csi.CreateVolumeRequest{
+		Name: testVolumeName + "-" + uuid.New().String(),
+		CapacityRange: &csi.CapacityRange{
+			RequiredBytes: 1 * common.GbInBytes,
+		},
+		Parameters:         params,
+		VolumeCapabilities: capabilities,
+		VolumeContentSource: &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Volume{
+				Volume: &csi.VolumeContentSource_VolumeSource{
+					VolumeId: volID,
+				},
+			},
+		},
+	}
+	respCreateFromVolume, err := ct.controller.CreateVolume(ctx, reqCreateFromVolume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clonedVolID := respCreateFromVolume.Volume.VolumeId
+	defer func() {
+		_, err = ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: clonedVolID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if respCreateFromVolume.Volume.GetContentSource().GetVolume().GetVolumeId() != volID {
+		t.Fatalf("expected content source volume %q, got %+v", volID, respCreateFromVolume.Volume.ContentSource)
+	}
+
+	// Verify the cloned volume has been created.
+	queryFilter := cnstypes.CnsQueryFilter{
+		VolumeIds: []cnstypes.CnsVolumeId{
+			{
+				Id: clonedVolID,
+			},
+		},
+	}
+	queryResult, err := ct.vcenter.CnsClient.QueryVolume(ctx, &queryFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queryResult.Volumes) != 1 {
+		t.Fatalf("failed to find the newly created volume from volume with ID: %s", clonedVolID)
+	}
+
+	// Verify the snapshot used to clone the volume has been deleted.
+	respListSnapshots, err := ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SourceVolumeId: volID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(respListSnapshots.Entries) != 0 {
+		t.Fatalf("expected no snapshots on source volume %q, got %+v", volID, respListSnapshots.Entries)
+	}
+
+	// Retry the clone request, which returns the volume cloned by the first attempt.
+	respCreateFromVolume, err = ct.controller.CreateVolume(ctx, reqCreateFromVolume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if respCreateFromVolume.Volume.VolumeId != clonedVolID {
+		t.Fatalf("expected volume %q on retry, got %q", clonedVolID, respCreateFromVolume.Volume.VolumeId)
+	}
+	respListSnapshots, err = ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SourceVolumeId: volID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(respListSnapshots.Entries) != 0 {
+		t.Fatalf("expected no snapshots on source volume %q after retry, got %+v", volID,
+			respListSnapshots.Entries)
+	}
+
+	// Clone the source volume with a size larger than the source volume.
+	reqCreateFromVolume.Name = testVolumeName + "-" + uuid.New().String()
+	reqCreateFromVolume.CapacityRange.RequiredBytes = 2 * common.GbInBytes
+	respCreateFromVolume, err = ct.controller.CreateVolume(ctx, reqCreateFromVolume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	largerClonedVolID := respCreateFromVolume.Volume.VolumeId
+	defer func() {
+		_, err = ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: largerClonedVolID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if respCreateFromVolume.Volume.CapacityBytes != 2*common.GbInBytes {
+		t.Fatalf("expected cloned volume of %d bytes, got %d", 2*common.GbInBytes,
+			respCreateFromVolume.Volume.CapacityBytes)
+	}
+
+	// Clone the source volume with a size smaller than the source volume.
+	reqCreateFromVolume.Name = testVolumeName + "-" + uuid.New().String()
+	reqCreateFromVolume.CapacityRange.RequiredBytes = 512 * common.MbInBytes
+	_, err = ct.controller.CreateVolume(ctx, reqCreateFromVolume)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected error code %s when cloning with smaller size, got: %+v",
+			codes.InvalidArgument.String(), err)
+	}
+}
+
 func TestListSnapshotsOnSpecificVolumeAndSnapshot(t *testing.T) {
 	ct := getControllerTest(t)
 
@@ -2601,3 +2811,261 @@ func TestCreateSnapshotWithCnsSnapshotCreatedFault(t *testing.T) {
 		t.Fatal("expected error was not received for create snapshot operation.")
 	}
 }
+
+func TestGetCapacity(t *testing.T) {
+	ct := getControllerTest(t)
+
+	params := make(map[string]string)
+	if v := os.Getenv("VSPHERE_DATASTORE_URL"); v != "" {
+		params[common.AttributeDatastoreURL] = v
+	}
+	// Parameters reserved for the CSI sidecars should be ignored.
+	params["csi.storage.k8s.io/fstype"] = "ext4"
+	reqGetCapacity := &csi.GetCapacityRequest{
+		VolumeCapabilities: []*csi.VolumeCapability{
+			{
+				AccessMode: &csi.VolumeCapability_AccessMode{
+					Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+				},
+			},
+		},
+		Parameters: params,
+	}
+	resp, err := ct.controller.GetCapacity(ctx, reqGetCapacity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AvailableCapacity <= 0 {
+		t.Fatalf("expected available capacity to be greater than 0, got %d", resp.AvailableCapacity)
+	}
+	if resp.MaximumVolumeSize == nil || resp.MaximumVolumeSize.Value > resp.AvailableCapacity {
+		t.Fatalf("unexpected maximum volume size %v for available capacity %d",
+			resp.MaximumVolumeSize, resp.AvailableCapacity)
+	}
+
+	// Invalid StorageClass parameters should be rejected.
+	reqGetCapacity.Parameters = map[string]string{"invalid-param": "value"}
+	_, err = ct.controller.GetCapacity(ctx, reqGetCapacity)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument error for invalid parameters, got: %v", err)
+	}
+}
+
+func TestGetAvailableCapacity(t *testing.T) {
+	datastores := map[string]*cnsvsphere.DatastoreInfo{
+		"ds:///vmfs/volumes/ds1/": {
+			Info: &vimtypes.DatastoreInfo{FreeSpace: 100 * common.GbInBytes},
+		},
+		"ds:///vmfs/volumes/ds2/": {
+			Info: &vimtypes.DatastoreInfo{
+				FreeSpace:              300 * common.GbInBytes,
+				MaxVirtualDiskCapacity: 200 * common.GbInBytes,
+			},
+		},
+		"ds:///vmfs/volumes/ds3/": {
+			Info: &vimtypes.DatastoreInfo{FreeSpace: 0},
+		},
+	}
+	availableCapacity, maximumVolumeSize := getAvailableCapacity(datastores)
+	if availableCapacity != 400*common.GbInBytes {
+		t.Errorf("expected available capacity %d, got %d", 400*common.GbInBytes, availableCapacity)
+	}
+	if maximumVolumeSize != 200*common.GbInBytes {
+		t.Errorf("expected maximum volume size %d, got %d", 200*common.GbInBytes, maximumVolumeSize)
+	}
+}
+
+func TestControllerModifyVolumeWithInvalidParams(t *testing.T) {
+	ct := getControllerTest(t)
+
+	tests := []struct {
+		name string
+		req  *csi.ControllerModifyVolumeRequest
+	}{
+		{
+			name: "EmptyVolumeID",
+			req: &csi.ControllerModifyVolumeRequest{
+				MutableParameters: map[string]string{common.AttributeStoragePolicyName: "raid-5"},
+			},
+		},
+		{
+			name: "EmptyMutableParameters",
+			req: &csi.ControllerModifyVolumeRequest{
+				VolumeId: "dummy-volume-id",
+			},
+		},
+		{
+			name: "UnsupportedMutableParameter",
+			req: &csi.ControllerModifyVolumeRequest{
+				VolumeId:          "dummy-volume-id",
+				MutableParameters: map[string]string{common.AttributeDatastoreURL: "ds:///vmfs/volumes/ds1/"},
+			},
+		},
+		{
+			name: "MigratedInTreeVolume",
+			req: &csi.ControllerModifyVolumeRequest{
+				VolumeId:          "[vsanDatastore] 9c01ed5e/volume.vmdk",
+				MutableParameters: map[string]string{common.AttributeStoragePolicyName: "raid-5"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ct.controller.ControllerModifyVolume(ctx, test.req)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("expected InvalidArgument error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateVolumeGroupSnapshot(t *testing.T) {
+	ct := getControllerTest(t)
+
+	params := make(map[string]string)
+	if v := os.Getenv("VSPHERE_DATASTORE_URL"); v != "" {
+		params[common.AttributeDatastoreURL] = v
+	}
+	capabilities := []*csi.VolumeCapability{
+		{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			},
+		},
+	}
+	var volIDs []string
+	for i := 0; i < 2; i++ {
+		respCreate, err := ct.controller.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name: testVolumeName + "-" + uuid.New().String(),
+			CapacityRange: &csi.CapacityRange{
+				RequiredBytes: 1 * common.GbInBytes,
+			},
+			Parameters:         params,
+			VolumeCapabilities: capabilities,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		volID := respCreate.Volume.VolumeId
+		volIDs = append(volIDs, volID)
+		defer func() {
+			_, err := ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volID})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}()
+	}
+
+	reqCreate := &csi.CreateVolumeGroupSnapshotRequest{
+		Name:            "groupsnapshot-" + uuid.New().String(),
+		SourceVolumeIds: volIDs,
+	}
+	respCreate, err := ct.controller.CreateVolumeGroupSnapshot(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupSnapshot := respCreate.GroupSnapshot
+	if groupSnapshot.GroupSnapshotId != reqCreate.Name || len(groupSnapshot.Snapshots) != len(volIDs) {
+		t.Fatalf("unexpected group snapshot: %+v", groupSnapshot)
+	}
+	var snapIDs []string
+	for i, snapshot := range groupSnapshot.Snapshots {
+		if snapshot.SourceVolumeId != volIDs[i] || snapshot.GroupSnapshotId != reqCreate.Name {
+			t.Fatalf("unexpected snapshot %+v for volume %s", snapshot, volIDs[i])
+		}
+		snapIDs = append(snapIDs, snapshot.SnapshotId)
+	}
+
+	// Creating the group snapshot again returns the same snapshots.
+	respCreate, err = ct.controller.CreateVolumeGroupSnapshot(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, snapshot := range respCreate.GroupSnapshot.Snapshots {
+		if snapshot.SnapshotId != snapIDs[i] {
+			t.Fatalf("expected snapshot %s on retry, got %s", snapIDs[i], snapshot.SnapshotId)
+		}
+	}
+
+	respGet, err := ct.controller.GetVolumeGroupSnapshot(ctx, &csi.GetVolumeGroupSnapshotRequest{
+		GroupSnapshotId: reqCreate.Name,
+		SnapshotIds:     snapIDs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(respGet.GroupSnapshot.Snapshots) != len(snapIDs) {
+		t.Fatalf("unexpected group snapshot: %+v", respGet.GroupSnapshot)
+	}
+
+	reqDelete := &csi.DeleteVolumeGroupSnapshotRequest{
+		GroupSnapshotId: reqCreate.Name,
+		SnapshotIds:     snapIDs,
+	}
+	if _, err = ct.controller.DeleteVolumeGroupSnapshot(ctx, reqDelete); err != nil {
+		t.Fatal(err)
+	}
+	// Deleting the group snapshot again succeeds as its snapshots are gone.
+	if _, err = ct.controller.DeleteVolumeGroupSnapshot(ctx, reqDelete); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetSmbFileShareConfig(t *testing.T) {
+	netPermissions := map[string]*config.NetPermissionConfig{
+		"clients": {Ips: "10.20.30.0/24", Permissions: "READ_ONLY", RootSquash: true},
+	}
+	shareConfig, err := getSmbFileShareConfig(map[string]string{
+		common.SmbDomainNameSecretKey: "fs-domain",
+		common.SmbEncryptionSecretKey: "Mandatory",
+	}, netPermissions)
+	if err != nil {
+		t.Fatalf("failed to get SMB file share config, err: %v", err)
+	}
+	if shareConfig.DomainName != "fs-domain" || shareConfig.Encryption != "mandatory" {
+		t.Errorf("unexpected SMB file share config: %+v", shareConfig)
+	}
+	if len(shareConfig.Permissions) != 1 || shareConfig.Permissions[0].Ips != "10.20.30.0/24" ||
+		shareConfig.Permissions[0].Permissions != "READ_ONLY" || *shareConfig.Permissions[0].AllowRoot {
+		t.Errorf("unexpected SMB file share permissions: %+v", shareConfig.Permissions)
+	}
+
+	for _, secrets := range []map[string]string{
+		{},
+		{common.SmbEncryptionSecretKey: "disabled"},
+		{common.SmbDomainNameSecretKey: "fs-domain", common.SmbEncryptionSecretKey: "optional"},
+		{common.SmbDomainNameSecretKey: "fs-domain,sec=none"},
+		{common.SmbDomainNameSecretKey: "fs-domain\nusername=admin"},
+	} {
+		if _, err := getSmbFileShareConfig(secrets, netPermissions); err == nil {
+			t.Errorf("expected error for provisioner secret %v", secrets)
+		}
+	}
+}
+
+func TestGetNfsPublishInfo(t *testing.T) {
+	publishInfo, err := getNfsPublishInfo(map[string]string{
+		common.AttributeNfsSecurity:     "KRB5P",
+		common.AttributeNfsMountOptions: "rsize=1048576, timeo=600",
+	})
+	if err != nil {
+		t.Fatalf("failed to get NFS publish info, err: %v", err)
+	}
+	expected := map[string]string{
+		common.NfsSecurity:     common.NfsSecurityKrb5p,
+		common.NfsMountOptions: "rsize=1048576,timeo=600",
+	}
+	if !reflect.DeepEqual(publishInfo, expected) {
+		t.Errorf("expected NFS publish info %v, got %v", expected, publishInfo)
+	}
+
+	for _, volumeContext := range []map[string]string{
+		{common.AttributeNfsVersion: "4.2"},
+		{common.AttributeNfsVersion: common.NfsVersion3, common.AttributeNfsSecurity: common.NfsSecurityKrb5},
+		{common.AttributeNfsMountOptions: "vers=3"},
+	} {
+		if _, err := getNfsPublishInfo(volumeContext); err == nil {
+			t.Errorf("expected error for volume context %v", volumeContext)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	csifault "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/fault"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest"
+)
+
+// GroupControllerGetCapabilities returns the capabilities of the group
+// controller service.
+func (c *controller) GroupControllerGetCapabilities(ctx context.Context,
+	req *csi.GroupControllerGetCapabilitiesRequest) (*csi.GroupControllerGetCapabilitiesResponse, error) {
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	log.Infof("GroupControllerGetCapabilities: called with args %+v", req)
+	return &csi.GroupControllerGetCapabilitiesResponse{
+		Capabilities: []*csi.GroupControllerServiceCapability{
+			{
+				Type: &csi.GroupControllerServiceCapability_Rpc{
+					Rpc: &csi.GroupControllerServiceCapability_RPC{
+						Type: csi.GroupControllerServiceCapability_RPC_CREATE_DELETE_GET_VOLUME_GROUP_SNAPSHOT,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// CreateVolumeGroupSnapshot creates a snapshot of all the source volumes in a
+// single CNS task. The source volumes must belong to the same vCenter. The
+// group snapshot ID is the name of the request, which is also the description
+// of the member snapshots on CNS.
+func (c *controller) CreateVolumeGroupSnapshot(ctx context.Context, req *csi.CreateVolumeGroupSnapshotRequest) (
+	*csi.CreateVolumeGroupSnapshotResponse, error) {
+	start := time.Now()
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	volumeType := prometheus.PrometheusBlockVolumeType
+	createVolumeGroupSnapshotInternal := func() (*csi.CreateVolumeGroupSnapshotResponse, string, error) {
+		log.Infof("CreateVolumeGroupSnapshot: called with args %+v", req)
+		if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+			return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCode(log, codes.Unimplemented,
+				"createVolumeGroupSnapshot")
+		}
+		if err := validateCreateVolumeGroupSnapshotRequest(ctx, req); err != nil {
+			return nil, csifault.CSIInvalidArgumentFault, err
+		}
+		volumeManager, faultType, err := c.getGroupSnapshotVolumeManager(ctx, req.SourceVolumeIds)
+		if err != nil {
+			return nil, faultType, err
+		}
+
+		// Query capacity in MB, volume type and datastore url of the source volumes.
+		volumeIds := make([]cnstypes.CnsVolumeId, 0, len(req.SourceVolumeIds))
+		for _, volumeID := range req.SourceVolumeIds {
+			volumeIds = append(volumeIds, cnstypes.CnsVolumeId{Id: volumeID})
+		}
+		cnsVolumeDetailsMap, err := utils.QueryVolumeDetailsUtil(ctx, volumeManager, volumeIds)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, err
+		}
+		// A retry of a group snapshot already requested on CNS resumes it, so
+		// the snapshots it created must not count against the snapshot limit.
+		requested, err := isGroupSnapshotRequested(ctx, volumeManager, req.Name)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get the details of group snapshot %q. Error: %v", req.Name, err)
+		}
+		for _, volumeID := range req.SourceVolumeIds {
+			volumeDetails, ok := cnsVolumeDetailsMap[volumeID]
+			if !ok {
+				return nil, csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+					"cns query volume did not return the volume: %s", volumeID)
+			}
+			if volumeDetails.VolumeType != common.BlockVolumeType {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"volume %q of type %v cannot be part of a group snapshot", volumeID, volumeDetails.VolumeType)
+			}
+			if requested {
+				continue
+			}
+			if err := c.checkSnapshotLimit(ctx, volumeManager, volumeID, volumeDetails.DatastoreUrl); err != nil {
+				return nil, csifault.CSIInternalFault, err
+			}
+		}
+
+		cnsSnapshotInfos, err := volumeManager.CreateGroupSnapshot(ctx, req.Name, req.SourceVolumeIds)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to create group snapshot %q of volumes %v with error: %v", req.Name, req.SourceVolumeIds, err)
+		}
+		groupSnapshot := &csi.VolumeGroupSnapshot{
+			GroupSnapshotId: req.Name,
+			ReadyToUse:      true,
+		}
+		for _, cnsSnapshotInfo := range cnsSnapshotInfos {
+			volumeID := cnsSnapshotInfo.SourceVolumeID
+			snapshotID := volumeID + common.VSphereCSISnapshotIdDelimiter + cnsSnapshotInfo.SnapshotID
+			creationTime := cnsSnapshotInfo.SnapshotLatestOperationCompleteTime
+			if creationTime.IsZero() {
+				// The creation time is not persisted for group snapshots which
+				// were already created, so it is queried from CNS.
+				snapshots, err := common.QueryVolumeSnapshot(ctx, volumeManager, volumeID,
+					cnsSnapshotInfo.SnapshotID, common.QuerySnapshotLimit)
+				if err != nil {
+					return nil, csifault.CSIInternalFault, err
+				}
+				creationTime = snapshots[0].CreationTime.AsTime()
+			}
+			groupSnapshot.Snapshots = append(groupSnapshot.Snapshots, &csi.Snapshot{
+				SizeBytes:       cnsVolumeDetailsMap[volumeID].SizeInMB * common.MbInBytes,
+				SnapshotId:      snapshotID,
+				SourceVolumeId:  volumeID,
+				CreationTime:    timestamppb.New(creationTime),
+				ReadyToUse:      true,
+				GroupSnapshotId: req.Name,
+			})
+		}
+		groupSnapshot.CreationTime = earliestSnapshotCreationTime(groupSnapshot.Snapshots)
+		log.Infof("CreateVolumeGroupSnapshot succeeded for group snapshot %q: %+v", req.Name, groupSnapshot)
+		return &csi.CreateVolumeGroupSnapshotResponse{GroupSnapshot: groupSnapshot}, "", nil
+	}
+
+	resp, faultType, err := createVolumeGroupSnapshotInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusCreateGroupSnapshotOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusCreateGroupSnapshotOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusCreateGroupSnapshotOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// GetVolumeGroupSnapshot returns the group snapshot with the given member
+// snapshots, which must all exist on CNS.
+func (c *controller) GetVolumeGroupSnapshot(ctx context.Context, req *csi.GetVolumeGroupSnapshotRequest) (
+	*csi.GetVolumeGroupSnapshotResponse, error) {
+	start := time.Now()
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	volumeType := prometheus.PrometheusBlockVolumeType
+	getVolumeGroupSnapshotInternal := func() (*csi.GetVolumeGroupSnapshotResponse, string, error) {
+		log.Infof("GetVolumeGroupSnapshot: called with args %+v", req)
+		if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+			return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCode(log, codes.Unimplemented,
+				"getVolumeGroupSnapshot")
+		}
+		if req.GroupSnapshotId == "" || len(req.SnapshotIds) == 0 {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"group snapshot ID and snapshot IDs must be provided")
+		}
+		groupSnapshot := &csi.VolumeGroupSnapshot{
+			GroupSnapshotId: req.GroupSnapshotId,
+			ReadyToUse:      true,
+		}
+		for _, csiSnapshotID := range req.SnapshotIds {
+			volumeID, snapshotID, err := common.ParseCSISnapshotID(csiSnapshotID)
+			if err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+					err.Error())
+			}
+			_, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get vCenter/volume manager for snapshot Id: %q. Error: %v", csiSnapshotID, err)
+			}
+			snapshots, err := common.QueryVolumeSnapshot(ctx, volumeManager, volumeID, snapshotID,
+				common.QuerySnapshotLimit)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, err
+			}
+			snapshots[0].GroupSnapshotId = req.GroupSnapshotId
+			groupSnapshot.Snapshots = append(groupSnapshot.Snapshots, snapshots[0])
+		}
+		groupSnapshot.CreationTime = earliestSnapshotCreationTime(groupSnapshot.Snapshots)
+		return &csi.GetVolumeGroupSnapshotResponse{GroupSnapshot: groupSnapshot}, "", nil
+	}
+
+	resp, faultType, err := getVolumeGroupSnapshotInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetGroupSnapshotOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetGroupSnapshotOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetGroupSnapshotOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// DeleteVolumeGroupSnapshot deletes the member snapshots of a group snapshot.
+// Member snapshots which were already deleted are skipped.
+func (c *controller) DeleteVolumeGroupSnapshot(ctx context.Context, req *csi.DeleteVolumeGroupSnapshotRequest) (
+	*csi.DeleteVolumeGroupSnapshotResponse, error) {
+	start := time.Now()
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	volumeType := prometheus.PrometheusBlockVolumeType
+	deleteVolumeGroupSnapshotInternal := func() (*csi.DeleteVolumeGroupSnapshotResponse, string, error) {
+		log.Infof("DeleteVolumeGroupSnapshot: called with args %+v", req)
+		if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+			return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCode(log, codes.Unimplemented,
+				"deleteVolumeGroupSnapshot")
+		}
+		if req.GroupSnapshotId == "" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"group snapshot ID must be provided")
+		}
+		volumeIDs := make([]string, 0, len(req.SnapshotIds))
+		for _, csiSnapshotID := range req.SnapshotIds {
+			volumeID, _, err := common.ParseCSISnapshotID(csiSnapshotID)
+			if err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+					err.Error())
+			}
+			volumeIDs = append(volumeIDs, volumeID)
+		}
+		if len(volumeIDs) == 0 {
+			log.Infof("DeleteVolumeGroupSnapshot: group snapshot %q has no snapshots", req.GroupSnapshotId)
+			return &csi.DeleteVolumeGroupSnapshotResponse{}, "", nil
+		}
+		volumeManager, faultType, err := c.getGroupSnapshotVolumeManager(ctx, volumeIDs)
+		if err != nil {
+			return nil, faultType, err
+		}
+		for _, csiSnapshotID := range req.SnapshotIds {
+			if _, err := common.DeleteSnapshotUtil(ctx, volumeManager, csiSnapshotID, nil); err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to delete snapshot %q of group snapshot %q. Error: %+v",
+					csiSnapshotID, req.GroupSnapshotId, err)
+			}
+		}
+		log.Infof("DeleteVolumeGroupSnapshot: successfully deleted group snapshot %q", req.GroupSnapshotId)
+		return &csi.DeleteVolumeGroupSnapshotResponse{}, "", nil
+	}
+
+	resp, faultType, err := deleteVolumeGroupSnapshotInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusDeleteGroupSnapshotOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusDeleteGroupSnapshotOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusDeleteGroupSnapshotOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// validateCreateVolumeGroupSnapshotRequest validates the name and the source
+// volumes of a CreateVolumeGroupSnapshot request.
+func validateCreateVolumeGroupSnapshotRequest(ctx context.Context,
+	req *csi.CreateVolumeGroupSnapshotRequest) error {
+	log := logger.GetLogger(ctx)
+	if req.Name == "" {
+		return logger.LogNewErrorCode(log, codes.InvalidArgument, "group snapshot name must be provided")
+	}
+	if len(req.SourceVolumeIds) == 0 {
+		return logger.LogNewErrorCode(log, codes.InvalidArgument, "source volume IDs must be provided")
+	}
+	sourceVolumeIDs := make(map[string]struct{}, len(req.SourceVolumeIds))
+	for _, volumeID := range req.SourceVolumeIds {
+		if volumeID == "" {
+			return logger.LogNewErrorCode(log, codes.InvalidArgument, "source volume IDs must not be empty")
+		}
+		// Migrated vSphere volumes cannot be snapshotted.
+		if strings.Contains(volumeID, ".vmdk") {
+			return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"cannot snapshot migrated vSphere volume %q", volumeID)
+		}
+		if _, ok := sourceVolumeIDs[volumeID]; ok {
+			return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"source volume %q is given more than once", volumeID)
+		}
+		sourceVolumeIDs[volumeID] = struct{}{}
+	}
+	return nil
+}
+
+// getGroupSnapshotVolumeManager returns the volume manager of the vCenter of
+// the given volumes, which must all belong to that vCenter as the snapshots
+// of a group are created in a single CNS task.
+func (c *controller) getGroupSnapshotVolumeManager(ctx context.Context, volumeIDs []string) (
+	cnsvolume.Manager, string, error) {
+	log := logger.GetLogger(ctx)
+	var (
+		groupVCenterHost   string
+		groupVolumeManager cnsvolume.Manager
+	)
+	for _, volumeID := range volumeIDs {
+		vCenterHost, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volume Id: %q. Error: %v", volumeID, err)
+		}
+		if groupVolumeManager == nil {
+			groupVCenterHost, groupVolumeManager = vCenterHost, volumeManager
+		} else if vCenterHost != groupVCenterHost {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"volumes of a group snapshot must belong to the same vCenter, volume %q belongs to %q and not %q",
+				volumeID, vCenterHost, groupVCenterHost)
+		}
+	}
+	isCnsSnapshotSupported, err := getVCenterManagerForVCenter(ctx, c).IsCnsSnapshotSupported(ctx, groupVCenterHost)
+	if err != nil {
+		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to check if cns snapshot is supported on VC due to error: %v", err)
+	}
+	if !isCnsSnapshotSupported {
+		return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCode(log, codes.Unimplemented,
+			"VC version does not support snapshot operations")
+	}
+	return groupVolumeManager, "", nil
+}
+
+// earliestSnapshotCreationTime returns the creation time of the earliest of
+// the given snapshots.
+func earliestSnapshotCreationTime(snapshots []*csi.Snapshot) *timestamppb.Timestamp {
+	var earliest *timestamppb.Timestamp
+	for _, snapshot := range snapshots {
+		if earliest == nil || snapshot.CreationTime.AsTime().Before(earliest.AsTime()) {
+			earliest = snapshot.CreationTime
+		}
+	}
+	return earliest
+}
+
+// isGroupSnapshotRequested returns true if the CreateSnapshots task of the
+// group snapshot with the given name was already invoked on CNS and either
+// succeeded or is still pending, according to the operation store of the
+// volume manager.
+func isGroupSnapshotRequested(ctx context.Context, volumeManager cnsvolume.Manager, name string) (bool, error) {
+	operationStore := volumeManager.GetOperationStore()
+	if operationStore == nil {
+		return false, nil
+	}
+	details, err := operationStore.GetRequestDetails(ctx, name)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if details.OperationDetails == nil {
+		return false, nil
+	}
+	return details.OperationDetails.TaskStatus == cnsvolumeoperationrequest.TaskInvocationStatusSuccess ||
+		cnsvolume.IsTaskPending(details), nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest"
+)
+
+func TestValidateCreateVolumeGroupSnapshotRequest(t *testing.T) {
+	ctx := context.Background()
+	for name, req := range map[string]*csi.CreateVolumeGroupSnapshotRequest{
+		"missing name":      {SourceVolumeIds: []string{"vol-1"}},
+		"no source volumes": {Name: "group-1"},
+		"empty volume ID":   {Name: "group-1", SourceVolumeIds: []string{"vol-1", ""}},
+		"migrated volume":   {Name: "group-1", SourceVolumeIds: []string{"[ds] kubevols/vol-1.vmdk"}},
+		"duplicate volume":  {Name: "group-1", SourceVolumeIds: []string{"vol-1", "vol-2", "vol-1"}},
+	} {
+		err := validateCreateVolumeGroupSnapshotRequest(ctx, req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected InvalidArgument error, got: %v", name, err)
+		}
+	}
+	err := validateCreateVolumeGroupSnapshotRequest(ctx, &csi.CreateVolumeGroupSnapshotRequest{
+		Name:            "group-1",
+		SourceVolumeIds: []string{"vol-1", "vol-2"},
+	})
+	if err != nil {
+		t.Errorf("unexpected error for valid request: %v", err)
+	}
+}
+
+// operationStoreVolumeManager is a volume manager with the given operation
+// store.
+type operationStoreVolumeManager struct {
+	cnsvolume.Manager
+	operationStore cnsvolumeoperationrequest.VolumeOperationRequest
+}
+
+func (m *operationStoreVolumeManager) GetOperationStore() cnsvolumeoperationrequest.VolumeOperationRequest {
+	return m.operationStore
+}
+
+func TestIsGroupSnapshotRequested(t *testing.T) {
+	ctx := context.Background()
+	operationStore, err := unittestcommon.InitFakeVolumeOperationRequestInterface()
+	if err != nil {
+		t.Fatal(err)
+	}
+	volumeManager := &operationStoreVolumeManager{operationStore: operationStore}
+	for name, taskStatus := range map[string]string{
+		"group-succeeded":   cnsvolumeoperationrequest.TaskInvocationStatusSuccess,
+		"group-in-progress": cnsvolumeoperationrequest.TaskInvocationStatusInProgress,
+		"group-failed":      cnsvolumeoperationrequest.TaskInvocationStatusError,
+	} {
+		details := cnsvolumeoperationrequest.CreateVolumeOperationRequestDetails(name, "vol-1", "", 0, nil,
+			metav1.Now(), "task-1", "", "", taskStatus, "")
+		if err := operationStore.StoreRequestDetails(ctx, details); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, expected := range map[string]bool{
+		"group-succeeded":   true,
+		"group-in-progress": true,
+		"group-failed":      false,
+		"group-new":         false,
+	} {
+		requested, err := isGroupSnapshotRequested(ctx, volumeManager, name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if requested != expected {
+			t.Errorf("%s: expected requested to be %v, got %v", name, expected, requested)
+		}
+	}
+	// The snapshot limit is always checked without operation store.
+	requested, err := isGroupSnapshotRequested(ctx, &operationStoreVolumeManager{}, "group-succeeded")
+	if err != nil || requested {
+		t.Errorf("expected the group snapshot not to be requested without operation store, got %v, %v",
+			requested, err)
+	}
+}
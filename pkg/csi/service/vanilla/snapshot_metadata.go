@@ -0,0 +1,317 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/vmware/govmomi/vim25/types"
+	"google.golang.org/grpc/codes"
+
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	csifault "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/fault"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+const (
+	// defaultMaxBlockMetadataResults is the number of BlockMetadata tuples
+	// sent in each response message when the CO does not specify one.
+	defaultMaxBlockMetadataResults = 256
+	// allocatedAreasChangeID is the changed block tracking ID which makes
+	// QueryChangedDiskAreas return all the allocated areas of a snapshot.
+	allocatedAreasChangeID = "*"
+)
+
+// GetMetadataAllocated streams the allocated block ranges of a snapshot,
+// computed with vSphere changed block tracking.
+func (c *controller) GetMetadataAllocated(req *csi.GetMetadataAllocatedRequest,
+	stream csi.SnapshotMetadata_GetMetadataAllocatedServer) error {
+	start := time.Now()
+	ctx := logger.NewContextWithLogger(stream.Context())
+	log := logger.GetLogger(ctx)
+	volumeType := prometheus.PrometheusBlockVolumeType
+	getMetadataAllocatedInternal := func() (string, error) {
+		log.Infof("GetMetadataAllocated: called with args %+v", req)
+		if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+			return csifault.CSIUnimplementedFault, logger.LogNewErrorCode(log, codes.Unimplemented,
+				"getMetadataAllocated")
+		}
+		if err := validateSnapshotMetadataRange(ctx, req.StartingOffset, req.MaxResults); err != nil {
+			return csifault.CSIInvalidArgumentFault, err
+		}
+		volumeID, snapshotID, err := common.ParseCSISnapshotID(req.SnapshotId)
+		if err != nil {
+			return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument, err.Error())
+		}
+		vc, capacityInBytes, faultType, err := c.getSnapshotMetadataSource(ctx, volumeID, req.StartingOffset)
+		if err != nil {
+			return faultType, err
+		}
+		if _, faultType, err := getSnapshotChangeID(ctx, vc, volumeID, snapshotID); err != nil {
+			return faultType, err
+		}
+		queryChangedDiskAreas := func(startOffset int64) (*types.DiskChangeInfo, error) {
+			changeInfo, err := vc.QueryChangedDiskAreas(ctx, volumeID, snapshotID, startOffset,
+				allocatedAreasChangeID)
+			if err != nil {
+				return nil, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to query allocated areas of snapshot %q. Error: %+v", req.SnapshotId, err)
+			}
+			return changeInfo, nil
+		}
+		err = streamBlockMetadata(capacityInBytes, req.StartingOffset, req.MaxResults, queryChangedDiskAreas,
+			func(blocks []*csi.BlockMetadata) error {
+				return stream.Send(&csi.GetMetadataAllocatedResponse{
+					BlockMetadataType:   csi.BlockMetadataType_VARIABLE_LENGTH,
+					VolumeCapacityBytes: capacityInBytes,
+					BlockMetadata:       blocks,
+				})
+			})
+		if err != nil {
+			return csifault.CSIInternalFault, err
+		}
+		return "", nil
+	}
+
+	faultType, err := getMetadataAllocatedInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetMetadataAllocatedOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetMetadataAllocatedOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetMetadataAllocatedOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return err
+}
+
+// GetMetadataDelta streams the block ranges which changed between two
+// snapshots of the same volume, computed with vSphere changed block tracking.
+func (c *controller) GetMetadataDelta(req *csi.GetMetadataDeltaRequest,
+	stream csi.SnapshotMetadata_GetMetadataDeltaServer) error {
+	start := time.Now()
+	ctx := logger.NewContextWithLogger(stream.Context())
+	log := logger.GetLogger(ctx)
+	volumeType := prometheus.PrometheusBlockVolumeType
+	getMetadataDeltaInternal := func() (string, error) {
+		log.Infof("GetMetadataDelta: called with args %+v", req)
+		if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+			return csifault.CSIUnimplementedFault, logger.LogNewErrorCode(log, codes.Unimplemented,
+				"getMetadataDelta")
+		}
+		if err := validateSnapshotMetadataRange(ctx, req.StartingOffset, req.MaxResults); err != nil {
+			return csifault.CSIInvalidArgumentFault, err
+		}
+		baseVolumeID, baseSnapshotID, err := common.ParseCSISnapshotID(req.BaseSnapshotId)
+		if err != nil {
+			return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"invalid base snapshot ID. Error: %v", err)
+		}
+		volumeID, targetSnapshotID, err := common.ParseCSISnapshotID(req.TargetSnapshotId)
+		if err != nil {
+			return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"invalid target snapshot ID. Error: %v", err)
+		}
+		if baseVolumeID != volumeID {
+			return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"base snapshot %q and target snapshot %q do not belong to the same volume",
+				req.BaseSnapshotId, req.TargetSnapshotId)
+		}
+		vc, capacityInBytes, faultType, err := c.getSnapshotMetadataSource(ctx, volumeID, req.StartingOffset)
+		if err != nil {
+			return faultType, err
+		}
+		baseChangeID, faultType, err := getSnapshotChangeID(ctx, vc, volumeID, baseSnapshotID)
+		if err != nil {
+			return faultType, err
+		}
+		if _, faultType, err := getSnapshotChangeID(ctx, vc, volumeID, targetSnapshotID); err != nil {
+			return faultType, err
+		}
+		queryChangedDiskAreas := func(startOffset int64) (*types.DiskChangeInfo, error) {
+			changeInfo, err := vc.QueryChangedDiskAreas(ctx, volumeID, targetSnapshotID, startOffset, baseChangeID)
+			if err != nil {
+				return nil, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to query areas of snapshot %q changed since snapshot %q. Error: %+v",
+					req.TargetSnapshotId, req.BaseSnapshotId, err)
+			}
+			return changeInfo, nil
+		}
+		err = streamBlockMetadata(capacityInBytes, req.StartingOffset, req.MaxResults, queryChangedDiskAreas,
+			func(blocks []*csi.BlockMetadata) error {
+				return stream.Send(&csi.GetMetadataDeltaResponse{
+					BlockMetadataType:   csi.BlockMetadataType_VARIABLE_LENGTH,
+					VolumeCapacityBytes: capacityInBytes,
+					BlockMetadata:       blocks,
+				})
+			})
+		if err != nil {
+			return csifault.CSIInternalFault, err
+		}
+		return "", nil
+	}
+
+	faultType, err := getMetadataDeltaInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetMetadataDeltaOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetMetadataDeltaOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetMetadataDeltaOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return err
+}
+
+// validateSnapshotMetadataRange validates the starting offset and the
+// maximum number of results of a SnapshotMetadata request.
+func validateSnapshotMetadataRange(ctx context.Context, startingOffset int64, maxResults int32) error {
+	log := logger.GetLogger(ctx)
+	if startingOffset < 0 {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"starting offset %d must not be negative", startingOffset)
+	}
+	if maxResults < 0 {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"max results %d must not be negative", maxResults)
+	}
+	return nil
+}
+
+// getSnapshotMetadataSource returns the vCenter of the given volume along
+// with its capacity, and verifies that the starting offset lies within the
+// volume. FCDs with snapshots cannot be expanded, so the capacity of the
+// volume is also the capacity of its snapshots.
+func (c *controller) getSnapshotMetadataSource(ctx context.Context, volumeID string, startingOffset int64) (
+	*cnsvsphere.VirtualCenter, int64, string, error) {
+	log := logger.GetLogger(ctx)
+	vCenterHost, _, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+	if err != nil {
+		return nil, 0, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to get vCenter for volume Id: %q. Error: %v", volumeID, err)
+	}
+	vc, err := c.managers.VcenterManager.GetVirtualCenter(ctx, vCenterHost)
+	if err != nil {
+		return nil, 0, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to get vCenter %q. Error: %v", vCenterHost, err)
+	}
+	vso, err := vc.RetrieveVStorageObject(ctx, volumeID)
+	if err != nil {
+		if cnsvsphere.IsNotFoundError(err) {
+			return nil, 0, csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+				"volume %q not found", volumeID)
+		}
+		return nil, 0, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to retrieve volume %q. Error: %v", volumeID, err)
+	}
+	capacityInBytes := vso.Config.CapacityInMB * common.MbInBytes
+	if startingOffset >= capacityInBytes {
+		return nil, 0, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.OutOfRange,
+			"starting offset %d is beyond the capacity %d of volume %q", startingOffset, capacityInBytes, volumeID)
+	}
+	return vc, capacityInBytes, "", nil
+}
+
+// getSnapshotChangeID returns the changed block tracking ID of the given
+// snapshot, failing with FailedPrecondition when changed block tracking was
+// not enabled on the disk at the time the snapshot was taken.
+func getSnapshotChangeID(ctx context.Context, vc *cnsvsphere.VirtualCenter, volumeID string,
+	snapshotID string) (string, string, error) {
+	log := logger.GetLogger(ctx)
+	details, err := vc.RetrieveSnapshotDetails(ctx, volumeID, snapshotID)
+	if err != nil {
+		if cnsvsphere.IsNotFoundError(err) {
+			return "", csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+				"snapshot %q of volume %q not found", snapshotID, volumeID)
+		}
+		return "", csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to retrieve snapshot %q of volume %q. Error: %v", snapshotID, volumeID, err)
+	}
+	if details.ChangedBlockTrackingId == "" {
+		return "", csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+			"changed block tracking is not enabled on volume %q for snapshot %q", volumeID, snapshotID)
+	}
+	return details.ChangedBlockTrackingId, "", nil
+}
+
+// streamBlockMetadata pages through the changed disk areas returned by
+// queryChangedDiskAreas from startingOffset up to the capacity of the volume
+// and sends them in batches of at most maxResults tuples. Areas ending before
+// the starting offset are skipped, as required by the CSI specification.
+// Areas which straddle the end of a page are returned again with the next
+// page, so only the part of them which was not sent yet is sent.
+func streamBlockMetadata(capacityInBytes int64, startingOffset int64, maxResults int32,
+	queryChangedDiskAreas func(startOffset int64) (*types.DiskChangeInfo, error),
+	send func(blocks []*csi.BlockMetadata) error) error {
+	if maxResults == 0 {
+		maxResults = defaultMaxBlockMetadataResults
+	}
+	blocks := make([]*csi.BlockMetadata, 0, maxResults)
+	// sentOffset is the offset up to which areas were sent.
+	sentOffset := startingOffset
+	sentAny := false
+	for offset := startingOffset; offset < capacityInBytes; {
+		changeInfo, err := queryChangedDiskAreas(offset)
+		if err != nil {
+			return err
+		}
+		for _, area := range changeInfo.ChangedArea {
+			areaEnd := area.Start + area.Length
+			if areaEnd <= sentOffset {
+				continue
+			}
+			areaStart := area.Start
+			if sentAny && areaStart < sentOffset {
+				areaStart = sentOffset
+			}
+			blocks = append(blocks, &csi.BlockMetadata{ByteOffset: areaStart, SizeBytes: areaEnd - areaStart})
+			sentOffset = areaEnd
+			sentAny = true
+			if len(blocks) == int(maxResults) {
+				if err := send(blocks); err != nil {
+					return err
+				}
+				blocks = make([]*csi.BlockMetadata, 0, maxResults)
+			}
+		}
+		nextOffset := changeInfo.StartOffset + changeInfo.Length
+		if nextOffset <= offset {
+			// Guard against a response which does not advance through the disk.
+			break
+		}
+		offset = nextOffset
+	}
+	if len(blocks) > 0 {
+		return send(blocks)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// fakeChangedDiskAreas serves the given changed areas in pages of pageLength
+// bytes, the way QueryChangedDiskAreas pages through a disk.
+func fakeChangedDiskAreas(areas []types.DiskChangeExtent, pageLength int64) func(int64) (*types.DiskChangeInfo,
+	error) {
+	return func(startOffset int64) (*types.DiskChangeInfo, error) {
+		changeInfo := &types.DiskChangeInfo{StartOffset: startOffset, Length: pageLength}
+		for _, area := range areas {
+			if area.Start+area.Length > startOffset && area.Start < startOffset+pageLength {
+				changeInfo.ChangedArea = append(changeInfo.ChangedArea, area)
+			}
+		}
+		return changeInfo, nil
+	}
+}
+
+func TestStreamBlockMetadata(t *testing.T) {
+	areas := []types.DiskChangeExtent{
+		{Start: 0, Length: 100},
+		{Start: 300, Length: 50},
+		{Start: 500, Length: 100},
+		{Start: 800, Length: 10},
+	}
+	tests := []struct {
+		name           string
+		areas          []types.DiskChangeExtent
+		startingOffset int64
+		maxResults     int32
+		expected       [][]int64
+	}{
+		{
+			name:     "DefaultMaxResults",
+			expected: [][]int64{{0, 100, 300, 50, 500, 100, 800, 10}},
+		},
+		{
+			name:       "PagedResults",
+			maxResults: 3,
+			expected:   [][]int64{{0, 100, 300, 50, 500, 100}, {800, 10}},
+		},
+		{
+			name:           "StartingOffsetWithinArea",
+			startingOffset: 320,
+			maxResults:     2,
+			expected:       [][]int64{{300, 50, 500, 100}, {800, 10}},
+		},
+		{
+			name:           "StartingOffsetAfterLastArea",
+			startingOffset: 900,
+		},
+		{
+			name: "AreasStraddlingPages",
+			areas: []types.DiskChangeExtent{
+				{Start: 100, Length: 50},
+				{Start: 380, Length: 40},
+				{Start: 700, Length: 200},
+			},
+			expected: [][]int64{{100, 50, 380, 40, 700, 200}},
+		},
+		{
+			name:           "StartingOffsetWithinAreaStraddlingPages",
+			areas:          []types.DiskChangeExtent{{Start: 380, Length: 40}, {Start: 780, Length: 100}},
+			startingOffset: 400,
+			maxResults:     1,
+			expected:       [][]int64{{380, 40}, {780, 100}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testAreas := areas
+			if test.areas != nil {
+				testAreas = test.areas
+			}
+			var sent [][]int64
+			err := streamBlockMetadata(1000, test.startingOffset, test.maxResults,
+				fakeChangedDiskAreas(testAreas, 400),
+				func(blocks []*csi.BlockMetadata) error {
+					var message []int64
+					for _, block := range blocks {
+						message = append(message, block.ByteOffset, block.SizeBytes)
+					}
+					sent = append(sent, message)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(sent, test.expected) {
+				t.Errorf("expected messages %v, got %v", test.expected, sent)
+			}
+		})
+	}
+}
+
+func TestStreamBlockMetadataErrors(t *testing.T) {
+	queryErr := errors.New("query failed")
+	err := streamBlockMetadata(1000, 0, 0, func(int64) (*types.DiskChangeInfo, error) {
+		return nil, queryErr
+	}, func([]*csi.BlockMetadata) error { return nil })
+	if !errors.Is(err, queryErr) {
+		t.Errorf("expected query error, got %v", err)
+	}
+
+	sendErr := errors.New("send failed")
+	err = streamBlockMetadata(1000, 0, 0, fakeChangedDiskAreas([]types.DiskChangeExtent{{Start: 0, Length: 10}}, 1000),
+		func([]*csi.BlockMetadata) error { return sendErr })
+	if !errors.Is(err, sendErr) {
+		t.Errorf("expected send error, got %v", err)
+	}
+}
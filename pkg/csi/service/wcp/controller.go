@@ -77,6 +77,8 @@ var (
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
 		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
 	}
 	// volumeInfoService holds the pointer to VolumeInfo service instance
 	// This will hold mapping for VolumeID to Storage policy info for PodVMOnStretchedSupervisor deployments
@@ -371,6 +373,16 @@ func (c *controller) Init(config *cnsconfig.Config, version string) error {
 	return nil
 }
 
+// HealthChecks checks the session of the vCenter of the controller and the
+// listview of its volume manager.
+func (c *controller) HealthChecks(ctx context.Context) []csitypes.HealthCheck {
+	if c.manager == nil {
+		return []csitypes.HealthCheck{{Name: "controller", Message: "controller is not initialized"}}
+	}
+	return common.GetVCenterHealthChecks(ctx, c.manager.VcenterManager,
+		map[string]cnsvolume.Manager{c.manager.VcenterConfig.Host: c.manager.VolumeManager})
+}
+
 // ReloadConfiguration reloads configuration from the secret, and update
 // controller's config cache and VolumeManager's VC Config cache.
 // The function takes a boolean reconnectToVCFromNewConfig as ainputs.
@@ -2470,8 +2482,16 @@ func (c *controller) ControllerGetCapabilities(ctx context.Context, req *csi.Con
 		controllerCaps = append(controllerCaps, csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
 			csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES)
 	}
+	rpcTypes := append([]csi.ControllerServiceCapability_RPC_Type{}, controllerCaps...)
+	isPolicyReconfigSupported, err := c.manager.VcenterManager.IsCnsVolumePolicyReconfigSupported(ctx,
+		c.manager.VcenterConfig.Host)
+	if err != nil {
+		log.Errorf("failed to check if storage policy change is supported. Error: %v", err)
+	} else if isPolicyReconfigSupported {
+		rpcTypes = append(rpcTypes, csi.ControllerServiceCapability_RPC_MODIFY_VOLUME)
+	}
 
-	for _, cap := range controllerCaps {
+	for _, cap := range rpcTypes {
 		c := &csi.ControllerServiceCapability{
 			Type: &csi.ControllerServiceCapability_Rpc{
 				Rpc: &csi.ControllerServiceCapability_RPC{
@@ -2928,14 +2948,163 @@ func (c *controller) ControllerExpandVolume(ctx context.Context, req *csi.Contro
 	return resp, err
 }
 
+// ControllerGetVolume returns the capacity, the published nodes and the
+// condition of the volume. The condition is derived from the health status of
+// the volume reported by CNS.
 func (c *controller) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (
 	*csi.ControllerGetVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	start := time.Now()
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	volumeType := prometheus.PrometheusUnknownVolumeType
+	controllerGetVolumeInternal := func() (
+		*csi.ControllerGetVolumeResponse, string, error) {
+		log.Infof("ControllerGetVolume: called with args %+v", req)
+		if req.VolumeId == "" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"volume ID is a required parameter")
+		}
+		volume, capacityInBytes, volumeCondition, faultType, err := common.GetVolumeUtil(ctx,
+			c.manager.VolumeManager, req.VolumeId)
+		if err != nil {
+			return nil, faultType, err
+		}
+		volumeType = convertCnsVolumeType(ctx, volume.VolumeType)
+		nodeNames := commonco.ContainerOrchestratorUtility.GetNodesForVolumes(ctx, []string{req.VolumeId})
+		resp := &csi.ControllerGetVolumeResponse{
+			Volume: &csi.Volume{
+				VolumeId:      req.VolumeId,
+				CapacityBytes: capacityInBytes,
+			},
+			Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+				PublishedNodeIds: nodeNames[req.VolumeId],
+				VolumeCondition:  volumeCondition,
+			},
+		}
+		return resp, "", nil
+	}
+	resp, faultType, err := controllerGetVolumeInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetVolumeOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetVolumeOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetVolumeOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
 }
 
+// ControllerModifyVolume changes the storage policy of a block volume to the
+// one given in the mutable parameters of the VolumeAttributesClass.
 func (c *controller) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (
 	*csi.ControllerModifyVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	start := time.Now()
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	volumeType := prometheus.PrometheusUnknownVolumeType
+
+	controllerModifyVolumeInternal := func() (*csi.ControllerModifyVolumeResponse, string, error) {
+		log.Infof("ControllerModifyVolume: called with args %+v", req)
+		volumeID := req.GetVolumeId()
+		if volumeID == "" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"volume ID must be provided")
+		}
+		modifyParams, err := common.ParseModifyVolumeParams(ctx, req.GetMutableParameters())
+		if err != nil {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"parsing mutable parameters failed with error: %+v", err)
+		}
+		isPolicyReconfigSupported, err := c.manager.VcenterManager.IsCnsVolumePolicyReconfigSupported(ctx,
+			c.manager.VcenterConfig.Host)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to check if storage policy change is supported due to error: %v", err)
+		}
+		if !isPolicyReconfigSupported {
+			return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCodef(log, codes.Unimplemented,
+				"changing the storage policy of a volume is not supported on vCenter %q",
+				c.manager.VcenterConfig.Host)
+		}
+		cnsVolumeType, err := common.GetCnsVolumeType(ctx, c.manager.VolumeManager, volumeID)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to determine CNS volume type for volume: %q. Error: %+v", volumeID, err)
+		}
+		volumeType = convertCnsVolumeType(ctx, cnsVolumeType)
+		if cnsVolumeType == common.FileVolumeType {
+			return nil, csifault.CSIUnimplementedFault, logger.LogNewErrorCodef(log, codes.Unimplemented,
+				"changing the storage policy of file volume %q is not supported", volumeID)
+		}
+
+		vc, err := common.GetVCenterFromVCHost(ctx, c.manager.VcenterManager, c.manager.VcenterConfig.Host)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter instance for host %q. Error: %+v", c.manager.VcenterConfig.Host, err)
+		}
+		storagePolicyID := modifyParams.StoragePolicyID
+		if modifyParams.StoragePolicyName != "" {
+			storagePolicyID, err = vc.GetStoragePolicyIDByName(ctx, modifyParams.StoragePolicyName)
+			if err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"failed to get policy ID for storage policy name %q. Error: %+v",
+					modifyParams.StoragePolicyName, err)
+			}
+		}
+
+		// Relocation is only attempted within a single vSphere cluster, as the
+		// zone a volume is accessible from must not change under the workload.
+		var candidateDatastores []*cnsvsphere.DatastoreInfo
+		if c.manager.CnsConfig.Global.ClusterID != "" {
+			candidateDatastores, _, err = getCandidateDatastores(ctx, vc, c.manager.CnsConfig.Global.ClusterID, false)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed finding candidate datastores to relocate volume. Error: %v", err)
+			}
+		}
+		faultType, err := common.ModifyVolumePolicyUtil(ctx, vc, c.manager.VolumeManager, volumeID,
+			storagePolicyID, candidateDatastores)
+		if err != nil {
+			// Error is already wrapped in CSI error code.
+			return nil, faultType, err
+		}
+		if isPodVMOnStretchSupervisorFSSEnabled {
+			patch := map[string]interface{}{
+				"spec": map[string]interface{}{
+					"storagePolicyID": storagePolicyID,
+				},
+			}
+			err = c.UpdateCNSVolumeInfo(ctx, patch, volumeID)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, err
+			}
+		}
+		return &csi.ControllerModifyVolumeResponse{}, "", nil
+	}
+
+	resp, faultType, err := controllerModifyVolumeInternal()
+	if err != nil {
+		log.Debugf("controllerModifyVolumeInternal: returns fault %q for volume %q", faultType, req.VolumeId)
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusModifyVolumeOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusModifyVolumeOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		log.Infof("Volume %q modified successfully.", req.VolumeId)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusModifyVolumeOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
 }
 
 func (c *controller) UpdateCNSVolumeInfo(ctx context.Context, patch map[string]interface{}, volumeID string) error {
@@ -73,6 +73,8 @@ var (
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
 		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
 		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
 	}
 )
 
@@ -947,6 +949,10 @@ func controllerPublishForFileVolume(ctx context.Context, req *csi.ControllerPubl
 				publishInfo[common.Nfsv4AccessPoint] = value
 				break
 			}
+			if key == common.SmbAccessPointKey {
+				publishInfo[common.SmbAccessPoint] = value
+				break
+			}
 		}
 		publishInfo[common.AttributeDiskType] = common.DiskTypeFileVolume
 		resp := &csi.ControllerPublishVolumeResponse{
@@ -1013,11 +1019,20 @@ func controllerPublishForFileVolume(ctx context.Context, req *csi.ControllerPubl
 					publishInfo[common.Nfsv4AccessPoint] = value
 					break
 				}
+				if key == common.SmbAccessPointKey {
+					publishInfo[common.AttributeDiskType] = common.DiskTypeFileVolume
+					publishInfo[common.SmbAccessPoint] = value
+					break
+				}
 			}
 			if _, ok := publishInfo[common.Nfsv4AccessPoint]; ok {
 				log.Debugf("Found Nfsv4AccessPoint in publishInfo. publishInfo=%+v", publishInfo)
 				break
 			}
+			if _, ok := publishInfo[common.SmbAccessPoint]; ok {
+				log.Debugf("Found SmbAccessPoint in publishInfo. publishInfo=%+v", publishInfo)
+				break
+			}
 		}
 		cnsFileAccessConfigInstanceErr = cnsfileaccessconfig.Status.Error
 	}
@@ -1867,12 +1882,84 @@ func (c *controller) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRe
 	return resp, err
 }
 
+// ControllerGetVolume returns the capacity, the published nodes and the
+// condition of the volume. The capacity and the condition are taken from the
+// Supervisor PVC, whose health annotation is kept up to date by the syncer
+// running in the Supervisor cluster.
 func (c *controller) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (
 	*csi.ControllerGetVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	start := time.Now()
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	volumeType := prometheus.PrometheusUnknownVolumeType
+	controllerGetVolumeInternal := func() (
+		*csi.ControllerGetVolumeResponse, string, error) {
+		log.Infof("ControllerGetVolume: called with args %+v", req)
+		if req.VolumeId == "" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"volume ID is a required parameter")
+		}
+		svPVC, err := c.supervisorClient.CoreV1().PersistentVolumeClaims(c.supervisorNamespace).Get(
+			ctx, req.VolumeId, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+					"PVC: %q not found in the Supervisor cluster", req.VolumeId)
+			}
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to retrieve supervisor PVC %q in %q namespace. Error: %+v",
+				req.VolumeId, c.supervisorNamespace, err)
+		}
+		volumeType = prometheus.PrometheusBlockVolumeType
+		for _, accessMode := range svPVC.Spec.AccessModes {
+			if accessMode == corev1.ReadWriteMany || accessMode == corev1.ReadOnlyMany {
+				volumeType = prometheus.PrometheusFileVolumeType
+			}
+		}
+		var capacityInBytes int64
+		if capacity, ok := svPVC.Status.Capacity[corev1.ResourceStorage]; ok {
+			capacityInBytes = capacity.Value()
+		}
+		volumeCondition := common.GetVolumeConditionFromHealthAnnotation(svPVC.Annotations[common.AnnVolumeHealth])
+		nodeNames := commonco.ContainerOrchestratorUtility.GetNodesForVolumes(ctx, []string{req.VolumeId})
+		resp := &csi.ControllerGetVolumeResponse{
+			Volume: &csi.Volume{
+				VolumeId:      req.VolumeId,
+				CapacityBytes: capacityInBytes,
+			},
+			Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+				PublishedNodeIds: nodeNames[req.VolumeId],
+				VolumeCondition:  volumeCondition,
+			},
+		}
+		return resp, "", nil
+	}
+	resp, faultType, err := controllerGetVolumeInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetVolumeOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetVolumeOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetVolumeOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
 }
 
+// ControllerModifyVolume is not supported in the guest cluster. The storage
+// policy of a volume is owned by the supervisor PVC backing it, so it has to be
+// changed from the supervisor cluster.
 func (c *controller) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (
 	*csi.ControllerModifyVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	log.Infof("ControllerModifyVolume: called with args %+v", req)
+	return nil, logger.LogNewErrorCode(log, codes.Unimplemented,
+		"modifying volumes is not supported in the guest cluster, change the storage policy of "+
+			"the volume from the supervisor cluster instead")
 }
@@ -25,8 +25,11 @@ import (
 	"time"
 
 	vmoperatortypes "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientset "k8s.io/client-go/kubernetes"
@@ -638,3 +641,48 @@ func TestVirtualMachineVolumePatchWithOptimisticMerge(t *testing.T) {
 		t.Fatalf("invalid volume name: a=%s, e=%s", a, e)
 	}
 }
+
+// TestGuestClusterControllerGetVolume tests that ControllerGetVolume reports
+// the capacity and the condition of the volume from the Supervisor PVC.
+func TestGuestClusterControllerGetVolume(t *testing.T) {
+	ct := getControllerTest(t)
+	svPVCName := "get-volume-pvc"
+	svPVC := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        svPVCName,
+			Namespace:   supervisorNamespace,
+			Annotations: map[string]string{common.AnnVolumeHealth: common.VolHealthStatusInaccessible},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+		},
+		Status: v1.PersistentVolumeClaimStatus{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("1Gi")},
+		},
+	}
+	_, err := ct.controller.supervisorClient.CoreV1().PersistentVolumeClaims(supervisorNamespace).Create(
+		ctx, svPVC, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("failed to create supervisor PVC. Error: %+v", err)
+	}
+	defer func() {
+		_ = ct.controller.supervisorClient.CoreV1().PersistentVolumeClaims(supervisorNamespace).Delete(
+			ctx, svPVCName, metav1.DeleteOptions{})
+	}()
+
+	resp, err := ct.controller.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: svPVCName})
+	if err != nil {
+		t.Fatalf("ControllerGetVolume failed. Error: %+v", err)
+	}
+	if resp.Volume.CapacityBytes != 1024*1024*1024 {
+		t.Errorf("expected capacity %d, got %d", 1024*1024*1024, resp.Volume.CapacityBytes)
+	}
+	if !resp.Status.VolumeCondition.Abnormal {
+		t.Errorf("expected volume condition to be abnormal, got %+v", resp.Status.VolumeCondition)
+	}
+
+	_, err = ct.controller.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: "non-existent-pvc"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a missing supervisor PVC, got %v", err)
+	}
+}
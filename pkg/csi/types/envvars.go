@@ -47,4 +47,9 @@ const (
 	// Depending on the value, either controller and node service will be
 	// activated (The identity service is always activated).
 	EnvVarMode = "X_CSI_MODE"
+
+	// EnvVarHealthAddress specifies the address, e.g. ":9810", of the HTTP
+	// server exposing the /healthz and /readyz endpoints of the CSI driver.
+	// The server is not started if the variable is not set.
+	EnvVarHealthAddress = "CSI_HEALTH_ADDRESS"
 )
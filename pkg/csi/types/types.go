@@ -17,6 +17,8 @@ limitations under the License.
 package types
 
 import (
+	"context"
+
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
 )
@@ -27,3 +29,21 @@ type CnsController interface {
 	csi.ControllerServer
 	Init(config *config.Config, version string) error
 }
+
+// HealthCheck is the result of a check of a dependency of the CSI driver,
+// reported by the Identity Probe and the health endpoints.
+type HealthCheck struct {
+	// Name identifies the check, e.g. "vcenter-session/<vCenter host>".
+	Name string `json:"name"`
+	// Healthy is true if the check passed.
+	Healthy bool `json:"healthy"`
+	// Message describes why the check failed.
+	Message string `json:"message,omitempty"`
+}
+
+// HealthChecker is implemented by the CnsControllers which can check the
+// health of their dependencies, e.g. the vCenter connections.
+type HealthChecker interface {
+	// HealthChecks runs the health checks of the controller.
+	HealthChecks(ctx context.Context) []HealthCheck
+}
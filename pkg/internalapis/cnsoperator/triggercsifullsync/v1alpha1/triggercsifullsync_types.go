@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,12 +26,46 @@ import (
 // created to trigger full sync on demand.
 const TriggerCsiFullSyncCRName = "csifullsync"
 
+// MaxDriftReportChanges is the maximum number of changes listed in a drift
+// report, to keep the size of the TriggerCsiFullSync instance bounded.
+const MaxDriftReportChanges = 200
+
+// DriftReportExpiry is the time after which a drift report that has not been
+// approved expires, and periodic full sync resumes.
+const DriftReportExpiry = 24 * time.Hour
+
+const (
+	// DriftReportOperationCreate represents a volume to be created in CNS.
+	DriftReportOperationCreate = "create"
+	// DriftReportOperationUpdate represents a volume whose metadata is to be updated in CNS.
+	DriftReportOperationUpdate = "update"
+	// DriftReportOperationDelete represents a volume whose container cluster metadata
+	// is to be deleted in CNS.
+	DriftReportOperationDelete = "delete"
+)
+
 // TriggerCsiFullSyncSpec is the spec for TriggerCsiFullSync
 type TriggerCsiFullSyncSpec struct {
 	// TriggerSyncID gives an option to trigger full sync on demand.
 	// Initial value will be 0. In order to trigger a full sync, user
 	// has to set a number that is 1 greater than the previous one.
 	TriggerSyncID uint64 `json:"triggerSyncID"`
+
+	// DryRun indicates whether the full sync triggered by TriggerSyncID should
+	// only compute the drift between kubernetes and CNS and publish it in
+	// Status.DriftReport, without making any changes in CNS. Periodic full
+	// sync is suspended while the drift report awaits approval, for at most
+	// DriftReportExpiry (24 hours) after the report was computed. A full sync
+	// triggered without DryRun and ApproveDriftReportID, including the first
+	// periodic full sync after the report expired, discards the report.
+	// Periodic full sync resets DryRun and ApproveDriftReportID.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// ApproveDriftReportID gives an option to apply the changes of a drift report
+	// computed by a previous dry run. If it is set to the ID of Status.DriftReport,
+	// the full sync triggered by TriggerSyncID applies only the changes listed in
+	// that report which are still required.
+	ApproveDriftReportID uint64 `json:"approveDriftReportID,omitempty"`
 }
 
 // TriggerCsiFullSyncStatus contains the status for a TriggerCsiFullSync
@@ -58,6 +94,50 @@ type TriggerCsiFullSyncStatus struct {
 	// The last error encountered during CSI full sync operation, if any.
 	// Previous error will be cleared when a new full sync is in progress.
 	Error string `json:"error,omitempty"`
+
+	// DriftReport is the drift between kubernetes and CNS computed by the last
+	// dry run of CSI full sync.
+	DriftReport *DriftReport `json:"driftReport,omitempty"`
+}
+
+// DriftReport contains the changes a CSI full sync would make in CNS.
+type DriftReport struct {
+	// ID is the TriggerSyncID of the dry run which computed the report.
+	ID uint64 `json:"id"`
+
+	// GeneratedTimeStamp indicates when the report was computed.
+	GeneratedTimeStamp *metav1.Time `json:"generatedTimeStamp,omitempty"`
+
+	// Applied indicates whether the changes of the report have been applied.
+	Applied bool `json:"applied"`
+
+	// VolumesToCreate is the number of volumes to be created in CNS.
+	VolumesToCreate int `json:"volumesToCreate"`
+
+	// VolumesToUpdate is the number of volumes whose metadata is to be updated in CNS.
+	VolumesToUpdate int `json:"volumesToUpdate"`
+
+	// VolumesToDelete is the number of volumes whose container cluster metadata
+	// is to be deleted in CNS.
+	VolumesToDelete int `json:"volumesToDelete"`
+
+	// Changes lists the changes of the report. At most MaxDriftReportChanges
+	// changes are listed, and only the listed changes are applied when the
+	// report is approved.
+	Changes []DriftReportChange `json:"changes,omitempty"`
+}
+
+// DriftReportChange is a change a CSI full sync would make for a volume.
+type DriftReportChange struct {
+	// Operation is the operation to be performed on the volume, one of
+	// "create", "update" or "delete".
+	Operation string `json:"operation"`
+
+	// VolumeID is the ID of the volume.
+	VolumeID string `json:"volumeID"`
+
+	// VolumeName is the name of the volume, if known.
+	VolumeName string `json:"volumeName,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
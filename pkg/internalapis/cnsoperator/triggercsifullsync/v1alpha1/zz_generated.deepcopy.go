@@ -22,13 +22,54 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftReport) DeepCopyInto(out *DriftReport) {
+	*out = *in
+	if in.GeneratedTimeStamp != nil {
+		in, out := &in.GeneratedTimeStamp, &out.GeneratedTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]DriftReportChange, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftReport.
+func (in *DriftReport) DeepCopy() *DriftReport {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftReportChange) DeepCopyInto(out *DriftReportChange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftReportChange.
+func (in *DriftReportChange) DeepCopy() *DriftReportChange {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftReportChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TriggerCsiFullSync) DeepCopyInto(out *TriggerCsiFullSync) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -102,6 +143,27 @@ func (in *TriggerCsiFullSyncSpec) DeepCopy() *TriggerCsiFullSyncSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TriggerCsiFullSyncStatus) DeepCopyInto(out *TriggerCsiFullSyncStatus) {
 	*out = *in
+	if in.LastSuccessfulStartTimeStamp != nil {
+		in, out := &in.LastSuccessfulStartTimeStamp, &out.LastSuccessfulStartTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulEndTimeStamp != nil {
+		in, out := &in.LastSuccessfulEndTimeStamp, &out.LastSuccessfulEndTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRunStartTimeStamp != nil {
+		in, out := &in.LastRunStartTimeStamp, &out.LastRunStartTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRunEndTimeStamp != nil {
+		in, out := &in.LastRunEndTimeStamp, &out.LastRunEndTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.DriftReport != nil {
+		in, out := &in.DriftReport, &out.DriftReport
+		*out = new(DriftReport)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsvolumeoperationrequest
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	cnsvolumeoprequestv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest/v1alpha1"
+)
+
+// VolumeOperationRequestPersister persists the details of the volume
+// operations of the container orchestrators without an API server to store
+// CnsVolumeOperationRequest instances, e.g. in Nomad variables. The details
+// are stored as opaque data under the name of the operation.
+type VolumeOperationRequestPersister interface {
+	// GetVolumeOperationRequest returns the data stored with the given name.
+	// found is false if there is none.
+	GetVolumeOperationRequest(ctx context.Context, name string) (data string, found bool, err error)
+	// ListVolumeOperationRequests returns the data stored, keyed by name.
+	ListVolumeOperationRequests(ctx context.Context) (map[string]string, error)
+	// StoreVolumeOperationRequest stores the data with the given name,
+	// replacing the data stored with the name, if any.
+	StoreVolumeOperationRequest(ctx context.Context, name string, data string) error
+	// DeleteVolumeOperationRequest deletes the data stored with the given
+	// name, if any.
+	DeleteVolumeOperationRequest(ctx context.Context, name string) error
+}
+
+// persistentOperationRequestStore implements the VolumeOperationRequest
+// interface on top of a VolumeOperationRequestPersister, so that the operation
+// details survive a restart of the controller like CnsVolumeOperationRequest
+// instances do. Reads are done directly on the persister; there is no caching
+// layer involved.
+type persistentOperationRequestStore struct {
+	persister VolumeOperationRequestPersister
+}
+
+var persistentOperationRequestStoreInstance *persistentOperationRequestStore
+
+// InitPersistentVolumeOperationRequestInterface returns an implementation of
+// VolumeOperationRequest interface persisting the operation details with the
+// given persister.
+func InitPersistentVolumeOperationRequestInterface(ctx context.Context, cleanupInterval int,
+	persister VolumeOperationRequestPersister) (VolumeOperationRequest, error) {
+	log := logger.GetLogger(ctx)
+	operationStoreInitLock.Lock()
+	defer operationStoreInitLock.Unlock()
+	if persistentOperationRequestStoreInstance == nil {
+		log.Info("Initializing persistent VolumeOperationRequest instance")
+		persistentOperationRequestStoreInstance = &persistentOperationRequestStore{persister: persister}
+		go persistentOperationRequestStoreInstance.cleanupStaleInstances(cleanupInterval)
+	}
+	return persistentOperationRequestStoreInstance, nil
+}
+
+// GetRequestDetails returns the details of the last operation on the volume
+// stored with the given name, or a NotFound error.
+func (or *persistentOperationRequestStore) GetRequestDetails(ctx context.Context, name string) (
+	*VolumeOperationRequestDetails, error) {
+	log := logger.GetLogger(ctx)
+	data, found, err := or.persister.GetVolumeOperationRequest(ctx, name)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to get the details of operation %q. Error: %v", name, err)
+	}
+	if !found {
+		return nil, apierrors.NewNotFound(cnsvolumeoprequestv1alpha1.Resource(CRDPlural), name)
+	}
+	details := &VolumeOperationRequestDetails{}
+	if err := json.Unmarshal([]byte(data), details); err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to decode the details of operation %q. Error: %v", name, err)
+	}
+	log.Debugf("Details of operation %q retrieved: %+v", name, *details)
+	return details, nil
+}
+
+// StoreRequestDetails stores the details of the operation taking place on the
+// volume.
+func (or *persistentOperationRequestStore) StoreRequestDetails(ctx context.Context,
+	operationToStore *VolumeOperationRequestDetails) error {
+	log := logger.GetLogger(ctx)
+	if operationToStore == nil {
+		return logger.LogNewError(log, "cannot store empty operation")
+	}
+	data, err := json.Marshal(operationToStore)
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to encode the details of operation %q. Error: %v",
+			operationToStore.Name, err)
+	}
+	err = or.persister.StoreVolumeOperationRequest(ctx, operationToStore.Name, string(data))
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to store the details of operation %q. Error: %v",
+			operationToStore.Name, err)
+	}
+	log.Debugf("Details of operation %q stored: %+v", operationToStore.Name, *operationToStore)
+	return nil
+}
+
+// DeleteRequestDetails deletes the details of the operation stored with the
+// given name, if any.
+func (or *persistentOperationRequestStore) DeleteRequestDetails(ctx context.Context, name string) error {
+	log := logger.GetLogger(ctx)
+	if err := or.persister.DeleteVolumeOperationRequest(ctx, name); err != nil {
+		return logger.LogNewErrorf(log, "failed to delete the details of operation %q. Error: %v", name, err)
+	}
+	return nil
+}
+
+// cleanupStaleInstances deletes the operation details which are not in
+// progress and whose TaskInvocationTimestamp is older than 15 minutes.
+func (or *persistentOperationRequestStore) cleanupStaleInstances(cleanupInterval int) {
+	ticker := time.NewTicker(time.Duration(cleanupInterval) * time.Minute)
+	ctx, log := logger.GetNewContextWithLogger()
+	log.Infof("Persistent VolumeOperationRequest clean up interval is set to %d minutes", cleanupInterval)
+	for range ticker.C {
+		or.deleteStaleInstances(ctx, time.Now().Add(-15*time.Minute))
+	}
+}
+
+// deleteStaleInstances deletes the operation details which are not in
+// progress and were invoked before the cutoff time.
+func (or *persistentOperationRequestStore) deleteStaleInstances(ctx context.Context, cutoffTime time.Time) {
+	log := logger.GetLogger(ctx)
+	requests, err := or.persister.ListVolumeOperationRequests(ctx)
+	if err != nil {
+		log.Errorf("failed to list the details of the operations. Error: %v", err)
+		return
+	}
+	for name, data := range requests {
+		details := &VolumeOperationRequestDetails{}
+		if err := json.Unmarshal([]byte(data), details); err != nil {
+			log.Warnf("failed to decode the details of operation %q. Deleting them. Error: %v", name, err)
+		} else if details.OperationDetails != nil &&
+			(details.OperationDetails.TaskStatus == TaskInvocationStatusInProgress ||
+				details.OperationDetails.TaskInvocationTimestamp.Time.After(cutoffTime)) {
+			continue
+		}
+		if err := or.persister.DeleteVolumeOperationRequest(ctx, name); err != nil {
+			log.Errorf("failed to delete the details of operation %q. Error: %v", name, err)
+		}
+	}
+}
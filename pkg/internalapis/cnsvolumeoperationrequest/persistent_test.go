@@ -0,0 +1,150 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsvolumeoperationrequest
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakePersister is a VolumeOperationRequestPersister keeping the data in a
+// map, which outlives the stores using it.
+type fakePersister struct {
+	lock sync.Mutex
+	data map[string]string
+}
+
+func (p *fakePersister) GetVolumeOperationRequest(ctx context.Context, name string) (string, bool, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	data, found := p.data[name]
+	return data, found, nil
+}
+
+func (p *fakePersister) ListVolumeOperationRequests(ctx context.Context) (map[string]string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return maps.Clone(p.data), nil
+}
+
+func (p *fakePersister) StoreVolumeOperationRequest(ctx context.Context, name string, data string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.data[name] = data
+	return nil
+}
+
+func (p *fakePersister) DeleteVolumeOperationRequest(ctx context.Context, name string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.data, name)
+	return nil
+}
+
+// newTestPersistentStore returns a store without a cleanup routine using the
+// given persister.
+func newTestPersistentStore(persister *fakePersister) *persistentOperationRequestStore {
+	return &persistentOperationRequestStore{persister: persister}
+}
+
+func TestPersistentStore_StoreGetDelete(t *testing.T) {
+	ctx := context.Background()
+	persister := &fakePersister{data: make(map[string]string)}
+	store := newTestPersistentStore(persister)
+
+	_, err := store.GetRequestDetails(ctx, "pvc-123")
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("Expected NotFound error, got: %v", err)
+	}
+
+	details := createTestVolumeOperationDetails("pvc-123", "volume-123", "", "task-1",
+		TaskInvocationStatusInProgress, "", createTestQuotaDetails(1024))
+	if err := store.StoreRequestDetails(ctx, details); err != nil {
+		t.Fatalf("Failed to store details: %v", err)
+	}
+
+	// Changes to the stored details must not affect the store.
+	details.OperationDetails.TaskStatus = TaskInvocationStatusError
+	got, err := store.GetRequestDetails(ctx, "pvc-123")
+	if err != nil {
+		t.Fatalf("Failed to get details: %v", err)
+	}
+	if got.VolumeID != "volume-123" || got.OperationDetails.TaskStatus != TaskInvocationStatusInProgress ||
+		got.QuotaDetails.Reserved.Value() != 1024 {
+		t.Errorf("Unexpected details: %+v", got)
+	}
+
+	// The details survive a restart of the controller.
+	got, err = newTestPersistentStore(persister).GetRequestDetails(ctx, "pvc-123")
+	if err != nil {
+		t.Fatalf("Failed to get details after restart: %v", err)
+	}
+	if got.VolumeID != "volume-123" || got.OperationDetails.TaskID != "task-1" {
+		t.Errorf("Unexpected details after restart: %+v", got)
+	}
+
+	if err := store.DeleteRequestDetails(ctx, "pvc-123"); err != nil {
+		t.Fatalf("Failed to delete details: %v", err)
+	}
+	if _, err := store.GetRequestDetails(ctx, "pvc-123"); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected NotFound error after delete, got: %v", err)
+	}
+	// Deleting missing details is not an error.
+	if err := store.DeleteRequestDetails(ctx, "pvc-123"); err != nil {
+		t.Errorf("Unexpected error deleting missing details: %v", err)
+	}
+	if err := store.StoreRequestDetails(ctx, nil); err == nil {
+		t.Errorf("Expected error storing nil details")
+	}
+}
+
+func TestPersistentStore_DeleteStaleInstances(t *testing.T) {
+	ctx := context.Background()
+	store := newTestPersistentStore(&fakePersister{data: make(map[string]string)})
+	old := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	staleDone := createTestVolumeOperationDetails("stale-done", "volume-1", "", "task-1",
+		TaskInvocationStatusSuccess, "", nil)
+	staleDone.OperationDetails.TaskInvocationTimestamp = old
+	staleInProgress := createTestVolumeOperationDetails("stale-in-progress", "volume-2", "", "task-2",
+		TaskInvocationStatusInProgress, "", nil)
+	staleInProgress.OperationDetails.TaskInvocationTimestamp = old
+	recentDone := createTestVolumeOperationDetails("recent-done", "volume-3", "", "task-3",
+		TaskInvocationStatusError, "failed", nil)
+	for _, details := range []*VolumeOperationRequestDetails{staleDone, staleInProgress, recentDone} {
+		if err := store.StoreRequestDetails(ctx, details); err != nil {
+			t.Fatalf("Failed to store details: %v", err)
+		}
+	}
+
+	store.deleteStaleInstances(ctx, time.Now().Add(-15*time.Minute))
+
+	if _, err := store.GetRequestDetails(ctx, "stale-done"); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected stale-done to be deleted, got: %v", err)
+	}
+	for _, name := range []string{"stale-in-progress", "recent-done"} {
+		if _, err := store.GetRequestDetails(ctx, name); err != nil {
+			t.Errorf("Expected %s to be kept, got: %v", name, err)
+		}
+	}
+}
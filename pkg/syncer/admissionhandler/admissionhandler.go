@@ -244,50 +244,51 @@ func StartWebhookServer(ctx context.Context, enableWebhookClientCertVerification
 		featureFileVolumesWithVmServiceEnabled = containerOrchestratorUtility.IsFSSEnabled(ctx,
 			common.FileVolumesWithVmService)
 
-		if featureGateCsiMigrationEnabled || featureGateBlockVolumeSnapshotEnabled {
-			certs, err := tls.LoadX509KeyPair(cfg.WebHookConfig.CertFile, cfg.WebHookConfig.KeyFile)
-			if err != nil {
-				log.Errorf("failed to load key pair. certFile: %q, keyFile: %q err: %v",
-					cfg.WebHookConfig.CertFile, cfg.WebHookConfig.KeyFile, err)
-				return err
-			}
-			if cfg.WebHookConfig.Port == "" {
-				cfg.WebHookConfig.Port = defaultWebhookServerPort
-			}
-			server = &http.Server{
-				Addr: fmt.Sprintf(":%v", cfg.WebHookConfig.Port),
-				TLSConfig: &tls.Config{
-					Certificates: []tls.Certificate{certs},
-					CipherSuites: []uint16{
-						tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-						tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-						tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-						tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-					},
-					MinVersion: tls.VersionTLS12,
+		// StorageClasses of the driver are always validated, so the webhook
+		// server is started regardless of the enabled features.
+		initStorageClassValidation(ctx)
+		certs, err := tls.LoadX509KeyPair(cfg.WebHookConfig.CertFile, cfg.WebHookConfig.KeyFile)
+		if err != nil {
+			log.Errorf("failed to load key pair. certFile: %q, keyFile: %q err: %v",
+				cfg.WebHookConfig.CertFile, cfg.WebHookConfig.KeyFile, err)
+			return err
+		}
+		if cfg.WebHookConfig.Port == "" {
+			cfg.WebHookConfig.Port = defaultWebhookServerPort
+		}
+		server = &http.Server{
+			Addr: fmt.Sprintf(":%v", cfg.WebHookConfig.Port),
+			TLSConfig: &tls.Config{
+				Certificates: []tls.Certificate{certs},
+				CipherSuites: []uint16{
+					tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+					tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+					tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+					tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
 				},
-			}
-			// Define http server and server handler.
-			mux := http.NewServeMux()
-			mux.HandleFunc("/validate", validationHandler)
-			server.Handler = mux
+				MinVersion: tls.VersionTLS12,
+			},
+		}
+		// Define http server and server handler.
+		mux := http.NewServeMux()
+		mux.HandleFunc("/validate", validationHandler)
+		server.Handler = mux
 
-			// Start webhook server.
-			log.Debugf("Starting webhook server on port: %v", cfg.WebHookConfig.Port)
-			go func() {
-				if err = server.ListenAndServeTLS(cfg.WebHookConfig.CertFile, cfg.WebHookConfig.KeyFile); err != nil {
-					if err == http.ErrServerClosed {
-						log.Info("Webhook server stopped")
-					} else {
-						log.Fatalf("failed to listen and serve webhook server. err: %v", err)
-					}
+		// Start webhook server.
+		log.Debugf("Starting webhook server on port: %v", cfg.WebHookConfig.Port)
+		go func() {
+			if err = server.ListenAndServeTLS(cfg.WebHookConfig.CertFile, cfg.WebHookConfig.KeyFile); err != nil {
+				if err == http.ErrServerClosed {
+					log.Info("Webhook server stopped")
+				} else {
+					log.Fatalf("failed to listen and serve webhook server. err: %v", err)
 				}
-			}()
-			log.Info("Webhook server started")
-			watchConfigChange(enableWebhookClientCertVerification)
-			<-stopCh
-			return nil
-		}
+			}
+		}()
+		log.Info("Webhook server started")
+		watchConfigChange(enableWebhookClientCertVerification)
+		<-stopCh
+		return nil
 	}
 	return logger.LogNewError(log, "can't start webhook. no features are enabled which requires webhook")
 }
@@ -236,6 +236,17 @@ func (m *MockCOCommonInterface) UpdatePersistentVolumeLabel(ctx context.Context,
 	return args.Error(0)
 }
 
+func (m *MockCOCommonInterface) UpdateNodeAnnotation(ctx context.Context, nodeName, key, value string) error {
+	args := m.Called(ctx, nodeName, key, value)
+	return args.Error(0)
+}
+
+func (m *MockCOCommonInterface) RecordNodeEvent(ctx context.Context, nodeName, eventType, reason,
+	message string) error {
+	args := m.Called(ctx, nodeName, eventType, reason, message)
+	return args.Error(0)
+}
+
 func (m *MockCOCommonInterface) GetActiveClustersForNamespaceInRequestedZones(ctx context.Context,
 	namespace string, zones []string) ([]string, error) {
 	args := m.Called(ctx, namespace, zones)
@@ -19,13 +19,23 @@ package admissionhandler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	stroagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	cnsoperatorv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+	csinodetopologyv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/csinodetopology/v1alpha1"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 )
 
 var (
@@ -40,22 +50,83 @@ var (
 		common.ObjectspacereservationMigrationParam: struct{}{},
 		common.IopslimitMigrationParam:              struct{}{},
 	}
+	// scValidationVCenters are the vCenter servers used to validate the
+	// storage policy name of StorageClasses.
+	scValidationVCenters []*cnsvsphere.VirtualCenter
+	// scValidationAuthMgrs are the AuthorizationServices, per vCenter host,
+	// used to validate the datastore URL of StorageClasses.
+	scValidationAuthMgrs map[string]common.AuthorizationService
+	// onceForStorageClassValidation is used to connect to the vCenter servers
+	// only once, as the webhook server is restarted on config changes.
+	onceForStorageClassValidation sync.Once
+	// listCSINodeTopologies returns the CSINodeTopology instances of the
+	// cluster.
+	listCSINodeTopologies = getCSINodeTopologies
 )
 
 const (
 	migrationParamErrorMessage = "Invalid StorageClass Parameters. " +
 		"Migration specific parameters should not be used in the StorageClass"
+	invalidSCParamsErrorMessage   = "Invalid StorageClass Parameters"
+	invalidSCTopologyErrorMessage = "Invalid StorageClass allowedTopologies"
 )
 
-// validateStorageClass helps validate AdmissionReview requests for StroageClass.
-func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
-	if !featureGateCsiMigrationEnabled {
-		// If CSI migration is disabled and webhook is running,
-		// skip validation for StorageClass.
-		return &admissionv1.AdmissionResponse{
-			Allowed: true,
+// initStorageClassValidation connects to the vCenter servers of the CSI
+// config, and starts computing the datastores accessible for block volumes,
+// to validate the storage policy and datastore of StorageClasses. If the CSI
+// config cannot be read, StorageClass parameters are still validated but not
+// looked up in vCenter.
+func initStorageClassValidation(ctx context.Context) {
+	onceForStorageClassValidation.Do(func() {
+		log := logger.GetLogger(ctx)
+		cfg, err := cnsconfig.GetConfig(ctx)
+		if err != nil {
+			log.Warnf("failed to read CSI config. Storage policy and datastore of StorageClasses "+
+				"will not be validated. Error: %v", err)
+			return
+		}
+		vcenterConfigs, err := cnsvsphere.GetVirtualCenterConfigs(ctx, cfg)
+		if err != nil {
+			log.Warnf("failed to get VirtualCenterConfigs. Storage policy and datastore of StorageClasses "+
+				"will not be validated. Error: %v", err)
+			return
+		}
+		var vCenters []*cnsvsphere.VirtualCenter
+		for _, vcenterConfig := range vcenterConfigs {
+			vcenter, err := cnsvsphere.GetVirtualCenterInstanceForVCenterConfig(ctx, vcenterConfig, false)
+			if err != nil {
+				log.Warnf("failed to get vCenterInstance for vCenter %q. StorageClasses will not be "+
+					"validated against it. Error: %v", vcenterConfig.Host, err)
+				continue
+			}
+			vCenters = append(vCenters, vcenter)
+		}
+		authMgrs, err := common.GetAuthorizationServices(ctx, vCenters)
+		if err != nil {
+			log.Warnf("failed to initialize AuthorizationService. Datastore of StorageClasses "+
+				"will not be validated. Error: %v", err)
 		}
+		scValidationAuthMgrs = make(map[string]common.AuthorizationService)
+		for vcHost, authMgr := range authMgrs {
+			scValidationAuthMgrs[vcHost] = authMgr
+			go common.ComputeDatastoreMapForBlockVolumes(authMgr, cfg.Global.CSIAuthCheckIntervalInMin)
+		}
+		scValidationVCenters = vCenters
+		log.Infof("StorageClasses will be validated against vCenter servers %v", vcenterHosts(vCenters))
+	})
+}
+
+// vcenterHosts returns the hosts of the given vCenter servers.
+func vcenterHosts(vCenters []*cnsvsphere.VirtualCenter) []string {
+	var hosts []string
+	for _, vcenter := range vCenters {
+		hosts = append(hosts, vcenter.Config.Host)
 	}
+	return hosts
+}
+
+// validateStorageClass helps validate AdmissionReview requests for StroageClass.
+func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
 	log := logger.GetLogger(ctx)
 	req := ar.Request
 	var result *metav1.Status
@@ -74,7 +145,7 @@ func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview)
 			}
 		}
 		log.Infof("Validating StorageClass: %q", sc.Name)
-		if sc.Provisioner == "csi.vsphere.vmware.com" {
+		if sc.Provisioner == csitypes.Name && featureGateCsiMigrationEnabled {
 			// Migration parameters check for csi.vsphere.vmware.com provisioner.
 			for param := range sc.Parameters {
 				if unSupportedParameters.Has(param) {
@@ -86,6 +157,21 @@ func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview)
 				}
 			}
 		}
+		if sc.Provisioner == csitypes.Name && allowed {
+			if err := validateStorageClassParams(ctx, &sc); err != nil {
+				allowed = false
+				result = &metav1.Status{
+					Reason:  invalidSCParamsErrorMessage,
+					Message: err.Error(),
+				}
+			} else if err := validateAllowedTopologies(ctx, &sc); err != nil {
+				allowed = false
+				result = &metav1.Status{
+					Reason:  invalidSCTopologyErrorMessage,
+					Message: err.Error(),
+				}
+			}
+		}
 		if allowed {
 			log.Infof("Validation of StorageClass: %q Passed", sc.Name)
 		} else {
@@ -101,3 +187,148 @@ func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview)
 		Result:  result,
 	}
 }
+
+// validateStorageClassParams validates the parameters of the StorageClass as
+// CreateVolume does, and checks that its storage policy and datastore exist
+// in vCenter.
+func validateStorageClassParams(ctx context.Context, sc *stroagev1.StorageClass) error {
+	log := logger.GetLogger(ctx)
+	// The external provisioner strips the parameters with the CSI prefix
+	// before calling CreateVolume.
+	params := make(map[string]string)
+	for param, value := range sc.Parameters {
+		if !strings.HasPrefix(param, common.CSIParameterPrefix) {
+			params[param] = value
+		}
+	}
+	scParams, err := common.ParseStorageClassParams(ctx, params, featureGateCsiMigrationEnabled)
+	if err != nil {
+		return fmt.Errorf("%s: %v", invalidSCParamsErrorMessage, err)
+	}
+	if len(scValidationVCenters) == 0 {
+		log.Debugf("Not connected to vCenter. Skipping storage policy and datastore validation of "+
+			"StorageClass %q", sc.Name)
+		return nil
+	}
+	if scParams.StoragePolicyName != "" {
+		var lastErr error
+		found := false
+		for _, vcenter := range scValidationVCenters {
+			if _, lastErr = vcenter.GetStoragePolicyIDByName(ctx, scParams.StoragePolicyName); lastErr == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: storage policy %q not found in vCenter. Error: %v",
+				invalidSCParamsErrorMessage, scParams.StoragePolicyName, lastErr)
+		}
+	}
+	if scParams.DatastoreURL != "" {
+		accessible, known := false, false
+		for _, authMgr := range scValidationAuthMgrs {
+			dsMap := authMgr.GetDatastoreMapForBlockVolumes(ctx)
+			if len(dsMap) != 0 {
+				known = true
+			}
+			if _, exists := dsMap[scParams.DatastoreURL]; exists {
+				accessible = true
+				break
+			}
+		}
+		if !known {
+			// The accessible datastores are not computed yet, or the privilege
+			// check failed in every vCenter.
+			log.Warnf("No accessible datastores known. Skipping datastore validation of StorageClass %q",
+				sc.Name)
+		} else if !accessible {
+			return fmt.Errorf("%s: datastore %q not found in vCenter, or not accessible to the "+
+				"CSI user for block volumes", invalidSCParamsErrorMessage, scParams.DatastoreURL)
+		}
+	}
+	return nil
+}
+
+// validateAllowedTopologies checks that the labels and values of the
+// allowedTopologies of the StorageClass are reported by CSINodeTopology
+// instances.
+func validateAllowedTopologies(ctx context.Context, sc *stroagev1.StorageClass) error {
+	log := logger.GetLogger(ctx)
+	if len(sc.AllowedTopologies) == 0 {
+		return nil
+	}
+	nodeTopologies, err := listCSINodeTopologies(ctx)
+	if err != nil {
+		log.Warnf("failed to get CSINodeTopology instances. Skipping allowedTopologies "+
+			"validation of StorageClass %q. Error: %v", sc.Name, err)
+		return nil
+	}
+	if len(nodeTopologies) == 0 {
+		log.Infof("No CSINodeTopology instances found. Skipping allowedTopologies validation of "+
+			"StorageClass %q", sc.Name)
+		return nil
+	}
+	knownLabels := topologyLabelsFromCSINodeTopologies(nodeTopologies)
+	if len(knownLabels) == 0 {
+		return fmt.Errorf("%s: no node in the cluster has topology labels", invalidSCTopologyErrorMessage)
+	}
+	for _, term := range sc.AllowedTopologies {
+		for _, expression := range term.MatchLabelExpressions {
+			knownValues, exists := knownLabels[expression.Key]
+			if !exists {
+				return fmt.Errorf("%s: unknown topology label %q. Known topology labels are %v",
+					invalidSCTopologyErrorMessage, expression.Key, sortedKeys(knownLabels))
+			}
+			for _, value := range expression.Values {
+				if _, exists := knownValues[value]; !exists {
+					return fmt.Errorf("%s: unknown value %q of topology label %q. Known values are %v",
+						invalidSCTopologyErrorMessage, value, expression.Key, sortedKeys(knownValues))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getCSINodeTopologies returns the CSINodeTopology instances of the cluster.
+func getCSINodeTopologies(ctx context.Context) ([]csinodetopologyv1alpha1.CSINodeTopology, error) {
+	restConfig, err := k8s.GetKubeConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig. Error: %v", err)
+	}
+	cnsOperatorClient, err := k8s.NewClientForGroup(ctx, restConfig, cnsoperatorv1alpha1.GroupName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CnsOperator client. Error: %v", err)
+	}
+	nodeTopologyList := &csinodetopologyv1alpha1.CSINodeTopologyList{}
+	if err := cnsOperatorClient.List(ctx, nodeTopologyList); err != nil {
+		return nil, fmt.Errorf("failed to list CSINodeTopology instances. Error: %v", err)
+	}
+	return nodeTopologyList.Items, nil
+}
+
+// topologyLabelsFromCSINodeTopologies returns the values of the topology
+// labels of the given CSINodeTopology instances, keyed by label.
+func topologyLabelsFromCSINodeTopologies(
+	nodeTopologies []csinodetopologyv1alpha1.CSINodeTopology) map[string]map[string]struct{} {
+	knownLabels := make(map[string]map[string]struct{})
+	for _, nodeTopology := range nodeTopologies {
+		for _, label := range nodeTopology.Status.TopologyLabels {
+			if _, exists := knownLabels[label.Key]; !exists {
+				knownLabels[label.Key] = make(map[string]struct{})
+			}
+			knownLabels[label.Key][label.Value] = struct{}{}
+		}
+	}
+	return knownLabels
+}
+
+// sortedKeys returns the keys of the given map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
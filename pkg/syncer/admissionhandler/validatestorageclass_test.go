@@ -18,12 +18,26 @@ package admissionhandler
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
+	pbmsim "github.com/vmware/govmomi/pbm/simulator"
+	"github.com/vmware/govmomi/simulator"
 	v1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	csinodetopologyv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/csinodetopology/v1alpha1"
 )
 
 var admissionReview = v1.AdmissionReview{
@@ -119,3 +133,264 @@ func TestValidateStorageClassForValidStorageClass(t *testing.T) {
 	}
 	t.Log("TestValidateStorageClassForValidStorageClass Passed")
 }
+
+// validateCSIStorageClass returns the AdmissionResponse of validateStorageClass
+// for a csi.vsphere.vmware.com StorageClass with the given parameters and
+// allowedTopologies.
+func validateCSIStorageClass(t *testing.T, ctx context.Context, params map[string]string,
+	allowedTopologies []corev1.TopologySelectorTerm) *v1.AdmissionResponse {
+	sc := storagev1.StorageClass{
+		TypeMeta:          metav1.TypeMeta{Kind: "StorageClass", APIVersion: "storage.k8s.io/v1"},
+		ObjectMeta:        metav1.ObjectMeta{Name: "sc"},
+		Provisioner:       "csi.vsphere.vmware.com",
+		Parameters:        params,
+		AllowedTopologies: allowedTopologies,
+	}
+	raw, err := json.Marshal(sc)
+	if err != nil {
+		t.Fatalf("failed to marshal StorageClass: %v", err)
+	}
+	admissionReview.Request.Object = runtime.RawExtension{Raw: raw}
+	return validateStorageClass(ctx, &admissionReview)
+}
+
+// TestValidateStorageClassForInvalidParameters is the unit test for validating
+// admissionReview request containing csi.vsphere.vmware.com StorageClasses
+// with parameters rejected by CreateVolume.
+func TestValidateStorageClassForInvalidParameters(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	featureGateCsiMigrationEnabled = false
+	scValidationVCenters = nil
+	tests := []struct {
+		name            string
+		params          map[string]string
+		expectedAllowed bool
+		expectedMessage string
+	}{
+		{
+			name: "valid parameters",
+			params: map[string]string{
+				"storagepolicyname":         "vSAN Default Storage Policy",
+				"csi.storage.k8s.io/fstype": "ext4",
+			},
+			expectedAllowed: true,
+		},
+		{
+			name:            "misspelled parameter",
+			params:          map[string]string{"storagepolicyname1": "vSAN Default Storage Policy"},
+			expectedMessage: `invalid param: "storagepolicyname1"`,
+		},
+		{
+			name:            "migration parameter with CSI migration disabled",
+			params:          map[string]string{"hostfailurestotolerate-migrationparam": "2"},
+			expectedMessage: "invalid param",
+		},
+		{
+			name:            "invalid disk provisioning type",
+			params:          map[string]string{"storagepolicyname": "gold", "diskprovisioningtype": "sparse"},
+			expectedMessage: "supported disk provisioning types",
+		},
+		{
+			name:            "both storage policy name and ID",
+			params:          map[string]string{"storagepolicyname": "gold", "storagepolicyid": "id"},
+			expectedMessage: "only one of",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			admissionResponse := validateCSIStorageClass(t, ctx, test.params, nil)
+			if admissionResponse.Allowed != test.expectedAllowed {
+				t.Fatalf("expected allowed %t, got admissionResponse: %v", test.expectedAllowed, admissionResponse)
+			}
+			if !test.expectedAllowed && !strings.Contains(admissionResponse.Result.Message, test.expectedMessage) {
+				t.Fatalf("expected message containing %q, got: %q", test.expectedMessage,
+					admissionResponse.Result.Message)
+			}
+		})
+	}
+}
+
+// TestValidateStorageClassAgainstVCenter is the unit test for validating the
+// storage policy name and datastore URL of StorageClasses against vCenter.
+func TestValidateStorageClassAgainstVCenter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	featureGateCsiMigrationEnabled = false
+
+	model := simulator.VPX()
+	defer model.Remove()
+	if err := model.Create(); err != nil {
+		t.Fatalf("failed to create simulator model: %v", err)
+	}
+	model.Service.TLS = new(tls.Config)
+	model.Service.RegisterSDK(pbmsim.New())
+	s := model.Service.NewServer()
+	defer s.Close()
+	password, _ := s.URL.User.Password()
+	confPath := filepath.Join(t.TempDir(), "csi-vsphere.conf")
+	conf := fmt.Sprintf("[Global]\ncluster-id = \"test-cluster\"\ninsecure-flag = \"true\"\n"+
+		"[VirtualCenter \"%s\"]\nuser = \"%s\"\npassword = \"%s\"\ndatacenters = \"DC0\"\nport = \"%s\"\n",
+		s.URL.Hostname(), s.URL.User.Username()+"@vsphere.local", password, s.URL.Port())
+	if err := os.WriteFile(confPath, []byte(conf), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("VSPHERE_CSI_CONFIG", confPath)
+
+	onceForStorageClassValidation = sync.Once{}
+	initStorageClassValidation(ctx)
+	defer func() {
+		scValidationVCenters = nil
+		scValidationAuthMgrs = nil
+	}()
+	if len(scValidationVCenters) != 1 {
+		t.Fatalf("expected 1 vCenter to validate StorageClasses, got: %v", scValidationVCenters)
+	}
+	vcHost := scValidationVCenters[0].Config.Host
+	authMgr, _ := common.GetAuthorizationServiceForTesting(ctx, scValidationVCenters[0],
+		map[string]*cnsvsphere.DatastoreInfo{"ds:///vmfs/volumes/accessible/": {}}, nil)
+	scValidationAuthMgrs = map[string]common.AuthorizationService{vcHost: authMgr}
+
+	tests := []struct {
+		name            string
+		params          map[string]string
+		expectedAllowed bool
+		expectedMessage string
+	}{
+		{
+			name:            "existing storage policy",
+			params:          map[string]string{"storagepolicyname": "vSAN Default Storage Policy"},
+			expectedAllowed: true,
+		},
+		{
+			name:            "unknown storage policy",
+			params:          map[string]string{"storagepolicyname": "vSAN Default Storage Policyy"},
+			expectedMessage: `storage policy "vSAN Default Storage Policyy" not found`,
+		},
+		{
+			name:            "accessible datastore",
+			params:          map[string]string{"datastoreurl": "ds:///vmfs/volumes/accessible/"},
+			expectedAllowed: true,
+		},
+		{
+			name:            "unknown datastore",
+			params:          map[string]string{"datastoreurl": "ds:///vmfs/volumes/unknown/"},
+			expectedMessage: `datastore "ds:///vmfs/volumes/unknown/" not found`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			admissionResponse := validateCSIStorageClass(t, ctx, test.params, nil)
+			if admissionResponse.Allowed != test.expectedAllowed {
+				t.Fatalf("expected allowed %t, got admissionResponse: %v", test.expectedAllowed, admissionResponse)
+			}
+			if !test.expectedAllowed && !strings.Contains(admissionResponse.Result.Message, test.expectedMessage) {
+				t.Fatalf("expected message containing %q, got: %q", test.expectedMessage,
+					admissionResponse.Result.Message)
+			}
+		})
+	}
+
+	// The datastore is not validated until the accessible datastores are known.
+	authMgr, _ = common.GetAuthorizationServiceForTesting(ctx, scValidationVCenters[0],
+		map[string]*cnsvsphere.DatastoreInfo{}, nil)
+	scValidationAuthMgrs = map[string]common.AuthorizationService{vcHost: authMgr}
+	admissionResponse := validateCSIStorageClass(t, ctx,
+		map[string]string{"datastoreurl": "ds:///vmfs/volumes/unknown/"}, nil)
+	if !admissionResponse.Allowed {
+		t.Fatalf("expected StorageClass to be allowed, got admissionResponse: %v", admissionResponse)
+	}
+}
+
+// TestValidateStorageClassForAllowedTopologies is the unit test for validating
+// the allowedTopologies of StorageClasses against CSINodeTopology instances.
+func TestValidateStorageClassForAllowedTopologies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	featureGateCsiMigrationEnabled = false
+	scValidationVCenters = nil
+	defer func() {
+		listCSINodeTopologies = getCSINodeTopologies
+	}()
+	nodeTopology := func(labels ...string) csinodetopologyv1alpha1.CSINodeTopology {
+		var topologyLabels []csinodetopologyv1alpha1.TopologyLabel
+		for i := 0; i < len(labels); i += 2 {
+			topologyLabels = append(topologyLabels,
+				csinodetopologyv1alpha1.TopologyLabel{Key: labels[i], Value: labels[i+1]})
+		}
+		return csinodetopologyv1alpha1.CSINodeTopology{
+			Status: csinodetopologyv1alpha1.CSINodeTopologyStatus{TopologyLabels: topologyLabels},
+		}
+	}
+	allowedTopologies := func(key string, values ...string) []corev1.TopologySelectorTerm {
+		return []corev1.TopologySelectorTerm{{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{{Key: key, Values: values}},
+		}}
+	}
+	tests := []struct {
+		name              string
+		nodeTopologies    []csinodetopologyv1alpha1.CSINodeTopology
+		listErr           error
+		allowedTopologies []corev1.TopologySelectorTerm
+		expectedAllowed   bool
+		expectedMessage   string
+	}{
+		{
+			name: "known labels",
+			nodeTopologies: []csinodetopologyv1alpha1.CSINodeTopology{
+				nodeTopology("topology.csi.vmware.com/k8s-zone", "zone-a"),
+				nodeTopology("topology.csi.vmware.com/k8s-zone", "zone-b"),
+			},
+			allowedTopologies: allowedTopologies("topology.csi.vmware.com/k8s-zone", "zone-a", "zone-b"),
+			expectedAllowed:   true,
+		},
+		{
+			name: "unknown label",
+			nodeTopologies: []csinodetopologyv1alpha1.CSINodeTopology{
+				nodeTopology("topology.csi.vmware.com/k8s-zone", "zone-a"),
+			},
+			allowedTopologies: allowedTopologies("topology.csi.vmware.com/k8s-zonee", "zone-a"),
+			expectedMessage:   `unknown topology label "topology.csi.vmware.com/k8s-zonee"`,
+		},
+		{
+			name: "unknown value",
+			nodeTopologies: []csinodetopologyv1alpha1.CSINodeTopology{
+				nodeTopology("topology.csi.vmware.com/k8s-zone", "zone-a"),
+			},
+			allowedTopologies: allowedTopologies("topology.csi.vmware.com/k8s-zone", "zone-c"),
+			expectedMessage:   `unknown value "zone-c"`,
+		},
+		{
+			name:              "nodes without topology labels",
+			nodeTopologies:    []csinodetopologyv1alpha1.CSINodeTopology{nodeTopology()},
+			allowedTopologies: allowedTopologies("topology.csi.vmware.com/k8s-zone", "zone-a"),
+			expectedMessage:   "no node in the cluster has topology labels",
+		},
+		{
+			name:              "no CSINodeTopology instances",
+			allowedTopologies: allowedTopologies("topology.csi.vmware.com/k8s-zone", "zone-a"),
+			expectedAllowed:   true,
+		},
+		{
+			name:              "failure to list CSINodeTopology instances",
+			listErr:           fmt.Errorf("connection refused"),
+			allowedTopologies: allowedTopologies("topology.csi.vmware.com/k8s-zone", "zone-a"),
+			expectedAllowed:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listCSINodeTopologies = func(ctx context.Context) ([]csinodetopologyv1alpha1.CSINodeTopology, error) {
+				return test.nodeTopologies, test.listErr
+			}
+			admissionResponse := validateCSIStorageClass(t, ctx, nil, test.allowedTopologies)
+			if admissionResponse.Allowed != test.expectedAllowed {
+				t.Fatalf("expected allowed %t, got admissionResponse: %v", test.expectedAllowed, admissionResponse)
+			}
+			if !test.expectedAllowed && !strings.Contains(admissionResponse.Result.Message, test.expectedMessage) {
+				t.Fatalf("expected message containing %q, got: %q", test.expectedMessage,
+					admissionResponse.Result.Message)
+			}
+		})
+	}
+}
@@ -51,6 +51,7 @@ import (
 	apis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
 	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
 	storagepolicyusagev1alpha2 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/storagepolicy/v1alpha2"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
 	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
@@ -67,6 +68,9 @@ const (
 	workerThreadsEnvVar     = "WORKER_THREADS_REGISTER_VOLUME"
 	defaultMaxWorkerThreads = 40
 	staticPvNamePrefix      = "static-pv-"
+	// pvcBindRequeueInterval is the interval at which a CnsRegisterVolume
+	// instance on a vanilla cluster is reconciled until its PVC is bound.
+	pvcBindRequeueInterval = 5 * time.Second
 )
 
 var (
@@ -94,49 +98,66 @@ var (
 func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
 	ctx, log := logger.GetNewContextWithLogger()
-	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload {
-		log.Debug("Not initializing the CnsRegisterVolume Controller as its a non-WCP CSI deployment")
+	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload && clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+		log.Debug("Not initializing the CnsRegisterVolume Controller as its a non-WCP and non-vanilla CSI deployment")
 		return nil
 	}
-	workloadDomainIsolationEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
-		common.WorkloadDomainIsolation)
-	isSharedDiskEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
-		common.SharedDiskFss)
-	isTKGSHAEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.TKGsHA)
-	isMultipleClustersPerVsphereZoneEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
-		common.MultipleClustersPerVsphereZone)
-
-	var volumeInfoService cnsvolumeinfo.VolumeInfoService
-	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.TKGsHA) {
-		var err error
-		clusterComputeResourceMoIds, _, err = common.GetClusterComputeResourceMoIds(ctx)
+	var (
+		volumeInfoService cnsvolumeinfo.VolumeInfoService
+		nodeMgr           *node.Nodes
+		err               error
+	)
+	if clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		// The node manager and the topology service are required to compute
+		// the node affinity of the imported volumes.
+		nodeMgr = &node.Nodes{}
+		err = nodeMgr.Initialize(ctx)
 		if err != nil {
-			log.Errorf("failed to get clusterComputeResourceMoIds. err: %v", err)
-			return err
+			return logger.LogNewErrorf(log, "failed to initialize node manager. Error: %+v", err)
 		}
-		if syncer.IsPodVMOnStretchSupervisorFSSEnabled {
-			topologyMgr, err = commonco.ContainerOrchestratorUtility.InitTopologyServiceInController(ctx)
+		_, err = commonco.ContainerOrchestratorUtility.InitTopologyServiceInController(ctx)
+		if err != nil {
+			return logger.LogNewErrorf(log, "failed to initialize topology service. Error: %+v", err)
+		}
+	} else {
+		workloadDomainIsolationEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
+			common.WorkloadDomainIsolation)
+		isSharedDiskEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
+			common.SharedDiskFss)
+		isTKGSHAEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.TKGsHA)
+		isMultipleClustersPerVsphereZoneEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
+			common.MultipleClustersPerVsphereZone)
+
+		if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.TKGsHA) {
+			clusterComputeResourceMoIds, _, err = common.GetClusterComputeResourceMoIds(ctx)
 			if err != nil {
-				log.Errorf("failed to init topology manager. err: %v", err)
+				log.Errorf("failed to get clusterComputeResourceMoIds. err: %v", err)
 				return err
 			}
-			log.Info("Creating CnsVolumeInfo Service to persist mapping for VolumeID to storage policy info")
-			volumeInfoService, err = cnsvolumeinfo.InitVolumeInfoService(ctx)
-			if err != nil {
-				return logger.LogNewErrorf(log, "error initializing volumeInfoService. Error: %+v", err)
-			}
-			log.Infof("Successfully initialized VolumeInfoService")
-		} else {
-			if len(clusterComputeResourceMoIds) > 1 {
-				log.Infof("Not initializing the CnsRegisterVolume Controller as stretched supervisor is detected.")
-				return nil
+			if syncer.IsPodVMOnStretchSupervisorFSSEnabled {
+				topologyMgr, err = commonco.ContainerOrchestratorUtility.InitTopologyServiceInController(ctx)
+				if err != nil {
+					log.Errorf("failed to init topology manager. err: %v", err)
+					return err
+				}
+				log.Info("Creating CnsVolumeInfo Service to persist mapping for VolumeID to storage policy info")
+				volumeInfoService, err = cnsvolumeinfo.InitVolumeInfoService(ctx)
+				if err != nil {
+					return logger.LogNewErrorf(log, "error initializing volumeInfoService. Error: %+v", err)
+				}
+				log.Infof("Successfully initialized VolumeInfoService")
+			} else {
+				if len(clusterComputeResourceMoIds) > 1 {
+					log.Infof("Not initializing the CnsRegisterVolume Controller as stretched supervisor is detected.")
+					return nil
+				}
 			}
 		}
-	}
-	if isMultipleClustersPerVsphereZoneEnabled {
-		err := commonco.ContainerOrchestratorUtility.StartZonesInformer(ctx, nil, metav1.NamespaceAll)
-		if err != nil {
-			return logger.LogNewErrorf(log, "failed to start zone informer. Error: %v", err)
+		if isMultipleClustersPerVsphereZoneEnabled {
+			err = commonco.ContainerOrchestratorUtility.StartZonesInformer(ctx, nil, metav1.NamespaceAll)
+			if err != nil {
+				return logger.LogNewErrorf(log, "failed to start zone informer. Error: %v", err)
+			}
 		}
 	}
 	// Initializes kubernetes client.
@@ -155,15 +176,17 @@ func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 		},
 	)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: apis.GroupName})
-	return add(mgr, newReconciler(mgr, configInfo, volumeManager, recorder, volumeInfoService))
+	return add(mgr, newReconciler(mgr, clusterFlavor, configInfo, volumeManager, recorder, volumeInfoService,
+		nodeMgr))
 }
 
 // newReconciler returns a new reconcile.Reconciler.
-func newReconciler(mgr manager.Manager, configInfo *commonconfig.ConfigurationInfo,
-	volumeManager volumes.Manager, recorder record.EventRecorder,
-	volumeInfoService cnsvolumeinfo.VolumeInfoService) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
+	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager, recorder record.EventRecorder,
+	volumeInfoService cnsvolumeinfo.VolumeInfoService, nodeMgr *node.Nodes) reconcile.Reconciler {
 	return &ReconcileCnsRegisterVolume{client: mgr.GetClient(), scheme: mgr.GetScheme(),
-		configInfo: configInfo, volumeManager: volumeManager, recorder: recorder, volumeInfoService: volumeInfoService}
+		configInfo: configInfo, volumeManager: volumeManager, recorder: recorder, volumeInfoService: volumeInfoService,
+		clusterFlavor: clusterFlavor, nodeMgr: nodeMgr}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
@@ -209,6 +232,9 @@ type ReconcileCnsRegisterVolume struct {
 	volumeManager     volumes.Manager
 	recorder          record.EventRecorder
 	volumeInfoService cnsvolumeinfo.VolumeInfoService
+	clusterFlavor     cnstypes.CnsClusterFlavor
+	// nodeMgr is only set on vanilla clusters.
+	nodeMgr *node.Nodes
 }
 
 // Reconcile reads that state of the cluster for a CnsRegisterVolume object
@@ -261,6 +287,9 @@ func (r *ReconcileCnsRegisterVolume) Reconcile(ctx context.Context,
 		setInstanceError(ctx, r, instance, err.Error())
 		return reconcile.Result{RequeueAfter: timeout}, nil
 	}
+	if instance.Spec.DiskFolderURLPath != "" {
+		return r.reconcileDiskFolder(ctx, instance, timeout)
+	}
 	// Verify if CnsRegisterVolume request is for block volume registration
 	// Currently file volume registration is not supported.
 	ok := isBlockVolumeRegisterRequest(ctx, instance)
@@ -280,6 +309,9 @@ func (r *ReconcileCnsRegisterVolume) Reconcile(ctx context.Context,
 		setInstanceError(ctx, r, instance, "Unable to connect to VC for volume registration")
 		return reconcile.Result{RequeueAfter: timeout}, nil
 	}
+	if r.clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		return r.reconcileVanilla(ctx, instance, request, vc, timeout)
+	}
 	var (
 		volumeID       string
 		pvName         string
@@ -1031,6 +1063,9 @@ func validateCnsRegisterVolumeSpec(ctx context.Context, instance *cnsregistervol
 	var msg string
 	if instance.Spec.VolumeID != "" && instance.Spec.DiskURLPath != "" {
 		msg = "VolumeID and DiskURLPath cannot be specified together"
+	} else if instance.Spec.DiskFolderURLPath != "" &&
+		(instance.Spec.VolumeID != "" || instance.Spec.DiskURLPath != "") {
+		msg = "DiskFolderURLPath cannot be specified together with VolumeID or DiskURLPath"
 	} else if instance.Spec.DiskURLPath != "" && instance.Spec.AccessMode != "" &&
 		instance.Spec.AccessMode != v1.ReadWriteOnce {
 		if isSharedDiskEnabled {
@@ -224,6 +224,16 @@ func (m *mockVolumeManager) SyncVolume(ctx context.Context,
 	return "", nil
 }
 
+func (m *mockVolumeManager) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string,
+	volumeIDs []string) ([]*cnsvolume.CnsSnapshotInfo, error) {
+	return nil, nil
+}
+
+func (m *mockVolumeManager) ReconfigVolumePolicy(ctx context.Context, volumeID string,
+	storagePolicyID string) (string, error) {
+	return "", nil
+}
+
 type mockCOCommon struct{}
 
 func (m *mockCOCommon) GetPVCNamespacedNameByUID(uid string) (types.NamespacedName, bool) {
@@ -394,6 +404,17 @@ func (m *mockCOCommon) UpdatePersistentVolumeLabel(ctx context.Context, pvName s
 	panic("implement me")
 }
 
+func (m *mockCOCommon) UpdateNodeAnnotation(ctx context.Context, nodeName string, key string, value string) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *mockCOCommon) RecordNodeEvent(ctx context.Context, nodeName string, eventType string, reason string,
+	message string) error {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (m *mockCOCommon) GetZonesForNamespace(ns string) map[string]struct{} {
 	return map[string]struct{}{"zone-a": {}}
 }
@@ -1328,6 +1349,24 @@ func TestValidateCnsRegisterVolumeSpecWithVolumeIdAndAccessMode(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestValidateCnsRegisterVolumeSpecWithDiskFolderURLPathAndDiskUrlPath(t *testing.T) {
+	instance := &cnsregistervolumev1alpha1.CnsRegisterVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "register-vol",
+			Namespace: "test-ns",
+		},
+		Spec: cnsregistervolumev1alpha1.CnsRegisterVolumeSpec{
+			PvcName:           "pvc-1",
+			DiskURLPath:       "some-url",
+			DiskFolderURLPath: "some-folder-url",
+		},
+	}
+
+	err := validateCnsRegisterVolumeSpec(context.TODO(), instance)
+	assert.Error(t, err)
+	assert.Equal(t, "DiskFolderURLPath cannot be specified together with VolumeID or DiskURLPath", err.Error())
+}
+
 func TestIsBlockVolumeRegisterRequestWithSharedBlockVolume(t *testing.T) {
 	instance := &cnsregistervolumev1alpha1.CnsRegisterVolume{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,274 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsregistervolume
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	vim25types "github.com/vmware/govmomi/vim25/types"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// diskURLPathPrefix is the path prefix of a DiskURLPath or a
+// DiskFolderURLPath, e.g.
+// https://<vc_ip>/folder/<folder_path>?dcPath=<datacenterName>&dsName=<datastoreName>
+const diskURLPathPrefix = "/folder/"
+
+var (
+	// listDisksInFolder returns the DiskURLPaths of the virtual disks in the
+	// folder of a DiskFolderURLPath. It is a variable so that tests can
+	// replace it.
+	listDisksInFolder = _listDisksInFolder
+
+	// snapshotDeltaDiskPattern matches the delta disks of VM snapshots, e.g.
+	// vm_1-000001.vmdk, which cannot be registered as FCDs.
+	snapshotDeltaDiskPattern = regexp.MustCompile(`-\d{6}\.vmdk$`)
+
+	// invalidNameCharsPattern matches the characters which are not allowed
+	// in the names of Kubernetes objects.
+	invalidNameCharsPattern = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// reconcileDiskFolder imports all the virtual disks in the folder of the
+// DiskFolderURLPath of a CnsRegisterVolume instance. A child CnsRegisterVolume
+// instance is created for each disk, and the instance is registered once all
+// of its children are registered.
+func (r *ReconcileCnsRegisterVolume) reconcileDiskFolder(ctx context.Context,
+	instance *cnsregistervolumev1alpha1.CnsRegisterVolume, timeout time.Duration) (reconcile.Result, error) {
+	log := logger.GetLogger(ctx)
+	vc, err := cnsvsphere.GetVirtualCenterInstance(ctx, r.configInfo, false)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get virtual center instance with error: %+v", err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	diskURLPaths, err := listDisksInFolder(ctx, vc, instance.Spec.DiskFolderURLPath)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to list the disks in folder: %s with error: %+v",
+			instance.Spec.DiskFolderURLPath, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	if len(diskURLPaths) == 0 {
+		msg := fmt.Sprintf("No disks found in folder: %s", instance.Spec.DiskFolderURLPath)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	var failed []string
+	pending := 0
+	for _, diskURLPath := range diskURLPaths {
+		child, err := r.getOrCreateDiskFolderChild(ctx, instance, diskURLPath)
+		if err != nil {
+			log.Error(err)
+			failed = append(failed, err.Error())
+			continue
+		}
+		if child.Status.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", child.Name, child.Status.Error))
+		} else if !child.Status.Registered {
+			pending++
+		}
+	}
+	if len(failed) > 0 {
+		msg := fmt.Sprintf("Failed to import %d of %d disks in folder: %s. Errors: %s", len(failed),
+			len(diskURLPaths), instance.Spec.DiskFolderURLPath, strings.Join(failed, "; "))
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	if pending > 0 {
+		log.Infof("Waiting for %d of %d disks in folder: %s to be imported", pending,
+			len(diskURLPaths), instance.Spec.DiskFolderURLPath)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	origInstance := instance.DeepCopy()
+	instance.Status.Registered = true
+	instance.Status.Error = ""
+	err = patchCnsRegisterVolumeStatus(ctx, r.client, origInstance, instance)
+	if err != nil {
+		log.Errorf("patchCnsRegisterVolumeStatus failed. err: %v", err)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	msg := fmt.Sprintf("Successfully imported %d disks in folder: %s on namespace: %s", len(diskURLPaths),
+		instance.Spec.DiskFolderURLPath, instance.Namespace)
+	recordEvent(ctx, r, instance, v1.EventTypeNormal, msg)
+	backOffDurationMapMutex.Lock()
+	delete(backOffDuration, apitypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+	backOffDurationMapMutex.Unlock()
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
+
+// getOrCreateDiskFolderChild returns the child CnsRegisterVolume instance
+// importing the disk with the given DiskURLPath, and creates it if it does
+// not exist.
+func (r *ReconcileCnsRegisterVolume) getOrCreateDiskFolderChild(ctx context.Context,
+	instance *cnsregistervolumev1alpha1.CnsRegisterVolume,
+	diskURLPath string) (*cnsregistervolumev1alpha1.CnsRegisterVolume, error) {
+	log := logger.GetLogger(ctx)
+	suffix := getDiskNameSuffix(diskURLPath)
+	child := &cnsregistervolumev1alpha1.CnsRegisterVolume{}
+	key := apitypes.NamespacedName{Name: instance.Name + "-" + suffix, Namespace: instance.Namespace}
+	err := r.client.Get(ctx, key, child)
+	if err == nil {
+		if child.Spec.DiskURLPath != diskURLPath {
+			return nil, fmt.Errorf("CnsRegisterVolume: %s already exists for another disk: %s",
+				key.Name, child.Spec.DiskURLPath)
+		}
+		return child, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get CnsRegisterVolume: %s with error: %+v", key.Name, err)
+	}
+	child = &cnsregistervolumev1alpha1.CnsRegisterVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		Spec: cnsregistervolumev1alpha1.CnsRegisterVolumeSpec{
+			PvcName:          instance.Spec.PvcName + "-" + suffix,
+			DiskURLPath:      diskURLPath,
+			VolumeMode:       instance.Spec.VolumeMode,
+			StorageClassName: instance.Spec.StorageClassName,
+			BackingType:      instance.Spec.BackingType,
+		},
+	}
+	err = controllerutil.SetControllerReference(instance, child, r.scheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set the owner of CnsRegisterVolume: %s with error: %+v", key.Name, err)
+	}
+	err = r.client.Create(ctx, child)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CnsRegisterVolume: %s with error: %+v", key.Name, err)
+	}
+	log.Infof("Created CnsRegisterVolume: %s to import disk: %s", key.Name, diskURLPath)
+	return child, nil
+}
+
+// getDiskNameSuffix returns the name of the disk of a DiskURLPath, without
+// the .vmdk extension, as a suffix of the names of Kubernetes objects.
+func getDiskNameSuffix(diskURLPath string) string {
+	name := diskURLPath
+	if u, err := url.Parse(diskURLPath); err == nil {
+		name = u.Path
+	}
+	name = strings.TrimSuffix(path.Base(name), ".vmdk")
+	return strings.Trim(invalidNameCharsPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// parseDiskFolderURLPath returns the parsed URL, the datacenter path, the
+// datastore name and the folder path of a DiskFolderURLPath.
+func parseDiskFolderURLPath(diskFolderURLPath string) (*url.URL, string, string, string, error) {
+	u, err := url.Parse(diskFolderURLPath)
+	if err != nil {
+		return nil, "", "", "", fmt.Errorf("invalid diskFolderURLPath %s: %v", diskFolderURLPath, err)
+	}
+	query := u.Query()
+	dcPath, dsName := query.Get("dcPath"), query.Get("dsName")
+	if !strings.HasPrefix(u.Path, diskURLPathPrefix) || dcPath == "" || dsName == "" {
+		return nil, "", "", "", fmt.Errorf("invalid diskFolderURLPath %s, expected format "+
+			"https://<vc_ip>/folder/<folder_path>?dcPath=<datacenterName>&dsName=<datastoreName>",
+			diskFolderURLPath)
+	}
+	return u, dcPath, dsName, strings.Trim(strings.TrimPrefix(u.Path, diskURLPathPrefix), "/"), nil
+}
+
+// _listDisksInFolder browses the datastore folder of a DiskFolderURLPath and
+// returns the DiskURLPaths of its virtual disks. Delta disks of VM snapshots
+// are skipped.
+func _listDisksInFolder(ctx context.Context, vc *cnsvsphere.VirtualCenter,
+	diskFolderURLPath string) ([]string, error) {
+	log := logger.GetLogger(ctx)
+	u, dcPath, dsName, folder, err := parseDiskFolderURLPath(diskFolderURLPath)
+	if err != nil {
+		return nil, err
+	}
+	datacenters, err := vc.GetDatacenters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get datacenters: %v", err)
+	}
+	var datacenter *object.Datacenter
+	for _, dc := range datacenters {
+		if dc.Name() == dcPath || strings.TrimPrefix(dc.InventoryPath, "/") == dcPath {
+			datacenter = dc.Datacenter
+			break
+		}
+	}
+	if datacenter == nil {
+		return nil, fmt.Errorf("datacenter %s of diskFolderURLPath %s not found", dcPath, diskFolderURLPath)
+	}
+	finder := find.NewFinder(vc.Client.Client, false)
+	finder.SetDatacenter(datacenter)
+	ds, err := finder.Datastore(ctx, dsName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find datastore %s: %v", dsName, err)
+	}
+	browser, err := ds.Browser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the browser of datastore %s: %v", dsName, err)
+	}
+	spec := vim25types.HostDatastoreBrowserSearchSpec{
+		MatchPattern: []string{"*.vmdk"},
+		Query:        []vim25types.BaseFileQuery{&vim25types.VmDiskFileQuery{}},
+	}
+	task, err := browser.SearchDatastore(ctx, ds.Path(folder), &spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search folder %s of datastore %s: %v", folder, dsName, err)
+	}
+	info, err := task.WaitForResultEx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search folder %s of datastore %s: %v", folder, dsName, err)
+	}
+	results, ok := info.Result.(vim25types.HostDatastoreBrowserSearchResults)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result %T of the search of folder %s", info.Result, folder)
+	}
+	var diskURLPaths []string
+	for _, file := range results.File {
+		name := file.GetFileInfo().Path
+		if snapshotDeltaDiskPattern.MatchString(name) {
+			log.Debugf("Skipping snapshot delta disk %s in folder %s", name, folder)
+			continue
+		}
+		diskURL := *u
+		diskURL.Path = diskURLPathPrefix + path.Join(folder, name)
+		diskURLPaths = append(diskURLPaths, diskURL.String())
+	}
+	return diskURLPaths, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsregistervolume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+)
+
+func TestParseDiskFolderURLPath(t *testing.T) {
+	_, dcPath, dsName, folder, err := parseDiskFolderURLPath(
+		"https://10.192.255.221/folder/imported/disks/?dcPath=Datacenter-1&dsName=vsanDatastore")
+	assert.NoError(t, err)
+	assert.Equal(t, "Datacenter-1", dcPath)
+	assert.Equal(t, "vsanDatastore", dsName)
+	assert.Equal(t, "imported/disks", folder)
+
+	_, _, _, _, err = parseDiskFolderURLPath("https://10.192.255.221/folder/imported?dsName=vsanDatastore")
+	assert.Error(t, err)
+	_, _, _, _, err = parseDiskFolderURLPath("https://10.192.255.221/imported?dcPath=dc&dsName=vsanDatastore")
+	assert.Error(t, err)
+}
+
+func TestGetDiskNameSuffix(t *testing.T) {
+	assert.Equal(t, "vm2-1", getDiskNameSuffix(
+		"https://10.192.255.221/folder/imported/vm2_1.vmdk?dcPath=Datacenter-1&dsName=vsanDatastore"))
+	assert.Equal(t, "data-disk", getDiskNameSuffix(
+		"https://10.192.255.221/folder/imported/_Data%20Disk_.vmdk?dcPath=Datacenter-1&dsName=vsanDatastore"))
+}
+
+func TestSnapshotDeltaDiskPattern(t *testing.T) {
+	assert.True(t, snapshotDeltaDiskPattern.MatchString("vm_1-000001.vmdk"))
+	assert.False(t, snapshotDeltaDiskPattern.MatchString("vm_1.vmdk"))
+	assert.False(t, snapshotDeltaDiskPattern.MatchString("vm-1.vmdk"))
+}
+
+func TestReconcileDiskFolder(t *testing.T) {
+	backOffDuration = make(map[apitypes.NamespacedName]time.Duration)
+	ctx := context.Background()
+	const folderURLPath = "https://vc/folder/imported?dcPath=dc&dsName=ds"
+	diskURLPaths := []string{
+		"https://vc/folder/imported/disk_1.vmdk?dcPath=dc&dsName=ds",
+		"https://vc/folder/imported/disk_2.vmdk?dcPath=dc&dsName=ds",
+	}
+
+	patches := gomonkey.ApplyFunc(cnsvsphere.GetVirtualCenterInstance,
+		func(ctx context.Context, config *commonconfig.ConfigurationInfo,
+			reinitialize bool) (*cnsvsphere.VirtualCenter, error) {
+			return &cnsvsphere.VirtualCenter{}, nil
+		})
+	defer patches.Reset()
+	origListDisksInFolder := listDisksInFolder
+	defer func() { listDisksInFolder = origListDisksInFolder }()
+	listDisksInFolder = func(ctx context.Context, vc *cnsvsphere.VirtualCenter,
+		diskFolderURLPath string) ([]string, error) {
+		assert.Equal(t, folderURLPath, diskFolderURLPath)
+		return diskURLPaths, nil
+	}
+
+	instance := &cnsregistervolumev1alpha1.CnsRegisterVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "import",
+			Namespace: "test-ns",
+			UID:       "import-uid",
+		},
+		Spec: cnsregistervolumev1alpha1.CnsRegisterVolumeSpec{
+			PvcName:           "pvc",
+			DiskFolderURLPath: folderURLPath,
+			StorageClassName:  "sc",
+		},
+	}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	scheme.AddKnownTypes(schema.GroupVersion{
+		Group:   "cns.vmware.com",
+		Version: "v1alpha1",
+	}, &cnsregistervolumev1alpha1.CnsRegisterVolume{}, &cnsregistervolumev1alpha1.CnsRegisterVolumeList{})
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(instance).
+		WithStatusSubresource(&cnsregistervolumev1alpha1.CnsRegisterVolume{}).
+		Build()
+	r := &ReconcileCnsRegisterVolume{
+		client:     fakeClient,
+		scheme:     scheme,
+		configInfo: &commonconfig.ConfigurationInfo{},
+		recorder:   record.NewFakeRecorder(10),
+	}
+
+	// The first reconcile creates a CnsRegisterVolume instance for each disk
+	// and waits for them to be registered.
+	res, err := r.reconcileDiskFolder(ctx, instance, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, res.RequeueAfter)
+	for i, suffix := range []string{"disk-1", "disk-2"} {
+		child := &cnsregistervolumev1alpha1.CnsRegisterVolume{}
+		err = fakeClient.Get(ctx, apitypes.NamespacedName{Name: "import-" + suffix, Namespace: "test-ns"}, child)
+		assert.NoError(t, err)
+		assert.Equal(t, "pvc-"+suffix, child.Spec.PvcName)
+		assert.Equal(t, diskURLPaths[i], child.Spec.DiskURLPath)
+		assert.Equal(t, "sc", child.Spec.StorageClassName)
+		assert.Equal(t, 1, len(child.OwnerReferences))
+		assert.Equal(t, instance.UID, child.OwnerReferences[0].UID)
+
+		child.Status.Registered = true
+		err = fakeClient.Status().Update(ctx, child)
+		assert.NoError(t, err)
+	}
+
+	// The instance is registered once all the disks are registered.
+	res, err = r.reconcileDiskFolder(ctx, instance, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), res.RequeueAfter)
+	updated := &cnsregistervolumev1alpha1.CnsRegisterVolume{}
+	err = fakeClient.Get(ctx, apitypes.NamespacedName{Name: "import", Namespace: "test-ns"}, updated)
+	assert.NoError(t, err)
+	assert.True(t, updated.Status.Registered)
+}
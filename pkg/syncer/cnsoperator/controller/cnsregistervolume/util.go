@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -460,3 +461,35 @@ func setBackingDiskAnnotation(ctx context.Context, k8sClient clientset.Interface
 
 	return updatedpvc, nil
 }
+
+// getNodeAffinityForTopology returns the node affinity of a PV accessible
+// from the given topology segments. Each segment becomes a node selector term
+// requiring all of its labels, so that the PV is accessible from the nodes of
+// any of the segments.
+func getNodeAffinityForTopology(topology []map[string]string) *v1.VolumeNodeAffinity {
+	var terms []v1.NodeSelectorTerm
+	for _, segment := range topology {
+		keys := make([]string, 0, len(segment))
+		for key := range segment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var expressions []v1.NodeSelectorRequirement
+		for _, key := range keys {
+			expressions = append(expressions, v1.NodeSelectorRequirement{
+				Key:      key,
+				Operator: v1.NodeSelectorOpIn,
+				Values:   []string{segment[key]},
+			})
+		}
+		if len(expressions) > 0 {
+			terms = append(terms, v1.NodeSelectorTerm{MatchExpressions: expressions})
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return &v1.VolumeNodeAffinity{
+		Required: &v1.NodeSelector{NodeSelectorTerms: terms},
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/vmware/govmomi/vim25/types"
+	v1 "k8s.io/api/core/v1"
 	apitypes "k8s.io/apimachinery/pkg/types"
 	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 )
@@ -202,3 +203,42 @@ func TestIsDatastoreAccessibleToAZClusters(t *testing.T) {
 		assert.False(t, result)
 	})
 }
+
+func TestGetNodeAffinityForTopology(t *testing.T) {
+	assert.Nil(t, getNodeAffinityForTopology(nil))
+	assert.Nil(t, getNodeAffinityForTopology([]map[string]string{{}}))
+
+	nodeAffinity := getNodeAffinityForTopology([]map[string]string{
+		{"topology.csi.vmware.com/k8s-zone": "zone-a", "topology.csi.vmware.com/k8s-region": "region-1"},
+		{"topology.csi.vmware.com/k8s-zone": "zone-b"},
+	})
+	assert.Equal(t, &v1.VolumeNodeAffinity{
+		Required: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{
+				{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{
+							Key:      "topology.csi.vmware.com/k8s-region",
+							Operator: v1.NodeSelectorOpIn,
+							Values:   []string{"region-1"},
+						},
+						{
+							Key:      "topology.csi.vmware.com/k8s-zone",
+							Operator: v1.NodeSelectorOpIn,
+							Values:   []string{"zone-a"},
+						},
+					},
+				},
+				{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{
+							Key:      "topology.csi.vmware.com/k8s-zone",
+							Operator: v1.NodeSelectorOpIn,
+							Values:   []string{"zone-b"},
+						},
+					},
+				},
+			},
+		},
+	}, nodeAffinity)
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsregistervolume
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/placementengine"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+)
+
+// reconcileVanilla registers the volume of a CnsRegisterVolume instance on a
+// vanilla Kubernetes cluster. A VMDK is first registered as an FCD. The FCD
+// is then registered as a CNS volume and bound to a new PV and PVC. The node
+// affinity of the PV selects the nodes from which the datastore of the volume
+// is accessible.
+func (r *ReconcileCnsRegisterVolume) reconcileVanilla(ctx context.Context,
+	instance *cnsregistervolumev1alpha1.CnsRegisterVolume, request reconcile.Request,
+	vc *cnsvsphere.VirtualCenter, timeout time.Duration) (reconcile.Result, error) {
+	log := logger.GetLogger(ctx)
+	volumeID := instance.Spec.VolumeID
+	if instance.Spec.DiskURLPath != "" {
+		var err error
+		volumeID, err = r.volumeManager.RegisterDisk(ctx, instance.Spec.DiskURLPath,
+			instance.Namespace+"-"+instance.Name)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to register disk: %s as FCD with error: %+v", instance.Spec.DiskURLPath, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		log.Infof("Registered disk: %s as FCD: %s", instance.Spec.DiskURLPath, volumeID)
+	}
+	pvName := staticPvNamePrefix + volumeID
+	// A dynamically provisioned or manually created PV may already use the
+	// volume.
+	existingPVName, found := commonco.ContainerOrchestratorUtility.GetPVNameFromCSIVolumeID(volumeID)
+	if found && existingPVName != pvName {
+		msg := fmt.Sprintf("PV: %q with the volume ID: %q is already present. "+
+			"Can not create multiple PV with same volume Id.", existingPVName, volumeID)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	createSpec := constructVanillaCreateSpec(r, volumeID, vc.Config.Host)
+	log.Infof("Creating CNS volume: %+v for CnsRegisterVolume request with name: %q on namespace: %q",
+		createSpec, instance.Name, instance.Namespace)
+	_, _, err := r.volumeManager.CreateVolume(ctx, createSpec, nil)
+	if err != nil {
+		msg := fmt.Sprintf("failed to create CNS volume. Error: %v", err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{
+			string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+			string(cnstypes.QuerySelectionNameTypeBackingObjectDetails),
+		},
+	}
+	volume, err := common.QueryVolumeByID(ctx, r.volumeManager, volumeID, &querySelection)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to query CNS volume: %s with error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	pvNodeAffinity, err := r.getVanillaVolumeNodeAffinity(ctx, vc, volume.DatastoreUrl)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to compute the node affinity of volume: %s with error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		// Untag the CNS volume which was created previously.
+		_, err = common.DeleteVolumeUtil(ctx, r.volumeManager, volumeID, false)
+		if err != nil {
+			log.Errorf("Failed to untag CNS volume: %s with error: %+v", volumeID, err)
+		}
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Failed to initialize K8S client when registering the CnsRegisterVolume "+
+			"instance: %s on namespace: %s. Error: %+v", instance.Name, instance.Namespace, err)
+		setInstanceError(ctx, r, instance, "Failed to init K8S client for volume registration")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	storageClassName := instance.Spec.StorageClassName
+	if storageClassName != "" {
+		_, err = k8sclient.StorageV1().StorageClasses().Get(ctx, storageClassName, metav1.GetOptions{})
+		if err != nil {
+			msg := fmt.Sprintf("Failed to fetch StorageClass: %q with error: %+v", storageClassName, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+	}
+
+	// Check the PVC before creating the PV. Otherwise, the PVC is bound to
+	// the PV even if the validation fails.
+	pvc, err := k8sclient.CoreV1().PersistentVolumeClaims(instance.Namespace).Get(ctx,
+		instance.Spec.PvcName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			msg := fmt.Sprintf("Failed to get PVC: %s with error: %+v", instance.Spec.PvcName, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		pvc = nil
+	} else if pvc.Spec.VolumeName != pvName {
+		msg := fmt.Sprintf("Another PVC: %s already exists in namespace: %s which is not bound to PV: %s",
+			instance.Spec.PvcName, instance.Namespace, pvName)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		// Untag the CNS volume which was created previously.
+		_, err = common.DeleteVolumeUtil(ctx, r.volumeManager, volumeID, false)
+		if err != nil {
+			log.Errorf("Failed to untag CNS volume: %s with error: %+v", volumeID, err)
+		}
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	capacityInMb := volume.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb
+	accessMode := instance.Spec.AccessMode
+	if accessMode == "" {
+		accessMode = v1.ReadWriteOnce
+	}
+	pv, err := k8sclient.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			msg := fmt.Sprintf("Failed to get PV: %s with error: %+v", pvName, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		log.Infof("PV: %s not found. Creating a new PV", pvName)
+		claimRef := &v1.ObjectReference{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+			Namespace:  instance.Namespace,
+			Name:       instance.Spec.PvcName,
+		}
+		pvSpec := getPersistentVolumeSpec(pvName, volumeID, capacityInMb,
+			accessMode, instance.Spec.VolumeMode, storageClassName, claimRef)
+		pvSpec.Spec.NodeAffinity = pvNodeAffinity
+		log.Debugf("PV spec is: %+v", pvSpec)
+		pv, err = k8sclient.CoreV1().PersistentVolumes().Create(ctx, pvSpec, metav1.CreateOptions{})
+		if err != nil {
+			log.Errorf("Failed to create PV with spec: %+v. Error: %+v", pvSpec, err)
+			setInstanceError(ctx, r, instance,
+				fmt.Sprintf("Failed to create PV: %s for volume with err: %+v", pvName, err))
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		log.Infof("PV: %s is created successfully", pvName)
+	} else if pv.Spec.ClaimRef != nil && (pv.Spec.ClaimRef.Namespace != instance.Namespace ||
+		pv.Spec.ClaimRef.Name != instance.Spec.PvcName) {
+		log.Errorf("Duplicate Request. There already exists a PV: %s which is claimed by PVC: %s/%s",
+			pvName, pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
+		setInstanceError(ctx, r, instance, "Duplicate Request")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	if pvc == nil {
+		log.Infof("Creating PVC: %s", instance.Spec.PvcName)
+		pvcSpec, err := getPersistentVolumeClaimSpec(ctx, instance.Spec.PvcName, instance.Namespace, capacityInMb,
+			storageClassName, accessMode, *pv.Spec.VolumeMode, pvName, nil, instance)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to create spec for PVC: %q. Error: %v", instance.Spec.PvcName, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		// The volume mode of the PVC must match the one of the PV for raw
+		// block volumes to be bound.
+		pvcSpec.Spec.VolumeMode = pv.Spec.VolumeMode
+		log.Debugf("PVC spec is: %+v", pvcSpec)
+		pvc, err = k8sclient.CoreV1().PersistentVolumeClaims(instance.Namespace).Create(ctx,
+			pvcSpec, metav1.CreateOptions{})
+		if err != nil {
+			log.Errorf("Failed to create PVC with spec: %+v. Error: %+v", pvcSpec, err)
+			setInstanceError(ctx, r, instance,
+				fmt.Sprintf("Failed to create PVC: %s for volume with err: %+v", instance.Spec.PvcName, err))
+			// Delete PV created above.
+			err = k8sclient.CoreV1().PersistentVolumes().Delete(ctx, pvName, *metav1.NewDeleteOptions(0))
+			if err != nil {
+				log.Errorf("Delete PV %s failed with error: %+v", pvName, err)
+			}
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		log.Infof("PVC: %s is created successfully", instance.Spec.PvcName)
+	}
+	// The PV controller binds the PVC to the PV asynchronously. The instance
+	// is reconciled again until the PVC is bound, instead of blocking the
+	// worker while waiting for it.
+	if pvc.Status.Phase != v1.ClaimBound {
+		log.Infof("PVC: %s is not bound yet. Requeueing CnsRegisterVolume instance: %s on namespace: %s",
+			instance.Spec.PvcName, instance.Name, instance.Namespace)
+		return reconcile.Result{RequeueAfter: pvcBindRequeueInterval}, nil
+	}
+	log.Infof("PVC: %s is bound", instance.Spec.PvcName)
+
+	// Update the instance to indicate the volume registration is successful.
+	msg := fmt.Sprintf("Successfully registered the volume on namespace: %s", instance.Namespace)
+	err = setInstanceSuccess(ctx, r, instance, instance.Spec.PvcName, pvc.UID, msg)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to update CnsRegistered instance with error: %+v", err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	backOffDurationMapMutex.Lock()
+	delete(backOffDuration, request.NamespacedName)
+	backOffDurationMapMutex.Unlock()
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
+
+// constructVanillaCreateSpec creates the CNS CreateVolume spec registering
+// the FCD with the given ID on a vanilla Kubernetes cluster.
+func constructVanillaCreateSpec(r *ReconcileCnsRegisterVolume, volumeID string,
+	host string) *cnstypes.CnsVolumeCreateSpec {
+	containerCluster := cnsvsphere.GetContainerCluster(r.configInfo.Cfg.Global.ClusterID,
+		r.configInfo.Cfg.VirtualCenter[host].User, cnstypes.CnsClusterFlavorVanilla,
+		r.configInfo.Cfg.Global.ClusterDistribution)
+	return &cnstypes.CnsVolumeCreateSpec{
+		Name:       staticPvNamePrefix + volumeID,
+		VolumeType: common.BlockVolumeType,
+		Metadata: cnstypes.CnsVolumeMetadata{
+			ContainerCluster:      containerCluster,
+			ContainerClusterArray: []cnstypes.CnsContainerCluster{containerCluster},
+		},
+		BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{
+			BackingDiskId: volumeID,
+		},
+	}
+}
+
+// getVanillaVolumeNodeAffinity returns the node affinity of the PV of a volume
+// on the datastore with the given URL. On topology aware clusters, the node
+// affinity selects the nodes of the topology domains from which the datastore
+// is accessible. Otherwise, the datastore must be accessible from all the
+// nodes, and the PV has no node affinity.
+func (r *ReconcileCnsRegisterVolume) getVanillaVolumeNodeAffinity(ctx context.Context,
+	vc *cnsvsphere.VirtualCenter, datastoreURL string) (*v1.VolumeNodeAffinity, error) {
+	nodeVMs, err := r.nodeMgr.GetAllNodesByVC(ctx, vc.Config.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the node VMs of vCenter %q: %v", vc.Config.Host, err)
+	}
+	labels := r.configInfo.Cfg.Labels
+	if labels.TopologyCategories == "" && labels.Zone == "" && labels.Region == "" {
+		accessibleNodes, err := common.GetNodeVMsWithAccessToDatastore(ctx, vc, datastoreURL, nodeVMs)
+		if err != nil {
+			return nil, err
+		}
+		if len(accessibleNodes) != len(nodeVMs) {
+			return nil, fmt.Errorf("datastore %q is not accessible to all nodes in the cluster", datastoreURL)
+		}
+		return nil, nil
+	}
+	datastoreAccessibleTopology, err := placementengine.CalculateAccessibleTopologiesForDatastore(ctx, vc, nil,
+		nodeVMs, datastoreURL, r.nodeMgr)
+	if err != nil {
+		return nil, err
+	}
+	return getNodeAffinityForTopology(datastoreAccessibleTopology), nil
+}
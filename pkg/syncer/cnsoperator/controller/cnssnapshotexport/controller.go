@@ -0,0 +1,406 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnssnapshotexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"github.com/vmware/govmomi/fault"
+	vim25types "github.com/vmware/govmomi/vim25/types"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	apis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	v1a1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnssnapshotexport/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+	cnsoptypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/types"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
+)
+
+const (
+	workerThreadEnvVar      = "WORKER_THREADS_SNAPSHOT_EXPORT"
+	defaultMaxWorkerThreads = 4
+)
+
+var (
+	// backOffDuration is a map of cnssnapshotexport name's to the time after
+	// which a request for this instance will be requeued.
+	// Initialized to 1 second for new instances and for instances whose latest
+	// reconcile operation succeeded.
+	// If the reconcile fails, backoff is incremented exponentially.
+	backOffDuration         map[types.NamespacedName]time.Duration
+	backOffDurationMapMutex = sync.Mutex{}
+)
+
+// Add creates a new CnsSnapshotExport Controller and adds it to the Manager,
+// ConfigurationInfo and VirtualCenterTypes. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
+	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
+	ctx, log := logger.GetNewContextWithLogger()
+	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload && clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+		log.Debug("Not initializing the CnsSnapshotExport Controller as its a guest cluster CSI deployment")
+		return nil
+	}
+
+	coCommonInterface, err := commonco.GetContainerOrchestratorInterface(ctx,
+		common.Kubernetes, clusterFlavor, &syncer.COInitParams)
+	if err != nil {
+		log.Errorf("failed to create CO agnostic interface. Err: %v", err)
+		return err
+	}
+
+	if !coCommonInterface.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+		log.Infof("Not initializing the CnsSnapshotExport Controller as snapshots are disabled on the cluster")
+		return nil
+	}
+
+	// Initializes kubernetes client.
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Creating Kubernetes client failed. Err: %v", err)
+		return err
+	}
+
+	// eventBroadcaster broadcasts events on CnsSnapshotExport instances to the event sink.
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(
+		&typedcorev1.EventSinkImpl{
+			Interface: k8sclient.CoreV1().Events(""),
+		},
+	)
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: apis.GroupName})
+	return add(mgr, newReconciler(mgr, configInfo, recorder))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager, configInfo *commonconfig.ConfigurationInfo,
+	recorder record.EventRecorder) reconcile.Reconciler {
+	return &Reconciler{
+		client:     mgr.GetClient(),
+		scheme:     mgr.GetScheme(),
+		configInfo: configInfo,
+		recorder:   recorder,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	ctx, log := logger.GetNewContextWithLogger()
+
+	maxWorkerThreads := util.GetMaxWorkerThreads(ctx,
+		workerThreadEnvVar, defaultMaxWorkerThreads)
+	// Create a new controller.
+	err := ctrl.NewControllerManagedBy(mgr).Named("cnssnapshotexport-controller").
+		For(&v1a1.CnsSnapshotExport{}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxWorkerThreads}).
+		Complete(r)
+	if err != nil {
+		log.Errorf("Failed to build application controller. Err: %v", err)
+		return err
+	}
+
+	backOffDuration = make(map[types.NamespacedName]time.Duration)
+	return nil
+}
+
+// blank assignment to verify that Reconciler implements
+// reconcile.Reconciler.
+var _ reconcile.Reconciler = &Reconciler{}
+
+// Reconciler reconciles a CnsSnapshotExport object.
+type Reconciler struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver.
+	client     client.Client
+	scheme     *runtime.Scheme
+	configInfo *commonconfig.ConfigurationInfo
+	recorder   record.EventRecorder
+}
+
+var (
+	getVirtualCenters      = _getVirtualCenters
+	retrieveVStorageObject = (*cnsvsphere.VirtualCenter).RetrieveVStorageObject
+	createDiskFromSnapshot = (*cnsvsphere.VirtualCenter).CreateDiskFromSnapshot
+	deleteDisk             = (*cnsvsphere.VirtualCenter).DeleteDisk
+	exportToDatastore      = _exportToDatastore
+	copyToDiskURLPath      = _copyToDiskURLPath
+	deleteDiskURLPath      = _deleteDiskURLPath
+)
+
+// Reconcile reads that state of the cluster for a Reconciler object
+// and makes changes based on the state read and what is in the
+// Reconciler.Spec.
+// Note:
+// The Controller will requeue the Request to be processed again if the
+// returned error is non-nil or Result.Requeue is true. Otherwise, upon
+// completion it will remove the work from the queue.
+func (r *Reconciler) Reconcile(ctx context.Context,
+	request reconcile.Request) (reconcile.Result, error) {
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx).With("name", request.NamespacedName)
+
+	// Fetch the CnsSnapshotExport instance.
+	instance := &v1a1.CnsSnapshotExport{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("instance not found. Ignoring since it must be deleted.")
+			return reconcile.Result{}, nil
+		}
+
+		log.Error("Error reading the instance. ", err)
+		return reconcile.Result{}, err
+	}
+
+	// The exported FCD or VMDK is not removed when the instance is deleted,
+	// as it must outlive the snapshot it was exported from.
+	if instance.DeletionTimestamp != nil {
+		log.Info("instance is marked for deletion")
+		deleteBackoffEntry(ctx, request.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
+	if instance.Status.Exported {
+		log.Debug("instance is already exported")
+		deleteBackoffEntry(ctx, request.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
+	log.Info("reconciling instance")
+	defer func() {
+		log.Info("finished reconciling instance")
+	}()
+
+	backoff := getBackoffDuration(ctx, request.NamespacedName)
+	log.Info("backoff duration is ", backoff)
+
+	err = r.reconcile(ctx, instance, request)
+	if err != nil {
+		log.Error("failed to reconcile with error ", err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: backoff}, nil
+	}
+
+	msg := "successfully exported the snapshot"
+	err = setInstanceSuccess(ctx, r, instance, msg)
+	if err != nil {
+		log.Warn("failed to update status to success with error ", err)
+		setInstanceError(ctx, r, instance, "failed to update status to success")
+		return reconcile.Result{RequeueAfter: backoff}, nil
+	}
+
+	deleteBackoffEntry(ctx, request.NamespacedName)
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
+
+// reconcile exports the snapshot of a CnsSnapshotExport instance. The
+// snapshot is first copied into a new FCD on the datastore of the snapshot,
+// which is then either moved to the target datastore or copied to the target
+// VMDK and deleted. The snapshot is exported on the vCenter it is on, so the
+// target must be on that vCenter too.
+// The ID of the new FCD is recorded in the status of the instance before the
+// FCD is moved or copied, and the copy to the target VMDK is recorded before
+// the FCD is deleted, so that retries don't repeat either step.
+func (r *Reconciler) reconcile(ctx context.Context,
+	instance *v1a1.CnsSnapshotExport, request reconcile.Request) error {
+	log := logger.GetLogger(ctx).With("name", request.NamespacedName)
+
+	if (instance.Spec.DatastoreURL == "") == (instance.Spec.DiskURLPath == "") {
+		return errors.New("exactly one of datastoreURL or diskURLPath must be specified")
+	}
+	volumeID, snapshotID, err := common.ParseCSISnapshotID(instance.Spec.SnapshotID)
+	if err != nil {
+		return err
+	}
+
+	vcs, err := getVirtualCenters(ctx, r.configInfo)
+	if err != nil {
+		log.Error("failed to get vCenters with error ", err)
+		return errors.New("failed to get vCenters")
+	}
+	vc, err := getVirtualCenterForVolume(ctx, vcs, volumeID)
+	if err != nil {
+		return err
+	}
+	err = checkTargetVirtualCenter(ctx, vcs, vc, instance.Spec)
+	if err != nil {
+		return err
+	}
+
+	if instance.Status.VolumeID == "" {
+		vso, err := createDiskFromSnapshot(vc, ctx, volumeID, snapshotID, instance.Namespace+"-"+instance.Name)
+		if err != nil {
+			return fmt.Errorf("failed to create disk from snapshot %s: %v", instance.Spec.SnapshotID, err)
+		}
+		instance.Status.VolumeID = vso.Config.Id.Id
+		instance.Status.Error = ""
+		err = k8s.UpdateStatus(ctx, r.client, instance)
+		if err != nil {
+			// The disk created from the snapshot can't be tracked, so it is
+			// orphaned if it isn't deleted.
+			log.Error("failed to record the disk created from the snapshot with error ", err)
+			if deleteErr := deleteDisk(vc, ctx, instance.Status.VolumeID); deleteErr != nil {
+				log.Error("failed to delete the disk created from the snapshot with error ", deleteErr)
+			}
+			instance.Status.VolumeID = ""
+			return errors.New("failed to record the disk created from the snapshot")
+		}
+		log.Infof("created disk %s from snapshot %s", instance.Status.VolumeID, instance.Spec.SnapshotID)
+	}
+
+	if instance.Spec.DatastoreURL != "" {
+		return exportToDatastore(ctx, vc, instance.Status.VolumeID, instance.Spec.DatastoreURL)
+	}
+
+	if !instance.Status.DiskCopied {
+		err = copyToDiskURLPath(ctx, vc, instance.Status.VolumeID, instance.Spec.DiskURLPath)
+		if err != nil {
+			return err
+		}
+		instance.Status.DiskCopied = true
+		instance.Status.Error = ""
+		err = k8s.UpdateStatus(ctx, r.client, instance)
+		if err != nil {
+			// The copied VMDK would fail the copy on retries if it isn't
+			// deleted.
+			log.Error("failed to record the copied disk with error ", err)
+			if deleteErr := deleteDiskURLPath(ctx, vc, instance.Spec.DiskURLPath); deleteErr != nil {
+				log.Error("failed to delete the copied disk with error ", deleteErr)
+			}
+			instance.Status.DiskCopied = false
+			return errors.New("failed to record the copied disk")
+		}
+	}
+
+	// The intermediate disk is deleted once it is copied. It is already
+	// deleted if a previous attempt failed to clear it from the status.
+	err = deleteDisk(vc, ctx, instance.Status.VolumeID)
+	if err != nil && !fault.Is(err, &vim25types.NotFound{}) {
+		return fmt.Errorf("failed to delete disk %s after copying it to %s: %v",
+			instance.Status.VolumeID, instance.Spec.DiskURLPath, err)
+	}
+	instance.Status.VolumeID = ""
+	return nil
+}
+
+// setInstanceError sets error and records an event on the CnsSnapshotExport
+// instance.
+func setInstanceError(ctx context.Context, r *Reconciler,
+	instance *v1a1.CnsSnapshotExport, errMsg string) {
+	instance.Status.Error = errMsg
+	_ = k8s.UpdateStatus(ctx, r.client, instance)
+	recordEvent(ctx, r, instance, v1.EventTypeWarning, errMsg)
+}
+
+// setInstanceSuccess sets instance to success and records an event on the
+// CnsSnapshotExport instance.
+func setInstanceSuccess(ctx context.Context, r *Reconciler,
+	instance *v1a1.CnsSnapshotExport, msg string) error {
+	instance.Status.Exported = true
+	instance.Status.Error = ""
+	err := k8s.UpdateStatus(ctx, r.client, instance)
+	if err != nil {
+		return err
+	}
+
+	recordEvent(ctx, r, instance, v1.EventTypeNormal, msg)
+	return nil
+}
+
+// recordEvent records the event, sets the backOffDuration for the instance
+// appropriately and logs the message.
+// backOffDuration is reset to 1 second on success and doubled on failure
+// until it reaches a maximum of 5 minutes.
+func recordEvent(ctx context.Context, r *Reconciler,
+	instance *v1a1.CnsSnapshotExport, eventtype string, msg string) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("Event type is %s", eventtype)
+	namespacedName := types.NamespacedName{
+		Name:      instance.Name,
+		Namespace: instance.Namespace,
+	}
+	switch eventtype {
+	case v1.EventTypeWarning:
+		// Double backOff duration.
+		doubleBackoffDuration(ctx, namespacedName)
+		r.recorder.Event(instance, v1.EventTypeWarning, "CnsSnapshotExportFailed", msg)
+	case v1.EventTypeNormal:
+		// Reset backOff duration to one second.
+		updateBackoffEntry(ctx, namespacedName, time.Second)
+		r.recorder.Event(instance, v1.EventTypeNormal, "CnsSnapshotExportSucceeded", msg)
+	}
+}
+
+// getBackoffDuration returns the backoff duration for the instance.
+// If the instance is not present in the map, it is added with
+// a backoff duration of 1 second.
+func getBackoffDuration(ctx context.Context, name types.NamespacedName) time.Duration {
+	backOffDurationMapMutex.Lock()
+	defer backOffDurationMapMutex.Unlock()
+	if _, exists := backOffDuration[name]; !exists {
+		backOffDuration[name] = time.Second
+	}
+
+	return backOffDuration[name]
+}
+
+// doubleBackoffDuration doubles the backoff duration for the instance
+// until it reaches a maximum of 5 minutes.
+func doubleBackoffDuration(ctx context.Context, name types.NamespacedName) {
+	d := getBackoffDuration(ctx, name)
+	d = min(d*2, cnsoptypes.MaxBackOffDurationForReconciler)
+	updateBackoffEntry(ctx, name, d)
+}
+
+// updateBackoffEntry updates the backoff duration for the instance.
+func updateBackoffEntry(ctx context.Context, name types.NamespacedName, duration time.Duration) {
+	backOffDurationMapMutex.Lock()
+	defer backOffDurationMapMutex.Unlock()
+	backOffDuration[name] = duration
+}
+
+// deleteBackoffEntry deletes the backoff entry for the instance.
+func deleteBackoffEntry(ctx context.Context, name types.NamespacedName) {
+	backOffDurationMapMutex.Lock()
+	defer backOffDurationMapMutex.Unlock()
+	delete(backOffDuration, name)
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnssnapshotexport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/task"
+	vim25types "github.com/vmware/govmomi/vim25/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	apis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	v1a1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnssnapshotexport/v1alpha1"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+)
+
+func TestReconciler_Reconcile(t *testing.T) {
+	// function overrides
+	getVirtualCentersOriginal := getVirtualCenters
+	createDiskFromSnapshotOriginal := createDiskFromSnapshot
+	deleteDiskOriginal := deleteDisk
+	exportToDatastoreOriginal := exportToDatastore
+	copyToDiskURLPathOriginal := copyToDiskURLPath
+	defer func() {
+		getVirtualCenters = getVirtualCentersOriginal
+		createDiskFromSnapshot = createDiskFromSnapshotOriginal
+		deleteDisk = deleteDiskOriginal
+		exportToDatastore = exportToDatastoreOriginal
+		copyToDiskURLPath = copyToDiskURLPathOriginal
+	}()
+	getVirtualCenters = func(ctx context.Context,
+		configInfo *commonconfig.ConfigurationInfo) ([]*cnsvsphere.VirtualCenter, error) {
+		return []*cnsvsphere.VirtualCenter{{}}, nil
+	}
+
+	var createdDisks, deletedDisks []string
+	createDiskFromSnapshot = func(vc *cnsvsphere.VirtualCenter, ctx context.Context, volumeID string,
+		snapshotID string, name string) (*vim25types.VStorageObject, error) {
+		createdDisks = append(createdDisks, snapshotID)
+		return &vim25types.VStorageObject{
+			Config: vim25types.VStorageObjectConfigInfo{
+				BaseConfigInfo: vim25types.BaseConfigInfo{Id: vim25types.ID{Id: "exported-disk"}},
+			},
+		}, nil
+	}
+	var deleteDiskErr error
+	deleteDisk = func(vc *cnsvsphere.VirtualCenter, ctx context.Context, volumeID string) error {
+		deletedDisks = append(deletedDisks, volumeID)
+		return deleteDiskErr
+	}
+	var exportedTo string
+	exportToDatastore = func(ctx context.Context, vc *cnsvsphere.VirtualCenter, volumeID string,
+		datastoreURL string) error {
+		exportedTo = datastoreURL
+		return nil
+	}
+	copyToDiskURLPath = func(ctx context.Context, vc *cnsvsphere.VirtualCenter, volumeID string,
+		diskURLPath string) error {
+		exportedTo = diskURLPath
+		return nil
+	}
+
+	request := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "export",
+			Namespace: "ns",
+		},
+	}
+	setup := func(t *testing.T, instance *v1a1.CnsSnapshotExport) *Reconciler {
+		t.Helper()
+		createdDisks, deletedDisks, exportedTo, deleteDiskErr = nil, nil, "", nil
+		backOffDuration = make(map[types.NamespacedName]time.Duration)
+		return &Reconciler{
+			client:   newClient(t, instance),
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+	getInstance := func(t *testing.T, r *Reconciler) *v1a1.CnsSnapshotExport {
+		t.Helper()
+		instance := &v1a1.CnsSnapshotExport{}
+		if err := r.client.Get(context.Background(), request.NamespacedName, instance); err != nil {
+			t.Fatalf("Failed to get instance: %v", err)
+		}
+		return instance
+	}
+
+	t.Run("WhenExportingToDatastore", func(tt *testing.T) {
+		r := setup(tt, newInstance(request, v1a1.CnsSnapshotExportSpec{
+			SnapshotID:   "vol-1+snap-1",
+			DatastoreURL: "ds:///vmfs/volumes/ds-2/",
+		}, ""))
+
+		res, err := r.Reconcile(context.Background(), request)
+
+		assert.NoError(tt, err)
+		assert.True(tt, res.IsZero())
+		assert.Equal(tt, []string{"snap-1"}, createdDisks)
+		assert.Equal(tt, "ds:///vmfs/volumes/ds-2/", exportedTo)
+		instance := getInstance(tt, r)
+		assert.True(tt, instance.Status.Exported)
+		assert.Equal(tt, "exported-disk", instance.Status.VolumeID)
+	})
+
+	t.Run("WhenExportingToDiskURLPath", func(tt *testing.T) {
+		diskURLPath := "https://vc/folder/backups/disk.vmdk?dcPath=dc&dsName=ds-2"
+		r := setup(tt, newInstance(request, v1a1.CnsSnapshotExportSpec{
+			SnapshotID:  "vol-1+snap-1",
+			DiskURLPath: diskURLPath,
+		}, ""))
+
+		res, err := r.Reconcile(context.Background(), request)
+
+		assert.NoError(tt, err)
+		assert.True(tt, res.IsZero())
+		assert.Equal(tt, diskURLPath, exportedTo)
+		assert.Equal(tt, []string{"exported-disk"}, deletedDisks)
+		instance := getInstance(tt, r)
+		assert.True(tt, instance.Status.Exported)
+		assert.True(tt, instance.Status.DiskCopied)
+		assert.Empty(tt, instance.Status.VolumeID, "Expected the intermediate disk to be cleared")
+	})
+
+	t.Run("WhenRetryingAfterDiskIsCopied", func(tt *testing.T) {
+		instance := newInstance(request, v1a1.CnsSnapshotExportSpec{
+			SnapshotID:  "vol-1+snap-1",
+			DiskURLPath: "https://vc/folder/backups/disk.vmdk?dcPath=dc&dsName=ds-2",
+		}, "created-disk")
+		instance.Status.DiskCopied = true
+		r := setup(tt, instance)
+		// The intermediate disk was deleted by the previous attempt.
+		deleteDiskErr = task.Error{LocalizedMethodFault: &vim25types.LocalizedMethodFault{
+			Fault: &vim25types.NotFound{},
+		}}
+
+		_, err := r.Reconcile(context.Background(), request)
+
+		assert.NoError(tt, err)
+		assert.Empty(tt, createdDisks, "Expected no disk to be created again")
+		assert.Empty(tt, exportedTo, "Expected the disk not to be copied again")
+		assert.Equal(tt, []string{"created-disk"}, deletedDisks)
+		instance = getInstance(tt, r)
+		assert.True(tt, instance.Status.Exported)
+		assert.Empty(tt, instance.Status.VolumeID)
+	})
+
+	t.Run("WhenRetryingAfterDiskIsCreated", func(tt *testing.T) {
+		r := setup(tt, newInstance(request, v1a1.CnsSnapshotExportSpec{
+			SnapshotID:   "vol-1+snap-1",
+			DatastoreURL: "ds:///vmfs/volumes/ds-2/",
+		}, "created-disk"))
+
+		_, err := r.Reconcile(context.Background(), request)
+
+		assert.NoError(tt, err)
+		assert.Empty(tt, createdDisks, "Expected no disk to be created again")
+		instance := getInstance(tt, r)
+		assert.True(tt, instance.Status.Exported)
+		assert.Equal(tt, "created-disk", instance.Status.VolumeID)
+	})
+
+	t.Run("WhenSnapshotIDIsInvalid", func(tt *testing.T) {
+		r := setup(tt, newInstance(request, v1a1.CnsSnapshotExportSpec{
+			SnapshotID:   "snap-1",
+			DatastoreURL: "ds:///vmfs/volumes/ds-2/",
+		}, ""))
+
+		res, err := r.Reconcile(context.Background(), request)
+
+		assert.NoError(tt, err)
+		assert.Equal(tt, time.Second, res.RequeueAfter)
+		assert.Equal(tt, 2*time.Second, backOffDuration[request.NamespacedName])
+		instance := getInstance(tt, r)
+		assert.False(tt, instance.Status.Exported)
+		assert.NotEmpty(tt, instance.Status.Error)
+	})
+}
+
+func TestGetVirtualCenterForVolume(t *testing.T) {
+	retrieveVStorageObjectOriginal := retrieveVStorageObject
+	defer func() {
+		retrieveVStorageObject = retrieveVStorageObjectOriginal
+	}()
+	vc1 := &cnsvsphere.VirtualCenter{Config: &cnsvsphere.VirtualCenterConfig{Host: "vc-1"}}
+	vc2 := &cnsvsphere.VirtualCenter{Config: &cnsvsphere.VirtualCenterConfig{Host: "vc-2"}}
+	retrieveVStorageObject = func(vc *cnsvsphere.VirtualCenter, ctx context.Context,
+		volumeID string) (*vim25types.VStorageObject, error) {
+		if vc == vc2 && volumeID == "vol-2" {
+			return &vim25types.VStorageObject{}, nil
+		}
+		return nil, task.Error{LocalizedMethodFault: &vim25types.LocalizedMethodFault{
+			Fault: &vim25types.NotFound{},
+		}}
+	}
+
+	vc, err := getVirtualCenterForVolume(context.Background(), []*cnsvsphere.VirtualCenter{vc1, vc2}, "vol-2")
+	assert.NoError(t, err)
+	assert.Equal(t, vc2, vc)
+
+	_, err = getVirtualCenterForVolume(context.Background(), []*cnsvsphere.VirtualCenter{vc1, vc2}, "vol-3")
+	assert.Error(t, err)
+}
+
+func TestCheckTargetVirtualCenter(t *testing.T) {
+	vc1 := &cnsvsphere.VirtualCenter{Config: &cnsvsphere.VirtualCenterConfig{Host: "vc-1"}}
+	vc2 := &cnsvsphere.VirtualCenter{Config: &cnsvsphere.VirtualCenterConfig{Host: "vc-2"}}
+	vcs := []*cnsvsphere.VirtualCenter{vc1, vc2}
+
+	err := checkTargetVirtualCenter(context.Background(), vcs, vc1, v1a1.CnsSnapshotExportSpec{
+		DiskURLPath: "https://vc-1/folder/backups/disk.vmdk?dcPath=dc&dsName=ds",
+	})
+	assert.NoError(t, err)
+
+	err = checkTargetVirtualCenter(context.Background(), vcs, vc1, v1a1.CnsSnapshotExportSpec{
+		DiskURLPath: "https://vc-2/folder/backups/disk.vmdk?dcPath=dc&dsName=ds",
+	})
+	assert.Error(t, err)
+}
+
+func TestParseDiskURLPath(t *testing.T) {
+	dcPath, dsPath, err := parseDiskURLPath(
+		"https://10.0.0.1/folder/backups/disk-1.vmdk?dcPath=Datacenter-1&dsName=vsanDatastore")
+	assert.NoError(t, err)
+	assert.Equal(t, "Datacenter-1", dcPath)
+	assert.Equal(t, "[vsanDatastore] backups/disk-1.vmdk", dsPath)
+
+	for _, diskURLPath := range []string{
+		"https://10.0.0.1/backups/disk-1.vmdk?dcPath=Datacenter-1&dsName=vsanDatastore",
+		"https://10.0.0.1/folder/backups/disk-1?dcPath=Datacenter-1&dsName=vsanDatastore",
+		"https://10.0.0.1/folder/backups/disk-1.vmdk?dsName=vsanDatastore",
+		"https://10.0.0.1/folder/backups/disk-1.vmdk?dcPath=Datacenter-1",
+	} {
+		_, _, err := parseDiskURLPath(diskURLPath)
+		assert.Error(t, err, "Expected error for %s", diskURLPath)
+	}
+}
+
+func newInstance(request reconcile.Request, spec v1a1.CnsSnapshotExportSpec,
+	volumeID string) *v1a1.CnsSnapshotExport {
+	return &v1a1.CnsSnapshotExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      request.Name,
+			Namespace: request.Namespace,
+		},
+		Spec:   spec,
+		Status: v1a1.CnsSnapshotExportStatus{VolumeID: volumeID},
+	}
+}
+
+func newClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	schemeBuilder := runtime.NewSchemeBuilder(apis.AddToScheme, v1.AddToScheme)
+	err := schemeBuilder.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add scheme: %v", err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(objs...).Build()
+}
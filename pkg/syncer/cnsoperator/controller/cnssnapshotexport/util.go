@@ -0,0 +1,269 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnssnapshotexport
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/object"
+	vim25types "github.com/vmware/govmomi/vim25/types"
+	v1a1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnssnapshotexport/v1alpha1"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// diskURLPathPrefix is the path prefix of a DiskURLPath, e.g.
+// https://<vc_ip>/folder/<vmdk_path>?dcPath=<datacenterName>&dsName=<datastoreName>
+const diskURLPathPrefix = "/folder/"
+
+// _getVirtualCenters returns the vCenters of the cluster.
+func _getVirtualCenters(ctx context.Context,
+	configInfo *commonconfig.ConfigurationInfo) ([]*cnsvsphere.VirtualCenter, error) {
+	vcConfigs, err := cnsvsphere.GetVirtualCenterConfigs(ctx, configInfo.Cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vCenter configs: %v", err)
+	}
+	vcs := make([]*cnsvsphere.VirtualCenter, 0, len(vcConfigs))
+	for _, vcConfig := range vcConfigs {
+		vc, err := cnsvsphere.GetVirtualCenterInstanceForVCenterConfig(ctx, vcConfig, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get vCenter %s: %v", vcConfig.Host, err)
+		}
+		vcs = append(vcs, vc)
+	}
+	return vcs, nil
+}
+
+// getVirtualCenterForVolume returns the vCenter of the FCD with the given ID
+// out of the given vCenters.
+func getVirtualCenterForVolume(ctx context.Context, vcs []*cnsvsphere.VirtualCenter,
+	volumeID string) (*cnsvsphere.VirtualCenter, error) {
+	if len(vcs) == 1 {
+		return vcs[0], nil
+	}
+	for _, vc := range vcs {
+		_, err := retrieveVStorageObject(vc, ctx, volumeID)
+		if err == nil {
+			return vc, nil
+		}
+		if !fault.Is(err, &vim25types.NotFound{}) {
+			return nil, fmt.Errorf("failed to retrieve disk %s on vCenter %s: %v", volumeID, vc.Config.Host, err)
+		}
+	}
+	return nil, fmt.Errorf("disk %s not found on any vCenter", volumeID)
+}
+
+// checkTargetVirtualCenter returns an error if the target of the given spec
+// is on another vCenter than vc, as vSphere can't move or copy a disk from
+// one vCenter to another.
+func checkTargetVirtualCenter(ctx context.Context, vcs []*cnsvsphere.VirtualCenter, vc *cnsvsphere.VirtualCenter,
+	spec v1a1.CnsSnapshotExportSpec) error {
+	if len(vcs) == 1 {
+		return nil
+	}
+	if spec.DiskURLPath != "" {
+		u, err := url.Parse(spec.DiskURLPath)
+		if err != nil {
+			return fmt.Errorf("invalid diskURLPath %s: %v", spec.DiskURLPath, err)
+		}
+		for _, other := range vcs {
+			if other != vc && other.Config.Host == u.Hostname() {
+				return fmt.Errorf("diskURLPath %s is on vCenter %s, but the snapshot is on vCenter %s. "+
+					"Exporting a snapshot to another vCenter is not supported",
+					spec.DiskURLPath, other.Config.Host, vc.Config.Host)
+			}
+		}
+		return nil
+	}
+	if _, err := getDatastoreInfoByURL(ctx, vc, spec.DatastoreURL); err == nil {
+		return nil
+	}
+	for _, other := range vcs {
+		if other == vc {
+			continue
+		}
+		if _, err := getDatastoreInfoByURL(ctx, other, spec.DatastoreURL); err == nil {
+			return fmt.Errorf("datastore %s is on vCenter %s, but the snapshot is on vCenter %s. "+
+				"Exporting a snapshot to another vCenter is not supported",
+				spec.DatastoreURL, other.Config.Host, vc.Config.Host)
+		}
+	}
+	return nil
+}
+
+// _exportToDatastore moves the FCD with the given ID to the datastore with
+// the given URL, unless it is already on that datastore.
+func _exportToDatastore(ctx context.Context, vc *cnsvsphere.VirtualCenter, volumeID string,
+	datastoreURL string) error {
+	log := logger.GetLogger(ctx)
+	backing, err := getDiskBacking(ctx, vc, volumeID)
+	if err != nil {
+		return err
+	}
+	dsInfo, err := getDatastoreInfoByURL(ctx, vc, datastoreURL)
+	if err != nil {
+		return err
+	}
+	if backing.Datastore == dsInfo.Reference() {
+		log.Infof("disk %s is already on datastore %s", volumeID, datastoreURL)
+		return nil
+	}
+	err = vc.RelocateDisk(ctx, volumeID, dsInfo.Reference())
+	if err != nil {
+		return fmt.Errorf("failed to move disk %s to datastore %s: %v", volumeID, datastoreURL, err)
+	}
+	log.Infof("moved disk %s to datastore %s", volumeID, datastoreURL)
+	return nil
+}
+
+// _copyToDiskURLPath copies the backing VMDK of the FCD with the given ID
+// to the given DiskURLPath.
+func _copyToDiskURLPath(ctx context.Context, vc *cnsvsphere.VirtualCenter, volumeID string,
+	diskURLPath string) error {
+	log := logger.GetLogger(ctx)
+	dcPath, dstPath, err := parseDiskURLPath(diskURLPath)
+	if err != nil {
+		return err
+	}
+	backing, err := getDiskBacking(ctx, vc, volumeID)
+	if err != nil {
+		return err
+	}
+	datacenters, err := vc.GetDatacenters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get datacenters: %v", err)
+	}
+	var srcDC *object.Datacenter
+	for _, dc := range datacenters {
+		datastores, err := dc.GetAllDatastores(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get datastores of datacenter %s: %v", dc.Name(), err)
+		}
+		for _, dsInfo := range datastores {
+			if dsInfo.Reference() == backing.Datastore {
+				srcDC = dc.Datacenter
+			}
+		}
+	}
+	if srcDC == nil {
+		return fmt.Errorf("datacenter of datastore %v of disk %s not found", backing.Datastore, volumeID)
+	}
+	dstDC, err := getDatacenter(ctx, vc, dcPath)
+	if err != nil {
+		return err
+	}
+
+	task, err := object.NewVirtualDiskManager(vc.Client.Client).CopyVirtualDisk(ctx,
+		backing.FilePath, srcDC, dstPath, dstDC, nil, false)
+	if err == nil {
+		err = task.Wait(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy disk %s to %s: %v", volumeID, dstPath, err)
+	}
+	log.Infof("copied disk %s to %s", volumeID, dstPath)
+	return nil
+}
+
+// _deleteDiskURLPath deletes the VMDK at the given DiskURLPath.
+func _deleteDiskURLPath(ctx context.Context, vc *cnsvsphere.VirtualCenter, diskURLPath string) error {
+	dcPath, dstPath, err := parseDiskURLPath(diskURLPath)
+	if err != nil {
+		return err
+	}
+	dc, err := getDatacenter(ctx, vc, dcPath)
+	if err != nil {
+		return err
+	}
+	task, err := object.NewVirtualDiskManager(vc.Client.Client).DeleteVirtualDisk(ctx, dstPath, dc)
+	if err == nil {
+		err = task.Wait(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %v", dstPath, err)
+	}
+	return nil
+}
+
+// getDatacenter returns the datacenter of the vCenter with the given name or
+// inventory path.
+func getDatacenter(ctx context.Context, vc *cnsvsphere.VirtualCenter, dcPath string) (*object.Datacenter, error) {
+	datacenters, err := vc.GetDatacenters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get datacenters: %v", err)
+	}
+	for _, dc := range datacenters {
+		if dc.Name() == dcPath || strings.TrimPrefix(dc.InventoryPath, "/") == dcPath {
+			return dc.Datacenter, nil
+		}
+	}
+	return nil, fmt.Errorf("datacenter %s not found on vCenter %s", dcPath, vc.Config.Host)
+}
+
+// parseDiskURLPath returns the datacenter path and the datastore path, e.g.
+// "[vsanDatastore] dir/disk.vmdk", of a DiskURLPath.
+func parseDiskURLPath(diskURLPath string) (string, string, error) {
+	u, err := url.Parse(diskURLPath)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid diskURLPath %s: %v", diskURLPath, err)
+	}
+	query := u.Query()
+	dcPath, dsName := query.Get("dcPath"), query.Get("dsName")
+	filePath := strings.TrimPrefix(u.Path, diskURLPathPrefix)
+	if !strings.HasPrefix(u.Path, diskURLPathPrefix) || !strings.HasSuffix(filePath, ".vmdk") ||
+		dcPath == "" || dsName == "" {
+		return "", "", fmt.Errorf("invalid diskURLPath %s, expected format "+
+			"https://<vc_ip>/folder/<vmdk_path>?dcPath=<datacenterName>&dsName=<datastoreName>", diskURLPath)
+	}
+	return dcPath, fmt.Sprintf("[%s] %s", dsName, filePath), nil
+}
+
+// getDiskBacking returns the backing of the FCD with the given ID.
+func getDiskBacking(ctx context.Context, vc *cnsvsphere.VirtualCenter,
+	volumeID string) (*vim25types.BaseConfigInfoDiskFileBackingInfo, error) {
+	vso, err := vc.RetrieveVStorageObject(ctx, volumeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve disk %s: %v", volumeID, err)
+	}
+	backing, ok := vso.Config.Backing.(*vim25types.BaseConfigInfoDiskFileBackingInfo)
+	if !ok {
+		return nil, fmt.Errorf("unexpected backing %T of disk %s", vso.Config.Backing, volumeID)
+	}
+	return backing, nil
+}
+
+// getDatastoreInfoByURL returns the datastore with the given URL in any of
+// the datacenters of the vCenter.
+func getDatastoreInfoByURL(ctx context.Context, vc *cnsvsphere.VirtualCenter,
+	datastoreURL string) (*cnsvsphere.DatastoreInfo, error) {
+	datacenters, err := vc.GetDatacenters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get datacenters: %v", err)
+	}
+	for _, dc := range datacenters {
+		dsInfo, err := dc.GetDatastoreInfoByURL(ctx, datastoreURL)
+		if err == nil {
+			return dsInfo, nil
+		}
+	}
+	return nil, fmt.Errorf("datastore %s not found on vCenter %s", datastoreURL, vc.Config.Host)
+}
@@ -0,0 +1,388 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnssnapshotimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	apis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
+	v1a1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnssnapshotimport/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+	cnsoptypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/types"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
+)
+
+const (
+	workerThreadEnvVar      = "WORKER_THREADS_SNAPSHOT_IMPORT"
+	defaultMaxWorkerThreads = 4
+)
+
+var (
+	// backOffDuration is a map of cnssnapshotimport name's to the time after
+	// which a request for this instance will be requeued.
+	// Initialized to 1 second for new instances and for instances whose latest
+	// reconcile operation succeeded.
+	// If the reconcile fails, backoff is incremented exponentially.
+	backOffDuration         map[types.NamespacedName]time.Duration
+	backOffDurationMapMutex = sync.Mutex{}
+)
+
+// Add creates a new CnsSnapshotImport Controller and adds it to the Manager,
+// ConfigurationInfo and VirtualCenterTypes. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
+	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
+	ctx, log := logger.GetNewContextWithLogger()
+	// Exported snapshots are imported through CnsRegisterVolume, which is
+	// only served on WCP and vanilla clusters.
+	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload && clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+		log.Debug("Not initializing the CnsSnapshotImport Controller as its a non-WCP and non-vanilla " +
+			"CSI deployment")
+		return nil
+	}
+
+	coCommonInterface, err := commonco.GetContainerOrchestratorInterface(ctx,
+		common.Kubernetes, clusterFlavor, &syncer.COInitParams)
+	if err != nil {
+		log.Errorf("failed to create CO agnostic interface. Err: %v", err)
+		return err
+	}
+
+	if !coCommonInterface.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+		log.Infof("Not initializing the CnsSnapshotImport Controller as snapshots are disabled on the cluster")
+		return nil
+	}
+
+	// Initializes kubernetes client.
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Creating Kubernetes client failed. Err: %v", err)
+		return err
+	}
+
+	// eventBroadcaster broadcasts events on CnsSnapshotImport instances to the event sink.
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(
+		&typedcorev1.EventSinkImpl{
+			Interface: k8sclient.CoreV1().Events(""),
+		},
+	)
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: apis.GroupName})
+	return add(mgr, newReconciler(mgr, volumeManager, recorder))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager, volumeManager volumes.Manager,
+	recorder record.EventRecorder) reconcile.Reconciler {
+	return &Reconciler{
+		client:        mgr.GetClient(),
+		scheme:        mgr.GetScheme(),
+		volumeManager: volumeManager,
+		recorder:      recorder,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	ctx, log := logger.GetNewContextWithLogger()
+
+	maxWorkerThreads := util.GetMaxWorkerThreads(ctx,
+		workerThreadEnvVar, defaultMaxWorkerThreads)
+	// Create a new controller.
+	err := ctrl.NewControllerManagedBy(mgr).Named("cnssnapshotimport-controller").
+		For(&v1a1.CnsSnapshotImport{}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxWorkerThreads}).
+		Complete(r)
+	if err != nil {
+		log.Errorf("Failed to build application controller. Err: %v", err)
+		return err
+	}
+
+	backOffDuration = make(map[types.NamespacedName]time.Duration)
+	return nil
+}
+
+// blank assignment to verify that Reconciler implements
+// reconcile.Reconciler.
+var _ reconcile.Reconciler = &Reconciler{}
+
+// Reconciler reconciles a CnsSnapshotImport object.
+type Reconciler struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver.
+	client        client.Client
+	scheme        *runtime.Scheme
+	volumeManager volumes.Manager
+	recorder      record.EventRecorder
+}
+
+// errImportPending is returned by reconcile while the CnsRegisterVolume
+// instance importing the exported snapshot is not registered yet.
+var errImportPending = errors.New("import is pending")
+
+// Reconcile reads that state of the cluster for a Reconciler object
+// and makes changes based on the state read and what is in the
+// Reconciler.Spec.
+// Note:
+// The Controller will requeue the Request to be processed again if the
+// returned error is non-nil or Result.Requeue is true. Otherwise, upon
+// completion it will remove the work from the queue.
+func (r *Reconciler) Reconcile(ctx context.Context,
+	request reconcile.Request) (reconcile.Result, error) {
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx).With("name", request.NamespacedName)
+
+	// Fetch the CnsSnapshotImport instance.
+	instance := &v1a1.CnsSnapshotImport{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("instance not found. Ignoring since it must be deleted.")
+			return reconcile.Result{}, nil
+		}
+
+		log.Error("Error reading the instance. ", err)
+		return reconcile.Result{}, err
+	}
+
+	if instance.DeletionTimestamp != nil {
+		log.Info("instance is marked for deletion")
+		deleteBackoffEntry(ctx, request.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
+	if instance.Status.Imported {
+		log.Debug("instance is already imported")
+		deleteBackoffEntry(ctx, request.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
+	log.Info("reconciling instance")
+	defer func() {
+		log.Info("finished reconciling instance")
+	}()
+
+	backoff := getBackoffDuration(ctx, request.NamespacedName)
+	log.Info("backoff duration is ", backoff)
+
+	err = r.reconcile(ctx, instance, request)
+	if errors.Is(err, errImportPending) {
+		log.Info("waiting for the CnsRegisterVolume instance to be registered")
+		doubleBackoffDuration(ctx, request.NamespacedName)
+		return reconcile.Result{RequeueAfter: backoff}, nil
+	}
+	if err != nil {
+		log.Error("failed to reconcile with error ", err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: backoff}, nil
+	}
+
+	msg := "successfully imported the exported snapshot"
+	err = setInstanceSuccess(ctx, r, instance, msg)
+	if err != nil {
+		log.Warn("failed to update status to success with error ", err)
+		setInstanceError(ctx, r, instance, "failed to update status to success")
+		return reconcile.Result{RequeueAfter: backoff}, nil
+	}
+
+	deleteBackoffEntry(ctx, request.NamespacedName)
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
+
+// reconcile imports the FCD or VMDK of a CnsSnapshotImport instance. A VMDK
+// is first registered as an FCD. The FCD is then imported as a PVC by a
+// CnsRegisterVolume instance with the same name as the CnsSnapshotImport
+// instance, which owns it.
+// It returns errImportPending until the CnsRegisterVolume instance is
+// registered.
+func (r *Reconciler) reconcile(ctx context.Context,
+	instance *v1a1.CnsSnapshotImport, request reconcile.Request) error {
+	log := logger.GetLogger(ctx).With("name", request.NamespacedName)
+
+	if (instance.Spec.VolumeID == "") == (instance.Spec.DiskURLPath == "") {
+		return errors.New("exactly one of volumeID or diskURLPath must be specified")
+	}
+
+	if instance.Status.VolumeID == "" {
+		volumeID := instance.Spec.VolumeID
+		if instance.Spec.DiskURLPath != "" {
+			var err error
+			volumeID, err = r.volumeManager.RegisterDisk(ctx, instance.Spec.DiskURLPath,
+				instance.Namespace+"-"+instance.Name)
+			if err != nil {
+				return fmt.Errorf("failed to register disk %s: %v", instance.Spec.DiskURLPath, err)
+			}
+			log.Infof("registered disk %s as FCD %s", instance.Spec.DiskURLPath, volumeID)
+		}
+		instance.Status.VolumeID = volumeID
+		err := k8s.UpdateStatus(ctx, r.client, instance)
+		if err != nil {
+			log.Error("failed to record the ID of the imported volume with error ", err)
+			return errors.New("failed to record the ID of the imported volume")
+		}
+	}
+
+	registerVolume := &cnsregistervolumev1alpha1.CnsRegisterVolume{}
+	err := r.client.Get(ctx, request.NamespacedName, registerVolume)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get CnsRegisterVolume %s: %v", request.NamespacedName, err)
+		}
+		registerVolume = &cnsregistervolumev1alpha1.CnsRegisterVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      instance.Name,
+				Namespace: instance.Namespace,
+			},
+			Spec: cnsregistervolumev1alpha1.CnsRegisterVolumeSpec{
+				PvcName:    instance.Spec.PvcName,
+				VolumeID:   instance.Status.VolumeID,
+				AccessMode: v1.ReadWriteOnce,
+			},
+		}
+		err = controllerutil.SetControllerReference(instance, registerVolume, r.scheme)
+		if err != nil {
+			return fmt.Errorf("failed to set owner of CnsRegisterVolume %s: %v", request.NamespacedName, err)
+		}
+		err = r.client.Create(ctx, registerVolume)
+		if err != nil {
+			return fmt.Errorf("failed to create CnsRegisterVolume %s: %v", request.NamespacedName, err)
+		}
+		log.Infof("created CnsRegisterVolume to import volume %s as PVC %s",
+			instance.Status.VolumeID, instance.Spec.PvcName)
+		return errImportPending
+	}
+
+	if registerVolume.Status.Registered {
+		return nil
+	}
+	if registerVolume.Status.Error != "" {
+		return fmt.Errorf("CnsRegisterVolume %s failed: %s", request.NamespacedName, registerVolume.Status.Error)
+	}
+	return errImportPending
+}
+
+// setInstanceError sets error and records an event on the CnsSnapshotImport
+// instance.
+func setInstanceError(ctx context.Context, r *Reconciler,
+	instance *v1a1.CnsSnapshotImport, errMsg string) {
+	instance.Status.Error = errMsg
+	_ = k8s.UpdateStatus(ctx, r.client, instance)
+	recordEvent(ctx, r, instance, v1.EventTypeWarning, errMsg)
+}
+
+// setInstanceSuccess sets instance to success and records an event on the
+// CnsSnapshotImport instance.
+func setInstanceSuccess(ctx context.Context, r *Reconciler,
+	instance *v1a1.CnsSnapshotImport, msg string) error {
+	instance.Status.Imported = true
+	instance.Status.Error = ""
+	err := k8s.UpdateStatus(ctx, r.client, instance)
+	if err != nil {
+		return err
+	}
+
+	recordEvent(ctx, r, instance, v1.EventTypeNormal, msg)
+	return nil
+}
+
+// recordEvent records the event, sets the backOffDuration for the instance
+// appropriately and logs the message.
+// backOffDuration is reset to 1 second on success and doubled on failure
+// until it reaches a maximum of 5 minutes.
+func recordEvent(ctx context.Context, r *Reconciler,
+	instance *v1a1.CnsSnapshotImport, eventtype string, msg string) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("Event type is %s", eventtype)
+	namespacedName := types.NamespacedName{
+		Name:      instance.Name,
+		Namespace: instance.Namespace,
+	}
+	switch eventtype {
+	case v1.EventTypeWarning:
+		// Double backOff duration.
+		doubleBackoffDuration(ctx, namespacedName)
+		r.recorder.Event(instance, v1.EventTypeWarning, "CnsSnapshotImportFailed", msg)
+	case v1.EventTypeNormal:
+		// Reset backOff duration to one second.
+		updateBackoffEntry(ctx, namespacedName, time.Second)
+		r.recorder.Event(instance, v1.EventTypeNormal, "CnsSnapshotImportSucceeded", msg)
+	}
+}
+
+// getBackoffDuration returns the backoff duration for the instance.
+// If the instance is not present in the map, it is added with
+// a backoff duration of 1 second.
+func getBackoffDuration(ctx context.Context, name types.NamespacedName) time.Duration {
+	backOffDurationMapMutex.Lock()
+	defer backOffDurationMapMutex.Unlock()
+	if _, exists := backOffDuration[name]; !exists {
+		backOffDuration[name] = time.Second
+	}
+
+	return backOffDuration[name]
+}
+
+// doubleBackoffDuration doubles the backoff duration for the instance
+// until it reaches a maximum of 5 minutes.
+func doubleBackoffDuration(ctx context.Context, name types.NamespacedName) {
+	d := getBackoffDuration(ctx, name)
+	d = min(d*2, cnsoptypes.MaxBackOffDurationForReconciler)
+	updateBackoffEntry(ctx, name, d)
+}
+
+// updateBackoffEntry updates the backoff duration for the instance.
+func updateBackoffEntry(ctx context.Context, name types.NamespacedName, duration time.Duration) {
+	backOffDurationMapMutex.Lock()
+	defer backOffDurationMapMutex.Unlock()
+	backOffDuration[name] = duration
+}
+
+// deleteBackoffEntry deletes the backoff entry for the instance.
+func deleteBackoffEntry(ctx context.Context, name types.NamespacedName) {
+	backOffDurationMapMutex.Lock()
+	defer backOffDurationMapMutex.Unlock()
+	delete(backOffDuration, name)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnssnapshotimport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	apis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
+	v1a1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnssnapshotimport/v1alpha1"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+)
+
+// fakeVolumeManager registers disks as FCDs with a fixed ID.
+type fakeVolumeManager struct {
+	volume.Manager
+	registeredPaths []string
+}
+
+func (m *fakeVolumeManager) RegisterDisk(ctx context.Context, path string, name string) (string, error) {
+	m.registeredPaths = append(m.registeredPaths, path)
+	return "registered-disk", nil
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	request := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "import",
+			Namespace: "ns",
+		},
+	}
+	setup := func(t *testing.T, spec v1a1.CnsSnapshotImportSpec) (*Reconciler, *fakeVolumeManager) {
+		t.Helper()
+		backOffDuration = make(map[types.NamespacedName]time.Duration)
+		instance := &v1a1.CnsSnapshotImport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			},
+			Spec: spec,
+		}
+		scheme := runtime.NewScheme()
+		schemeBuilder := runtime.NewSchemeBuilder(apis.AddToScheme, v1.AddToScheme)
+		if err := schemeBuilder.AddToScheme(scheme); err != nil {
+			t.Fatalf("Failed to add scheme: %v", err)
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).
+			WithStatusSubresource(instance, &cnsregistervolumev1alpha1.CnsRegisterVolume{}).Build()
+		volumeManager := &fakeVolumeManager{}
+		return &Reconciler{
+			client:        c,
+			scheme:        scheme,
+			volumeManager: volumeManager,
+			recorder:      record.NewFakeRecorder(10),
+		}, volumeManager
+	}
+	getObject := func(t *testing.T, r *Reconciler, obj client.Object) {
+		t.Helper()
+		if err := r.client.Get(context.Background(), request.NamespacedName, obj); err != nil {
+			t.Fatalf("Failed to get %T: %v", obj, err)
+		}
+	}
+	setRegisterVolumeStatus := func(t *testing.T, r *Reconciler,
+		status cnsregistervolumev1alpha1.CnsRegisterVolumeStatus) {
+		t.Helper()
+		registerVolume := &cnsregistervolumev1alpha1.CnsRegisterVolume{}
+		getObject(t, r, registerVolume)
+		registerVolume.Status = status
+		if err := r.client.Status().Update(context.Background(), registerVolume); err != nil {
+			t.Fatalf("Failed to update CnsRegisterVolume status: %v", err)
+		}
+	}
+
+	t.Run("WhenImportingDiskURLPath", func(tt *testing.T) {
+		diskURLPath := "https://vc/folder/backups/disk.vmdk?dcPath=dc&dsName=ds-2"
+		r, volumeManager := setup(tt, v1a1.CnsSnapshotImportSpec{PvcName: "pvc", DiskURLPath: diskURLPath})
+
+		res, err := r.Reconcile(context.Background(), request)
+
+		assert.NoError(tt, err)
+		assert.Equal(tt, time.Second, res.RequeueAfter, "Expected requeue while the import is pending")
+		assert.Equal(tt, []string{diskURLPath}, volumeManager.registeredPaths)
+		registerVolume := &cnsregistervolumev1alpha1.CnsRegisterVolume{}
+		getObject(tt, r, registerVolume)
+		assert.Equal(tt, "registered-disk", registerVolume.Spec.VolumeID)
+		assert.Equal(tt, "pvc", registerVolume.Spec.PvcName)
+		assert.Equal(tt, v1.ReadWriteOnce, registerVolume.Spec.AccessMode)
+		assert.Len(tt, registerVolume.OwnerReferences, 1)
+
+		setRegisterVolumeStatus(tt, r, cnsregistervolumev1alpha1.CnsRegisterVolumeStatus{Registered: true})
+		res, err = r.Reconcile(context.Background(), request)
+
+		assert.NoError(tt, err)
+		assert.True(tt, res.IsZero())
+		assert.Len(tt, volumeManager.registeredPaths, 1, "Expected the disk to be registered once")
+		instance := &v1a1.CnsSnapshotImport{}
+		getObject(tt, r, instance)
+		assert.True(tt, instance.Status.Imported)
+		assert.Equal(tt, "registered-disk", instance.Status.VolumeID)
+	})
+
+	t.Run("WhenCnsRegisterVolumeFails", func(tt *testing.T) {
+		r, volumeManager := setup(tt, v1a1.CnsSnapshotImportSpec{PvcName: "pvc", VolumeID: "exported-disk"})
+
+		_, err := r.Reconcile(context.Background(), request)
+		assert.NoError(tt, err)
+		assert.Empty(tt, volumeManager.registeredPaths, "Expected no disk to be registered for a volume ID")
+
+		setRegisterVolumeStatus(tt, r, cnsregistervolumev1alpha1.CnsRegisterVolumeStatus{Error: "PVC already exists"})
+		res, err := r.Reconcile(context.Background(), request)
+
+		assert.NoError(tt, err)
+		assert.NotZero(tt, res.RequeueAfter)
+		instance := &v1a1.CnsSnapshotImport{}
+		getObject(tt, r, instance)
+		assert.False(tt, instance.Status.Imported)
+		assert.Contains(tt, instance.Status.Error, "PVC already exists")
+	})
+}
@@ -66,6 +66,9 @@ var (
 	// If the reconcile fails, backoff is incremented exponentially.
 	backOffDuration         map[types.NamespacedName]time.Duration
 	backOffDurationMapMutex = sync.Mutex{}
+	// controllerClusterFlavor is the flavor of the cluster the controller
+	// runs on. Guest clusters and VM Service VMs only exist on WCP clusters.
+	controllerClusterFlavor cnstypes.CnsClusterFlavor
 )
 
 // Add creates a new CnsUnregisterVolume Controller and adds it to the Manager,
@@ -74,22 +77,26 @@ var (
 func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
 	ctx, log := logger.GetNewContextWithLogger()
-	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload {
-		log.Debug("Not initializing the CnsUnregisterVolume Controller as its a non-WCP CSI deployment")
+	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload && clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+		log.Debug("Not initializing the CnsUnregisterVolume Controller as its a non-WCP and non-vanilla " +
+			"CSI deployment")
 		return nil
 	}
 
-	coCommonInterface, err := commonco.GetContainerOrchestratorInterface(ctx,
-		common.Kubernetes, clusterFlavor, &syncer.COInitParams)
-	if err != nil {
-		log.Errorf("failed to create CO agnostic interface. Err: %v", err)
-		return err
-	}
+	if clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+		coCommonInterface, err := commonco.GetContainerOrchestratorInterface(ctx,
+			common.Kubernetes, clusterFlavor, &syncer.COInitParams)
+		if err != nil {
+			log.Errorf("failed to create CO agnostic interface. Err: %v", err)
+			return err
+		}
 
-	if !coCommonInterface.IsFSSEnabled(ctx, common.WCPMobilityNonDisruptiveImport) {
-		log.Infof("Not initializing the CnsUnregisterVolume Controller as this feature is disabled on the cluster")
-		return nil
+		if !coCommonInterface.IsFSSEnabled(ctx, common.WCPMobilityNonDisruptiveImport) {
+			log.Infof("Not initializing the CnsUnregisterVolume Controller as this feature is disabled on the cluster")
+			return nil
+		}
 	}
+	controllerClusterFlavor = clusterFlavor
 
 	// Initializes kubernetes client.
 	k8sclient, err := k8s.NewClient(ctx)
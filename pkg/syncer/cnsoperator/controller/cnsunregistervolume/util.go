@@ -23,6 +23,7 @@ import (
 
 	snapshotclient "github.com/kubernetes-csi/external-snapshotter/client/v8/clientset/versioned"
 	vmoperatortypes "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	cnstypes "github.com/vmware/govmomi/cns/types"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -257,6 +258,10 @@ func getSnapshotsForPVC(ctx context.Context, pvcName string, pvcNamespace string
 func getGuestClustersForPVC(ctx context.Context, pvcName, pvcNamespace string,
 	cfg rest.Config) ([]string, bool, error) {
 	log := logger.GetLogger(ctx)
+	if controllerClusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		// Guest clusters only exist on WCP clusters.
+		return nil, false, nil
+	}
 	c, err := k8s.NewClientForGroup(ctx, &cfg, apis.GroupName)
 	if err != nil {
 		return nil, false, err
@@ -292,6 +297,10 @@ func getGuestClustersForPVC(ctx context.Context, pvcName, pvcNamespace string,
 // getVMsForPVC returns a list of virtual machines that are using the specified PVC.
 func getVMsForPVC(ctx context.Context, pvcName string, pvcNamespace string,
 	cfg rest.Config) ([]string, bool, error) {
+	if controllerClusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		// VM Service VMs only exist on WCP clusters.
+		return nil, false, nil
+	}
 	c, err := k8s.NewClientForGroup(ctx, &cfg, vmoperatortypes.GroupName)
 	if err != nil {
 		return nil, false, errors.New("failed to create client for virtual machine group")
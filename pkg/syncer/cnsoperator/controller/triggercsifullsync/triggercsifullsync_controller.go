@@ -254,24 +254,65 @@ func (r *ReconcileTriggerCsiFullSync) Reconcile(ctx context.Context,
 
 	startTime := time.Now()
 	triggerSyncID := instance.Spec.TriggerSyncID
+	dryRun := instance.Spec.DryRun
+	approveDriftReportID := instance.Spec.ApproveDriftReportID
+	pendingDriftReport := instance.Status.DriftReport.DeepCopy()
 	var fullSyncErr error
-	if r.clusterFlavor == cnstypes.CnsClusterFlavorGuest {
+	var driftReport *triggercsifullsyncv1alpha1.DriftReport
+	var skippedChanges int
+	switch {
+	case (dryRun || approveDriftReportID != 0) && r.clusterFlavor == cnstypes.CnsClusterFlavorGuest:
+		fullSyncErr = fmt.Errorf("dry run and drift report approval are not supported in guest clusters")
+	case dryRun && approveDriftReportID != 0:
+		fullSyncErr = fmt.Errorf("dryRun and approveDriftReportID cannot be set together")
+	case dryRun:
+		driftReport, fullSyncErr = computeDriftReport(ctx, r.configInfo.Cfg.Global.VCenterIP, triggerSyncID)
+	case approveDriftReportID != 0:
+		skippedChanges, fullSyncErr = applyDriftReport(ctx, r.configInfo.Cfg.Global.VCenterIP, pendingDriftReport,
+			approveDriftReportID)
+	case r.clusterFlavor == cnstypes.CnsClusterFlavorGuest:
 		fullSyncErr = syncer.PvcsiFullSync(ctx, syncer.MetadataSyncer)
-	} else {
+	default:
 		fullSyncErr = syncer.CsiFullSync(ctx, syncer.MetadataSyncer, r.configInfo.Cfg.Global.VCenterIP)
 	}
 	err = r.client.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		return reconcile.Result{}, nil
 	}
+	if !dryRun && approveDriftReportID == 0 && instance.Status.DriftReport != nil &&
+		!instance.Status.DriftReport.Applied {
+		// A regular full sync discards the drift report awaiting approval,
+		// which resumes periodic full sync.
+		instance.Status.DriftReport = nil
+	}
 	if fullSyncErr != nil {
 		msg := fmt.Sprintf("Full sync failed for triggerSyncID: %d with error: %+v", triggerSyncID, fullSyncErr)
 		log.Error(msg)
 		setInstanceError(ctx, r, instance, msg, startTime)
 	} else {
 		msg := fmt.Sprintf("Full sync successful with triggerSyncID: %d", triggerSyncID)
+		switch {
+		case driftReport != nil:
+			msg = fmt.Sprintf("Full sync dry run successful with triggerSyncID: %d. Found %d volumes to create, "+
+				"%d volumes to update and %d volumes to delete", triggerSyncID, driftReport.VolumesToCreate,
+				driftReport.VolumesToUpdate, driftReport.VolumesToDelete)
+			instance.Status.DriftReport = driftReport
+		case approveDriftReportID != 0:
+			msg = fmt.Sprintf("Drift report %d applied with triggerSyncID: %d", approveDriftReportID, triggerSyncID)
+			if skippedChanges > 0 {
+				msg += fmt.Sprintf(". Skipped %d approved changes which are no longer required", skippedChanges)
+			}
+			if instance.Status.DriftReport != nil && instance.Status.DriftReport.ID == approveDriftReportID {
+				instance.Status.DriftReport.Applied = true
+			}
+		}
 		log.Info(msg)
 		setInstanceSuccess(ctx, r, instance, msg, startTime)
+		if driftReport != nil {
+			for _, driftMsg := range driftReportMessages(driftReport) {
+				r.recorder.Event(instance, v1.EventTypeNormal, "TriggerCsiFullSyncDriftDetected", driftMsg)
+			}
+		}
 	}
 	backOffDurationMapMutex.Lock()
 	delete(backOffDuration, instance.Name)
@@ -279,6 +320,38 @@ func (r *ReconcileTriggerCsiFullSync) Reconcile(ctx context.Context,
 	return reconcile.Result{}, nil
 }
 
+// computeDriftReport computes the drift between kubernetes and CNS without
+// making any changes in CNS. The report is kept in the status of the
+// TriggerCsiFullSync instance until it is approved.
+func computeDriftReport(ctx context.Context, vc string,
+	triggerSyncID uint64) (*triggercsifullsyncv1alpha1.DriftReport, error) {
+	report, err := syncer.CsiFullSyncDryRun(ctx, syncer.MetadataSyncer, vc)
+	if err != nil {
+		return nil, err
+	}
+	return newDriftReport(triggerSyncID, report), nil
+}
+
+// applyDriftReport applies the changes of the given drift report, kept in the
+// status of the TriggerCsiFullSync instance, if it is the one with the given
+// ID. A drift report can be applied only once. Full sync computes the changes
+// again and applies the ones which are approved by the report, so approved
+// changes which are no longer required are skipped. The number of skipped
+// changes is returned.
+func applyDriftReport(ctx context.Context, vc string, driftReport *triggercsifullsyncv1alpha1.DriftReport,
+	driftReportID uint64) (int, error) {
+	if driftReport == nil || driftReport.ID != driftReportID || driftReport.Applied {
+		return 0, fmt.Errorf("drift report %d is not available for approval. Trigger a dry run to compute "+
+			"a new drift report", driftReportID)
+	}
+	report, err := syncer.CsiFullSyncApproved(ctx, syncer.MetadataSyncer, vc, driftReport.Changes)
+	if err != nil {
+		return 0, err
+	}
+	applied := newDriftReport(driftReportID, report)
+	return len(driftReport.Changes) - len(applied.Changes), nil
+}
+
 // setInstanceError sets error and records an event on the TriggerCsiFullSync
 // instance.
 func setInstanceError(ctx context.Context, r *ReconcileTriggerCsiFullSync,
@@ -0,0 +1,112 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package triggercsifullsync
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	triggercsifullsyncv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsoperator/triggercsifullsync/v1alpha1"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+)
+
+// maxDriftReportEventVolumes is the maximum number of volume IDs listed in a
+// drift report event.
+const maxDriftReportEventVolumes = 10
+
+// newDriftReport returns the status representation of the given drift report
+// computed by the dry run with the given TriggerSyncID.
+func newDriftReport(id uint64, report *syncer.FullSyncDriftReport) *triggercsifullsyncv1alpha1.DriftReport {
+	driftReport := &triggercsifullsyncv1alpha1.DriftReport{
+		ID:                 id,
+		GeneratedTimeStamp: &metav1.Time{Time: time.Now()},
+		VolumesToCreate:    len(report.CreateSpecs),
+		VolumesToDelete:    len(report.DeleteVolumeIDs),
+	}
+	addChange := func(operation string, volumeID string, volumeName string) {
+		if len(driftReport.Changes) < triggercsifullsyncv1alpha1.MaxDriftReportChanges {
+			driftReport.Changes = append(driftReport.Changes, triggercsifullsyncv1alpha1.DriftReportChange{
+				Operation:  operation,
+				VolumeID:   volumeID,
+				VolumeName: volumeName,
+			})
+		}
+	}
+
+	for _, createSpec := range report.CreateSpecs {
+		addChange(triggercsifullsyncv1alpha1.DriftReportOperationCreate, syncer.CreateSpecVolumeID(createSpec),
+			createSpec.Name)
+	}
+	// Block volumes used by several pods have an update spec for each pod.
+	updatedVolumes := make(map[string]bool)
+	for _, updateSpec := range report.UpdateSpecs {
+		if updatedVolumes[updateSpec.VolumeId.Id] {
+			continue
+		}
+		updatedVolumes[updateSpec.VolumeId.Id] = true
+		var volumeName string
+		for _, metadata := range updateSpec.Metadata.EntityMetadata {
+			entityMetadata, ok := metadata.(*cnstypes.CnsKubernetesEntityMetadata)
+			if ok && !entityMetadata.Delete &&
+				entityMetadata.EntityType == string(cnstypes.CnsKubernetesEntityTypePV) {
+				volumeName = entityMetadata.EntityName
+			}
+		}
+		addChange(triggercsifullsyncv1alpha1.DriftReportOperationUpdate, updateSpec.VolumeId.Id, volumeName)
+	}
+	driftReport.VolumesToUpdate = len(updatedVolumes)
+	for _, volumeID := range report.DeleteVolumeIDs {
+		addChange(triggercsifullsyncv1alpha1.DriftReportOperationDelete, volumeID.Id,
+			report.DeleteVolumeNames[volumeID.Id])
+	}
+	return driftReport
+}
+
+// driftReportMessages returns the messages of the events published for the
+// given drift report, one for each operation with changes.
+func driftReportMessages(driftReport *triggercsifullsyncv1alpha1.DriftReport) []string {
+	var messages []string
+	for _, operation := range []struct {
+		name  string
+		count int
+	}{
+		{triggercsifullsyncv1alpha1.DriftReportOperationCreate, driftReport.VolumesToCreate},
+		{triggercsifullsyncv1alpha1.DriftReportOperationUpdate, driftReport.VolumesToUpdate},
+		{triggercsifullsyncv1alpha1.DriftReportOperationDelete, driftReport.VolumesToDelete},
+	} {
+		if operation.count == 0 {
+			continue
+		}
+		var volumeIDs []string
+		for _, change := range driftReport.Changes {
+			if change.Operation == operation.name && len(volumeIDs) < maxDriftReportEventVolumes {
+				volumeIDs = append(volumeIDs, change.VolumeID)
+			}
+		}
+		msg := fmt.Sprintf("Drift report %d: %d volumes to %s: %s", driftReport.ID, operation.count,
+			operation.name, strings.Join(volumeIDs, ", "))
+		if operation.count > len(volumeIDs) {
+			msg += fmt.Sprintf(" and %d more", operation.count-len(volumeIDs))
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package triggercsifullsync
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+
+	triggercsifullsyncv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsoperator/triggercsifullsync/v1alpha1"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+)
+
+func newTestUpdateSpec(volumeID string, pvName string, podName string) cnstypes.CnsVolumeMetadataUpdateSpec {
+	return cnstypes.CnsVolumeMetadataUpdateSpec{
+		VolumeId: cnstypes.CnsVolumeId{Id: volumeID},
+		Metadata: cnstypes.CnsVolumeMetadata{
+			EntityMetadata: []cnstypes.BaseCnsEntityMetadata{
+				&cnstypes.CnsKubernetesEntityMetadata{
+					CnsEntityMetadata: cnstypes.CnsEntityMetadata{EntityName: pvName},
+					EntityType:        string(cnstypes.CnsKubernetesEntityTypePV),
+				},
+				&cnstypes.CnsKubernetesEntityMetadata{
+					CnsEntityMetadata: cnstypes.CnsEntityMetadata{EntityName: podName},
+					EntityType:        string(cnstypes.CnsKubernetesEntityTypePOD),
+				},
+			},
+		},
+	}
+}
+
+func TestNewDriftReport(t *testing.T) {
+	report := &syncer.FullSyncDriftReport{
+		CreateSpecs: []cnstypes.CnsVolumeCreateSpec{
+			{
+				Name: "pv-1",
+				BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{
+					BackingDiskId: "vol-1",
+				},
+			},
+		},
+		UpdateSpecs: []cnstypes.CnsVolumeMetadataUpdateSpec{
+			newTestUpdateSpec("vol-2", "pv-2", "pod-1"),
+			newTestUpdateSpec("vol-2", "pv-2", "pod-2"),
+		},
+		DeleteVolumeIDs:   []cnstypes.CnsVolumeId{{Id: "vol-3"}},
+		DeleteVolumeNames: map[string]string{"vol-3": "pv-3"},
+	}
+	driftReport := newDriftReport(5, report)
+	assert.Equal(t, uint64(5), driftReport.ID)
+	assert.False(t, driftReport.Applied)
+	assert.NotNil(t, driftReport.GeneratedTimeStamp)
+	assert.Equal(t, 1, driftReport.VolumesToCreate)
+	assert.Equal(t, 1, driftReport.VolumesToUpdate)
+	assert.Equal(t, 1, driftReport.VolumesToDelete)
+	assert.Equal(t, []triggercsifullsyncv1alpha1.DriftReportChange{
+		{Operation: triggercsifullsyncv1alpha1.DriftReportOperationCreate, VolumeID: "vol-1", VolumeName: "pv-1"},
+		{Operation: triggercsifullsyncv1alpha1.DriftReportOperationUpdate, VolumeID: "vol-2", VolumeName: "pv-2"},
+		{Operation: triggercsifullsyncv1alpha1.DriftReportOperationDelete, VolumeID: "vol-3", VolumeName: "pv-3"},
+	}, driftReport.Changes)
+
+	assert.Equal(t, []string{
+		"Drift report 5: 1 volumes to create: vol-1",
+		"Drift report 5: 1 volumes to update: vol-2",
+		"Drift report 5: 1 volumes to delete: vol-3",
+	}, driftReportMessages(driftReport))
+}
+
+func TestNewDriftReportTruncated(t *testing.T) {
+	report := &syncer.FullSyncDriftReport{DeleteVolumeNames: map[string]string{}}
+	for i := 0; i < triggercsifullsyncv1alpha1.MaxDriftReportChanges+5; i++ {
+		report.DeleteVolumeIDs = append(report.DeleteVolumeIDs, cnstypes.CnsVolumeId{Id: fmt.Sprintf("vol-%d", i)})
+	}
+	driftReport := newDriftReport(1, report)
+	assert.Equal(t, triggercsifullsyncv1alpha1.MaxDriftReportChanges+5, driftReport.VolumesToDelete)
+	assert.Len(t, driftReport.Changes, triggercsifullsyncv1alpha1.MaxDriftReportChanges)
+
+	messages := driftReportMessages(driftReport)
+	assert.Len(t, messages, 1)
+	assert.Contains(t, messages[0], fmt.Sprintf("and %d more",
+		triggercsifullsyncv1alpha1.MaxDriftReportChanges+5-maxDriftReportEventVolumes))
+}
@@ -27,6 +27,7 @@ import (
 	cnstypes "github.com/vmware/govmomi/cns/types"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
@@ -163,68 +164,15 @@ func InitCnsOperator(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavo
 			log.Info("Observed stretchedSupervisor setup")
 		}
 		if !stretchedSupervisor || (stretchedSupervisor && syncer.IsPodVMOnStretchSupervisorFSSEnabled) {
-			// Create CnsRegisterVolume CRD from manifest.
-			log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsRegisterVolumePlural)
-			err = k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsRegisterVolumeCRFile,
-				cnsoperatorconfig.EmbedCnsRegisterVolumeCRFileName)
+			err = createCnsRegisterVolumeCRD(ctx, cnsOperator, restConfig)
 			if err != nil {
-				log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsRegisterVolumePlural, err)
 				return err
 			}
-			log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsRegisterVolumePlural)
-
-			// Clean up routine to cleanup successful CnsRegisterVolume instances.
-			log.Info("Starting go routine to cleanup successful CnsRegisterVolume instances.")
-			err = watcher(ctx, cnsOperator)
-			if err != nil {
-				log.Error("Failed to watch on config file for changes to "+
-					"CnsRegisterVolumesCleanupIntervalInMin. Error: %+v", err)
-				return err
-			}
-			go func() {
-				for {
-					ctx, log = logger.GetNewContextWithLogger()
-					log.Infof("Triggering CnsRegisterVolume cleanup routine")
-					cleanUpCnsRegisterVolumeInstances(ctx, restConfig,
-						cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
-					log.Infof("Completed CnsRegisterVolume cleanup")
-					for i := 1; i <= cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin; i++ {
-						time.Sleep(time.Duration(1 * time.Minute))
-					}
-				}
-			}()
-
 			if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.WCPMobilityNonDisruptiveImport) {
-				// Create CnsUnregisterVolume CRD from manifest.
-				log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsUnregisterVolumePlural)
-				err = k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsUnregisterVolumeCRFile,
-					cnsoperatorconfig.EmbedCnsUnregisterVolumeCRFileName)
+				err = createCnsUnregisterVolumeCRD(ctx, cnsOperator, restConfig)
 				if err != nil {
-					log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsUnregisterVolumePlural, err)
 					return err
 				}
-				log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsUnregisterVolumePlural)
-
-				// Clean up routine to cleanup successful CnsUnregisterVolume instances.
-				log.Info("Starting go routine to cleanup successful CnsUnregisterVolume instances.")
-				err = watcher(ctx, cnsOperator)
-				if err != nil {
-					log.Error("Failed to watch on config file for changes to "+
-						"CnsRegisterVolumesCleanupIntervalInMin. Error: %+v", err)
-					return err
-				}
-				go func() {
-					for {
-						ctx, log = logger.GetNewContextWithLogger()
-						log.Infof("Triggering CnsUnregisterVolume cleanup routine")
-						cleanUpCnsUnregisterVolumeInstances(ctx, restConfig,
-							cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
-						log.Infof("Completed CnsUnregisterVolume cleanup")
-						for i := 1; i <= cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin; i++ {
-							time.Sleep(time.Duration(1 * time.Minute))
-						}
-					}
-				}()
 			}
 		}
 
@@ -257,6 +205,14 @@ func InitCnsOperator(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavo
 			log.Errorf("Failed to create %q CRD. Error: %+v", csinodetopology.CRDSingular, err)
 			return err
 		}
+		err = createCnsRegisterVolumeCRD(ctx, cnsOperator, restConfig)
+		if err != nil {
+			return err
+		}
+		err = createCnsUnregisterVolumeCRD(ctx, cnsOperator, restConfig)
+		if err != nil {
+			return err
+		}
 	} else if clusterFlavor == cnstypes.CnsClusterFlavorGuest {
 		if cnsOperator.coCommonInterface.IsFSSEnabled(ctx, common.TKGsHA) {
 			// Create CSINodeTopology CRD.
@@ -269,6 +225,27 @@ func InitCnsOperator(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavo
 		}
 	}
 
+	if (clusterFlavor == cnstypes.CnsClusterFlavorWorkload || clusterFlavor == cnstypes.CnsClusterFlavorVanilla) &&
+		cnsOperator.coCommonInterface.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+		// Create CnsSnapshotExport CRD from manifest.
+		log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsSnapshotExportPlural)
+		err = k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsSnapshotExportCRFile,
+			cnsoperatorconfig.EmbedCnsSnapshotExportCRFileName)
+		if err != nil {
+			log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsSnapshotExportPlural, err)
+			return err
+		}
+		// Create CnsSnapshotImport CRD from manifest. The exported snapshots
+		// are imported through CnsRegisterVolume.
+		log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsSnapshotImportPlural)
+		err = k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsSnapshotImportCRFile,
+			cnsoperatorconfig.EmbedCnsSnapshotImportCRFileName)
+		if err != nil {
+			log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsSnapshotImportPlural, err)
+			return err
+		}
+	}
+
 	// Create a new operator to provide shared dependencies and start components
 	// Setting namespace to empty would let operator watch all namespaces.
 	mgr, err := manager.New(restConfig, manager.Options{
@@ -321,6 +298,80 @@ func InitCnsOperator(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavo
 	return nil
 }
 
+// createCnsRegisterVolumeCRD creates the CnsRegisterVolume CRD and starts the
+// routine cleaning up the successful CnsRegisterVolume instances.
+func createCnsRegisterVolumeCRD(ctx context.Context, cnsOperator *cnsOperatorInfo, restConfig *rest.Config) error {
+	log := logger.GetLogger(ctx)
+	// Create CnsRegisterVolume CRD from manifest.
+	log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsRegisterVolumePlural)
+	err := k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsRegisterVolumeCRFile,
+		cnsoperatorconfig.EmbedCnsRegisterVolumeCRFileName)
+	if err != nil {
+		log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsRegisterVolumePlural, err)
+		return err
+	}
+	log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsRegisterVolumePlural)
+
+	// Clean up routine to cleanup successful CnsRegisterVolume instances.
+	log.Info("Starting go routine to cleanup successful CnsRegisterVolume instances.")
+	err = watcher(ctx, cnsOperator)
+	if err != nil {
+		log.Error("Failed to watch on config file for changes to "+
+			"CnsRegisterVolumesCleanupIntervalInMin. Error: %+v", err)
+		return err
+	}
+	go func() {
+		for {
+			ctx, log = logger.GetNewContextWithLogger()
+			log.Infof("Triggering CnsRegisterVolume cleanup routine")
+			cleanUpCnsRegisterVolumeInstances(ctx, restConfig,
+				cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
+			log.Infof("Completed CnsRegisterVolume cleanup")
+			for i := 1; i <= cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin; i++ {
+				time.Sleep(time.Duration(1 * time.Minute))
+			}
+		}
+	}()
+	return nil
+}
+
+// createCnsUnregisterVolumeCRD creates the CnsUnregisterVolume CRD and starts
+// the routine cleaning up the successful CnsUnregisterVolume instances.
+func createCnsUnregisterVolumeCRD(ctx context.Context, cnsOperator *cnsOperatorInfo, restConfig *rest.Config) error {
+	log := logger.GetLogger(ctx)
+	// Create CnsUnregisterVolume CRD from manifest.
+	log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsUnregisterVolumePlural)
+	err := k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsUnregisterVolumeCRFile,
+		cnsoperatorconfig.EmbedCnsUnregisterVolumeCRFileName)
+	if err != nil {
+		log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsUnregisterVolumePlural, err)
+		return err
+	}
+	log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsUnregisterVolumePlural)
+
+	// Clean up routine to cleanup successful CnsUnregisterVolume instances.
+	log.Info("Starting go routine to cleanup successful CnsUnregisterVolume instances.")
+	err = watcher(ctx, cnsOperator)
+	if err != nil {
+		log.Error("Failed to watch on config file for changes to "+
+			"CnsRegisterVolumesCleanupIntervalInMin. Error: %+v", err)
+		return err
+	}
+	go func() {
+		for {
+			ctx, log = logger.GetNewContextWithLogger()
+			log.Infof("Triggering CnsUnregisterVolume cleanup routine")
+			cleanUpCnsUnregisterVolumeInstances(ctx, restConfig,
+				cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
+			log.Infof("Completed CnsUnregisterVolume cleanup")
+			for i := 1; i <= cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin; i++ {
+				time.Sleep(time.Duration(1 * time.Minute))
+			}
+		}
+	}()
+	return nil
+}
+
 // InitCommonModules initializes the common modules for all flavors.
 func InitCommonModules(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavor,
 	coInitParams *interface{}) error {
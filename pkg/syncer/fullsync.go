@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 	"sync"
@@ -55,6 +56,7 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
 	commoncotypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/types"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	triggercsifullsyncv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsoperator/triggercsifullsync/v1alpha1"
 	cnsvolumeinfov1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeinfo/v1alpha1"
 	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 	cnsoperatortypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/types"
@@ -68,6 +70,32 @@ const (
 // CsiFullSync reconciles volume metadata on a vanilla k8s cluster with volume
 // metadata on CNS.
 func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc string) error {
+	_, err := csiFullSync(ctx, metadataSyncer, vc, false, nil)
+	return err
+}
+
+// CsiFullSyncDryRun computes the changes CsiFullSync would make in CNS for the
+// given VC and returns them as a drift report, without making any changes in
+// CNS or kubernetes. A dry run does not count as a full sync cycle for the
+// volumes which are created or deleted only after two cycles.
+func CsiFullSyncDryRun(ctx context.Context, metadataSyncer *metadataSyncInformer,
+	vc string) (*FullSyncDriftReport, error) {
+	return csiFullSync(ctx, metadataSyncer, vc, true, nil)
+}
+
+// CsiFullSyncApproved runs CsiFullSync for the given VC, but only applies the
+// changes which are listed in approved and are still required. It returns the
+// drift report of the applied changes.
+func CsiFullSyncApproved(ctx context.Context, metadataSyncer *metadataSyncInformer, vc string,
+	approved []triggercsifullsyncv1alpha1.DriftReportChange) (*FullSyncDriftReport, error) {
+	return csiFullSync(ctx, metadataSyncer, vc, false, newFullSyncApproval(approved))
+}
+
+// csiFullSync reconciles volume metadata on CNS with volume metadata on k8s.
+// If dryRun is set, the changes are only computed and returned as a drift
+// report. If approval is set, only the approved changes are applied.
+func csiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc string,
+	dryRun bool, approval fullSyncApproval) (*FullSyncDriftReport, error) {
 	log := logger.GetLogger(ctx)
 	log.Infof("FullSync for VC %s: start", vc)
 	fullSyncStartTime := time.Now()
@@ -80,22 +108,24 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 			migrationFeatureStateForFullSync = true
 		}
 	}
+	// The CRs and kubernetes objects maintained by full sync are left untouched
+	// in dry run mode.
 	// Attempt to create StoragePolicyUsage CRs.
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+	if !dryRun && metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
 		if IsPodVMOnStretchSupervisorFSSEnabled {
 			createStoragePolicyUsageCRS(ctx, metadataSyncer)
 		}
 	}
 	// Sync VolumeInfo CRs for the below conditions:
 	// Either it is a Vanilla k8s deployment with Multi-VC configuration or, it's a StretchSupervisor cluster
-	if len(metadataSyncer.configInfo.Cfg.VirtualCenter) > 1 ||
-		(metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && IsPodVMOnStretchSupervisorFSSEnabled) {
+	if !dryRun && (len(metadataSyncer.configInfo.Cfg.VirtualCenter) > 1 ||
+		(metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && IsPodVMOnStretchSupervisorFSSEnabled)) {
 		volumeInfoCRFullSync(ctx, metadataSyncer, vc)
 		cleanUpVolumeInfoCrDeletionMap(ctx, metadataSyncer, vc)
 	}
 	// Attempt to patch StoragePolicyUsage CRs. For storagePolicyUsageCRSync to work,
 	// we need CNSVolumeInfo CRs to be present for all existing volumes.
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+	if !dryRun && metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
 		if IsPodVMOnStretchSupervisorFSSEnabled {
 			storagePolicyUsageCRSync(ctx, metadataSyncer)
 		}
@@ -107,7 +137,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	// For all such PVCs/Snapshots, attempt to remove CNS finalizer if corresponding guest cluster does not exist.
 	// This code handles cases where namespace deletion causes guest cluster and its corresponding components
 	// to be deleted but associated objects on supervisor remain stuck in Terminating state.
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+	if !dryRun && metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
 		if metadataSyncer.coCommonInterface.IsFSSEnabled(ctx, common.SVPVCSnapshotProtectionFinalizer) {
 			cleanupUnusedPVCsAndSnapshotsFromGuestCluster(ctx)
 		}
@@ -126,7 +156,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	k8sPVs, err := getPVsInBoundAvailableOrReleasedForVc(ctx, metadataSyncer, vc)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: Failed to get PVs from kubernetes. Err: %v", vc, err)
-		return err
+		return nil, err
 	}
 
 	// k8sPVMap is useful for clean and quicker look up.
@@ -136,12 +166,13 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 		// Instantiate volumeMigrationService when migration feature state is True.
 		if err = initVolumeMigrationService(ctx, metadataSyncer); err != nil {
 			log.Errorf("FullSync for VC %s: Failed to initialize migration service. Err: %v", vc, err)
-			return err
+			return nil, err
 		}
 	}
 
 	// Iterate through all the k8sPVs and use volume id as the key for k8sPVMap
 	// items. For migrated volumes, invoke GetVolumeID from migration service.
+	var registeredK8sPVs []*v1.PersistentVolume
 	for _, pv := range k8sPVs {
 		// k8sPVs contains valid CSI volumes or migrated vSphere volumes
 		if pv.Spec.CSI != nil {
@@ -154,21 +185,31 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 				VolumePath:        pv.Spec.VsphereVolume.VolumePath,
 				StoragePolicyName: pv.Spec.VsphereVolume.StoragePolicyName}
 			var volumeHandle string
-			volumeHandle, err = volumeMigrationService.GetVolumeID(ctx, migrationVolumeSpec, true)
+			volumeHandle, err = volumeMigrationService.GetVolumeID(ctx, migrationVolumeSpec, !dryRun)
 			if err != nil {
+				if dryRun {
+					// Volumes which are not registered yet are left out of the
+					// drift report, as registering them would change CNS.
+					log.Infof("FullSync for VC %s: Skipping unregistered vSphere volume %q in dry run. Err: %v",
+						vc, pv.Name, err)
+					err = nil
+					continue
+				}
 				log.Errorf("FullSync for VC %s: Failed to get VolumeID from volumeMigrationService for spec: %v. Err: %+v",
 					vc, migrationVolumeSpec, err)
-				return err
+				return nil, err
 			}
 			k8sPVMap[volumeHandle] = ""
 		}
+		registeredK8sPVs = append(registeredK8sPVs, pv)
 	}
+	k8sPVs = registeredK8sPVs
 	// pvToPVCMap maps pv name to corresponding PVC.
 	// pvcToPodMap maps pvc to the mounted Pod.
 	pvToPVCMap, pvcToPodMap, err := buildPVCMapPodMap(ctx, k8sPVs, metadataSyncer, vc)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: Failed to build PVCMap and PodMap. Err: %v", vc, err)
-		return err
+		return nil, err
 	}
 	log.Debugf("FullSync for VC %s: pvToPVCMap %v", vc, pvToPVCMap)
 	log.Debugf("FullSyncfor VC %s: pvcToPodMap %v", vc, pvcToPodMap)
@@ -176,7 +217,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	volManager, err := getVolManagerForVcHost(ctx, vc, metadataSyncer)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: Failed to get volume manager. Err: %v", vc, err)
-		return err
+		return nil, err
 	}
 
 	var vcenter *cnsvsphere.VirtualCenter
@@ -184,17 +225,17 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	vcenter, err = cnsvsphere.GetVirtualCenterInstanceForVCenterHost(ctx, vc, true)
 	if err != nil {
 		log.Errorf("failed to get virtual center instance for VC: %s. Error: %v", vc, err)
-		return err
+		return nil, err
 	}
 
 	// Iterate through all the k8sPVs to find all PVs with node affinity missing and
 	// patch such PVs and their corresponding PVCs with topology discovered
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload &&
+	if !dryRun && metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload &&
 		IsWorkloadDomainIsolationSupported {
 		k8sClient, err := k8s.NewClient(ctx)
 		if err != nil {
 			log.Errorf("FullSync for VC %s: Failed to create kubernetes client. Err: %+v", vc, err)
-			return err
+			return nil, err
 		}
 		var pvWithMissingNodeAffinityList [](*v1.PersistentVolume)
 		for _, pv := range k8sPVs {
@@ -225,11 +266,11 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 
 	// Iterate over all the file volume PVCs and check if file share export paths are added as annotations
 	// on it. If not added, then add file share export path annotations on such PVCs.
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+	if !dryRun && metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
 		k8sClient, err := k8sNewClient(ctx)
 		if err != nil {
 			log.Errorf("FullSync for VC %s: Failed to create kubernetes client. Err: %+v", vc, err)
-			return err
+			return nil, err
 		}
 		for _, pv := range k8sPVs {
 			if IsFileVolume(pv) {
@@ -256,7 +297,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 			metadataSyncer.configInfo.Cfg.Global.ClusterID, cnstypes.CnsQuerySelection{})
 		if err != nil {
 			log.Errorf("FullSync for VC %s: QueryVolume failed with err=%+v", vc, err.Error())
-			return err
+			return nil, err
 		}
 	} else {
 		log.Infof("observed emptry string cluster-id in the vSphere Config secret. " +
@@ -273,7 +314,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 		//   before 9.0 has already been updated to use the new Supervisor-ID.
 
 		var volumeIDsWithOldClusterID []cnstypes.CnsVolumeId
-		if queryAllResult != nil && len(queryAllResult.Volumes) > 0 {
+		if !dryRun && queryAllResult != nil && len(queryAllResult.Volumes) > 0 {
 			for _, volume := range queryAllResult.Volumes {
 				volumeIDsWithOldClusterID = append(volumeIDsWithOldClusterID, volume.VolumeId)
 			}
@@ -281,7 +322,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 				metadataSyncer.configInfo.Cfg.Global.ClusterID, volManager, metadataSyncer)
 			if err != nil {
 				log.Errorf("FullSync for VC %s: fullSyncGetQueryResults failed to query volume metadata from vc. Err: %v", vc, err)
-				return err
+				return nil, err
 			}
 			var updateMetadataSpecArray []cnstypes.CnsVolumeMetadataUpdateSpec
 			for _, queryResult := range queryAllResult {
@@ -369,10 +410,10 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 			metadataSyncer.configInfo.Cfg.Global.SupervisorID, querySelection)
 		if err != nil {
 			log.Errorf("FullSync for VC %s: QueryVolume failed with err=%+v", vc, err.Error())
-			return err
+			return nil, err
 		}
 	}
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && isStorageQuotaM2FSSEnabled {
+	if !dryRun && metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && isStorageQuotaM2FSSEnabled {
 		cnsBlockVolumeMap := make(map[string]cnstypes.CnsVolume)
 		for _, vol := range queryAllResult.Volumes {
 			// We do not support file volume snapshot, filtering out block volume only.
@@ -388,7 +429,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	vcHostObj, vcHostObjFound := metadataSyncer.configInfo.Cfg.VirtualCenter[vc]
 	if !vcHostObjFound {
 		log.Errorf("FullSync for VC %s: Failed to get VC host object.", vc)
-		return errors.New("failed to get VC host object")
+		return nil, errors.New("failed to get VC host object")
 	}
 
 	volumeToCnsEntityMetadataMap, volumeToK8sEntityMetadataMap, volumeClusterDistributionMap, err :=
@@ -396,7 +437,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 			pvcToPodMap, metadataSyncer, migrationFeatureStateForFullSync, volManager, vc)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: fullSyncGetEntityMetadata failed with err %+v", vc, err)
-		return err
+		return nil, err
 	}
 	log.Debugf("FullSync for VC %s: pvToCnsEntityMetadataMap %+v \n pvToK8sEntityMetadataMap: %+v \n",
 		vc, spew.Sdump(volumeToCnsEntityMetadataMap), spew.Sdump(volumeToK8sEntityMetadataMap))
@@ -405,29 +446,51 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	containerCluster := cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
 		vcHostObj.User, metadataSyncer.clusterFlavor,
 		metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
+	// A dry run works on copies of cnsCreationMap and cnsDeletionMap, so that
+	// it does not count as one of the two full sync cycles after which
+	// volumes are created or deleted.
+	creationMap, deletionMap := cnsCreationMap[vc], cnsDeletionMap[vc]
+	if dryRun {
+		creationMap, deletionMap = maps.Clone(creationMap), maps.Clone(deletionMap)
+	}
 	createSpecArray, updateSpecArray := fullSyncGetVolumeSpecs(ctx, vcenter.Client.Version, k8sPVs,
 		volumeToCnsEntityMetadataMap, volumeToK8sEntityMetadataMap, volumeClusterDistributionMap,
-		containerCluster, migrationFeatureStateForFullSync, vc)
+		containerCluster, migrationFeatureStateForFullSync, creationMap, vc)
 	volToBeDeleted, err := getVolumesToBeDeleted(ctx, queryAllResult.Volumes, k8sPVMap, metadataSyncer,
-		migrationFeatureStateForFullSync, vc)
+		migrationFeatureStateForFullSync, dryRun, deletionMap, vc)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: failed to get list of volumes to be deleted with err %+v", vc, err)
-		return err
+		return nil, err
 	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(3)
-	// Perform operations.
-	go fullSyncCreateVolumes(ctx, createSpecArray, metadataSyncer, &wg, migrationFeatureStateForFullSync, volManager, vc)
-	go fullSyncUpdateVolumes(ctx, updateSpecArray, metadataSyncer, &wg, volManager, vc)
-	go fullSyncDeleteVolumes(ctx, volToBeDeleted, metadataSyncer, &wg, migrationFeatureStateForFullSync, volManager, vc)
-	wg.Wait()
-
-	cleanupCnsMaps(k8sPVMap, vc)
-	log.Debugf("FullSync for VC %s: cnsDeletionMap at end of cycle: %v", vc, cnsDeletionMap)
-	log.Debugf("FullSync for VC %s: cnsCreationMap at end of cycle: %v", vc, cnsCreationMap)
+	report := &FullSyncDriftReport{
+		VC:                    vc,
+		CreateSpecs:           createSpecArray,
+		UpdateSpecs:           updateSpecArray,
+		DeleteVolumeIDs:       volToBeDeleted,
+		DeleteVolumeNames:     make(map[string]string),
+		migrationFeatureState: migrationFeatureStateForFullSync,
+	}
+	for _, vol := range queryAllResult.Volumes {
+		if _, existsInK8s := k8sPVMap[vol.VolumeId.Id]; !existsInK8s {
+			report.DeleteVolumeNames[vol.VolumeId.Id] = vol.Name
+		}
+	}
+	if approval != nil {
+		report = approval.filter(report)
+	}
+	if dryRun {
+		log.Infof("FullSync for VC %s: dry run found %d volumes to create, %d volume updates and "+
+			"%d volumes to delete", vc, len(createSpecArray), len(updateSpecArray), len(volToBeDeleted))
+	} else {
+		applyFullSyncDriftReport(ctx, metadataSyncer, volManager, report)
+		exportVolumeMetrics(ctx, metadataSyncer, volManager, vc, k8sPVs, pvToPVCMap)
+	}
+	cleanupCnsMaps(k8sPVMap, creationMap, deletionMap)
+	log.Debugf("FullSync for VC %s: cnsDeletionMap at end of cycle: %v", vc, deletionMap)
+	log.Debugf("FullSync for VC %s: cnsCreationMap at end of cycle: %v", vc, creationMap)
 	log.Infof("FullSync for VC %s: end", vc)
-	return nil
+	return report, nil
 }
 
 // getPVNodeAffinity finds topology associated with given PV and returns the same
@@ -1317,7 +1380,7 @@ func fullSyncGetVolumeSpecs(ctx context.Context, vCenterVersion string, pvList [
 	volumeToCnsEntityMetadataMap map[string][]cnstypes.BaseCnsEntityMetadata,
 	volumeToK8sEntityMetadataMap map[string][]cnstypes.BaseCnsEntityMetadata,
 	volumeClusterDistributionMap map[string]bool, containerCluster cnstypes.CnsContainerCluster,
-	migrationFeatureStateForFullSync bool, vc string) (
+	migrationFeatureStateForFullSync bool, creationMap map[string]bool, vc string) (
 	[]cnstypes.CnsVolumeCreateSpec, []cnstypes.CnsVolumeMetadataUpdateSpec) {
 	log := logger.GetLogger(ctx)
 	var createSpecArray []cnstypes.CnsVolumeCreateSpec
@@ -1350,14 +1413,14 @@ func fullSyncGetVolumeSpecs(ctx context.Context, vCenterVersion string, pvList [
 		}
 		if !presentInCNS {
 			// PV exist in K8S but not in CNS cache, need to create
-			if _, existsInCnsCreationMap := cnsCreationMap[vc][volumeHandle]; existsInCnsCreationMap {
+			if _, existsInCnsCreationMap := creationMap[volumeHandle]; existsInCnsCreationMap {
 				// Volume was present in cnsCreationMap across two full-sync cycles.
 				log.Infof("FullSync for VC %s: create is required for volume: %q", vc, volumeHandle)
 				operationType = "createVolume"
 			} else {
 				log.Infof("FullSync for VC %s: Volume with id: %q and name: %q is added "+
 					"to cnsCreationMap", vc, volumeHandle, pv.Name)
-				cnsCreationMap[vc][volumeHandle] = true
+				creationMap[volumeHandle] = true
 			}
 		} else {
 			// volume exist in K8S and CNS, Check if update is required.
@@ -1487,11 +1550,12 @@ func fullSyncGetVolumeSpecs(ctx context.Context, vCenterVersion string, pvList [
 }
 
 // getVolumesToBeDeleted return list of volumeIds that need to be deleted.
-// A volumeId is added to this list only if it was present in cnsDeletionMap
-// across two cycles of full sync.
+// A volumeId is added to this list only if it was present in deletionMap,
+// the cnsDeletionMap of the VC, across two cycles of full sync. In dry run
+// mode, inline migrated volumes are not registered in CNS.
 func getVolumesToBeDeleted(ctx context.Context, cnsVolumeList []cnstypes.CnsVolume, k8sPVMap map[string]string,
-	metadataSyncer *metadataSyncInformer, migrationFeatureStateForFullSync bool,
-	vc string) ([]cnstypes.CnsVolumeId, error) {
+	metadataSyncer *metadataSyncInformer, migrationFeatureStateForFullSync bool, dryRun bool,
+	deletionMap map[string]bool, vc string) ([]cnstypes.CnsVolumeId, error) {
 	log := logger.GetLogger(ctx)
 	var volToBeDeleted []cnstypes.CnsVolumeId
 	// inlineVolumeMap holds the volume path information for migrated volumes
@@ -1499,7 +1563,8 @@ func getVolumesToBeDeleted(ctx context.Context, cnsVolumeList []cnstypes.CnsVolu
 	inlineVolumeMap := make(map[string]string)
 	var err error
 	if migrationFeatureStateForFullSync {
-		inlineVolumeMap, err = fullSyncGetInlineMigratedVolumesInfo(ctx, metadataSyncer, migrationFeatureStateForFullSync)
+		inlineVolumeMap, err = fullSyncGetInlineMigratedVolumesInfo(ctx, metadataSyncer,
+			migrationFeatureStateForFullSync, !dryRun)
 		if err != nil {
 			log.Errorf("FullSync for VC %s: Failed to get inline migrated volumes. Err: %v", vc, err)
 			return volToBeDeleted, err
@@ -1507,7 +1572,7 @@ func getVolumesToBeDeleted(ctx context.Context, cnsVolumeList []cnstypes.CnsVolu
 	}
 	for _, vol := range cnsVolumeList {
 		if _, existsInK8s := k8sPVMap[vol.VolumeId.Id]; !existsInK8s {
-			if _, existsInCnsDeletionMap := cnsDeletionMap[vc][vol.VolumeId.Id]; existsInCnsDeletionMap {
+			if _, existsInCnsDeletionMap := deletionMap[vol.VolumeId.Id]; existsInCnsDeletionMap {
 				// Volume does not exist in K8s across two fullsync cycles, because
 				// it was present in cnsDeletionMap across two full sync cycles.
 				// Add it to delete list.
@@ -1527,14 +1592,14 @@ func getVolumesToBeDeleted(ctx context.Context, cnsVolumeList []cnstypes.CnsVolu
 					// If migration is ON, verify if the volume is present in inlineVolumeMap.
 					if _, existsInInlineVolumeMap := inlineVolumeMap[vol.VolumeId.Id]; !existsInInlineVolumeMap {
 						log.Infof("FullSync for VC %s: Volume with id %q added to cnsDeletionMap", vc, vol.VolumeId.Id)
-						cnsDeletionMap[vc][vol.VolumeId.Id] = true
+						deletionMap[vol.VolumeId.Id] = true
 					} else {
 						log.Debugf("FullSync for VC %s: Inline migrated volume with id %s is in use. Skipping for deletion",
 							vc, vol.VolumeId.Id)
 					}
 				} else {
 					log.Debugf("FullSync for VC %s: Volume with id %s added to cnsDeletionMap", vc, vol.VolumeId.Id)
-					cnsDeletionMap[vc][vol.VolumeId.Id] = true
+					deletionMap[vol.VolumeId.Id] = true
 				}
 			}
 		}
@@ -1636,26 +1701,24 @@ func isUpdateRequired(ctx context.Context, vCenterVersion string, k8sMetadataLis
 	return false
 }
 
-// cleanupCnsMaps performs cleanup on cnsCreationMap and cnsDeletionMap.
-// Removes volume entries from cnsCreationMap that do not exist in K8s
-// and volume entries from cnsDeletionMap that exist in K8s.
+// cleanupCnsMaps performs cleanup on the cnsCreationMap and cnsDeletionMap
+// of a VC. Removes volume entries from creationMap that do not exist in K8s
+// and volume entries from deletionMap that exist in K8s.
 // An entry could have been added to cnsCreationMap (or cnsDeletionMap),
 // because full sync was triggered in between the delete (or create)
 // operation of a volume.
-func cleanupCnsMaps(k8sPVs map[string]string, vc string) {
+func cleanupCnsMaps(k8sPVs map[string]string, creationMap map[string]bool, deletionMap map[string]bool) {
 	// Cleanup cnsCreationMap.
-	cnsCreationMapForVc := cnsCreationMap[vc]
-	for volID := range cnsCreationMapForVc {
+	for volID := range creationMap {
 		if _, existsInK8s := k8sPVs[volID]; !existsInK8s {
-			delete(cnsCreationMap[vc], volID)
+			delete(creationMap, volID)
 		}
 	}
 	// Cleanup cnsDeletionMap.
-	cnsDeletionMapForVc := cnsDeletionMap[vc]
-	for volID := range cnsDeletionMapForVc {
+	for volID := range deletionMap {
 		if _, existsInK8s := k8sPVs[volID]; existsInK8s {
 			// Delete volume from cnsDeletionMap which is present in kubernetes.
-			delete(cnsDeletionMap[vc], volID)
+			delete(deletionMap, volID)
 		}
 	}
 }
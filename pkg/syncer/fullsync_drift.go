@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	triggercsifullsyncv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsoperator/triggercsifullsync/v1alpha1"
+)
+
+// FullSyncDriftReport contains the changes computed by a CSI full sync for a
+// VC, i.e. the drift between the volume metadata on kubernetes and on CNS.
+type FullSyncDriftReport struct {
+	// VC is the vCenter the report was computed for.
+	VC string
+	// CreateSpecs are the specs of the volumes to be created in CNS.
+	CreateSpecs []cnstypes.CnsVolumeCreateSpec
+	// UpdateSpecs are the specs of the volume metadata updates in CNS.
+	UpdateSpecs []cnstypes.CnsVolumeMetadataUpdateSpec
+	// DeleteVolumeIDs are the IDs of the volumes whose container cluster
+	// metadata is to be deleted in CNS.
+	DeleteVolumeIDs []cnstypes.CnsVolumeId
+	// DeleteVolumeNames maps the IDs of the volumes in DeleteVolumeIDs to
+	// their names in CNS.
+	DeleteVolumeNames map[string]string
+	// migrationFeatureState is the CSI migration feature state the report
+	// was computed with.
+	migrationFeatureState bool
+}
+
+// CreateSpecVolumeID returns the ID of the volume to be created with the
+// given create spec.
+func CreateSpecVolumeID(createSpec cnstypes.CnsVolumeCreateSpec) string {
+	switch backingDetails := createSpec.BackingObjectDetails.(type) {
+	case *cnstypes.CnsBlockBackingDetails:
+		return backingDetails.BackingDiskId
+	case *cnstypes.CnsVsanFileShareBackingDetails:
+		return backingDetails.BackingFileId
+	}
+	return ""
+}
+
+// driftReportAwaitsApproval returns whether the given drift report awaits
+// approval at the given time, i.e. it has been neither applied nor computed
+// more than DriftReportExpiry before.
+func driftReportAwaitsApproval(report *triggercsifullsyncv1alpha1.DriftReport, now time.Time) bool {
+	if report == nil || report.Applied {
+		return false
+	}
+	return report.GeneratedTimeStamp == nil ||
+		now.Sub(report.GeneratedTimeStamp.Time) < triggercsifullsyncv1alpha1.DriftReportExpiry
+}
+
+// fullSyncApproval holds the IDs of the volumes of an approved drift report
+// by the operation approved for them.
+type fullSyncApproval map[string]map[string]bool
+
+// newFullSyncApproval returns the approval of the given drift report changes.
+func newFullSyncApproval(approved []triggercsifullsyncv1alpha1.DriftReportChange) fullSyncApproval {
+	approval := fullSyncApproval{
+		triggercsifullsyncv1alpha1.DriftReportOperationCreate: make(map[string]bool),
+		triggercsifullsyncv1alpha1.DriftReportOperationUpdate: make(map[string]bool),
+		triggercsifullsyncv1alpha1.DriftReportOperationDelete: make(map[string]bool),
+	}
+	for _, change := range approved {
+		if volumeIDs, ok := approval[change.Operation]; ok {
+			volumeIDs[change.VolumeID] = true
+		}
+	}
+	return approval
+}
+
+// filter returns the drift report with the changes of the given report which
+// are approved.
+func (approval fullSyncApproval) filter(report *FullSyncDriftReport) *FullSyncDriftReport {
+	approved := &FullSyncDriftReport{
+		VC:                    report.VC,
+		DeleteVolumeNames:     report.DeleteVolumeNames,
+		migrationFeatureState: report.migrationFeatureState,
+	}
+	for _, createSpec := range report.CreateSpecs {
+		if approval[triggercsifullsyncv1alpha1.DriftReportOperationCreate][CreateSpecVolumeID(createSpec)] {
+			approved.CreateSpecs = append(approved.CreateSpecs, createSpec)
+		}
+	}
+	for _, updateSpec := range report.UpdateSpecs {
+		if approval[triggercsifullsyncv1alpha1.DriftReportOperationUpdate][updateSpec.VolumeId.Id] {
+			approved.UpdateSpecs = append(approved.UpdateSpecs, updateSpec)
+		}
+	}
+	for _, volumeID := range report.DeleteVolumeIDs {
+		if approval[triggercsifullsyncv1alpha1.DriftReportOperationDelete][volumeID.Id] {
+			approved.DeleteVolumeIDs = append(approved.DeleteVolumeIDs, volumeID)
+		}
+	}
+	return approved
+}
+
+// applyFullSyncDriftReport creates, updates and deletes the volumes of the
+// given drift report in CNS.
+func applyFullSyncDriftReport(ctx context.Context, metadataSyncer *metadataSyncInformer,
+	volManager volumes.Manager, report *FullSyncDriftReport) {
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	// Perform operations.
+	go fullSyncCreateVolumes(ctx, report.CreateSpecs, metadataSyncer, &wg, report.migrationFeatureState,
+		volManager, report.VC)
+	go fullSyncUpdateVolumes(ctx, report.UpdateSpecs, metadataSyncer, &wg, volManager, report.VC)
+	go fullSyncDeleteVolumes(ctx, report.DeleteVolumeIDs, metadataSyncer, &wg, report.migrationFeatureState,
+		volManager, report.VC)
+	wg.Wait()
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +34,7 @@ import (
 	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	triggercsifullsyncv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsoperator/triggercsifullsync/v1alpha1"
 )
 
 func TestSetFileShareAnnotationsOnPVC_Success(t *testing.T) {
@@ -459,3 +461,49 @@ func TestSetFileShareAnnotationsOnPVC_ExistingAnnotations(t *testing.T) {
 	assert.Equal(t, "192.168.1.100:/nfs/v3/path", pvc.Annotations[common.Nfsv3ExportPathAnnotationKey])
 	assert.Empty(t, pvc.Annotations[common.Nfsv4ExportPathAnnotationKey])
 }
+
+func TestFullSyncApprovalFilter(t *testing.T) {
+	report := &FullSyncDriftReport{
+		VC: "vc",
+		CreateSpecs: []cnstypes.CnsVolumeCreateSpec{
+			{Name: "pv-1", BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{BackingDiskId: "vol-1"}},
+			{Name: "pv-2", BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{BackingDiskId: "vol-2"}},
+		},
+		UpdateSpecs: []cnstypes.CnsVolumeMetadataUpdateSpec{
+			{VolumeId: cnstypes.CnsVolumeId{Id: "vol-3"}},
+			{VolumeId: cnstypes.CnsVolumeId{Id: "vol-3"}},
+			{VolumeId: cnstypes.CnsVolumeId{Id: "vol-4"}},
+		},
+		DeleteVolumeIDs: []cnstypes.CnsVolumeId{{Id: "vol-5"}, {Id: "vol-6"}},
+	}
+	approval := newFullSyncApproval([]triggercsifullsyncv1alpha1.DriftReportChange{
+		{Operation: triggercsifullsyncv1alpha1.DriftReportOperationCreate, VolumeID: "vol-1"},
+		{Operation: triggercsifullsyncv1alpha1.DriftReportOperationUpdate, VolumeID: "vol-3"},
+		{Operation: triggercsifullsyncv1alpha1.DriftReportOperationDelete, VolumeID: "vol-6"},
+		// Changes approved for another operation are not applied.
+		{Operation: triggercsifullsyncv1alpha1.DriftReportOperationDelete, VolumeID: "vol-2"},
+	})
+	approved := approval.filter(report)
+	assert.Equal(t, "vc", approved.VC)
+	assert.Len(t, approved.CreateSpecs, 1)
+	assert.Equal(t, "pv-1", approved.CreateSpecs[0].Name)
+	assert.Len(t, approved.UpdateSpecs, 2)
+	assert.Equal(t, "vol-3", approved.UpdateSpecs[1].VolumeId.Id)
+	assert.Equal(t, []cnstypes.CnsVolumeId{{Id: "vol-6"}}, approved.DeleteVolumeIDs)
+
+	assert.Empty(t, newFullSyncApproval(nil).filter(report).DeleteVolumeIDs)
+}
+
+func TestDriftReportAwaitsApproval(t *testing.T) {
+	now := time.Now()
+	generated := metav1.NewTime(now.Add(-time.Hour))
+	report := &triggercsifullsyncv1alpha1.DriftReport{ID: 1, GeneratedTimeStamp: &generated}
+	assert.True(t, driftReportAwaitsApproval(report, now))
+	assert.False(t, driftReportAwaitsApproval(nil, now))
+
+	// Periodic full sync resumes once the report is applied or expired.
+	report.Applied = true
+	assert.False(t, driftReportAwaitsApproval(report, now))
+	report.Applied = false
+	assert.False(t, driftReportAwaitsApproval(report, now.Add(triggercsifullsyncv1alpha1.DriftReportExpiry)))
+}
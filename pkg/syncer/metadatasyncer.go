@@ -717,12 +717,26 @@ func InitMetadataSyncer(ctx context.Context, clusterFlavor cnstypes.CnsClusterFl
 				}
 
 				// Update TriggerCsiFullSync instance if full sync is not already in progress
+				driftReport := triggerCsiFullSyncInstance.Status.DriftReport
 				if triggerCsiFullSyncInstance.Status.InProgress {
 					log.Info("There is a full sync already in progress. Ignoring this current cycle of periodic full sync")
+				} else if driftReportAwaitsApproval(driftReport, time.Now()) {
+					// Periodic full sync would make the changes of the drift
+					// report before they are approved.
+					log.Infof("Drift report %d awaits approval. Ignoring this current cycle of periodic full sync",
+						driftReport.ID)
 				} else if !triggerCsiFullSyncInstance.Status.InProgress &&
 					triggerCsiFullSyncInstance.Spec.TriggerSyncID != triggerCsiFullSyncInstance.Status.LastTriggerSyncID {
 					log.Info("FullSync is already triggered. Ignoring this current cycle of periodic full sync")
 				} else {
+					if driftReport != nil && !driftReport.Applied {
+						log.Warnf("Drift report %d was not approved within %v. Resuming periodic full sync, "+
+							"which discards the report", driftReport.ID, triggercsifullsyncv1alpha1.DriftReportExpiry)
+					}
+					// Periodic full sync is a regular full sync, which also
+					// discards a drift report that was not approved in time.
+					triggerCsiFullSyncInstance.Spec.DryRun = false
+					triggerCsiFullSyncInstance.Spec.ApproveDriftReportID = 0
 					triggerCsiFullSyncInstance.Spec.TriggerSyncID = triggerCsiFullSyncInstance.Spec.TriggerSyncID + 1
 					err = updateTriggerCsiFullSyncInstance(ctx, cnsOperatorClient, triggerCsiFullSyncInstance)
 					if err != nil {
@@ -43,6 +43,7 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
 	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+	triggercsifullsyncv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsoperator/triggercsifullsync/v1alpha1"
 	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 )
 
@@ -216,6 +217,7 @@ func TestSyncerWorkflows(t *testing.T) {
 	}
 	dsList = append(dsList, datastoreInfoObj.Datastore.Reference())
 	runTestMetadataSyncInformer(t)
+	runTestFullSyncDryRunWorkflows(t)
 	runTestFullSyncWorkflows(t)
 	runTestCsiFullSync_WorkloadCluster(t)
 	t.Log("TestSyncerWorkflows: end")
@@ -588,6 +590,116 @@ func getPersistentVolumeClaimSpec(pvcName string, namespace string,
 	return pvc
 }
 
+// This test verifies the dry run and approval workflow of fullsync for a PV
+// which does not exist in K8S, but exists in CNS cache:
+//  1. A dry run of fullsync reports the volume for deletion without
+//     deleting it.
+//  2. Fullsync applying an approved drift report deletes the volume only if
+//     its deletion is approved.
+func runTestFullSyncDryRunWorkflows(t *testing.T) {
+	t.Log("TestFullSyncDryRunWorkflows start")
+	createSpec := cnstypes.CnsVolumeCreateSpec{
+		DynamicData: vimtypes.DynamicData{},
+		Name:        testVolumeName + "-" + uuid.New().String(),
+		VolumeType:  testVolumeType,
+		Datastores:  dsList,
+		Metadata: cnstypes.CnsVolumeMetadata{
+			DynamicData: vimtypes.DynamicData{},
+			ContainerCluster: cnstypes.CnsContainerCluster{
+				ClusterType: string(cnstypes.CnsClusterTypeKubernetes),
+				ClusterId:   csiConfig.Global.ClusterID,
+				VSphereUser: csiConfig.VirtualCenter[cnsVCenterConfig.Host].User,
+			},
+		},
+		BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{
+			CnsBackingObjectDetails: cnstypes.CnsBackingObjectDetails{CapacityInMb: gbInMb},
+		},
+	}
+	cnsCreationMap = make(map[string]map[string]bool)
+	cnsCreationMap[csiConfig.Global.VCenterIP] = make(map[string]bool)
+
+	volumeInfo, _, err := volumeManager.CreateVolume(ctx, &createSpec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queryFilter := cnstypes.CnsQueryFilter{
+		VolumeIds: []cnstypes.CnsVolumeId{
+			{
+				Id: volumeInfo.VolumeID.Id,
+			},
+		},
+	}
+	cnsDeletionMap = make(map[string]map[string]bool)
+	cnsDeletionMap[csiConfig.Global.VCenterIP] = make(map[string]bool)
+	// PV does not exist in K8S, but volume exist in CNS cache.
+	// A dry run of FullSync does not count as a full sync cycle, so it reports
+	// the volume for deletion only after a FullSync cycle found it missing in
+	// K8S, and it does not delete it.
+	waitForListerSync()
+	report, err := CsiFullSyncDryRun(ctx, metadataSyncer, csiConfig.Global.VCenterIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.DeleteVolumeIDs) != 0 || len(cnsDeletionMap[csiConfig.Global.VCenterIP]) != 0 {
+		t.Fatalf("dry run counted as a full sync cycle for volume %s. Report: %+v", volumeInfo.VolumeID.Id, report)
+	}
+	err = CsiFullSync(ctx, metadataSyncer, csiConfig.Global.VCenterIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		report, err = CsiFullSyncDryRun(ctx, metadataSyncer, csiConfig.Global.VCenterIP)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.DeleteVolumeIDs) != 1 || report.DeleteVolumeIDs[0].Id != volumeInfo.VolumeID.Id {
+			t.Fatalf("dry run did not report volume %s for deletion. Report: %+v", volumeInfo.VolumeID.Id, report)
+		}
+	}
+	queryResult, err := virtualCenter.CnsClient.QueryVolume(ctx, &queryFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queryResult.Volumes) != 1 {
+		t.Fatalf("dry run of full sync removed volume %s", volumeInfo.VolumeID.Id)
+	}
+
+	// FullSync applying an approved drift report deletes the volume only if
+	// its deletion is approved.
+	report, err = CsiFullSyncApproved(ctx, metadataSyncer, csiConfig.Global.VCenterIP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.DeleteVolumeIDs) != 0 {
+		t.Fatalf("full sync deleted volume %s without approval. Report: %+v", volumeInfo.VolumeID.Id, report)
+	}
+	queryResult, err = virtualCenter.CnsClient.QueryVolume(ctx, &queryFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queryResult.Volumes) != 1 {
+		t.Fatalf("full sync removed volume %s without approval", volumeInfo.VolumeID.Id)
+	}
+	_, err = CsiFullSyncApproved(ctx, metadataSyncer, csiConfig.Global.VCenterIP,
+		[]triggercsifullsyncv1alpha1.DriftReportChange{{
+			Operation: triggercsifullsyncv1alpha1.DriftReportOperationDelete,
+			VolumeID:  volumeInfo.VolumeID.Id,
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify if volume has been deleted from cache.
+	queryResult, err = virtualCenter.CnsClient.QueryVolume(ctx, &queryFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queryResult.Volumes) != 0 {
+		t.Fatalf("Full sync failed to remove volume %s with approved deletion", volumeInfo.VolumeID.Id)
+	}
+	t.Log("TestFullSyncDryRunWorkflows end")
+}
+
 // This test verifies the fullsync workflow:
 //  1. PV does not exist in K8S, but exist in CNS cache, fullsync should
 //     delete this volume from CNS cache.
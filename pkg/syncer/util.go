@@ -102,9 +102,11 @@ func getBoundPVs(ctx context.Context, metadataSyncer *metadataSyncInformer) ([]*
 }
 
 // fullSyncGetInlineMigratedVolumesInfo is a helper function for retrieving
-// inline PV information from Pods.
+// inline PV information from Pods. Inline volumes which are not registered in
+// CNS yet are registered if registerIfNotFound is set.
 func fullSyncGetInlineMigratedVolumesInfo(ctx context.Context,
-	metadataSyncer *metadataSyncInformer, migrationFeatureState bool) (map[string]string, error) {
+	metadataSyncer *metadataSyncInformer, migrationFeatureState bool,
+	registerIfNotFound bool) (map[string]string, error) {
 	log := logger.GetLogger(ctx)
 	inlineVolumes := make(map[string]string)
 	// Get all Pods from kubernetes.
@@ -119,7 +121,7 @@ func fullSyncGetInlineMigratedVolumesInfo(ctx context.Context,
 			if migrationFeatureState && volume.VsphereVolume != nil {
 				volumeHandle, err := volumeMigrationService.GetVolumeID(ctx,
 					&migration.VolumeSpec{VolumePath: volume.VsphereVolume.VolumePath,
-						StoragePolicyName: volume.VsphereVolume.StoragePolicyName}, true)
+						StoragePolicyName: volume.VsphereVolume.StoragePolicyName}, registerIfNotFound)
 				if err != nil {
 					log.Warnf(
 						"FullSync: Failed to get VolumeID from volumeMigrationService for volumePath: %s with error %+v",
@@ -0,0 +1,303 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	pbmtypes "github.com/vmware/govmomi/pbm/types"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	cnsvolumeinfov1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeinfo/v1alpha1"
+)
+
+const (
+	// volumeHealthStatusAccessible is the value of the volume health status
+	// metric for accessible volumes.
+	volumeHealthStatusAccessible = 1
+	// volumeHealthStatusInaccessible is the value of the volume health status
+	// metric for inaccessible volumes.
+	volumeHealthStatusInaccessible = 0
+	// volumeHealthStatusUnknown is the value of the volume health status
+	// metric for volumes whose health is not known yet.
+	volumeHealthStatusUnknown = -1
+)
+
+var (
+	// exportedVolumeMetrics maps each vCenter to the label values of the
+	// per-volume metrics exported for its volumes, keyed by volume ID. It is
+	// used to remove the metrics of volumes which are no longer exported.
+	exportedVolumeMetrics = make(map[string]map[string][]string)
+	// exportedVolumeMetricsLock guards exportedVolumeMetrics, as full sync
+	// runs concurrently for each vCenter.
+	exportedVolumeMetricsLock sync.Mutex
+)
+
+// volumeMetrics holds the values of the per-volume metrics of a volume.
+type volumeMetrics struct {
+	// labelValues are the values of prometheus.VolumeMetricLabels.
+	labelValues []string
+	// capacityInBytes is the provisioned capacity of the volume.
+	capacityInBytes int64
+	// healthStatus is the CNS health status of the volume.
+	healthStatus float64
+	// snapshotCount is the number of snapshots of the volume, or -1 if it
+	// could not be retrieved.
+	snapshotCount int
+	// aggregatedSnapshotSizeInBytes is the aggregated size of the snapshots
+	// of the volume.
+	aggregatedSnapshotSizeInBytes int64
+}
+
+// getVolumeMetricsNamespaces returns the set of namespaces for which
+// per-volume metrics are exported.
+func getVolumeMetricsNamespaces(metadataSyncer *metadataSyncInformer) map[string]struct{} {
+	namespaces := make(map[string]struct{})
+	for _, namespace := range strings.Split(metadataSyncer.configInfo.Cfg.Global.VolumeMetricsNamespaces, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace != "" {
+			namespaces[namespace] = struct{}{}
+		}
+	}
+	return namespaces
+}
+
+// getVolumeMetricsHealthStatus returns the value of the volume health status
+// metric for the given CNS health status.
+func getVolumeMetricsHealthStatus(ctx context.Context, volumeID string, cnsHealthStatus string) float64 {
+	healthStatus, _ := common.ConvertVolumeHealthStatus(ctx, volumeID, cnsHealthStatus)
+	switch healthStatus {
+	case common.VolHealthStatusAccessible:
+		return volumeHealthStatusAccessible
+	case string(pbmtypes.PbmHealthStatusForEntityUnknown):
+		return volumeHealthStatusUnknown
+	default:
+		return volumeHealthStatusInaccessible
+	}
+}
+
+// getVolumeInfoSnapshotSizes returns the aggregated snapshot sizes recorded in
+// the CNSVolumeInfo CRs, keyed by volume ID.
+func getVolumeInfoSnapshotSizes(ctx context.Context) map[string]int64 {
+	log := logger.GetLogger(ctx)
+	snapshotSizes := make(map[string]int64)
+	if volumeInfoService == nil {
+		return snapshotSizes
+	}
+	for _, volumeInfo := range volumeInfoService.ListAllVolumeInfos() {
+		cnsvolumeinfo := &cnsvolumeinfov1alpha1.CNSVolumeInfo{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(volumeInfo.(*unstructured.Unstructured).Object,
+			&cnsvolumeinfo)
+		if err != nil {
+			log.Errorf("Failed to parse cnsvolumeinfo object: %v, err: %v", volumeInfo, err)
+			continue
+		}
+		if cnsvolumeinfo.Spec.ValidAggregatedSnapshotSize && cnsvolumeinfo.Spec.AggregatedSnapshotSize != nil {
+			snapshotSizes[cnsvolumeinfo.Spec.VolumeID] = cnsvolumeinfo.Spec.AggregatedSnapshotSize.Value()
+		}
+	}
+	return snapshotSizes
+}
+
+// buildVolumeMetrics returns the per-volume metrics of the bound PVs in the
+// given namespaces, keyed by volume ID. The aggregated snapshot size recorded
+// in CNSVolumeInfo takes precedence over the one reported by CNS.
+func buildVolumeMetrics(ctx context.Context, k8sPVs []*v1.PersistentVolume, pvToPVCMap pvcMap,
+	cnsVolumes []cnstypes.CnsVolume, volumeInfoSnapshotSizes map[string]int64,
+	namespaces map[string]struct{}) map[string]*volumeMetrics {
+	cnsVolumeMap := make(map[string]cnstypes.CnsVolume, len(cnsVolumes))
+	for _, vol := range cnsVolumes {
+		cnsVolumeMap[vol.VolumeId.Id] = vol
+	}
+	metrics := make(map[string]*volumeMetrics)
+	for _, pv := range k8sPVs {
+		// In-tree vSphere volumes are not exported, as they are not identified
+		// by their CNS volume ID in the PV spec.
+		if pv.Spec.CSI == nil {
+			continue
+		}
+		pvc, ok := pvToPVCMap[pv.Name]
+		if !ok {
+			continue
+		}
+		if _, ok := namespaces[pvc.Namespace]; !ok {
+			continue
+		}
+		volumeID := pv.Spec.CSI.VolumeHandle
+		vol, ok := cnsVolumeMap[volumeID]
+		if !ok {
+			continue
+		}
+		volMetrics := &volumeMetrics{
+			labelValues: []string{pv.Name, pvc.Namespace, pvc.Name, pv.Spec.StorageClassName,
+				vol.DatastoreUrl, vol.StoragePolicyId},
+			healthStatus:  getVolumeMetricsHealthStatus(ctx, volumeID, vol.HealthStatus),
+			snapshotCount: -1,
+		}
+		if vol.BackingObjectDetails != nil {
+			volMetrics.capacityInBytes = vol.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb *
+				common.MbInBytes
+			if blockBackingDetails, ok := vol.BackingObjectDetails.(*cnstypes.CnsBlockBackingDetails); ok {
+				volMetrics.aggregatedSnapshotSizeInBytes = blockBackingDetails.AggregatedSnapshotCapacityInMb *
+					common.MbInBytes
+			}
+		}
+		if snapshotSize, ok := volumeInfoSnapshotSizes[volumeID]; ok {
+			volMetrics.aggregatedSnapshotSizeInBytes = snapshotSize
+		}
+		metrics[volumeID] = volMetrics
+	}
+	return metrics
+}
+
+// getVolumeSnapshotCount returns the number of snapshots of the given volume.
+func getVolumeSnapshotCount(ctx context.Context, volManager cnsvolume.Manager, volumeID string) (int, error) {
+	queryFilter := cnstypes.CnsSnapshotQueryFilter{
+		SnapshotQuerySpecs: []cnstypes.CnsSnapshotQuerySpec{
+			{VolumeId: cnstypes.CnsVolumeId{Id: volumeID}},
+		},
+		Cursor: &cnstypes.CnsCursor{
+			Offset: 0,
+			Limit:  common.QuerySnapshotLimit,
+		},
+	}
+	queryResultEntries, _, err := utils.QuerySnapshotsUtil(ctx, volManager, queryFilter, common.QuerySnapshotLimit)
+	if err != nil {
+		return 0, err
+	}
+	snapshotCount := 0
+	for _, queryResult := range queryResultEntries {
+		if queryResult.Error == nil {
+			snapshotCount++
+		}
+	}
+	return snapshotCount, nil
+}
+
+// setVolumeMetrics sets the per-volume metrics of the volumes of the given
+// vCenter and removes the metrics of its volumes which are no longer exported.
+func setVolumeMetrics(vc string, metrics map[string]*volumeMetrics) {
+	exportedVolumeMetricsLock.Lock()
+	defer exportedVolumeMetricsLock.Unlock()
+	exportedLabelValues := make(map[string][]string, len(metrics))
+	for volumeID, volMetrics := range metrics {
+		labelValues := volMetrics.labelValues
+		if oldLabelValues, ok := exportedVolumeMetrics[vc][volumeID]; ok &&
+			strings.Join(oldLabelValues, ",") != strings.Join(labelValues, ",") {
+			deleteVolumeMetrics(oldLabelValues)
+		}
+		prometheus.VolumeCapacityGaugeVec.WithLabelValues(labelValues...).Set(float64(volMetrics.capacityInBytes))
+		prometheus.VolumeHealthStatusGaugeVec.WithLabelValues(labelValues...).Set(volMetrics.healthStatus)
+		if volMetrics.snapshotCount >= 0 {
+			prometheus.VolumeSnapshotCountGaugeVec.WithLabelValues(labelValues...).Set(
+				float64(volMetrics.snapshotCount))
+		} else {
+			prometheus.VolumeSnapshotCountGaugeVec.DeleteLabelValues(labelValues...)
+		}
+		prometheus.VolumeAggregatedSnapshotSizeGaugeVec.WithLabelValues(labelValues...).Set(
+			float64(volMetrics.aggregatedSnapshotSizeInBytes))
+		exportedLabelValues[volumeID] = labelValues
+	}
+	for volumeID, oldLabelValues := range exportedVolumeMetrics[vc] {
+		if _, ok := metrics[volumeID]; !ok {
+			deleteVolumeMetrics(oldLabelValues)
+		}
+	}
+	exportedVolumeMetrics[vc] = exportedLabelValues
+}
+
+// deleteVolumeMetrics removes the per-volume metrics with the given label values.
+func deleteVolumeMetrics(labelValues []string) {
+	prometheus.VolumeCapacityGaugeVec.DeleteLabelValues(labelValues...)
+	prometheus.VolumeHealthStatusGaugeVec.DeleteLabelValues(labelValues...)
+	prometheus.VolumeSnapshotCountGaugeVec.DeleteLabelValues(labelValues...)
+	prometheus.VolumeAggregatedSnapshotSizeGaugeVec.DeleteLabelValues(labelValues...)
+}
+
+// exportVolumeMetrics exports the per-volume metrics of the bound PVs of the
+// given vCenter in the namespaces configured in VolumeMetricsNamespaces.
+func exportVolumeMetrics(ctx context.Context, metadataSyncer *metadataSyncInformer, volManager cnsvolume.Manager,
+	vc string, k8sPVs []*v1.PersistentVolume, pvToPVCMap pvcMap) {
+	log := logger.GetLogger(ctx)
+	namespaces := getVolumeMetricsNamespaces(metadataSyncer)
+	if len(namespaces) == 0 {
+		setVolumeMetrics(vc, nil)
+		return
+	}
+
+	var volumeIDs []cnstypes.CnsVolumeId
+	for _, pv := range k8sPVs {
+		if pv.Spec.CSI == nil {
+			continue
+		}
+		if pvc, ok := pvToPVCMap[pv.Name]; ok {
+			if _, ok := namespaces[pvc.Namespace]; ok {
+				volumeIDs = append(volumeIDs, cnstypes.CnsVolumeId{Id: pv.Spec.CSI.VolumeHandle})
+			}
+		}
+	}
+	var cnsVolumes []cnstypes.CnsVolume
+	if len(volumeIDs) > 0 {
+		queryFilter := cnstypes.CnsQueryFilter{
+			VolumeIds: volumeIDs,
+		}
+		querySelection := cnstypes.CnsQuerySelection{
+			Names: []string{
+				string(cnstypes.QuerySelectionNameTypeVolumeType),
+				string(cnstypes.QuerySelectionNameTypeBackingObjectDetails),
+				string(cnstypes.QuerySelectionNameTypeHealthStatus),
+				string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+				string(cnstypes.QuerySelectionNameTypePolicyId),
+			},
+		}
+		queryResult, err := volManager.QueryAllVolume(ctx, queryFilter, querySelection)
+		if err != nil {
+			log.Errorf("FullSync for VC %s: failed to query volumes for volume metrics. Err: %v", vc, err)
+			return
+		}
+		cnsVolumes = queryResult.Volumes
+	}
+
+	metrics := buildVolumeMetrics(ctx, k8sPVs, pvToPVCMap, cnsVolumes, getVolumeInfoSnapshotSizes(ctx), namespaces)
+	if metadataSyncer.coCommonInterface.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) {
+		for _, vol := range cnsVolumes {
+			volMetrics, ok := metrics[vol.VolumeId.Id]
+			if !ok || vol.VolumeType != common.BlockVolumeType {
+				continue
+			}
+			snapshotCount, err := getVolumeSnapshotCount(ctx, volManager, vol.VolumeId.Id)
+			if err != nil {
+				log.Warnf("FullSync for VC %s: failed to query snapshots of volume %q for volume metrics. Err: %v",
+					vc, vol.VolumeId.Id, err)
+				continue
+			}
+			volMetrics.snapshotCount = snapshotCount
+		}
+	}
+	setVolumeMetrics(vc, metrics)
+	log.Infof("FullSync for VC %s: exported metrics for %d volumes", vc, len(metrics))
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+)
+
+func newVolumeMetricsTestPV(name string, volumeID string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			StorageClassName: "test-sc",
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{
+					Driver:       "csi.vsphere.vmware.com",
+					VolumeHandle: volumeID,
+				},
+			},
+		},
+	}
+}
+
+func newVolumeMetricsTestPVC(namespace string, name string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+}
+
+func TestBuildVolumeMetrics(t *testing.T) {
+	ctx := context.Background()
+	k8sPVs := []*v1.PersistentVolume{
+		newVolumeMetricsTestPV("pv-1", "vol-1"),
+		newVolumeMetricsTestPV("pv-2", "vol-2"),
+		newVolumeMetricsTestPV("pv-3", "vol-3"),
+		newVolumeMetricsTestPV("pv-4", "vol-4"),
+	}
+	pvToPVCMap := pvcMap{
+		"pv-1": newVolumeMetricsTestPVC("ns-1", "pvc-1"),
+		"pv-2": newVolumeMetricsTestPVC("ns-2", "pvc-2"),
+		"pv-3": newVolumeMetricsTestPVC("ns-3", "pvc-3"),
+	}
+	cnsVolumes := []cnstypes.CnsVolume{
+		{
+			VolumeId:        cnstypes.CnsVolumeId{Id: "vol-1"},
+			VolumeType:      common.BlockVolumeType,
+			DatastoreUrl:    "ds:///vmfs/volumes/ds-1/",
+			StoragePolicyId: "policy-1",
+			HealthStatus:    "green",
+			BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{
+				CnsBackingObjectDetails:        cnstypes.CnsBackingObjectDetails{CapacityInMb: 1024},
+				AggregatedSnapshotCapacityInMb: 10,
+			},
+		},
+		{
+			VolumeId:        cnstypes.CnsVolumeId{Id: "vol-2"},
+			VolumeType:      common.BlockVolumeType,
+			DatastoreUrl:    "ds:///vmfs/volumes/ds-2/",
+			StoragePolicyId: "policy-2",
+			HealthStatus:    "unknown",
+			BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{
+				CnsBackingObjectDetails:        cnstypes.CnsBackingObjectDetails{CapacityInMb: 2048},
+				AggregatedSnapshotCapacityInMb: 10,
+			},
+		},
+		{
+			VolumeId:     cnstypes.CnsVolumeId{Id: "vol-3"},
+			VolumeType:   common.FileVolumeType,
+			HealthStatus: "red",
+		},
+	}
+	volumeInfoSnapshotSizes := map[string]int64{"vol-2": 20 * common.MbInBytes}
+	namespaces := map[string]struct{}{"ns-1": {}, "ns-2": {}}
+
+	metrics := buildVolumeMetrics(ctx, k8sPVs, pvToPVCMap, cnsVolumes, volumeInfoSnapshotSizes, namespaces)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, &volumeMetrics{
+		labelValues:                   []string{"pv-1", "ns-1", "pvc-1", "test-sc", "ds:///vmfs/volumes/ds-1/", "policy-1"},
+		capacityInBytes:               1024 * common.MbInBytes,
+		healthStatus:                  volumeHealthStatusAccessible,
+		snapshotCount:                 -1,
+		aggregatedSnapshotSizeInBytes: 10 * common.MbInBytes,
+	}, metrics["vol-1"])
+	assert.Equal(t, &volumeMetrics{
+		labelValues:                   []string{"pv-2", "ns-2", "pvc-2", "test-sc", "ds:///vmfs/volumes/ds-2/", "policy-2"},
+		capacityInBytes:               2048 * common.MbInBytes,
+		healthStatus:                  volumeHealthStatusUnknown,
+		snapshotCount:                 -1,
+		aggregatedSnapshotSizeInBytes: 20 * common.MbInBytes,
+	}, metrics["vol-2"])
+}
+
+func TestGetVolumeMetricsNamespaces(t *testing.T) {
+	metadataSyncer := &metadataSyncInformer{configInfo: &cnsconfig.ConfigurationInfo{Cfg: &cnsconfig.Config{}}}
+	assert.Empty(t, getVolumeMetricsNamespaces(metadataSyncer))
+
+	metadataSyncer.configInfo.Cfg.Global.VolumeMetricsNamespaces = "ns-1, ns-2,,"
+	assert.Equal(t, map[string]struct{}{"ns-1": {}, "ns-2": {}}, getVolumeMetricsNamespaces(metadataSyncer))
+}
+
+func TestSetVolumeMetrics(t *testing.T) {
+	vc := "test-vc"
+	metrics := map[string]*volumeMetrics{
+		"vol-1": {
+			labelValues:     []string{"pv-1", "ns-1", "pvc-1", "test-sc", "ds-1", "policy-1"},
+			capacityInBytes: 1024,
+			healthStatus:    volumeHealthStatusAccessible,
+			snapshotCount:   2,
+		},
+		"vol-2": {
+			labelValues:     []string{"pv-2", "ns-1", "pvc-2", "test-sc", "ds-1", "policy-1"},
+			capacityInBytes: 2048,
+			healthStatus:    volumeHealthStatusInaccessible,
+			snapshotCount:   -1,
+		},
+	}
+	setVolumeMetrics(vc, metrics)
+	defer setVolumeMetrics(vc, nil)
+	assert.Equal(t, 2, testutil.CollectAndCount(prometheus.VolumeCapacityGaugeVec))
+	assert.Equal(t, 1, testutil.CollectAndCount(prometheus.VolumeSnapshotCountGaugeVec))
+	assert.Equal(t, float64(1024), testutil.ToFloat64(
+		prometheus.VolumeCapacityGaugeVec.WithLabelValues(metrics["vol-1"].labelValues...)))
+
+	// Metrics of volumes which are no longer exported, or whose labels have
+	// changed, are removed.
+	metrics = map[string]*volumeMetrics{
+		"vol-1": {
+			labelValues:     []string{"pv-1", "ns-1", "pvc-1", "test-sc", "ds-2", "policy-1"},
+			capacityInBytes: 4096,
+			healthStatus:    volumeHealthStatusAccessible,
+			snapshotCount:   2,
+		},
+	}
+	setVolumeMetrics(vc, metrics)
+	assert.Equal(t, 1, testutil.CollectAndCount(prometheus.VolumeCapacityGaugeVec))
+	assert.Equal(t, 1, testutil.CollectAndCount(prometheus.VolumeHealthStatusGaugeVec))
+	assert.Equal(t, float64(4096), testutil.ToFloat64(
+		prometheus.VolumeCapacityGaugeVec.WithLabelValues(metrics["vol-1"].labelValues...)))
+
+	setVolumeMetrics(vc, nil)
+	assert.Equal(t, 0, testutil.CollectAndCount(prometheus.VolumeCapacityGaugeVec))
+}